@@ -0,0 +1,65 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAbandoned is wrapped by the error delivered on ErrChan for a job that
+// was abandoned as it outlived its JobOptions.HardDeadline.
+var ErrAbandoned = errors.New("goworkers: job abandoned")
+
+// withHardDeadline runs run on a goroutine of its own and gives up on it
+// once d elapses, such that a job ignoring every cancellation does not hold
+// its worker forever. The abandoned goroutine is left running, and whatever
+// it returns is discarded.
+func (gw *GoWorkers) withHardDeadline(d time.Duration, run func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		o, ok := gw.detach(ctx, d, run)
+		if !ok {
+			atomic.AddUint32(&gw.numAbandoned, 1)
+			return nil, fmt.Errorf("%w after %s", ErrAbandoned, d)
+		}
+		return o.result, o.err
+	}
+}
+
+// outcome is what a detached run returned
+type outcome struct {
+	result interface{}
+	err    error
+}
+
+// detach runs run on a goroutine of its own, and waits up to d for it to
+// return. It reports false if it gave up on run, which is left running.
+func (gw *GoWorkers) detach(ctx context.Context, d time.Duration, run func(ctx context.Context) (interface{}, error)) (outcome, bool) {
+	// buffered, as nobody receives once the job is abandoned
+	done := make(chan outcome, 1)
+	ids := make(chan uint64, 1)
+	go func() {
+		// the job is a job of the pool until it is abandoned
+		id := goroutineID()
+		gw.workerGoroutines.Store(id, struct{}{})
+		ids <- id
+		result, err := run(ctx)
+		gw.workerGoroutines.Delete(id)
+		done <- outcome{result, err}
+	}()
+
+	timer := gw.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case o := <-done:
+		return o, true
+	case <-timer.C():
+		gw.workerGoroutines.Delete(<-ids)
+		return outcome{}, false
+	}
+}
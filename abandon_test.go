@@ -0,0 +1,63 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHardDeadline(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Workers: 1, Clock: clock})
+	defer gw.Stop(false)
+
+	stuck := make(chan struct{})
+	defer close(stuck)
+	gw.Submit(func() { <-stuck }, JobOptions{Name: "vendor", HardDeadline: time.Second})
+
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+
+	var je *JobError
+	if err := <-gw.ErrChan; !errors.Is(err, ErrAbandoned) || !errors.As(err, &je) || je.Name != "vendor" {
+		t.Errorf("Expected the job to be abandoned, Got %v", err)
+	}
+
+	// the only worker is free again
+	ran := make(chan struct{})
+	gw.Submit(func() { close(ran) })
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the next job to run on the freed worker")
+	}
+
+	gw.Wait(false)
+	st := gw.Stats()
+	if st.Abandoned != 1 || st.Failed != 1 || st.Completed != 2 {
+		t.Errorf("Expected 1 abandoned job out of 2, Got %+v", st)
+	}
+}
+
+func TestHardDeadlineMet(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	gw.SubmitCheckResult(func() (interface{}, error) {
+		// a job with a hard deadline runs as a job of the pool
+		return gw.Wait(false) == ErrCalledFromJob, nil
+	}, JobOptions{HardDeadline: time.Minute})
+
+	if result := <-gw.ResultChan; result != true {
+		t.Errorf("Expected %v, Got %v", true, result)
+	}
+	if st := gw.Stats(); st.Abandoned != 0 {
+		t.Errorf("Expected no abandoned job, Got %d", st.Abandoned)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInitialLimit = 8
+	defaultMaxLimit     = 1000
+	defaultBackoff      = 0.9
+	// the no-load latency is measured afresh every so many samples, so that
+	// the limiter follows a backend that got slower for good
+	minLatencyWindow = 1000
+	// weight of a new limit in the smoothed limit
+	limitSmoothing = 0.2
+)
+
+// AdaptiveOptions configures an AdaptiveLimiter.
+//
+// Initial is the limit to start with. If unspecified or zero, 8 is used.
+//
+// Min and Max bound the limit. If unspecified or zero, 1 and 1000 are used.
+//
+// Backoff is the factor the limit is multiplied by when a job fails, e.g. as
+// the backend sheds load. If unspecified or zero, 0.9 is used.
+type AdaptiveOptions struct {
+	Initial uint32
+	Min     uint32
+	Max     uint32
+	Backoff float64
+}
+
+// AdaptiveLimiter limits the number of jobs calling a backend concurrently,
+// adapting the limit to the latency of the backend, independently of the
+// number of workers.
+//
+// The limit is adjusted after every job, gradient-style: it shrinks as the
+// latency of the jobs grows over the lowest latency observed, which
+// approximates the latency of the backend under no load, and grows while
+// the latency stays close to it. A failed job multiplies the limit by
+// Backoff, as with AIMD.
+//
+// Jobs are limited with JobOptions.Limiter, or by wrapping their calls to
+// the backend with Do().
+type AdaptiveLimiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	opts     AdaptiveOptions
+	limit    float64
+	inflight uint32
+
+	minLatency time.Duration
+	samples    uint32
+}
+
+// NewAdaptiveLimiter creates a new adaptive limiter.
+//
+// Accepts optional AdaptiveOptions{} argument.
+func NewAdaptiveLimiter(args ...AdaptiveOptions) *AdaptiveLimiter {
+	var opts AdaptiveOptions
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if opts.Min == 0 {
+		opts.Min = 1
+	}
+	if opts.Max == 0 {
+		opts.Max = defaultMaxLimit
+	}
+	if opts.Initial == 0 {
+		opts.Initial = defaultInitialLimit
+	}
+	if opts.Backoff == 0 {
+		opts.Backoff = defaultBackoff
+	}
+
+	l := &AdaptiveLimiter{opts: opts}
+	l.cond = sync.NewCond(&l.mu)
+	l.limit = l.clamp(float64(opts.Initial))
+	return l
+}
+
+// Limit returns the current limit.
+func (l *AdaptiveLimiter) Limit() uint32 {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+	return uint32(l.limit)
+}
+
+// InFlight returns the number of jobs holding the limiter.
+func (l *AdaptiveLimiter) InFlight() uint32 {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+	return l.inflight
+}
+
+// Acquire blocks until the limit allows one more job.
+func (l *AdaptiveLimiter) Acquire() {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+	for l.inflight >= uint32(l.limit) {
+		l.cond.Wait()
+	}
+	l.inflight++
+}
+
+// Release gives back a slot acquired with Acquire(), reporting the latency
+// of the job and whether it failed.
+func (l *AdaptiveLimiter) Release(latency time.Duration, failed bool) {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+	// saturated, if the job held the last slot
+	saturated := l.inflight >= uint32(l.limit)
+	l.inflight--
+	defer l.cond.Broadcast()
+
+	if failed {
+		l.limit = l.clamp(l.limit * l.opts.Backoff)
+		return
+	}
+
+	l.samples++
+	if (l.minLatency == 0) || (latency < l.minLatency) || (l.samples%minLatencyWindow == 0) {
+		l.minLatency = latency
+	}
+	// no evidence that the backend can take more if the limit is not reached
+	if !saturated && (latency <= l.minLatency) {
+		return
+	}
+
+	gradient := 1.0
+	if latency > 0 {
+		gradient = math.Max(0.5, math.Min(1, float64(l.minLatency)/float64(latency)))
+	}
+	next := l.limit*gradient + math.Sqrt(l.limit)
+	l.limit = l.clamp((1-limitSmoothing)*l.limit + limitSmoothing*next)
+}
+
+// Do runs fn once the limit allows it, and adjusts the limit to its latency.
+func (l *AdaptiveLimiter) Do(fn func() error) error {
+	l.Acquire()
+	started := time.Now()
+	err := fn()
+	l.Release(time.Since(started), err != nil)
+	return err
+}
+
+func (l *AdaptiveLimiter) clamp(limit float64) float64 {
+	return math.Max(float64(l.opts.Min), math.Min(float64(l.opts.Max), limit))
+}
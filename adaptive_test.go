@@ -0,0 +1,113 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// saturate fills the limiter and releases its slots with latency
+func saturate(l *AdaptiveLimiter, latency time.Duration, failed bool) {
+	n := l.Limit()
+	for i := uint32(0); i < n; i++ {
+		l.Acquire()
+	}
+	for i := uint32(0); i < n; i++ {
+		l.Release(latency, failed)
+	}
+}
+
+func TestAdaptiveLimiterDefaults(t *testing.T) {
+	l := NewAdaptiveLimiter()
+	if l.Limit() != defaultInitialLimit {
+		t.Errorf("Expected %v, Got %v", defaultInitialLimit, l.Limit())
+	}
+
+	l = NewAdaptiveLimiter(AdaptiveOptions{Initial: 50, Max: 20})
+	if l.Limit() != 20 {
+		t.Errorf("Expected %v, Got %v", 20, l.Limit())
+	}
+}
+
+func TestAdaptiveLimiterGrows(t *testing.T) {
+	l := NewAdaptiveLimiter(AdaptiveOptions{Initial: 4, Max: 64})
+	for i := 0; i < 20; i++ {
+		saturate(l, 10*time.Millisecond, false)
+	}
+	if l.Limit() <= 4 {
+		t.Errorf("Expected the limit to grow at a steady latency, Got %v", l.Limit())
+	}
+	if l.InFlight() != 0 {
+		t.Errorf("Expected %v, Got %v", 0, l.InFlight())
+	}
+}
+
+func TestAdaptiveLimiterShrinks(t *testing.T) {
+	tables := []struct {
+		name    string
+		latency time.Duration
+		failed  bool
+	}{
+		{"latency", 100 * time.Millisecond, false},
+		{"failure", 10 * time.Millisecond, true},
+	}
+
+	for _, table := range tables {
+		l := NewAdaptiveLimiter(AdaptiveOptions{Initial: 32, Min: 2})
+		saturate(l, 10*time.Millisecond, false)
+		before := l.Limit()
+		for i := 0; i < 50; i++ {
+			saturate(l, table.latency, table.failed)
+		}
+		if l.Limit() >= before {
+			t.Errorf("%s: Expected the limit to shrink below %v, Got %v", table.name, before, l.Limit())
+		}
+		if l.Limit() < 2 {
+			t.Errorf("%s: Expected the limit not to go below %v, Got %v", table.name, 2, l.Limit())
+		}
+	}
+}
+
+func TestAdaptiveLimiterDo(t *testing.T) {
+	l := NewAdaptiveLimiter()
+	errBackend := errors.New("backend down")
+	if err := l.Do(func() error { return errBackend }); err != errBackend {
+		t.Errorf("Expected %v, Got %v", errBackend, err)
+	}
+	if l.Limit() >= defaultInitialLimit {
+		t.Errorf("Expected the limit to back off below %v, Got %v", defaultInitialLimit, l.Limit())
+	}
+}
+
+func TestJobLimiter(t *testing.T) {
+	gw := New(Options{Workers: 8})
+	l := NewAdaptiveLimiter(AdaptiveOptions{Initial: 2, Max: 2})
+
+	var running, peak int32
+	for i := 0; i < 20; i++ {
+		gw.Submit(func() {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}, JobOptions{Limiter: l})
+	}
+	gw.Stop(true)
+
+	if p := atomic.LoadInt32(&peak); p > 2 {
+		t.Errorf("Expected at most %v jobs at once, Got %v", 2, p)
+	}
+	if l.InFlight() != 0 {
+		t.Errorf("Expected %v, Got %v", 0, l.InFlight())
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrOverloaded is returned when a job is shed as the jobs of the pool wait
+// in the queue for longer than Options.MaxQueueWait.
+var ErrOverloaded = errors.New("goworkers: overloaded")
+
+// queueWaitWeight is the weight of the latest queue wait in the moving
+// average, as a power of two
+const queueWaitWeight = 3
+
+// observeQueueWait folds the queue wait of a job that is starting into the
+// moving average
+func (gw *GoWorkers) observeQueueWait(t *task, started time.Time) {
+	if t.submitted.IsZero() {
+		return
+	}
+	wait := int64(started.Sub(t.submitted))
+	for {
+		avg := atomic.LoadInt64(&gw.queueWait)
+		next := avg + (wait-avg)>>queueWaitWeight
+		if atomic.CompareAndSwapInt64(&gw.queueWait, avg, next) {
+			return
+		}
+	}
+}
+
+// overloaded reports whether a job should be shed. The average only moves
+// when jobs start, so jobs are admitted whenever none is queued.
+func (gw *GoWorkers) overloaded() bool {
+	max := atomic.LoadInt64(&gw.maxQueueWait)
+	if max == 0 {
+		return false
+	}
+	return (gw.queued() > 0) && (atomic.LoadInt64(&gw.queueWait) > max)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxQueueWait(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Workers: 1, Clock: clock, MaxQueueWait: time.Second})
+
+	release := make(chan struct{})
+	gw.Submit(func() { <-release })
+	for gw.Stats().Running != 1 {
+	}
+	for i := 0; i < 16; i++ {
+		if err := gw.Submit(func() {}); err != nil {
+			t.Fatalf("Expected nil, Got %v", err)
+		}
+	}
+
+	// the queued jobs waited for 10s each
+	clock.Advance(10 * time.Second)
+	close(release)
+	for gw.Stats().QueueWait <= time.Second {
+	}
+
+	// shed while jobs are queued
+	blocked, started := make(chan struct{}), make(chan struct{})
+	for gw.JobNum() != 0 {
+	}
+	gw.Submit(func() { close(started); <-blocked })
+	<-started
+	if err := gw.Submit(func() {}); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if err := gw.Submit(func() {}); err != ErrOverloaded {
+		t.Errorf("Expected %v, Got %v", ErrOverloaded, err)
+	}
+	if gw.TrySubmit(func() {}) {
+		t.Errorf("Expected the job to be shed")
+	}
+
+	// admitted again once the queue is empty
+	close(blocked)
+	for gw.JobNum() != 0 {
+	}
+	if err := gw.Submit(func() {}); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	gw.Stop(false)
+}
+
+func TestQueueWaitUnmeasured(t *testing.T) {
+	gw := New()
+	gw.Submit(func() {})
+	gw.Stop(false)
+
+	if st := gw.Stats(); st.QueueWait != 0 {
+		t.Errorf("Expected 0, Got %v", st.QueueWait)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package amqpconsumer implements an adapter that maps the deliveries of an
+// AMQP (RabbitMQ) queue to goworkers jobs.
+//
+// A delivery is acked if its handler succeeds and nacked otherwise, unless
+// Options.Delivery is goworkers.AtMostOnce. The
+// prefetch count of the channel is tied to the number of workers of the
+// pool, so that the broker never pushes more deliveries than the pool can
+// run at once.
+//
+// The package does not depend on an AMQP client. Wrap the client of your
+// choice, e.g. amqp091-go, to implement Channel and Delivery.
+package amqpconsumer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dpaks/goworkers"
+)
+
+// defaultPrefetch is used for pools that spawn workers as per demand
+const defaultPrefetch = 64
+
+// Delivery is a message delivered by the broker.
+type Delivery interface {
+	Body() []byte
+	// Ack acknowledges the delivery.
+	Ack() error
+	// Nack negatively acknowledges the delivery, requeueing it if requeue is true.
+	Nack(requeue bool) error
+}
+
+// Channel is the subset of an AMQP channel used by the consumer.
+type Channel interface {
+	// Qos sets the number of unacknowledged deliveries the broker may push.
+	Qos(prefetchCount int) error
+	// Consume starts delivering the messages of queue until ctx is done,
+	// after which the returned channel must be closed.
+	Consume(ctx context.Context, queue string) (<-chan Delivery, error)
+}
+
+// Handler processes the body of a delivery. The delivery is acked if the
+// handler returns nil.
+type Handler func(body []byte) error
+
+// Options configures the consumer.
+//
+// Queue is the name of the queue to consume from.
+//
+// Prefetch specifies the prefetch count of the channel. If unspecified or
+// zero, the maximum number of workers of the pool is used, or 64 if the pool
+// spawns workers as per demand.
+//
+// Requeue specifies whether a delivery whose handler failed is requeued.
+// If false, the broker dead-letters or drops it as per the queue's policy.
+//
+// Delivery specifies the delivery semantics. Default is
+// goworkers.AtLeastOnce. With goworkers.AtMostOnce, a delivery is acked
+// right before its handler runs and Requeue is not used.
+type Options struct {
+	Queue    string
+	Prefetch uint32
+	Requeue  bool
+	Delivery goworkers.Delivery
+}
+
+// Consume submits the deliveries of the queue to gw until ctx is done.
+//
+// This is a blocking call. It returns after the jobs handed over to the pool
+// have finished, with the context's error or the first error of ch.
+func Consume(ctx context.Context, gw *goworkers.GoWorkers, ch Channel, h Handler, opts Options) error {
+	prefetch := opts.Prefetch
+	if prefetch == 0 {
+		prefetch = gw.MaxWorkers()
+	}
+	if prefetch == 0 {
+		prefetch = defaultPrefetch
+	}
+	if err := ch.Qos(int(prefetch)); err != nil {
+		return err
+	}
+
+	deliveries, err := ch.Consume(ctx, opts.Queue)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for d := range deliveries {
+		delivery := d
+		// set once the delivery is acked or nacked
+		var settled int32
+		wg.Add(1)
+		accepted := gw.TrySubmit(func() {
+			if opts.Delivery == goworkers.AtMostOnce {
+				atomic.StoreInt32(&settled, 1)
+				if delivery.Ack() == nil {
+					_ = h(delivery.Body())
+				}
+				return
+			}
+			err := h(delivery.Body())
+			atomic.StoreInt32(&settled, 1)
+			if err != nil {
+				_ = delivery.Nack(opts.Requeue)
+				return
+			}
+			_ = delivery.Ack()
+		}, goworkers.JobOptions{
+			// the handler panicked, or the job was skipped without running
+			OnFinish: func(err error) {
+				if atomic.LoadInt32(&settled) == 0 {
+					_ = delivery.Nack((err == nil) || opts.Requeue)
+				}
+				wg.Done()
+			},
+		})
+		if !accepted {
+			wg.Done()
+			_ = delivery.Nack(true)
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package amqpconsumer
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dpaks/goworkers"
+)
+
+type fakeDelivery struct {
+	body          string
+	acked, nacked *int32
+	requeued      *int32
+}
+
+func (d fakeDelivery) Body() []byte { return []byte(d.body) }
+func (d fakeDelivery) Ack() error   { atomic.AddInt32(d.acked, 1); return nil }
+func (d fakeDelivery) Nack(requeue bool) error {
+	atomic.AddInt32(d.nacked, 1)
+	if requeue {
+		atomic.AddInt32(d.requeued, 1)
+	}
+	return nil
+}
+
+type fakeChannel struct {
+	prefetch   int
+	deliveries chan Delivery
+}
+
+func (c *fakeChannel) Qos(prefetchCount int) error {
+	c.prefetch = prefetchCount
+	return nil
+}
+
+func (c *fakeChannel) Consume(ctx context.Context, queue string) (<-chan Delivery, error) {
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		for d := range c.deliveries {
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+		<-ctx.Done()
+	}()
+	return out, nil
+}
+
+func TestConsume(t *testing.T) {
+	var acked, nacked, requeued int32
+	ch := &fakeChannel{deliveries: make(chan Delivery, 10)}
+	for i := 0; i < 10; i++ {
+		ch.deliveries <- fakeDelivery{body: fmt.Sprint(i), acked: &acked, nacked: &nacked, requeued: &requeued}
+	}
+	close(ch.deliveries)
+
+	gw := goworkers.New(goworkers.Options{Workers: 4})
+	defer gw.Stop(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, gw, ch, func(body []byte) error {
+			if string(body) == "3" {
+				return fmt.Errorf("failed")
+			}
+			return nil
+		}, Options{Queue: "jobs"})
+	}()
+
+	for atomic.LoadInt32(&acked)+atomic.LoadInt32(&nacked) != 10 {
+	}
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+	if ch.prefetch != 4 {
+		t.Errorf("Expected the prefetch to match the 4 workers, Got %d", ch.prefetch)
+	}
+	if acked != 9 || nacked != 1 || requeued != 0 {
+		t.Errorf("Expected 9 acks and 1 nack without requeue, Got %d, %d and %d", acked, nacked, requeued)
+	}
+}
+
+func TestConsumeAtMostOnce(t *testing.T) {
+	var acked, nacked, requeued int32
+	ch := &fakeChannel{deliveries: make(chan Delivery, 10)}
+	for i := 0; i < 10; i++ {
+		ch.deliveries <- fakeDelivery{body: fmt.Sprint(i), acked: &acked, nacked: &nacked, requeued: &requeued}
+	}
+	close(ch.deliveries)
+
+	gw := goworkers.New(goworkers.Options{Workers: 4})
+	defer gw.Stop(false)
+
+	var handled int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, gw, ch, func(body []byte) error {
+			if atomic.LoadInt32(&acked) == atomic.LoadInt32(&handled) {
+				t.Errorf("Expected the delivery to be acked before its handler runs")
+			}
+			atomic.AddInt32(&handled, 1)
+			return fmt.Errorf("failed")
+		}, Options{Queue: "jobs", Delivery: goworkers.AtMostOnce})
+	}()
+
+	for atomic.LoadInt32(&handled) != 10 {
+	}
+	cancel()
+	<-done
+
+	if acked != 10 || nacked != 0 {
+		t.Errorf("Expected 10 acks and no nack, Got %d and %d", acked, nacked)
+	}
+}
+
+func TestConsumeDefaultPrefetch(t *testing.T) {
+	ch := &fakeChannel{deliveries: make(chan Delivery)}
+	close(ch.deliveries)
+
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = Consume(ctx, gw, ch, func(body []byte) error { return nil }, Options{})
+
+	if ch.prefetch != defaultPrefetch {
+		t.Errorf("Expected %d, Got %d", defaultPrefetch, ch.prefetch)
+	}
+}
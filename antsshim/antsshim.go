@@ -0,0 +1,111 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package antsshim implements the API of the pools of panjf2000/ants on top
+// of goworkers, such that code written against ants can switch to goworkers
+// by changing its imports only:
+//
+//	p, _ := antsshim.NewPool(10)
+//	defer p.Release()
+//	_ = p.Submit(func() { ... })
+//
+// The functional options of ants are not supported. Unlike with ants,
+// Submit() does not block when all the workers are busy, the job is queued
+// instead.
+package antsshim
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/dpaks/goworkers"
+)
+
+// ErrPoolClosed is returned by Submit() once the pool is released, as with
+// ants.
+var ErrPoolClosed = errors.New("this pool has been closed")
+
+// Pool is a pool of goroutines with the API of ants.Pool.
+type Pool struct {
+	gw     *goworkers.GoWorkers
+	closed int32
+}
+
+// NewPool creates a new pool of size workers. If size is zero or negative,
+// the pool is unbounded, as with ants.
+func NewPool(size int) (*Pool, error) {
+	if size < 0 {
+		size = 0
+	}
+	return &Pool{gw: goworkers.New(goworkers.Options{Workers: uint32(size)})}, nil
+}
+
+// Pool returns the goworkers pool backing p, e.g. to use the features of
+// goworkers while migrating.
+func (p *Pool) Pool() *goworkers.GoWorkers {
+	return p.gw
+}
+
+// Submit submits a task to the pool.
+// Returns ErrPoolClosed if the pool is released.
+func (p *Pool) Submit(task func()) error {
+	if err := p.gw.Submit(task); err != nil {
+		if errors.Is(err, goworkers.ErrStopped) {
+			return ErrPoolClosed
+		}
+		return err
+	}
+	return nil
+}
+
+// Running returns the number of workers running a task.
+func (p *Pool) Running() int {
+	return int(p.gw.Stats().Running)
+}
+
+// Free returns the number of workers available, -1 if the pool is unbounded.
+func (p *Pool) Free() int {
+	c := p.Cap()
+	if c == -1 {
+		return -1
+	}
+	if free := c - p.Running(); free > 0 {
+		return free
+	}
+	return 0
+}
+
+// Waiting returns the number of tasks waiting for a worker.
+func (p *Pool) Waiting() int {
+	return int(p.gw.Stats().Queued)
+}
+
+// Cap returns the capacity of the pool, -1 if the pool is unbounded.
+func (p *Pool) Cap() int {
+	if max := p.gw.MaxWorkers(); max != 0 {
+		return int(max)
+	}
+	return -1
+}
+
+// Tune changes the capacity of the pool. Unlike with ants, an unbounded pool
+// can be bounded, and a bounded one made unbounded with a size of zero or
+// less.
+func (p *Pool) Tune(size int) {
+	if size < 0 {
+		size = 0
+	}
+	_ = p.gw.ApplyOptions(goworkers.Options{Workers: uint32(size)})
+}
+
+// IsClosed reports whether the pool is released.
+func (p *Pool) IsClosed() bool {
+	return atomic.LoadInt32(&p.closed) == 1
+}
+
+// Release waits for the submitted tasks to finish and closes the pool.
+func (p *Pool) Release() {
+	atomic.StoreInt32(&p.closed, 1)
+	_ = p.gw.Stop(false)
+}
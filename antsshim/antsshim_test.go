@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package antsshim
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	p, err := NewPool(4)
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var ran int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		if err := p.Submit(func() {
+			defer wg.Done()
+			atomic.AddInt32(&ran, 1)
+		}); err != nil {
+			t.Fatalf("Expected nil, Got %v", err)
+		}
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&ran); n != 10 {
+		t.Errorf("Expected %v, Got %v", 10, n)
+	}
+
+	tables := []struct {
+		name     string
+		got      int
+		expected int
+	}{
+		{"Cap", p.Cap(), 4},
+		{"Free", p.Free(), 4},
+		{"Waiting", p.Waiting(), 0},
+	}
+	for _, table := range tables {
+		if table.got != table.expected {
+			t.Errorf("%s: Expected %v, Got %v", table.name, table.expected, table.got)
+		}
+	}
+
+	p.Tune(8)
+	if c := p.Cap(); c != 8 {
+		t.Errorf("Expected %v, Got %v", 8, c)
+	}
+	p.Tune(0)
+	if c, f := p.Cap(), p.Free(); c != -1 || f != -1 {
+		t.Errorf("Expected an unbounded pool, Got cap %v and free %v", c, f)
+	}
+
+	p.Release()
+	if !p.IsClosed() {
+		t.Errorf("Expected the pool to be closed")
+	}
+	if err := p.Submit(func() {}); err != ErrPoolClosed {
+		t.Errorf("Expected %v, Got %v", ErrPoolClosed, err)
+	}
+}
+
+func TestRunning(t *testing.T) {
+	p, _ := NewPool(2)
+	defer p.Release()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	_ = p.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	if n := p.Running(); n != 1 {
+		t.Errorf("Expected %v, Got %v", 1, n)
+	}
+	if n := p.Free(); n != 1 {
+		t.Errorf("Expected %v, Got %v", 1, n)
+	}
+	close(release)
+}
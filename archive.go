@@ -0,0 +1,178 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoArchive is returned by QueryHistory() for a pool without an archive.
+var ErrNoArchive = errors.New("goworkers: no archive")
+
+// JobStatus is the final status of a job.
+type JobStatus string
+
+const (
+	// JobSucceeded is the status of a job that returned no error.
+	JobSucceeded JobStatus = "succeeded"
+	// JobFailed is the status of a job that returned an error.
+	JobFailed JobStatus = "failed"
+)
+
+// JobRecord is the outcome of a finished job, see Archive.
+type JobRecord struct {
+	Name     string            `json:"name,omitempty"`
+	Key      string            `json:"key,omitempty"`
+	Tenant   string            `json:"tenant,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Status   JobStatus         `json:"status"`
+	Error    string            `json:"error,omitempty"`
+	Started  time.Time         `json:"started"`
+	Duration time.Duration     `json:"duration"`
+}
+
+// HistoryFilter selects the records returned by QueryHistory(). Zero fields
+// match every record.
+//
+// Metadata matches the records carrying all of its entries. Since and Until
+// bound the start time of the jobs. Limit is the maximum number of records
+// returned, the most recent ones.
+type HistoryFilter struct {
+	Name     string
+	Key      string
+	Tenant   string
+	Status   JobStatus
+	Metadata map[string]string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// Match reports whether r is selected by the filter, regardless of Limit.
+func (f HistoryFilter) Match(r JobRecord) bool {
+	if ((f.Name != "") && (r.Name != f.Name)) ||
+		((f.Key != "") && (r.Key != f.Key)) ||
+		((f.Tenant != "") && (r.Tenant != f.Tenant)) ||
+		((f.Status != "") && (r.Status != f.Status)) {
+		return false
+	}
+	if (!f.Since.IsZero() && r.Started.Before(f.Since)) || (!f.Until.IsZero() && r.Started.After(f.Until)) {
+		return false
+	}
+	for k, v := range f.Metadata {
+		if r.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Archive persists the outcomes of the finished jobs of a pool, see
+// Options.Archive, such that support can tell what happened to a job after
+// the fact.
+type Archive interface {
+	// Record persists the outcome of a finished job.
+	Record(r JobRecord) error
+	// Query returns the records selected by f, most recent first.
+	Query(f HistoryFilter) ([]JobRecord, error)
+}
+
+// MemoryArchive is an Archive held in memory, which keeps the records for
+// a retention period.
+//
+// It is not durable and is meant for tests and for processes whose history
+// need not survive a restart.
+type MemoryArchive struct {
+	ttl time.Duration
+
+	mu sync.Mutex
+	// oldest first
+	records []JobRecord
+}
+
+// NewMemoryArchive creates a new in-memory archive keeping the records for
+// ttl of real time after their job finished. If ttl is zero, the records
+// are kept forever.
+func NewMemoryArchive(ttl time.Duration) *MemoryArchive {
+	return &MemoryArchive{ttl: ttl}
+}
+
+// Record persists the outcome of a finished job.
+func (m *MemoryArchive) Record(r JobRecord) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	m.expire()
+	m.records = append(m.records, r)
+	return nil
+}
+
+// Query returns the records selected by f, most recent first.
+func (m *MemoryArchive) Query(f HistoryFilter) ([]JobRecord, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	m.expire()
+	var records []JobRecord
+	for i := len(m.records) - 1; i >= 0; i-- {
+		if (f.Limit > 0) && (len(records) == f.Limit) {
+			break
+		}
+		if f.Match(m.records[i]) {
+			records = append(records, m.records[i])
+		}
+	}
+	return records, nil
+}
+
+// expire must be called with mu held
+func (m *MemoryArchive) expire() {
+	if m.ttl == 0 {
+		return
+	}
+	now := time.Now()
+	n := 0
+	for ; n < len(m.records); n++ {
+		r := m.records[n]
+		if now.Sub(r.Started.Add(r.Duration)) < m.ttl {
+			break
+		}
+	}
+	m.records = m.records[n:]
+}
+
+// QueryHistory returns the records of the finished jobs selected by f, most
+// recent first.
+// Returns ErrNoArchive if the pool has no Options.Archive.
+func (gw *GoWorkers) QueryHistory(f HistoryFilter) ([]JobRecord, error) {
+	if gw.archive == nil {
+		return nil, ErrNoArchive
+	}
+	return gw.archive.Query(f)
+}
+
+// record archives the outcome of t. Archival errors are delivered on
+// ErrChan, if there is room.
+func (gw *GoWorkers) record(t *task, started time.Time, elapsed time.Duration, err error) {
+	r := JobRecord{
+		Name:     t.opts.Name,
+		Key:      t.opts.Key,
+		Tenant:   t.opts.Tenant,
+		Metadata: t.opts.Metadata,
+		Status:   JobSucceeded,
+		Started:  started,
+		Duration: elapsed,
+	}
+	if err != nil {
+		r.Status, r.Error = JobFailed, err.Error()
+	}
+	if err := gw.archive.Record(r); err != nil {
+		select {
+		case gw.ErrChan <- fmt.Errorf("goworkers: archiving job: %w", err):
+		default:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueryHistory(t *testing.T) {
+	gw := New(Options{Archive: NewMemoryArchive(time.Hour)})
+
+	errFailed := errors.New("failed")
+	_ = gw.SubmitCheckError(func() error { return nil }, JobOptions{Name: "export", Key: "x1"})
+	gw.Wait(false)
+	_ = gw.SubmitCheckError(func() error { return errFailed }, JobOptions{Name: "export", Key: "x2", Metadata: map[string]string{"user": "42"}})
+	_ = gw.Submit(func() {})
+	gw.Stop(false)
+
+	tables := []struct {
+		filter HistoryFilter
+		keys   []string
+	}{
+		{HistoryFilter{Name: "export"}, []string{"x2", "x1"}},
+		{HistoryFilter{Name: "export", Limit: 1}, []string{"x2"}},
+		{HistoryFilter{Key: "x1"}, []string{"x1"}},
+		{HistoryFilter{Status: JobFailed}, []string{"x2"}},
+		{HistoryFilter{Metadata: map[string]string{"user": "42"}}, []string{"x2"}},
+		{HistoryFilter{Name: "import"}, nil},
+	}
+
+	for _, table := range tables {
+		records, err := gw.QueryHistory(table.filter)
+		if err != nil {
+			t.Fatalf("Expected nil, Got %v", err)
+		}
+		var keys []string
+		for _, r := range records {
+			keys = append(keys, r.Key)
+		}
+		if len(keys) != len(table.keys) {
+			t.Errorf("Expected %v, Got %v", table.keys, keys)
+			continue
+		}
+		for i := range keys {
+			if keys[i] != table.keys[i] {
+				t.Errorf("Expected %v, Got %v", table.keys, keys)
+			}
+		}
+	}
+
+	records, _ := gw.QueryHistory(HistoryFilter{Key: "x2"})
+	if records[0].Error != "failed" || records[0].Name != "export" {
+		t.Errorf("Expected the error of job export, Got %+v", records[0])
+	}
+	if all, _ := gw.QueryHistory(HistoryFilter{}); len(all) != 3 {
+		t.Errorf("Expected %v, Got %v", 3, len(all))
+	}
+}
+
+func TestMemoryArchiveExpiry(t *testing.T) {
+	m := NewMemoryArchive(10 * time.Millisecond)
+	_ = m.Record(JobRecord{Key: "old", Started: time.Now()})
+	time.Sleep(20 * time.Millisecond)
+	_ = m.Record(JobRecord{Key: "new", Started: time.Now()})
+
+	records, _ := m.Query(HistoryFilter{})
+	if len(records) != 1 || records[0].Key != "new" {
+		t.Errorf("Expected the old record to expire, Got %+v", records)
+	}
+}
+
+func TestQueryHistoryNoArchive(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	if _, err := gw.QueryHistory(HistoryFilter{}); err != ErrNoArchive {
+		t.Errorf("Expected %v, Got %v", ErrNoArchive, err)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// BackpressureState tells producers whether to keep submitting jobs to a
+// pool, see Backpressure().
+type BackpressureState int
+
+const (
+	// Flowing is the state of a pool whose queue has drained down to the
+	// low watermark. Producers may resume.
+	Flowing BackpressureState = iota
+	// Throttled is the state of a pool whose queue has filled up to the high
+	// watermark. Producers should pause.
+	Throttled
+)
+
+func (s BackpressureState) String() string {
+	switch s {
+	case Flowing:
+		return "flowing"
+	case Throttled:
+		return "throttled"
+	}
+	return "unknown"
+}
+
+// backpressure tracks the state of the queue against the watermarks
+type backpressure struct {
+	// accessed atomically, see ApplyOptions()
+	high, low uint32
+	// set once throttled, read without mu on the fast path
+	throttled int32
+
+	mu     sync.Mutex
+	closed bool
+	c      chan BackpressureState
+}
+
+func newBackpressure(opts Options) *backpressure {
+	return &backpressure{
+		high: opts.HighWatermark,
+		low:  opts.LowWatermark,
+		c:    make(chan BackpressureState, 1),
+	}
+}
+
+// Backpressure returns a channel on which the transitions of the pool
+// between Flowing and Throttled are delivered, such that producers, e.g. a
+// socket reader, can pause and resume reading as the queue crosses the
+// watermarks, see Options.HighWatermark.
+//
+// The channel holds the latest transition only, replacing the ones a slow
+// receiver has not read yet, and is closed after Stop() returns. A pool
+// starts Flowing, which is not delivered.
+func (gw *GoWorkers) Backpressure() <-chan BackpressureState {
+	return gw.backpressure.c
+}
+
+func (gw *GoWorkers) watermarks() (high, low uint32) {
+	high, low = gw.backpressure.watermarks()
+	if high == 0 {
+		high = gw.qsize()
+	}
+	// without a queue, the submissions waiting for a worker throttle the
+	// pool
+	if high == 0 {
+		high = 1
+	}
+	// a LowWatermark above the QSize used by default is of no use
+	if (low == 0) || (low >= high) {
+		low = high / 2
+	}
+	return high, low
+}
+
+// observeBackpressure must be called as the queue changes
+func (gw *GoWorkers) observeBackpressure() {
+	bp := gw.backpressure
+	high, low := gw.watermarks()
+	throttled := atomic.LoadInt32(&bp.throttled) == 1
+	if queued := gw.queued(); (!throttled && (queued < high)) || (throttled && (queued > low)) {
+		return
+	}
+
+	defer bp.mu.Unlock()
+	bp.mu.Lock()
+	// the state may have moved on meanwhile
+	throttled = atomic.LoadInt32(&bp.throttled) == 1
+	queued := gw.queued()
+	state := Flowing
+	switch {
+	case bp.closed:
+		return
+	case !throttled && (queued >= high):
+		atomic.StoreInt32(&bp.throttled, 1)
+		state = Throttled
+	case throttled && (queued <= low):
+		atomic.StoreInt32(&bp.throttled, 0)
+	default:
+		return
+	}
+
+	// replace the transition not read yet, if any
+	select {
+	case <-bp.c:
+	default:
+	}
+	bp.c <- state
+}
+
+// watermarks returns the watermarks as configured, zero if unspecified
+func (bp *backpressure) watermarks() (high, low uint32) {
+	return atomic.LoadUint32(&bp.high), atomic.LoadUint32(&bp.low)
+}
+
+func (bp *backpressure) setWatermarks(high, low uint32) {
+	atomic.StoreUint32(&bp.high, high)
+	atomic.StoreUint32(&bp.low, low)
+}
+
+func (bp *backpressure) close() {
+	defer bp.mu.Unlock()
+	bp.mu.Lock()
+	if !bp.closed {
+		bp.closed = true
+		close(bp.c)
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"testing"
+)
+
+func TestBackpressure(t *testing.T) {
+	gw := New(Options{Workers: 1, HighWatermark: 4, LowWatermark: 1})
+
+	started, release := make(chan struct{}), make(chan struct{})
+	_ = gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	for i := 0; i < 4; i++ {
+		_ = gw.Submit(func() { <-release })
+	}
+	if state := <-gw.Backpressure(); state != Throttled {
+		t.Errorf("Expected %v, Got %v", Throttled, state)
+	}
+
+	close(release)
+	if state := <-gw.Backpressure(); state != Flowing {
+		t.Errorf("Expected %v, Got %v", Flowing, state)
+	}
+
+	gw.Stop(false)
+	for range gw.Backpressure() {
+	}
+}
+
+func TestBackpressureState(t *testing.T) {
+	tables := []struct {
+		state    BackpressureState
+		expected string
+	}{
+		{Flowing, "flowing"},
+		{Throttled, "throttled"},
+		{BackpressureState(7), "unknown"},
+	}
+
+	for _, table := range tables {
+		if s := table.state.String(); s != table.expected {
+			t.Errorf("Expected %v, Got %v", table.expected, s)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of BatchOptions, unless specified.
+const (
+	defaultBatchSize     = 100
+	defaultBatchInterval = time.Second
+)
+
+// BatchOptions configures a Batcher.
+//
+// Size is the number of items that makes a batch. If unspecified or zero,
+// 100 is used.
+//
+// Interval is how long the first item of a batch may wait for the batch to
+// fill up. If unspecified or zero, 1 second is used.
+//
+// Job is applied to the jobs processing the batches.
+type BatchOptions struct {
+	Size     int
+	Interval time.Duration
+	Job      JobOptions
+}
+
+// Batcher accumulates items and submits one job processing them as a
+// batch, once Size items are added or Interval has passed since the first
+// one, e.g. for write-behind caches and bulk API calls.
+//
+// The pending items are submitted as a batch when the pool is stopped or
+// waited for, so that none are stranded.
+type Batcher struct {
+	gw   *GoWorkers
+	fn   func(items []interface{}) error
+	opts BatchOptions
+
+	mu    sync.Mutex
+	items []interface{}
+	// closed to stop waiting for the interval of the pending batch
+	flushed chan struct{}
+}
+
+// batchers holds the batchers of a pool, flushed as it is stopped or waited
+// for
+type batchers struct {
+	mu  sync.Mutex
+	set map[*Batcher]struct{}
+}
+
+// NewBatcher creates a new batcher submitting jobs that call fn with the
+// batches. The error of fn, if any, is delivered on ErrChan, as with
+// SubmitCheckError().
+//
+// Accepts optional BatchOptions{} argument.
+func (gw *GoWorkers) NewBatcher(fn func(items []interface{}) error, args ...BatchOptions) *Batcher {
+	var opts BatchOptions
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if opts.Size <= 0 {
+		opts.Size = defaultBatchSize
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultBatchInterval
+	}
+
+	b := &Batcher{gw: gw, fn: fn, opts: opts}
+	gw.batchers.mu.Lock()
+	if gw.batchers.set == nil {
+		gw.batchers.set = make(map[*Batcher]struct{})
+	}
+	gw.batchers.set[b] = struct{}{}
+	gw.batchers.mu.Unlock()
+	return b
+}
+
+// Add adds an item to the pending batch, and submits the batch if it is
+// full.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (b *Batcher) Add(item interface{}) error {
+	gw := b.gw
+	// held such that Stop() and Wait() find the items added before them
+	defer gw.submitMu.RUnlock()
+	gw.submitMu.RLock()
+	stopping := atomic.LoadInt32(&gw.stopping) != stateRunning
+	if stopping && !gw.calledFromJob() {
+		return ErrStopped
+	}
+
+	defer b.mu.Unlock()
+	b.mu.Lock()
+	b.items = append(b.items, item)
+	if len(b.items) == 1 {
+		b.await()
+	}
+	// the items added by the jobs of a pool being stopped or waited for
+	// would be stranded
+	if stopping || (len(b.items) >= b.opts.Size) {
+		gw.handOver(b.take())
+	}
+	return nil
+}
+
+// Flush submits the pending batch, if any, without waiting for it to fill
+// up.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (b *Batcher) Flush() error {
+	gw := b.gw
+	defer gw.submitMu.RUnlock()
+	gw.submitMu.RLock()
+	if (atomic.LoadInt32(&gw.stopping) != stateRunning) && !gw.calledFromJob() {
+		return ErrStopped
+	}
+	b.flush()
+	return nil
+}
+
+// Close submits the pending batch, if any, and releases the batcher, which
+// must not be used anymore.
+func (b *Batcher) Close() error {
+	b.gw.batchers.mu.Lock()
+	delete(b.gw.batchers.set, b)
+	b.gw.batchers.mu.Unlock()
+	return b.Flush()
+}
+
+// flush must be called with submitMu held for reading, or while no
+// submission can race with it
+func (b *Batcher) flush() {
+	defer b.mu.Unlock()
+	b.mu.Lock()
+	if len(b.items) != 0 {
+		b.gw.handOver(b.take())
+	}
+}
+
+// await submits the pending batch once the interval has passed. It must be
+// called with mu held.
+func (b *Batcher) await() {
+	gw, flushed := b.gw, make(chan struct{})
+	b.flushed = flushed
+	timer := gw.clock.NewTimer(b.opts.Interval)
+	gw.goHelper(func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			_ = b.Flush()
+		case <-flushed:
+		case <-gw.stopped:
+		}
+	})
+}
+
+// take must be called with mu held. It returns the job processing the
+// pending batch.
+func (b *Batcher) take() *task {
+	items := b.items
+	b.items = nil
+	close(b.flushed)
+	return &task{
+		run: func(context.Context) (interface{}, error) {
+			return nil, b.fn(items)
+		},
+		outputs: errOutput,
+		opts:    b.opts.Job,
+	}
+}
+
+// flushBatchers submits the pending batches of the pool. It must be called
+// once the pool no longer accepts submissions, see awaitSubmissions().
+func (gw *GoWorkers) flushBatchers() {
+	gw.batchers.mu.Lock()
+	pending := make([]*Batcher, 0, len(gw.batchers.set))
+	for b := range gw.batchers.set {
+		pending = append(pending, b)
+	}
+	gw.batchers.mu.Unlock()
+
+	for _, b := range pending {
+		b.flush()
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type batches struct {
+	mu  sync.Mutex
+	got [][]interface{}
+}
+
+func (b *batches) process(items []interface{}) error {
+	defer b.mu.Unlock()
+	b.mu.Lock()
+	b.got = append(b.got, items)
+	return nil
+}
+
+func (b *batches) len() int {
+	defer b.mu.Unlock()
+	b.mu.Lock()
+	return len(b.got)
+}
+
+func TestBatcherSize(t *testing.T) {
+	gw := New()
+	var b batches
+	batcher := gw.NewBatcher(b.process, BatchOptions{Size: 3, Interval: time.Hour})
+
+	for i := 0; i < 7; i++ {
+		if err := batcher.Add(i); err != nil {
+			t.Fatalf("Expected nil, Got %v", err)
+		}
+	}
+	for b.len() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the pending item is not stranded by Stop()
+	gw.Stop(false)
+	if n := b.len(); n != 3 {
+		t.Fatalf("Expected %v batches, Got %v", 3, n)
+	}
+	sizes := 0
+	for _, items := range b.got {
+		sizes += len(items)
+		if len(items) == 1 && items[0] != 6 {
+			t.Errorf("Expected the pending item, Got %v", items)
+		}
+	}
+	if sizes != 7 {
+		t.Errorf("Expected %v items, Got %v", 7, sizes)
+	}
+	if err := batcher.Add(7); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+	if err := gw.VerifyShutdown(time.Second); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+}
+
+func TestBatcherInterval(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Clock: clock})
+	defer gw.Stop(false)
+	var b batches
+	batcher := gw.NewBatcher(b.process, BatchOptions{Size: 10, Interval: time.Second})
+
+	_ = batcher.Add("a")
+	_ = batcher.Add("b")
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	for b.len() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if len(b.got[0]) != 2 {
+		t.Errorf("Expected %v items, Got %v", 2, b.got[0])
+	}
+}
+
+func TestBatcherClose(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+	var b batches
+	batcher := gw.NewBatcher(b.process)
+
+	_ = batcher.Add("a")
+	if err := batcher.Close(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	gw.Wait(false)
+	if n := b.len(); n != 1 {
+		t.Errorf("Expected %v, Got %v", 1, n)
+	}
+}
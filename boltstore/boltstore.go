@@ -0,0 +1,404 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package boltstore implements a goworkers.Store persisted in a bbolt
+// database, suitable for single-node daemons that must not lose queued work
+// across restarts.
+//
+// Wrap the store with goworkers.EncryptStore() to encrypt the payloads of
+// the jobs at rest.
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dpaks/goworkers"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	jobsBucket = []byte("goworkers.jobs")
+	deadBucket = []byte("goworkers.dead")
+)
+
+// ErrNotCompactable is returned by Compact for the stores created with New,
+// whose database cannot be reopened.
+var ErrNotCompactable = errors.New("boltstore: only the stores created with Open can be compacted")
+
+type record struct {
+	Data        []byte `json:"data"`
+	Attempts    uint32 `json:"attempts"`
+	Enqueued    int64  `json:"enqueued,omitempty"`
+	Checkpoint  []byte `json:"checkpoint,omitempty"`
+	LeasedUntil int64  `json:"leased_until,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// Store is a goworkers.Store backed by a bbolt database.
+//
+// It is a goworkers.CheckpointStore, and a goworkers.Maintainer: Vacuum
+// expires and quarantines jobs, and Compact shrinks the database file, which
+// bbolt never does by itself. Jobs enqueued before the store recorded enqueue times never expire.
+type Store struct {
+	// mu is held for writing while Compact swaps the database
+	mu   sync.RWMutex
+	db   *bolt.DB
+	path string
+}
+
+// Open opens, creating it if needed, the database at path.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.path = path
+	return s, nil
+}
+
+// New creates a store in an already open database.
+func New(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{jobsBucket, deadBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	defer s.mu.RUnlock()
+	s.mu.RLock()
+	return s.db.Close()
+}
+
+// Enqueue durably adds a job and returns its id.
+func (s *Store) Enqueue(data []byte) (string, error) {
+	var id uint64
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		id = seq
+		return put(b, key(seq), record{Data: data, Enqueued: time.Now().UnixNano()})
+	})
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(id, 10), nil
+}
+
+// Lease hands out the oldest available job for d. Corrupted jobs, which
+// would otherwise block the queue, are quarantined on the way.
+func (s *Store) Lease(d time.Duration) (goworkers.StoredJob, error) {
+	var sj goworkers.StoredJob
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		now := time.Now()
+
+		// keys are big endian sequences, so the cursor walks the oldest first
+		var corrupted []corruptedJob
+		err := goworkers.ErrNoJob
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var r record
+			if err := json.Unmarshal(v, &r); err != nil {
+				corrupted = append(corrupted, newCorruptedJob(k, v, err))
+				continue
+			}
+			if now.UnixNano() < r.LeasedUntil {
+				continue
+			}
+			r.LeasedUntil = now.Add(d).UnixNano()
+			r.Attempts++
+			if err := put(b, k, r); err != nil {
+				return err
+			}
+			sj = goworkers.StoredJob{
+				ID:         strconv.FormatUint(binary.BigEndian.Uint64(k), 10),
+				Data:       r.Data,
+				Attempts:   r.Attempts,
+				Checkpoint: r.Checkpoint,
+			}
+			err = nil
+			break
+		}
+		if err := quarantine(tx, corrupted); err != nil {
+			return err
+		}
+		return err
+	})
+	return sj, err
+}
+
+// ExtendLease extends the lease of a job to d from now.
+func (s *Store) ExtendLease(id string, d time.Duration) error {
+	k, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		v := b.Get(k)
+		if v == nil {
+			return goworkers.ErrJobNotFound
+		}
+		var r record
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		r.LeasedUntil = time.Now().Add(d).UnixNano()
+		return put(b, k, r)
+	})
+}
+
+// SaveCheckpoint records state as the last checkpoint of a job.
+func (s *Store) SaveCheckpoint(id string, state []byte) error {
+	k, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		v := b.Get(k)
+		if v == nil {
+			return goworkers.ErrJobNotFound
+		}
+		var r record
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		r.Checkpoint = state
+		return put(b, k, r)
+	})
+}
+
+// Ack removes a finished job.
+func (s *Store) Ack(id string) error {
+	k, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		if b.Get(k) == nil {
+			return goworkers.ErrJobNotFound
+		}
+		return b.Delete(k)
+	})
+}
+
+// DeadLetter moves a job to the dead-letter bucket, recording the reason.
+func (s *Store) DeadLetter(id string, reason string) error {
+	k, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		v := b.Get(k)
+		if v == nil {
+			return goworkers.ErrJobNotFound
+		}
+		var r record
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		r.LeasedUntil = 0
+		r.Reason = reason
+		if err := put(tx.Bucket(deadBucket), k, r); err != nil {
+			return err
+		}
+		return b.Delete(k)
+	})
+}
+
+// DeadLetters returns the reason for every dead-lettered job by its id.
+func (s *Store) DeadLetters() (map[string]string, error) {
+	dead := make(map[string]string)
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadBucket).ForEach(func(k, v []byte) error {
+			var r record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			dead[strconv.FormatUint(binary.BigEndian.Uint64(k), 10)] = r.Reason
+			return nil
+		})
+	})
+	return dead, err
+}
+
+// Len returns number of jobs in the queue, leased or not.
+func (s *Store) Len() (int, error) {
+	var n int
+	err := s.view(func(tx *bolt.Tx) error {
+		n = tx.Bucket(jobsBucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+// Vacuum dead-letters the jobs that are not leased and were enqueued more
+// than ttl ago, if ttl is not zero, and quarantines the jobs whose record is
+// corrupted.
+func (s *Store) Vacuum(ttl time.Duration) (goworkers.VacuumStats, error) {
+	var st goworkers.VacuumStats
+	err := s.update(func(tx *bolt.Tx) error {
+		b, dead := tx.Bucket(jobsBucket), tx.Bucket(deadBucket)
+		now := time.Now().UnixNano()
+
+		var corrupted []corruptedJob
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var r record
+			if err := json.Unmarshal(v, &r); err != nil {
+				corrupted = append(corrupted, newCorruptedJob(k, v, err))
+				return nil
+			}
+			if (ttl == 0) || (r.Enqueued == 0) || (now < r.LeasedUntil) || (now-r.Enqueued <= int64(ttl)) {
+				return nil
+			}
+			r.Reason = goworkers.ErrJobExpired.Error()
+			expired = append(expired, append([]byte(nil), k...))
+			return put(dead, k, r)
+		})
+		if err != nil {
+			return err
+		}
+		// buckets must not be changed while iterated
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		if err := quarantine(tx, corrupted); err != nil {
+			return err
+		}
+		st = goworkers.VacuumStats{Expired: len(expired), Quarantined: len(corrupted)}
+		return nil
+	})
+	return st, err
+}
+
+// Compact rewrites the database into a new file, which drops the pages
+// freed by the acknowledged jobs, and swaps it for the current one. The
+// store is blocked meanwhile.
+// Returns ErrNotCompactable if the store was created with New.
+func (s *Store) Compact() error {
+	if s.path == "" {
+		return ErrNotCompactable
+	}
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	tmp := s.path + ".compact"
+	dst, err := bolt.Open(tmp, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	err = bolt.Compact(dst, s.db, 0)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := s.db.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	swapErr := os.Rename(tmp, s.path)
+	if swapErr != nil {
+		os.Remove(tmp)
+	}
+	// reopen the compacted database, or the original one if the swap failed
+	db, err := bolt.Open(s.path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	s.db = db
+	return swapErr
+}
+
+func (s *Store) update(fn func(tx *bolt.Tx) error) error {
+	defer s.mu.RUnlock()
+	s.mu.RLock()
+	return s.db.Update(fn)
+}
+
+func (s *Store) view(fn func(tx *bolt.Tx) error) error {
+	defer s.mu.RUnlock()
+	s.mu.RLock()
+	return s.db.View(fn)
+}
+
+type corruptedJob struct {
+	k, v []byte
+	err  error
+}
+
+// newCorruptedJob copies k and v, which are valid until the bucket changes only
+func newCorruptedJob(k, v []byte, err error) corruptedJob {
+	return corruptedJob{append([]byte(nil), k...), append([]byte(nil), v...), err}
+}
+
+// quarantine moves the corrupted jobs to the dead-letter bucket, keeping
+// their raw record for inspection
+func quarantine(tx *bolt.Tx, jobs []corruptedJob) error {
+	for _, j := range jobs {
+		r := record{Data: j.v, Reason: goworkers.CorruptedReason(j.err)}
+		if err := put(tx.Bucket(deadBucket), j.k, r); err != nil {
+			return err
+		}
+		if err := tx.Bucket(jobsBucket).Delete(j.k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func key(seq uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return k
+}
+
+func parseID(id string) ([]byte, error) {
+	seq, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, goworkers.ErrJobNotFound
+	}
+	return key(seq), nil
+}
+
+func put(b *bolt.Bucket, k []byte, r record) error {
+	v, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return b.Put(k, v)
+}
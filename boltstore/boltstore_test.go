@@ -0,0 +1,231 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package boltstore
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+	bolt "go.etcd.io/bbolt"
+)
+
+// the store must be usable wherever a goworkers.Store is expected
+var (
+	_ goworkers.Store           = (*Store)(nil)
+	_ goworkers.LeaseExtender   = (*Store)(nil)
+	_ goworkers.Maintainer      = (*Store)(nil)
+	_ goworkers.CheckpointStore = (*Store)(nil)
+)
+
+func open(t *testing.T, path string) *Store {
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	return s
+}
+
+func TestLeaseAndAck(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "jobs.db"))
+	defer s.Close()
+
+	first, _ := s.Enqueue([]byte("1"))
+	second, _ := s.Enqueue([]byte("2"))
+
+	sj, err := s.Lease(time.Hour)
+	if err != nil || sj.ID != first || string(sj.Data) != "1" || sj.Attempts != 1 {
+		t.Fatalf("Expected the oldest job on its first attempt, Got %+v and %v", sj, err)
+	}
+	if sj, _ := s.Lease(time.Hour); sj.ID != second {
+		t.Errorf("Expected %s, Got %s", second, sj.ID)
+	}
+	if _, err := s.Lease(time.Hour); err != goworkers.ErrNoJob {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrNoJob, err)
+	}
+
+	if err := s.Ack(first); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if err := s.Ack(first); err != goworkers.ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrJobNotFound, err)
+	}
+	if n, _ := s.Len(); n != 1 {
+		t.Errorf("Expected 1 job, Got %d", n)
+	}
+}
+
+func TestExtendLease(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "jobs.db"))
+	defer s.Close()
+
+	id, _ := s.Enqueue([]byte("1"))
+	_, _ = s.Lease(10 * time.Millisecond)
+	if err := s.ExtendLease(id, time.Hour); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := s.Lease(time.Hour); err != goworkers.ErrNoJob {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrNoJob, err)
+	}
+	if err := s.ExtendLease("42", time.Hour); err != goworkers.ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrJobNotFound, err)
+	}
+}
+
+func TestSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+
+	s := open(t, path)
+	id, _ := s.Enqueue([]byte("job"))
+	// leased and checkpointed but never acknowledged before the crash
+	_, _ = s.Lease(10 * time.Millisecond)
+	if err := s.SaveCheckpoint(id, []byte("step 3")); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	s.Close()
+
+	time.Sleep(20 * time.Millisecond)
+
+	s = open(t, path)
+	defer s.Close()
+
+	sj, err := s.Lease(time.Hour)
+	if err != nil || sj.ID != id || sj.Attempts != 2 || string(sj.Checkpoint) != "step 3" {
+		t.Errorf("Expected the job to be leased again with its checkpoint, Got %+v and %v", sj, err)
+	}
+	if err := s.SaveCheckpoint("42", nil); err != goworkers.ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrJobNotFound, err)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "jobs.db"))
+	defer s.Close()
+
+	id, _ := s.Enqueue([]byte("job"))
+	if err := s.DeadLetter(id, "poison"); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+
+	dead, err := s.DeadLetters()
+	if err != nil || dead[id] != "poison" {
+		t.Errorf("Expected the job to be dead-lettered, Got %v and %v", dead, err)
+	}
+	if _, err := s.Lease(time.Hour); err != goworkers.ErrNoJob {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrNoJob, err)
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "jobs.db"))
+	defer s.Close()
+
+	leased, _ := s.Enqueue([]byte("leased"))
+	stale, _ := s.Enqueue([]byte("stale"))
+	if _, err := s.Lease(time.Hour); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	fresh, _ := s.Enqueue([]byte("fresh"))
+
+	// corrupt a job behind the back of the store
+	k, _ := parseID(fresh)
+	corrupted := key(binary.BigEndian.Uint64(k) + 1)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put(corrupted, []byte("{not json"))
+	})
+	if err != nil {
+		t.Fatalf("Failed to corrupt: %v", err)
+	}
+
+	st, err := s.Vacuum(10 * time.Millisecond)
+	if err != nil || st != (goworkers.VacuumStats{Expired: 1, Quarantined: 1}) {
+		t.Fatalf("Expected one expired and one quarantined job, Got %+v and %v", st, err)
+	}
+
+	dead, _ := s.DeadLetters()
+	if dead[stale] != goworkers.ErrJobExpired.Error() {
+		t.Errorf("Expected %v, Got %q", goworkers.ErrJobExpired, dead[stale])
+	}
+	if reason := dead[strconv.FormatUint(binary.BigEndian.Uint64(corrupted), 10)]; !strings.HasPrefix(reason, goworkers.ErrCorruptedJob.Error()) {
+		t.Errorf("Expected the corrupted job to be quarantined, Got %q", reason)
+	}
+	for _, id := range []string{leased, fresh} {
+		if _, ok := dead[id]; ok {
+			t.Errorf("Expected job %s not to expire", id)
+		}
+	}
+}
+
+func TestLeaseQuarantinesCorruptedJobs(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "jobs.db"))
+	defer s.Close()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put(key(0), []byte("{not json"))
+	})
+	if err != nil {
+		t.Fatalf("Failed to corrupt: %v", err)
+	}
+	id, _ := s.Enqueue([]byte("job"))
+
+	sj, err := s.Lease(time.Hour)
+	if err != nil || sj.ID != id {
+		t.Fatalf("Expected job %s, Got %+v and %v", id, sj, err)
+	}
+	if dead, _ := s.DeadLetters(); len(dead) != 1 {
+		t.Errorf("Expected the corrupted job to be quarantined, Got %v", dead)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	s := open(t, path)
+	defer s.Close()
+
+	payload := make([]byte, 64<<10)
+	var ids []string
+	for i := 0; i < 64; i++ {
+		id, _ := s.Enqueue(payload)
+		ids = append(ids, id)
+	}
+	kept := ids[0]
+	for _, id := range ids[1:] {
+		_ = s.Ack(id)
+	}
+
+	before, _ := os.Stat(path)
+	if err := s.Compact(); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size() {
+		t.Errorf("Expected the file to shrink from %d bytes, Got %d", before.Size(), after.Size())
+	}
+
+	sj, err := s.Lease(time.Hour)
+	if err != nil || sj.ID != kept {
+		t.Errorf("Expected job %s to survive, Got %+v and %v", kept, sj, err)
+	}
+}
+
+func TestCompactNotCompactable(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "jobs.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer db.Close()
+	s, _ := New(db)
+	if err := s.Compact(); err != ErrNotCompactable {
+		t.Errorf("Expected %v, Got %v", ErrNotCompactable, err)
+	}
+}
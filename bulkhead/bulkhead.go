@@ -0,0 +1,118 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package bulkhead implements a net/http middleware that executes the
+// handling of every request as a job on a goworkers pool.
+//
+// Requests are rejected with 503 Service Unavailable when the queue of the
+// selected pool is full, turning the pool into a server-side concurrency
+// limiter.
+package bulkhead
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dpaks/goworkers"
+)
+
+// Bulkhead selects a worker pool for every request, by the longest
+// matching route prefix, and runs the request's handler on it.
+type Bulkhead struct {
+	mu     sync.RWMutex
+	def    *goworkers.GoWorkers
+	routes []route
+}
+
+type route struct {
+	prefix string
+	gw     *goworkers.GoWorkers
+}
+
+// errSkipped is handed over in place of a panic when the job of a request
+// is skipped, e.g. by an interceptor of the pool
+var errSkipped = errors.New("bulkhead: request skipped")
+
+// New creates a new bulkhead.
+//
+// Requests that do not match any route are run on def. If def is nil,
+// such requests are served directly without a pool.
+func New(def *goworkers.GoWorkers) *Bulkhead {
+	return &Bulkhead{def: def}
+}
+
+// Route runs the requests whose URL path starts with prefix on gw.
+func (b *Bulkhead) Route(prefix string, gw *goworkers.GoWorkers) {
+	defer b.mu.Unlock()
+	b.mu.Lock()
+	b.routes = append(b.routes, route{prefix: prefix, gw: gw})
+	// the longest prefix wins
+	sort.SliceStable(b.routes, func(i, j int) bool {
+		return len(b.routes[i].prefix) > len(b.routes[j].prefix)
+	})
+}
+
+func (b *Bulkhead) pool(r *http.Request) *goworkers.GoWorkers {
+	defer b.mu.RUnlock()
+	b.mu.RLock()
+	for _, rt := range b.routes {
+		if strings.HasPrefix(r.URL.Path, rt.prefix) {
+			return rt.gw
+		}
+	}
+	return b.def
+}
+
+// Handler wraps next such that it is executed as a job on the pool selected
+// for the request.
+func (b *Bulkhead) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gw := b.pool(r)
+		if gw == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		done := make(chan interface{}, 1)
+		accepted := gw.TrySubmit(func() {
+			// hand over the panic, if any, to the serving goroutine
+			defer func() {
+				done <- recover()
+			}()
+			next.ServeHTTP(w, r)
+		}, goworkers.JobOptions{
+			// the job was skipped without running unless it handed over
+			// its outcome already
+			OnFinish: func(error) {
+				select {
+				case done <- errSkipped:
+				default:
+				}
+			},
+		})
+		if !accepted {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		// the response writer must not be used after this handler returns,
+		// so wait for the job even if the client goes away
+		p := <-done
+		if p == errSkipped {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		if p != nil {
+			panic(p)
+		}
+	})
+}
+
+// Middleware returns a middleware that runs every request on gw.
+func Middleware(gw *goworkers.GoWorkers) func(http.Handler) http.Handler {
+	return New(gw).Handler
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package bulkhead
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dpaks/goworkers"
+)
+
+func TestHandler(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	h := Middleware(gw)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("Expected %d, Got %d", http.StatusTeapot, rec.Code)
+	}
+}
+
+func TestHandlerRejected(t *testing.T) {
+	gw := goworkers.New()
+	gw.Stop(false)
+
+	h := Middleware(gw)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected %d, Got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+}
+
+func TestHandlerSkipped(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	gw.Use(func(ctx context.Context, job goworkers.JobInfo, next func(ctx context.Context) error) error {
+		return nil
+	})
+
+	h := Middleware(gw)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected %d, Got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	def := goworkers.New()
+	defer def.Stop(false)
+	api := goworkers.New()
+	defer api.Stop(false)
+	stopped := goworkers.New()
+	stopped.Stop(false)
+
+	b := New(def)
+	b.Route("/api", api)
+	b.Route("/api/export", stopped)
+
+	h := b.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tables := []struct {
+		Path     string
+		Expected int
+	}{
+		{"/", http.StatusOK},
+		{"/api/users", http.StatusOK},
+		{"/api/export/all", http.StatusServiceUnavailable},
+	}
+
+	for _, table := range tables {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, table.Path, nil))
+		if rec.Code != table.Expected {
+			t.Errorf("%s: Expected %d, Got %d", table.Path, table.Expected, rec.Code)
+		}
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	h := Middleware(gw)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("Expected the panic to be handed over, Got %v", p)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"time"
+)
+
+// defaultBurstDuration is the duration of a burst if unspecified
+const defaultBurstDuration = 10 * time.Second
+
+// burst lets a pool exceed its maximum number of workers for a while, see
+// Options.BurstWorkers. Its state is guarded by mx.
+type burst struct {
+	workers  uint32
+	duration time.Duration
+	// whether a burst is under way, and when the last one ended
+	active bool
+	ended  time.Time
+}
+
+func newBurst(opts Options) burst {
+	b := burst{workers: opts.BurstWorkers, duration: opts.BurstDuration}
+	if b.duration == 0 {
+		b.duration = defaultBurstDuration
+	}
+	return b
+}
+
+// canBurst reports whether a worker can be started beyond max for the
+// waiting jobs, starting a burst if none is under way. It must be called
+// with mx held.
+func (gw *GoWorkers) canBurst(max uint32) bool {
+	b := &gw.burst
+	if (b.workers == 0) || (gw.WorkerNum() >= max+b.workers) {
+		return false
+	}
+	if b.active {
+		return true
+	}
+	now := gw.clock.Now()
+	if !b.ended.IsZero() && now.Sub(b.ended) < b.duration {
+		// cool down for as long as a burst lasts, such that a sustained
+		// load does not keep the pool beyond max
+		return false
+	}
+	b.active = true
+
+	timer := gw.clock.NewTimer(b.duration)
+	gw.goHelper(func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			gw.shedBurst()
+		case <-gw.stopped:
+		}
+	})
+	return true
+}
+
+// shedBurst ends the burst under way and retires the workers beyond max
+// once they finish their current job.
+func (gw *GoWorkers) shedBurst() {
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	gw.burst.active = false
+	gw.burst.ended = gw.clock.Now()
+	max := gw.MaxWorkers()
+	if max == 0 {
+		return
+	}
+	for _, w := range gw.workers {
+		if uint32(len(gw.workers)) <= max {
+			break
+		}
+		gw.retire(w)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBurst(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Workers: 1, BurstWorkers: 2, BurstDuration: time.Second, Clock: clock})
+
+	release := make(chan struct{})
+	var ran int32
+	for i := 0; i < 4; i++ {
+		gw.Submit(func() {
+			<-release
+			atomic.AddInt32(&ran, 1)
+		})
+	}
+
+	// the queue spike starts the burst workers, up to Workers+BurstWorkers
+	for gw.Stats().Running != 3 {
+	}
+	if n := gw.WorkerNum(); n != 3 {
+		t.Errorf("Expected 3, Got %d", n)
+	}
+
+	// the burst ends, and the extra workers are retired
+	for clock.Waiters() == 0 {
+	}
+	clock.Advance(time.Second)
+	for len(gw.WorkerIDs()) != 1 {
+	}
+
+	close(release)
+	waitWorkerNum(t, gw, 1)
+
+	// no burst starts again while cooling down
+	block := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		gw.Submit(func() { <-block })
+	}
+	for gw.Stats().Running != 1 {
+	}
+	if n := gw.WorkerNum(); n != 1 {
+		t.Errorf("Expected 1, Got %d", n)
+	}
+	close(block)
+
+	gw.Stop(false)
+	if ran != 4 {
+		t.Errorf("Expected 4, Got %d", ran)
+	}
+}
+
+func TestBurstDisabled(t *testing.T) {
+	gw := New(Options{Workers: 1})
+
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		gw.Submit(func() { <-release })
+	}
+	for gw.Stats().Running != 1 {
+	}
+	if n := gw.WorkerNum(); n != 1 {
+		t.Errorf("Expected 1, Got %d", n)
+	}
+	close(release)
+	gw.Stop(false)
+}
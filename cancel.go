@@ -0,0 +1,49 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrCancelled is wrapped by the error delivered on ErrChan for a job that
+// was dropped as its context was done by the time a worker picked it up.
+var ErrCancelled = errors.New("goworkers: job cancelled before it ran")
+
+// cancelled reports whether the context of t is done, such that running t
+// would be wasted
+func (t *task) cancelled() bool {
+	return (t.ctx != nil) && (t.ctx.Err() != nil)
+}
+
+// dropCancelled drops t instead of running it, delivering an error wrapping
+// ErrCancelled unless t has no outputs
+func (gw *GoWorkers) dropCancelled(t *task) {
+	atomic.AddUint32(&gw.numCancelled, 1)
+	gw.skip(t, fmt.Errorf("%w: %v", ErrCancelled, t.ctx.Err()))
+}
+
+// skip does not run t, delivering err unless t has no outputs
+func (gw *GoWorkers) skip(t *task, err error) {
+	if t.onSkip != nil {
+		t.onSkip()
+	}
+	if t.onDone != nil {
+		t.onDone(nil, err)
+		return
+	}
+	if t.outputs == noOutputs {
+		return
+	}
+
+	if t.opts.Name != "" || len(t.opts.Metadata) != 0 {
+		err = &JobError{Name: t.opts.Name, Metadata: t.opts.Metadata, Err: err}
+	}
+	if !gw.deliverErr(t.opts.Tags, err) {
+		gw.sendErr(err)
+	}
+}
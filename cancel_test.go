@@ -0,0 +1,90 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDropCancelled(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Workers: 1, Clock: clock})
+	defer gw.Stop(false)
+
+	g := gw.NewGroup(context.Background(), time.Second)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	g.Submit(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	ran := false
+	g.Submit(func(ctx context.Context) error {
+		ran = true
+		return nil
+	}, JobOptions{Name: "doomed"})
+
+	// the budget is spent while the second job waits in the queue
+	clock.Advance(time.Second)
+	for g.ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if err := g.Wait(); err != ErrBudgetExceeded {
+		t.Errorf("Expected %v, Got %v", ErrBudgetExceeded, err)
+	}
+	if ran {
+		t.Errorf("Expected the queued job not to run")
+	}
+
+	var je *JobError
+	if err := <-gw.ErrChan; !errors.Is(err, ErrCancelled) || !errors.As(err, &je) || je.Name != "doomed" {
+		t.Errorf("Expected the job to be dropped as cancelled, Got %v", err)
+	}
+	if st := gw.Stats(); st.Cancelled != 1 || st.Completed != 1 || st.Failed != 0 {
+		t.Errorf("Expected 1 completed and 1 cancelled job, Got %+v", st)
+	}
+}
+
+func TestSubmitCtx(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	defer gw.Stop(false)
+
+	// the running job observes the cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	aborted := make(chan error)
+	gw.SubmitCtx(ctx, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		aborted <- ctx.Err()
+	})
+	<-started
+
+	// the queued job is skipped
+	gw.SubmitCtx(ctx, func(ctx context.Context) {
+		t.Errorf("Expected the job not to run")
+	})
+	cancel()
+	if err := <-aborted; err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+
+	gw.Wait(false)
+	if st := gw.Stats(); st.Cancelled != 1 || st.Completed != 1 {
+		t.Errorf("Expected 1 cancelled and 1 completed job, Got %+v", st)
+	}
+	select {
+	case err := <-gw.ErrChan:
+		t.Errorf("Expected no error, Got %v", err)
+	default:
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoCheckpoints is returned by a Checkpointer whose store cannot hold
+// checkpoints, i.e. is not a CheckpointStore.
+var ErrNoCheckpoints = errors.New("goworkers: store does not support checkpoints")
+
+// Checkpointer persists the intermediate state of a running job, such that
+// a job interrupted by a restart or a deploy resumes from its last
+// checkpoint instead of from scratch, see ResumableJob.
+type Checkpointer interface {
+	// Checkpoint durably records state as the last checkpoint of the job,
+	// replacing the previous one.
+	// Returns ErrNoCheckpoints if the store cannot hold checkpoints.
+	Checkpoint(state []byte) error
+}
+
+// ResumableJob is implemented by the jobs of registered types that record
+// checkpoints along the way.
+//
+// When consumed from a Store, see ConsumeStore(), Resume is run instead of
+// Run, with the last checkpoint recorded by the previous attempts of the
+// job, or nil if there is none. Run is used otherwise.
+type ResumableJob interface {
+	Job
+	Resume(checkpoint []byte, cp Checkpointer) error
+}
+
+// CheckpointStore is implemented by the stores that can hold the
+// checkpoints of their jobs. A checkpoint is handed out along with its job
+// by Lease, as StoredJob.Checkpoint, and dropped along with its job.
+type CheckpointStore interface {
+	// SaveCheckpoint records state as the last checkpoint of a job.
+	// Returns ErrJobNotFound if the job was acknowledged or dead-lettered.
+	SaveCheckpoint(id string, state []byte) error
+}
+
+// storeCheckpointer records the checkpoints of the stored job id
+type storeCheckpointer struct {
+	s  Store
+	id string
+}
+
+func (c storeCheckpointer) Checkpoint(state []byte) error {
+	cs, ok := c.s.(CheckpointStore)
+	if !ok {
+		return ErrNoCheckpoints
+	}
+	return cs.SaveCheckpoint(c.id, state)
+}
+
+// runStoredJob runs job, resuming it from the checkpoint of sj if it is a
+// ResumableJob
+func runStoredJob(s Store, sj StoredJob, job Job) func(ctx context.Context) error {
+	rj, ok := job.(ResumableJob)
+	if !ok {
+		return runContext(job)
+	}
+	return func(context.Context) error {
+		return rj.Resume(sj.Checkpoint, storeCheckpointer{s: s, id: sj.ID})
+	}
+}
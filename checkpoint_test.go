@@ -0,0 +1,116 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+var _ CheckpointStore = (*MemoryStore)(nil)
+
+var (
+	stepsMu sync.Mutex
+	// the first step run by every attempt of stepsJob
+	stepsStarts []int
+)
+
+// stepsJob runs 5 steps, checkpointing after each, and fails after the
+// third step of its first attempt
+type stepsJob struct{}
+
+// Run is not used by ConsumeStore, see Resume
+func (stepsJob) Run() error {
+	return nil
+}
+
+func (stepsJob) Resume(checkpoint []byte, cp Checkpointer) error {
+	step := 0
+	if checkpoint != nil {
+		step, _ = strconv.Atoi(string(checkpoint))
+	}
+	stepsMu.Lock()
+	stepsStarts = append(stepsStarts, step)
+	first := len(stepsStarts) == 1
+	stepsMu.Unlock()
+
+	for ; step < 5; step++ {
+		if first && (step == 3) {
+			return errors.New("interrupted")
+		}
+		if err := cp.Checkpoint([]byte(strconv.Itoa(step + 1))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterJobType("steps", func(payload []byte) Job { return stepsJob{} })
+}
+
+func TestConsumeStoreResumesFromCheckpoint(t *testing.T) {
+	tables := []struct {
+		opts Options
+		key  string
+	}{
+		{Options{}, ""},
+		// deduplicated jobs are resumed alike
+		{Options{Idempotency: NewMemoryIdempotencyStore()}, "report-42"},
+	}
+
+	for _, table := range tables {
+		stepsMu.Lock()
+		stepsStarts = nil
+		stepsMu.Unlock()
+
+		s := NewMemoryStore()
+		enqueue(t, s, Envelope{Name: "steps", Key: table.key})
+
+		gw := New(table.opts)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- gw.ConsumeStore(ctx, s, StoreOptions{
+				Lease:        10 * time.Millisecond,
+				PollInterval: time.Millisecond,
+			})
+		}()
+		for s.Len() != 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		<-done
+		gw.Stop(false)
+
+		stepsMu.Lock()
+		if len(stepsStarts) != 2 || stepsStarts[0] != 0 || stepsStarts[1] != 3 {
+			t.Errorf("Expected the second attempt to resume from step 3, Got %v", stepsStarts)
+		}
+		stepsMu.Unlock()
+		if len(s.DeadLetters()) != 0 {
+			t.Errorf("Expected the job to succeed, Got %v", s.DeadLetters())
+		}
+	}
+}
+
+func TestCheckpointerWithoutCheckpointStore(t *testing.T) {
+	m := NewMemoryStore()
+	id, _ := m.Enqueue(nil)
+
+	cp := storeCheckpointer{s: plainStore{m}, id: id}
+	if err := cp.Checkpoint([]byte("1")); err != ErrNoCheckpoints {
+		t.Errorf("Expected %v, Got %v", ErrNoCheckpoints, err)
+	}
+
+	cp = storeCheckpointer{s: m, id: "unknown"}
+	if err := cp.Checkpoint([]byte("1")); err != ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", ErrJobNotFound, err)
+	}
+}
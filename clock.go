@@ -0,0 +1,171 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass. All the time-based
+// features of a pool go through its clock, so that they can be tested with
+// a FakeClock instead of real sleeps.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a timer created by a Clock, see time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker is a ticker created by a Clock, see time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock is the Clock backed by package time.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time { return time.Now() }
+
+// Sleep calls time.Sleep().
+func (RealClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// NewTimer wraps time.NewTimer().
+func (RealClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+// NewTicker wraps time.NewTicker().
+func (RealClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// FakeClock is a Clock whose time only moves when told to, for tests.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	clock  *FakeClock
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+// NewFakeClock creates a new fake clock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time of the fake clock.
+func (f *FakeClock) Now() time.Time {
+	defer f.mu.Unlock()
+	f.mu.Lock()
+	return f.now
+}
+
+// Sleep blocks until the fake clock is advanced by d.
+func (f *FakeClock) Sleep(d time.Duration) {
+	<-f.NewTimer(d).C()
+}
+
+// NewTimer creates a timer firing once the fake clock is advanced by d.
+func (f *FakeClock) NewTimer(d time.Duration) Timer {
+	return f.wait(d, 0)
+}
+
+// NewTicker creates a ticker firing every time the fake clock is advanced
+// by d.
+func (f *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("goworkers: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.wait(d, d)}
+}
+
+func (f *FakeClock) wait(d, period time.Duration) *fakeWaiter {
+	defer f.mu.Unlock()
+	f.mu.Lock()
+	w := &fakeWaiter{clock: f, at: f.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	if d <= 0 && period == 0 {
+		w.c <- f.now
+		return w
+	}
+	f.waiters = append(f.waiters, w)
+	return w
+}
+
+// Advance moves the fake clock forward by d, firing the timers and the
+// tickers that are due, in order.
+func (f *FakeClock) Advance(d time.Duration) {
+	defer f.mu.Unlock()
+	f.mu.Lock()
+	end := f.now.Add(d)
+	for {
+		sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+		if len(f.waiters) == 0 || f.waiters[0].at.After(end) {
+			break
+		}
+
+		w := f.waiters[0]
+		f.now = w.at
+		// as with package time, a tick is dropped for a slow receiver
+		select {
+		case w.c <- f.now:
+		default:
+		}
+		if w.period == 0 {
+			f.waiters = f.waiters[1:]
+			continue
+		}
+		w.at = w.at.Add(w.period)
+	}
+	f.now = end
+}
+
+// Waiters returns the number of pending timers and tickers, such that tests
+// can wait for the code under test to start waiting before advancing.
+func (f *FakeClock) Waiters() int {
+	defer f.mu.Unlock()
+	f.mu.Lock()
+	return len(f.waiters)
+}
+
+func (f *FakeClock) remove(w *fakeWaiter) bool {
+	defer f.mu.Unlock()
+	f.mu.Lock()
+	for i, x := range f.waiters {
+		if x == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.c }
+func (w *fakeWaiter) Stop() bool          { return w.clock.remove(w) }
+
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.c }
+func (t fakeTicker) Stop()               { t.w.Stop() }
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFakeClockTimers(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	t1 := clock.NewTimer(time.Second)
+	t2 := clock.NewTimer(2 * time.Second)
+	t3 := clock.NewTimer(3 * time.Second)
+	if !t3.Stop() {
+		t.Errorf("Expected a pending timer to be stopped")
+	}
+	if n := clock.Waiters(); n != 2 {
+		t.Errorf("Expected 2, Got %d", n)
+	}
+
+	clock.Advance(1500 * time.Millisecond)
+	select {
+	case now := <-t1.C():
+		if !now.Equal(start.Add(time.Second)) {
+			t.Errorf("Expected the timer to fire at its due time, Got %v", now)
+		}
+	default:
+		t.Errorf("Expected the first timer to fire")
+	}
+	select {
+	case <-t2.C():
+		t.Errorf("Expected the second timer not to fire yet")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	<-t2.C()
+	if t2.Stop() {
+		t.Errorf("Expected a fired timer not to be stopped")
+	}
+	if now := clock.Now(); !now.Equal(start.Add(2500 * time.Millisecond)) {
+		t.Errorf("Expected %v, Got %v", start.Add(2500*time.Millisecond), now)
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	ticker := clock.NewTicker(time.Second)
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Second)
+		<-ticker.C()
+	}
+
+	ticker.Stop()
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("Expected 0, Got %d", n)
+	}
+}
+
+func TestFakeClockSleep(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+
+	var woke int32
+	go func() {
+		clock.Sleep(time.Minute)
+		atomic.StoreInt32(&woke, 1)
+	}()
+
+	for clock.Waiters() != 1 {
+	}
+	clock.Advance(59 * time.Second)
+	if atomic.LoadInt32(&woke) == 1 {
+		t.Errorf("Expected the sleeper not to wake up early")
+	}
+	clock.Advance(time.Second)
+	for atomic.LoadInt32(&woke) != 1 {
+	}
+}
+
+func TestDrainFakeClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	gw := New(Options{Clock: clock})
+
+	release := make(chan struct{})
+	gw.Submit(func() { <-release })
+
+	leftover := make(chan uint32)
+	go func() {
+		leftover <- gw.Drain(time.Hour)
+	}()
+
+	for clock.Waiters() != 1 {
+	}
+	clock.Advance(time.Hour)
+	if n := <-leftover; n != 1 {
+		t.Errorf("Expected 1, Got %d", n)
+	}
+
+	close(release)
+	gw.Stop(false)
+}
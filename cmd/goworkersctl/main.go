@@ -0,0 +1,194 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Command goworkersctl inspects and controls a goworkers pool through the
+// endpoints served by package debugapi.
+//
+// Usage:
+//
+//	goworkersctl [-addr unix:///path/to.sock | http://host:port] <command> [flags]
+//
+// The commands are:
+//
+//	stats                 show live stats of the pool
+//	slow [-over 1s]       list the jobs that have been running for longer than -over
+//	costs                 show the share of the pool used by every job name
+//	pause                 stop the workers from picking up new jobs
+//	resume                let the workers pick up jobs again
+//	drain [-timeout 30s]  stop intake and drain the pool within -timeout
+//
+// The address defaults to $GOWORKERS_ADDR, else unix:///tmp/goworkers.sock.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dpaks/goworkers"
+	"github.com/dpaks/goworkers/debugapi"
+)
+
+const defaultAddr = "unix:///tmp/goworkers.sock"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "goworkersctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("goworkersctl", flag.ContinueOnError)
+	addr := fs.String("addr", os.Getenv("GOWORKERS_ADDR"), "address of the debug endpoint")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *addr == "" {
+		*addr = defaultAddr
+	}
+	if fs.NArg() == 0 {
+		return errors.New("missing command, one of stats, slow, costs, pause, resume, drain")
+	}
+	c := newClient(*addr)
+
+	cmd, args := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "stats":
+		var st goworkers.Stats
+		if err := c.call(http.MethodGet, "/stats", &st); err != nil {
+			return err
+		}
+		printStats(out, st)
+	case "slow":
+		sub := flag.NewFlagSet("slow", flag.ContinueOnError)
+		over := sub.Duration("over", time.Second, "minimum running time of a job")
+		if err := sub.Parse(args); err != nil {
+			return err
+		}
+		var st goworkers.Stats
+		if err := c.call(http.MethodGet, "/stats", &st); err != nil {
+			return err
+		}
+		printSlow(out, st, *over)
+	case "costs":
+		var st goworkers.Stats
+		if err := c.call(http.MethodGet, "/stats", &st); err != nil {
+			return err
+		}
+		printCosts(out, st)
+	case "pause", "resume":
+		var st goworkers.Stats
+		if err := c.call(http.MethodPost, "/"+cmd, &st); err != nil {
+			return err
+		}
+		printStats(out, st)
+	case "drain":
+		sub := flag.NewFlagSet("drain", flag.ContinueOnError)
+		timeout := sub.Duration("timeout", debugapi.DefaultDrainTimeout, "how long to wait for the jobs to finish")
+		if err := sub.Parse(args); err != nil {
+			return err
+		}
+		var resp debugapi.DrainResponse
+		if err := c.call(http.MethodPost, "/drain?timeout="+timeout.String(), &resp); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "drained, %d jobs left over\n", resp.Leftover)
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	return nil
+}
+
+type client struct {
+	base string
+	http *http.Client
+}
+
+// newClient returns a client of the debug endpoint at addr, which is either
+// a unix:// socket path or an http:// URL.
+func newClient(addr string) *client {
+	if !strings.HasPrefix(addr, "unix://") {
+		return &client{base: strings.TrimSuffix(addr, "/"), http: http.DefaultClient}
+	}
+	path := strings.TrimPrefix(addr, "unix://")
+	return &client{
+		base: "http://unix",
+		http: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}},
+	}
+}
+
+func (c *client) call(method, path string, v interface{}) error {
+	req, err := http.NewRequest(method, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func printStats(out io.Writer, st goworkers.Stats) {
+	max := "on demand"
+	if st.MaxWorkers != 0 {
+		max = fmt.Sprint(st.MaxWorkers)
+	}
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "workers\t%d (max %s)\n", st.Workers, max)
+	fmt.Fprintf(tw, "running\t%d\n", st.Running)
+	fmt.Fprintf(tw, "pending\t%d\n", st.Queued)
+	fmt.Fprintf(tw, "paused\t%t\n", st.Paused)
+	tw.Flush()
+}
+
+func printCosts(out io.Writer, st goworkers.Stats) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tCOMPLETED\tRUNTIME\tSHARE")
+	for _, c := range st.Costs() {
+		name := c.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%.1f%%\n", name, c.Completed, c.Runtime.Round(time.Millisecond), c.Share*100)
+	}
+	tw.Flush()
+}
+
+func printSlow(out io.Writer, st goworkers.Stats, over time.Duration) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKER\tJOB\tRUNNING FOR")
+	now := time.Now()
+	for _, b := range st.Busy {
+		job := b.Job
+		if job == "" {
+			job = "-"
+		}
+		if d := now.Sub(b.Since); d >= over {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", b.ID, job, d.Round(time.Millisecond))
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package main
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+	"github.com/dpaks/goworkers/debugapi"
+)
+
+func TestCommands(t *testing.T) {
+	gw := goworkers.New()
+	srv := httptest.NewServer(debugapi.Handler(gw))
+	defer srv.Close()
+
+	release := make(chan struct{})
+	gw.SubmitNamed("resize-image", func() { <-release })
+	for {
+		if len(gw.Stats().Busy) == 1 {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	tables := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"stats"}, "running  1"},
+		{[]string{"slow", "-over", "10ms"}, "RUNNING FOR\n1       resize-image "},
+		{[]string{"costs"}, "COMPLETED  RUNTIME  SHARE\n"},
+		{[]string{"pause"}, "paused   true"},
+		{[]string{"resume"}, "paused   false"},
+	}
+
+	for _, table := range tables {
+		var out bytes.Buffer
+		if err := run(append([]string{"-addr", srv.URL}, table.args...), &out); err != nil {
+			t.Errorf("%v: Expected nil, Got %v", table.args, err)
+			continue
+		}
+		if !strings.Contains(out.String(), table.want) {
+			t.Errorf("%v: Expected %q in output, Got %q", table.args, table.want, out.String())
+		}
+	}
+
+	close(release)
+	var out bytes.Buffer
+	if err := run([]string{"-addr", srv.URL, "drain", "-timeout", "1s"}, &out); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if out.String() != "drained, 0 jobs left over\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	gw := goworkers.New(goworkers.Options{Workers: 4})
+	defer gw.Stop(false)
+
+	path := filepath.Join(t.TempDir(), "gw.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	go http.Serve(l, debugapi.Handler(gw))
+	defer l.Close()
+
+	var out bytes.Buffer
+	if err := run([]string{"-addr", "unix://" + path, "stats"}, &out); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if !strings.Contains(out.String(), "(max 4)") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-addr", "http://127.0.0.1:1"}, &out); err == nil {
+		t.Errorf("Expected an error for a missing command")
+	}
+	if err := run([]string{"-addr", "http://127.0.0.1:1", "bogus"}, &out); err == nil {
+		t.Errorf("Expected an error for an unknown command")
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// JobKind tags a job submitted to a Composite pool.
+type JobKind int
+
+const (
+	// IO marks a job that spends most of its time waiting on I/O.
+	IO JobKind = iota
+	// CPU marks a job that spends most of its time computing.
+	CPU
+)
+
+// CompositeOptions configures the behaviour of a composite worker pool.
+//
+// IOWorkers specifies the maximum number of workers for IO-bound jobs.
+// If unspecified or zero, IO workers will be spawned as per demand.
+//
+// CPUWorkers specifies the maximum number of workers for CPU-bound jobs.
+// If unspecified or zero, runtime.GOMAXPROCS(0) is used.
+//
+// QSize specifies the size of the queue of each of the sub-pools.
+// If unspecified or zero, 128 is used.
+type CompositeOptions struct {
+	IOWorkers  uint32
+	CPUWorkers uint32
+	QSize      uint32
+}
+
+// Composite is a worker pool made of two internally managed sub-pools,
+// one sized for IO-bound jobs and the other for CPU-bound jobs.
+//
+// Every job is tagged with a JobKind at submission and is dispatched to the
+// matching sub-pool. Errors and outputs of both the sub-pools are delivered
+// on the composite's own ErrChan and ResultChan.
+type Composite struct {
+	io      *GoWorkers
+	cpu     *GoWorkers
+	wg      sync.WaitGroup
+	stopped int32
+	// ErrChan is a safe buffered output channel of size 100 on which error
+	// returned by a job of either kind can be caught, if any. The channel
+	// will be closed after Stop() returns.
+	ErrChan chan error
+	// ResultChan is a safe buffered output channel of size 100 on which
+	// output returned by a job of either kind can be caught, if any. The
+	// channel will be closed after Stop() returns.
+	ResultChan chan interface{}
+}
+
+// NewComposite creates a new composite worker pool.
+//
+// Accepts optional CompositeOptions{} argument.
+func NewComposite(args ...CompositeOptions) *Composite {
+	var opts CompositeOptions
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if opts.CPUWorkers == 0 {
+		opts.CPUWorkers = uint32(runtime.GOMAXPROCS(0))
+	}
+
+	c := &Composite{
+		io:         New(Options{Workers: opts.IOWorkers, QSize: opts.QSize}),
+		cpu:        New(Options{Workers: opts.CPUWorkers, QSize: opts.QSize}),
+		ErrChan:    make(chan error, outputChanSize),
+		ResultChan: make(chan interface{}, outputChanSize),
+	}
+
+	for _, gw := range []*GoWorkers{c.io, c.cpu} {
+		c.wg.Add(2)
+		go c.forwardErrors(gw)
+		go c.forwardResults(gw)
+	}
+
+	return c
+}
+
+func (c *Composite) pool(kind JobKind) *GoWorkers {
+	if kind == CPU {
+		return c.cpu
+	}
+	return c.io
+}
+
+func (c *Composite) forwardErrors(gw *GoWorkers) {
+	defer c.wg.Done()
+	for err := range gw.ErrChan {
+		select {
+		case c.ErrChan <- err:
+		default:
+		}
+	}
+}
+
+func (c *Composite) forwardResults(gw *GoWorkers) {
+	defer c.wg.Done()
+	for res := range gw.ResultChan {
+		select {
+		case c.ResultChan <- res:
+		default:
+		}
+	}
+}
+
+// JobNum returns number of active jobs across both the sub-pools
+func (c *Composite) JobNum() uint32 {
+	return c.io.JobNum() + c.cpu.JobNum()
+}
+
+// WorkerNum returns number of active workers across both the sub-pools
+func (c *Composite) WorkerNum() uint32 {
+	return c.io.WorkerNum() + c.cpu.WorkerNum()
+}
+
+// Submit is a non-blocking call with arg of type `func()`
+//
+// The job is dispatched to the sub-pool matching kind.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (c *Composite) Submit(kind JobKind, job func(), args ...JobOptions) error {
+	return c.pool(kind).Submit(job, args...)
+}
+
+// SubmitCheckError is a non-blocking call with arg of type `func() error`
+//
+// The job is dispatched to the sub-pool matching kind.
+// Use ErrChan buffered channel to read error, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (c *Composite) SubmitCheckError(kind JobKind, job func() error, args ...JobOptions) error {
+	return c.pool(kind).SubmitCheckError(job, args...)
+}
+
+// SubmitCheckResult is a non-blocking call with arg of type `func() (interface{}, error)`
+//
+// The job is dispatched to the sub-pool matching kind.
+// Use ErrChan buffered channel to read error, if any.
+// Use ResultChan buffered channel to read output, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (c *Composite) SubmitCheckResult(kind JobKind, job func() (interface{}, error), args ...JobOptions) error {
+	return c.pool(kind).SubmitCheckResult(job, args...)
+}
+
+// Wait waits for the jobs of both the sub-pools to finish running.
+//
+// See GoWorkers.Wait() for the semantics of the 'wait' argument.
+// Returns ErrCalledFromJob if called from a job of either sub-pool.
+func (c *Composite) Wait(wait bool) error {
+	if c.io.calledFromJob() || c.cpu.calledFromJob() {
+		return ErrCalledFromJob
+	}
+	c.io.Wait(wait)
+	c.cpu.Wait(wait)
+
+	if wait {
+		for {
+			if len(c.ResultChan)|len(c.ErrChan) == 0 {
+				break
+			}
+			yield()
+		}
+	}
+	return nil
+}
+
+// Stop gracefully waits for the jobs of both the sub-pools to finish running
+// and releases the associated resources.
+//
+// See GoWorkers.Stop() for the semantics of the 'wait' argument.
+// Returns ErrCalledFromJob if called from a job of either sub-pool.
+func (c *Composite) Stop(wait bool) error {
+	if c.io.calledFromJob() || c.cpu.calledFromJob() {
+		return ErrCalledFromJob
+	}
+	if !atomic.CompareAndSwapInt32(&c.stopped, 0, 1) {
+		return nil
+	}
+
+	c.io.Stop(wait)
+	c.cpu.Stop(wait)
+
+	// the sub-pools close their output channels once they are done
+	c.wg.Wait()
+
+	if wait {
+		for {
+			if len(c.ResultChan)|len(c.ErrChan) == 0 {
+				break
+			}
+			yield()
+		}
+	}
+
+	close(c.ErrChan)
+	close(c.ResultChan)
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCompositeDefaults(t *testing.T) {
+	c := NewComposite()
+	defer c.Stop(false)
+
+	if c.cpu.MaxWorkers() != uint32(runtime.GOMAXPROCS(0)) {
+		t.Errorf("Expected %d CPU workers, Got %d", runtime.GOMAXPROCS(0), c.cpu.MaxWorkers())
+	}
+	if c.io.MaxWorkers() != 0 {
+		t.Errorf("Expected IO workers to be spawned as per demand, Got %d", c.io.MaxWorkers())
+	}
+}
+
+func TestCompositeDispatch(t *testing.T) {
+	c := NewComposite(CompositeOptions{IOWorkers: 4, CPUWorkers: 2})
+
+	var ioJobs, cpuJobs int32
+	for i := 0; i < 10; i++ {
+		c.Submit(IO, func() { atomic.AddInt32(&ioJobs, 1) })
+		c.Submit(CPU, func() { atomic.AddInt32(&cpuJobs, 1) })
+	}
+
+	c.Wait(false)
+
+	if ioJobs != 10 || cpuJobs != 10 {
+		t.Errorf("Expected 10 jobs of each kind, Got %d IO and %d CPU", ioJobs, cpuJobs)
+	}
+
+	c.Stop(false)
+}
+
+func TestCompositeOutputs(t *testing.T) {
+	c := NewComposite()
+
+	edone := make(chan int)
+	rdone := make(chan int)
+	go func() {
+		n := 0
+		for range c.ErrChan {
+			n++
+		}
+		edone <- n
+	}()
+	go func() {
+		n := 0
+		for range c.ResultChan {
+			n++
+		}
+		rdone <- n
+	}()
+
+	for i := 0; i < 10; i++ {
+		n := i
+		c.SubmitCheckError(IO, func() error { return fmt.Errorf("e%d", n) })
+		c.SubmitCheckResult(CPU, func() (interface{}, error) { return n, nil })
+	}
+
+	c.Stop(true)
+
+	if n := <-edone; n != 10 {
+		t.Errorf("Expected 10 error responses, got %d", n)
+	}
+	if n := <-rdone; n != 10 {
+		t.Errorf("Expected 10 result responses, got %d", n)
+	}
+}
+
+func TestCompositeStopAfterStop(t *testing.T) {
+	c := NewComposite()
+	c.Submit(IO, func() {})
+	c.Stop(false)
+	c.Stop(false)
+}
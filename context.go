@@ -0,0 +1,63 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import "context"
+
+// ContextJob is implemented by the jobs of registered types that take the
+// context of their job, see Options.ContextDecorator. RunContext is run
+// instead of Run by SubmitJob(), SubmitEnvelope() and ConsumeStore().
+type ContextJob interface {
+	Job
+	RunContext(ctx context.Context) error
+}
+
+type jobInfoKey struct{}
+
+// JobInfoFromContext returns the description of the job whose context ctx
+// is, or derives from.
+func JobInfoFromContext(ctx context.Context) (JobInfo, bool) {
+	info, ok := ctx.Value(jobInfoKey{}).(JobInfo)
+	return info, ok
+}
+
+// info describes t for the interceptors and the context of the job
+func (t *task) info() JobInfo {
+	return JobInfo{
+		Name:     t.opts.Name,
+		Metadata: t.opts.Metadata,
+		Tenant:   t.opts.Tenant,
+		Key:      t.opts.Key,
+		Tags:     t.opts.Tags,
+	}
+}
+
+// jobContext returns the context t runs with: its base context carrying the
+// description and the logger of t, decorated with Options.ContextDecorator
+func (gw *GoWorkers) jobContext(t *task) context.Context {
+	ctx := t.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithValue(ctx, jobInfoKey{}, t.info())
+	if gw.logger != nil {
+		ctx = ContextWithLogger(ctx, gw.jobLogger(t))
+	}
+	if gw.decorate != nil {
+		ctx = gw.decorate(ctx)
+	}
+	return ctx
+}
+
+// runContext returns the run of job, with the context of its job if it is a
+// ContextJob
+func runContext(job Job) func(ctx context.Context) error {
+	if cj, ok := job.(ContextJob); ok {
+		return cj.RunContext
+	}
+	return func(context.Context) error {
+		return job.Run()
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type requestIDKey struct{}
+
+// tenantJob reports the request id and the job name of its context
+type tenantJob struct{ seen chan<- string }
+
+func (tenantJob) Run() error { return nil }
+
+func (j tenantJob) RunContext(ctx context.Context) error {
+	info, _ := JobInfoFromContext(ctx)
+	j.seen <- ctx.Value(requestIDKey{}).(string) + " " + info.Name
+	return nil
+}
+
+func TestContextDecorator(t *testing.T) {
+	gw := New(Options{ContextDecorator: func(ctx context.Context) context.Context {
+		info, _ := JobInfoFromContext(ctx)
+		return context.WithValue(ctx, requestIDKey{}, "req-"+info.Tenant)
+	}})
+	defer gw.Stop(false)
+
+	seen := make(chan string, 4)
+	gw.Use(func(ctx context.Context, job JobInfo, next func(ctx context.Context) error) error {
+		seen <- "interceptor " + ctx.Value(requestIDKey{}).(string)
+		return next(ctx)
+	})
+
+	if err := gw.SubmitJob(tenantJob{seen}, JobOptions{Name: "report", Tenant: "acme"}); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	gw.Wait(false)
+
+	// the group cancels the decorated context of its jobs as well
+	g := gw.NewGroup(context.Background(), time.Minute)
+	g.Submit(func(ctx context.Context) error {
+		if id, _ := ctx.Value(requestIDKey{}).(string); ctx.Done() != nil {
+			seen <- "group " + id
+		}
+		return nil
+	}, JobOptions{Tenant: "globex"})
+	g.Wait()
+
+	for _, want := range []string{"interceptor req-acme", "req-acme report", "interceptor req-globex", "group req-globex"} {
+		if got := <-seen; got != want {
+			t.Errorf("Expected %q, Got %q", want, got)
+		}
+	}
+}
+
+func TestJobInfoFromContext(t *testing.T) {
+	if _, ok := JobInfoFromContext(context.Background()); ok {
+		t.Errorf("Expected no job info outside of a job")
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sort"
+	"time"
+)
+
+// Cost is the share of the capacity of a pool used by the jobs of a name.
+type Cost struct {
+	// Name is the name of the jobs, empty for the unnamed jobs.
+	Name      string        `json:"name"`
+	Completed uint32        `json:"completed"`
+	Runtime   time.Duration `json:"runtime"`
+	// Share is the fraction of the total runtime of the jobs of the pool.
+	Share float64 `json:"share"`
+	// Usage is measured with Options.MeasureUsage only.
+	Usage Usage `json:"usage"`
+}
+
+// Costs returns the cost report of the stats by job name, most expensive
+// first, such that the usage of a shared pool can be attributed to the
+// features submitting to it.
+func (st Stats) Costs() []Cost {
+	costs := make([]Cost, 0, len(st.Named)+1)
+	unnamed := Cost{Completed: st.Completed, Runtime: st.Runtime, Usage: st.Usage}
+	for name, ns := range st.Named {
+		costs = append(costs, Cost{Name: name, Completed: ns.Completed, Runtime: ns.Runtime, Usage: ns.Usage})
+		unnamed.Completed -= ns.Completed
+		unnamed.Runtime -= ns.Runtime
+		unnamed.Usage.Allocs -= ns.Usage.Allocs
+		unnamed.Usage.CPU -= ns.Usage.CPU
+	}
+	if unnamed.Completed != 0 {
+		costs = append(costs, unnamed)
+	}
+
+	for i := range costs {
+		if st.Runtime > 0 {
+			costs[i].Share = float64(costs[i].Runtime) / float64(st.Runtime)
+		}
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].Runtime != costs[j].Runtime {
+			return costs[i].Runtime > costs[j].Runtime
+		}
+		return costs[i].Name < costs[j].Name
+	})
+	return costs
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCosts(t *testing.T) {
+	st := Stats{
+		Completed: 6,
+		Runtime:   10 * time.Second,
+		Usage:     Usage{Allocs: 100},
+		Named: map[string]NamedStats{
+			"resize": {Completed: 3, Runtime: 6 * time.Second, Usage: Usage{Allocs: 70}},
+			"email":  {Completed: 1, Runtime: time.Second, Usage: Usage{Allocs: 10}},
+		},
+	}
+
+	tables := []Cost{
+		{Name: "resize", Completed: 3, Runtime: 6 * time.Second, Share: 0.6, Usage: Usage{Allocs: 70}},
+		{Name: "", Completed: 2, Runtime: 3 * time.Second, Share: 0.3, Usage: Usage{Allocs: 20}},
+		{Name: "email", Completed: 1, Runtime: time.Second, Share: 0.1, Usage: Usage{Allocs: 10}},
+	}
+
+	costs := st.Costs()
+	if len(costs) != len(tables) {
+		t.Fatalf("Expected %d, Got %d", len(tables), len(costs))
+	}
+	for i, table := range tables {
+		if costs[i] != table {
+			t.Errorf("Expected %+v, Got %+v", table, costs[i])
+		}
+	}
+}
+
+func TestRuntimeAccounting(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Clock: clock})
+
+	gw.SubmitNamed("resize", func() { clock.Advance(time.Second) })
+	gw.Wait(false)
+	gw.Submit(func() { clock.Advance(2 * time.Second) })
+	gw.Stop(false)
+
+	st := gw.Stats()
+	if st.Runtime != 3*time.Second {
+		t.Errorf("Expected %v, Got %v", 3*time.Second, st.Runtime)
+	}
+	if st.Named["resize"].Runtime != time.Second {
+		t.Errorf("Expected %v, Got %v", time.Second, st.Named["resize"].Runtime)
+	}
+}
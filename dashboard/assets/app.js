@@ -0,0 +1,54 @@
+"use strict";
+
+const charts = [
+  ["Queue depth", s => s.queued],
+  ["Worker utilization", s => s.utilization],
+  ["Error rate", s => (s.completed ? s.failed / s.completed : 0)],
+];
+
+function chart(title, samples, value) {
+  const values = samples.map(value);
+  const max = Math.max(1e-9, ...values);
+  const step = values.length > 1 ? 320 / (values.length - 1) : 0;
+  const points = values.map((v, i) => `${i * step},${100 - (v / max) * 95}`).join(" ");
+  const last = values.length ? values[values.length - 1] : 0;
+  return `<div class="chart"><h3>${title}: ${+last.toFixed(2)}</h3>` +
+    `<svg viewBox="0 0 320 100" preserveAspectRatio="none"><polyline points="${points}"/></svg></div>`;
+}
+
+function history(samples) {
+  const rows = samples.slice(-10).reverse().map(s =>
+    `<tr><td>${new Date(s.time).toLocaleTimeString()}</td><td>${s.workers}</td><td>${s.running}</td>` +
+    `<td>${s.queued}</td><td>${s.completed}</td><td>${s.failed}</td></tr>`).join("");
+  return `<table><tr><th>time</th><th>workers</th><th>running</th><th>queued</th>` +
+    `<th>completed</th><th>failed</th></tr>${rows}</table>`;
+}
+
+function render(pools) {
+  const select = document.getElementById("pool");
+  for (const p of pools) {
+    if (![...select.options].some(o => o.value === p.name)) {
+      select.add(new Option(p.name, p.name));
+    }
+  }
+  document.getElementById("pools").innerHTML = pools
+    .filter(p => !select.value || p.name === select.value)
+    .map(p => `<section><h2>${p.name}</h2><p>${p.stats.workers} workers, ` +
+      `${p.stats.running} running, ${p.stats.queued} queued${p.stats.paused ? ", paused" : ""}</p>` +
+      `<div class="charts">${charts.map(([t, f]) => chart(t, p.samples, f)).join("")}</div>` +
+      history(p.samples) + `</section>`)
+    .join("");
+}
+
+async function refresh() {
+  try {
+    const resp = await fetch("api/pools");
+    render(await resp.json());
+  } catch (e) {
+    console.error(e);
+  }
+}
+
+document.getElementById("pool").addEventListener("change", refresh);
+refresh();
+setInterval(refresh, 1000);
@@ -0,0 +1,226 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package dashboard implements an embeddable web dashboard for one or more
+// goworkers pools.
+//
+// A Dashboard samples the stats of the pools added to it at a fixed interval
+// and visualizes queue depth, worker utilization and error rate over time,
+// along with the recent history of each pool. It is a single http.Handler
+// with its assets embedded, so it can be mounted anywhere:
+//
+//	d := dashboard.New()
+//	d.Add("emails", gw)
+//	http.Handle("/debug/goworkers/", http.StripPrefix("/debug/goworkers", d))
+//
+// The following endpoints are served:
+//
+//	GET /                 the dashboard
+//	GET /api/pools        the samples of all the pools as JSON
+//	GET /api/pools?name=  the samples of one pool as JSON
+package dashboard
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// Defaults used unless specified.
+const (
+	DefaultInterval = time.Second
+	DefaultHistory  = 300
+)
+
+//go:embed assets
+var assets embed.FS
+
+// Options configures the behaviour of a dashboard.
+//
+// Interval is how often the pools are sampled.
+// If unspecified or zero, DefaultInterval is used.
+//
+// History is the number of samples retained per pool.
+// If unspecified or zero, DefaultHistory is used.
+type Options struct {
+	Interval time.Duration
+	History  int
+}
+
+// Sample is the state of a pool at a point in time.
+type Sample struct {
+	Time    time.Time `json:"time"`
+	Workers uint32    `json:"workers"`
+	Running uint32    `json:"running"`
+	Queued  uint32    `json:"queued"`
+	// Completed and Failed are the number of jobs that finished, and
+	// that returned an error, since the previous sample.
+	Completed uint32 `json:"completed"`
+	Failed    uint32 `json:"failed"`
+	// Utilization is the fraction of the workers running a job.
+	Utilization float64 `json:"utilization"`
+}
+
+// Pool is the JSON representation of a pool on the dashboard.
+type Pool struct {
+	Name    string          `json:"name"`
+	Stats   goworkers.Stats `json:"stats"`
+	Samples []Sample        `json:"samples"`
+}
+
+type pool struct {
+	gw      *goworkers.GoWorkers
+	last    goworkers.Stats
+	samples []Sample
+}
+
+// Dashboard is an http.Handler visualizing the state of goworkers pools.
+type Dashboard struct {
+	opts  Options
+	files http.Handler
+
+	mu    sync.Mutex
+	pools map[string]*pool
+	names []string
+
+	quit chan struct{}
+	once sync.Once
+}
+
+// New creates a new dashboard and starts sampling.
+// Call Close() to stop sampling.
+//
+// Accepts optional Options{} argument.
+func New(args ...Options) *Dashboard {
+	var opts Options
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.History <= 0 {
+		opts.History = DefaultHistory
+	}
+
+	sub, _ := fs.Sub(assets, "assets")
+	d := &Dashboard{
+		opts:  opts,
+		files: http.FileServer(http.FS(sub)),
+		pools: make(map[string]*pool),
+		quit:  make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// Add adds gw to the dashboard under name, replacing the pool previously
+// added under the same name, if any.
+func (d *Dashboard) Add(name string, gw *goworkers.GoWorkers) {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+	if _, ok := d.pools[name]; !ok {
+		d.names = append(d.names, name)
+	}
+	d.pools[name] = &pool{gw: gw, last: gw.Stats()}
+}
+
+// Close stops sampling. The retained samples are still served.
+func (d *Dashboard) Close() {
+	d.once.Do(func() { close(d.quit) })
+}
+
+func (d *Dashboard) run() {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.quit:
+			return
+		case now := <-ticker.C:
+			d.sample(now)
+		}
+	}
+}
+
+func (d *Dashboard) sample(now time.Time) {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+	for _, p := range d.pools {
+		st := p.gw.Stats()
+		s := Sample{
+			Time:      now,
+			Workers:   st.Workers,
+			Running:   st.Running,
+			Queued:    st.Queued,
+			Completed: since(st.Completed, p.last.Completed),
+			Failed:    since(st.Failed, p.last.Failed),
+		}
+		if st.Workers != 0 {
+			s.Utilization = float64(st.Running) / float64(st.Workers)
+		}
+		p.last = st
+
+		p.samples = append(p.samples, s)
+		if len(p.samples) > d.opts.History {
+			p.samples = p.samples[len(p.samples)-d.opts.History:]
+		}
+	}
+}
+
+// since returns the increase of a counter from last, counting from zero if
+// it was reset, see GoWorkers.ResetStats()
+func since(counter, last uint32) uint32 {
+	if counter < last {
+		return counter
+	}
+	return counter - last
+}
+
+// ServeHTTP serves the dashboard.
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Path != "/api/pools" {
+		d.files.ServeHTTP(w, r)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	pools := d.snapshot(name)
+	if name != "" && len(pools) == 0 {
+		http.Error(w, "pool not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pools)
+}
+
+// snapshot returns the pools in the order they were added, or only the pool
+// with the given name, if not empty.
+func (d *Dashboard) snapshot(name string) []Pool {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+	pools := []Pool{}
+	for _, n := range d.names {
+		if name != "" && n != name {
+			continue
+		}
+		p := d.pools[n]
+		pools = append(pools, Pool{
+			Name:    n,
+			Stats:   p.last,
+			Samples: append([]Sample(nil), p.samples...),
+		})
+	}
+	return pools
+}
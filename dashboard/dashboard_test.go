@@ -0,0 +1,133 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+func get(d *Dashboard, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestAssets(t *testing.T) {
+	d := New()
+	defer d.Close()
+
+	tables := []struct {
+		path string
+		want string
+	}{
+		{"/", "<title>goworkers</title>"},
+		{"/app.js", "api/pools"},
+		{"/style.css", "polyline"},
+	}
+
+	for _, table := range tables {
+		rec := get(d, table.path)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: Expected %d, Got %d", table.path, http.StatusOK, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), table.want) {
+			t.Errorf("%s: Expected %q in body", table.path, table.want)
+		}
+	}
+}
+
+func TestSamples(t *testing.T) {
+	d := New(Options{Interval: time.Hour, History: 2})
+	defer d.Close()
+
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	gb := goworkers.New()
+	defer gb.Stop(false)
+	d.Add("a", gw)
+	d.Add("b", gb)
+
+	for i := 0; i < 3; i++ {
+		gw.SubmitCheckError(func() error { return errors.New("failed") })
+	}
+	gw.Submit(func() {})
+	for {
+		if gw.Stats().Completed == 4 {
+			break
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		d.sample(time.Now())
+	}
+
+	var pools []Pool
+	if err := json.NewDecoder(get(d, "/api/pools").Body).Decode(&pools); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if len(pools) != 2 || pools[0].Name != "a" || pools[1].Name != "b" {
+		t.Fatalf("Expected pools a and b in order, Got %+v", pools)
+	}
+
+	samples := pools[0].Samples
+	if len(samples) != 2 {
+		t.Fatalf("Expected 2 retained samples, Got %d", len(samples))
+	}
+	// the jobs finished before the first, dropped, sample
+	if samples[0].Completed != 0 || samples[0].Failed != 0 {
+		t.Errorf("Expected deltas to be zero, Got %+v", samples[0])
+	}
+
+	if rec := get(d, "/api/pools?name=b"); !strings.Contains(rec.Body.String(), `"name":"b"`) {
+		t.Errorf("Expected pool b, Got %s", rec.Body.String())
+	}
+	if rec := get(d, "/api/pools?name=c"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected %d, Got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestSampleDeltas(t *testing.T) {
+	d := New(Options{Interval: time.Hour})
+	defer d.Close()
+
+	gw := goworkers.New(goworkers.Options{Workers: 1})
+	defer gw.Stop(false)
+	d.Add("a", gw)
+
+	gw.SubmitCheckError(func() error { return errors.New("failed") })
+	gw.Submit(func() {})
+	for {
+		if gw.Stats().Completed == 2 {
+			break
+		}
+	}
+	d.sample(time.Now())
+
+	s := d.snapshot("a")[0].Samples[0]
+	if s.Completed != 2 || s.Failed != 1 {
+		t.Errorf("Expected 2 completed and 1 failed, Got %d and %d", s.Completed, s.Failed)
+	}
+	if s.Workers != 1 || s.Utilization != 0 {
+		t.Errorf("Unexpected sample %+v", s)
+	}
+
+	// the counters start over once reset
+	gw.ResetStats()
+	gw.Submit(func() {})
+	for gw.Stats().Completed != 1 {
+	}
+	d.sample(time.Now())
+	if s := d.snapshot("a")[0].Samples[1]; s.Completed != 1 || s.Failed != 0 {
+		t.Errorf("Expected 1 completed and 0 failed, Got %d and %d", s.Completed, s.Failed)
+	}
+}
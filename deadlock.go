@@ -0,0 +1,30 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// goroutineID returns the id of the calling goroutine, as found in the
+// header of its stack trace, e.g. "goroutine 42 [running]:"
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// calledFromJob reports whether the caller runs on a worker of the pool
+func (gw *GoWorkers) calledFromJob() bool {
+	_, ok := gw.workerGoroutines.Load(goroutineID())
+	return ok
+}
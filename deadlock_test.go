@@ -0,0 +1,66 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	id := goroutineID()
+	if id == 0 {
+		t.Fatalf("Expected a goroutine id")
+	}
+	if goroutineID() != id {
+		t.Errorf("Expected the id to be stable")
+	}
+
+	other := make(chan uint64)
+	go func() { other <- goroutineID() }()
+	if <-other == id {
+		t.Errorf("Expected goroutines to have distinct ids")
+	}
+}
+
+func TestCalledFromJob(t *testing.T) {
+	gw := New()
+
+	errs := make(chan error, 2)
+	gw.Submit(func() {
+		errs <- gw.Wait(false)
+		errs <- gw.Stop(false)
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != ErrCalledFromJob {
+			t.Errorf("Expected %v, Got %v", ErrCalledFromJob, err)
+		}
+	}
+
+	// a job of another pool may stop this one
+	other := New()
+	other.Submit(func() { errs <- gw.Stop(false) })
+	if err := <-errs; err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	other.Stop(false)
+}
+
+func TestCompositeCalledFromJob(t *testing.T) {
+	c := NewComposite()
+	defer c.Stop(false)
+
+	errs := make(chan error, 2)
+	c.Submit(CPU, func() {
+		errs <- c.Wait(false)
+		errs <- c.Stop(false)
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != ErrCalledFromJob {
+			t.Errorf("Expected %v, Got %v", ErrCalledFromJob, err)
+		}
+	}
+}
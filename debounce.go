@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// debouncer holds the debounced jobs of a pool waiting for their window to
+// pass, by key
+type debouncer struct {
+	mu      sync.Mutex
+	pending map[string]*debounced
+}
+
+type debounced struct {
+	job    func()
+	args   []JobOptions
+	timer  Timer
+	cancel chan struct{}
+}
+
+// SubmitDebounced is a non-blocking call with arg of type `func()`
+//
+// The job is submitted once window has passed without another submission for
+// the same key, such that a burst of submissions, e.g. of a rebuild fed by a
+// noisy source of change events, runs a single job: the last one submitted.
+// Every submission restarts the window of its key.
+//
+// The jobs whose window passes while the pool is waited for are submitted
+// as usual, while those still waiting for their window when the pool is
+// stopped are discarded.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (gw *GoWorkers) SubmitDebounced(key string, window time.Duration, job func(), args ...JobOptions) error {
+	if atomic.LoadInt32(&gw.stopping) != stateRunning {
+		return ErrStopped
+	}
+
+	d := &debounced{job: job, args: args, timer: gw.clock.NewTimer(window), cancel: make(chan struct{})}
+
+	gw.debouncer.mu.Lock()
+	if gw.debouncer.pending == nil {
+		gw.debouncer.pending = make(map[string]*debounced)
+	}
+	if prev, ok := gw.debouncer.pending[key]; ok {
+		prev.timer.Stop()
+		close(prev.cancel)
+	}
+	gw.debouncer.pending[key] = d
+	gw.debouncer.mu.Unlock()
+
+	gw.goHelper(func() {
+		select {
+		case <-d.timer.C():
+		case <-d.cancel:
+			return
+		case <-gw.stopped:
+			d.timer.Stop()
+			return
+		}
+
+		// handed over without the checks of submit(), such that a window
+		// passing while the pool is waited for does not lose the job
+		defer gw.submitMu.RUnlock()
+		gw.submitMu.RLock()
+		if atomic.LoadInt32(&gw.stopping) == stateStopping {
+			return
+		}
+		gw.debouncer.mu.Lock()
+		current := gw.debouncer.pending[key] == d
+		if current {
+			delete(gw.debouncer.pending, key)
+		}
+		gw.debouncer.mu.Unlock()
+		if current {
+			gw.handOver(plainTask(d.job, d.args))
+		}
+	})
+	return nil
+}
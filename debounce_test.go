@@ -0,0 +1,87 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubmitDebounced(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Clock: clock})
+
+	var runs, last int32
+	for i := int32(1); i <= 5; i++ {
+		i := i
+		if err := gw.SubmitDebounced("rebuild", time.Second, func() {
+			atomic.AddInt32(&runs, 1)
+			atomic.StoreInt32(&last, i)
+		}); err != nil {
+			t.Fatalf("Expected nil, Got %v", err)
+		}
+		clock.Advance(500 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&runs); n != 0 {
+		t.Errorf("Expected no run within the window, Got %d", n)
+	}
+
+	clock.Advance(time.Second)
+	for atomic.LoadInt32(&runs) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	gw.Stop(false)
+
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("Expected %v, Got %v", 1, n)
+	}
+	if n := atomic.LoadInt32(&last); n != 5 {
+		t.Errorf("Expected the last submission to run, Got submission %v", n)
+	}
+	if err := gw.SubmitDebounced("rebuild", time.Second, func() {}); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+}
+
+func TestSubmitDebouncedDiscardedOnStop(t *testing.T) {
+	gw := New()
+
+	var runs int32
+	_ = gw.SubmitDebounced("rebuild", time.Hour, func() { atomic.AddInt32(&runs, 1) })
+	gw.Stop(false)
+
+	if err := gw.VerifyShutdown(time.Second); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if n := atomic.LoadInt32(&runs); n != 0 {
+		t.Errorf("Expected %v, Got %v", 0, n)
+	}
+}
+
+func TestSubmitDebouncedDuringWait(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Clock: clock})
+	defer gw.Stop(false)
+
+	var runs int32
+	release := make(chan struct{})
+	gw.Submit(func() { <-release })
+	_ = gw.SubmitDebounced("rebuild", time.Second, func() { atomic.AddInt32(&runs, 1) })
+
+	done := make(chan error)
+	go func() { done <- gw.Wait(false) }()
+	for atomic.LoadInt32(&gw.stopping) != stateWaiting {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the window passes while the pool is waited for
+	clock.Advance(time.Second)
+	for atomic.LoadInt32(&runs) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	<-done
+}
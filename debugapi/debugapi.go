@@ -0,0 +1,153 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package debugapi exposes a goworkers pool for inspection and control by
+// operators, typically over a unix socket, using the goworkersctl tool.
+//
+// The following endpoints are served:
+//
+//	GET  /stats                  live stats of the pool, including the busy workers
+//	POST /pause                  pause the pool
+//	POST /resume                 resume the pool
+//	POST /drain?timeout=30s      stop intake and drain the pool within timeout
+//	GET  /history?name=&key=     outcomes of the finished jobs, see HistoryQuery
+package debugapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// DefaultDrainTimeout is how long a drain waits for the jobs to finish, unless specified.
+const DefaultDrainTimeout = 30 * time.Second
+
+// DrainResponse is the JSON response of the drain endpoint.
+type DrainResponse struct {
+	// Leftover is the number of jobs that did not finish within the timeout.
+	Leftover uint32 `json:"leftover"`
+}
+
+// Handler returns an http.Handler serving the debug endpoints of gw.
+func Handler(gw *goworkers.GoWorkers) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		reply(w, gw.Stats())
+	})
+	mux.HandleFunc("/pause", control(gw, gw.Pause))
+	mux.HandleFunc("/resume", control(gw, gw.Resume))
+	mux.HandleFunc("/drain", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		timeout := DefaultDrainTimeout
+		if v := r.URL.Query().Get("timeout"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				http.Error(w, "invalid timeout", http.StatusBadRequest)
+				return
+			}
+			timeout = d
+		}
+		reply(w, DrainResponse{Leftover: gw.Drain(timeout)})
+	})
+	mux.HandleFunc("/history", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		f, err := HistoryQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		records, err := gw.QueryHistory(f)
+		if errors.Is(err, goworkers.ErrNoArchive) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reply(w, records)
+	})
+	return mux
+}
+
+// HistoryQuery parses the query parameters of the history endpoint: name,
+// key, tenant and status select the records by their fields, since and
+// until by the start time of their job, in RFC 3339, and limit caps their
+// number. Any other parameter matches the metadata entry of its name.
+func HistoryQuery(q url.Values) (goworkers.HistoryFilter, error) {
+	var f goworkers.HistoryFilter
+	for k := range q {
+		v := q.Get(k)
+		var err error
+		switch k {
+		case "name":
+			f.Name = v
+		case "key":
+			f.Key = v
+		case "tenant":
+			f.Tenant = v
+		case "status":
+			f.Status = goworkers.JobStatus(v)
+		case "since":
+			f.Since, err = time.Parse(time.RFC3339, v)
+		case "until":
+			f.Until, err = time.Parse(time.RFC3339, v)
+		case "limit":
+			f.Limit, err = strconv.Atoi(v)
+		default:
+			if f.Metadata == nil {
+				f.Metadata = make(map[string]string)
+			}
+			f.Metadata[k] = v
+		}
+		if err != nil {
+			return goworkers.HistoryFilter{}, errors.New("invalid " + k)
+		}
+	}
+	return f, nil
+}
+
+// ServeUnix serves the debug endpoints of gw on a unix socket at path,
+// replacing a stale socket file, if any. It blocks until the listener fails.
+func ServeUnix(path string, gw *goworkers.GoWorkers) error {
+	_ = os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	return http.Serve(l, Handler(gw))
+}
+
+func control(gw *goworkers.GoWorkers, fn func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fn()
+		reply(w, gw.Stats())
+	}
+}
+
+func reply(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
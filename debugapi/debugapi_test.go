@@ -0,0 +1,113 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package debugapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dpaks/goworkers"
+)
+
+func do(t *testing.T, h http.Handler, method, path string, v interface{}) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	if rec.Code == http.StatusOK && v != nil {
+		if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+			t.Fatalf("Invalid response: %v", err)
+		}
+	}
+	return rec.Code
+}
+
+func TestStats(t *testing.T) {
+	gw := goworkers.New(goworkers.Options{Workers: 3})
+	defer gw.Stop(false)
+	h := Handler(gw)
+
+	var st goworkers.Stats
+	if code := do(t, h, http.MethodGet, "/stats", &st); code != http.StatusOK {
+		t.Fatalf("Expected %d, Got %d", http.StatusOK, code)
+	}
+	if st.MaxWorkers != 3 {
+		t.Errorf("Expected 3, Got %d", st.MaxWorkers)
+	}
+
+	if code := do(t, h, http.MethodPost, "/stats", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected %d, Got %d", http.StatusMethodNotAllowed, code)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	h := Handler(gw)
+
+	var st goworkers.Stats
+	do(t, h, http.MethodPost, "/pause", &st)
+	if !st.Paused {
+		t.Errorf("Expected the pool to be paused")
+	}
+
+	do(t, h, http.MethodPost, "/resume", &st)
+	if st.Paused {
+		t.Errorf("Expected the pool to be resumed")
+	}
+
+	if code := do(t, h, http.MethodGet, "/pause", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected %d, Got %d", http.StatusMethodNotAllowed, code)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	gw := goworkers.New()
+	h := Handler(gw)
+
+	release := make(chan struct{})
+	gw.Submit(func() { <-release })
+
+	if code := do(t, h, http.MethodPost, "/drain?timeout=bogus", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected %d, Got %d", http.StatusBadRequest, code)
+	}
+
+	var resp DrainResponse
+	do(t, h, http.MethodPost, "/drain?timeout=20ms", &resp)
+	if resp.Leftover != 1 {
+		t.Errorf("Expected 1, Got %d", resp.Leftover)
+	}
+
+	close(release)
+	do(t, h, http.MethodPost, "/drain", &resp)
+	if resp.Leftover != 0 {
+		t.Errorf("Expected 0, Got %d", resp.Leftover)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	gw := goworkers.New(goworkers.Options{Archive: goworkers.NewMemoryArchive(0)})
+	h := Handler(gw)
+	gw.Submit(func() {}, goworkers.JobOptions{Name: "export", Metadata: map[string]string{"user": "42"}})
+	gw.Submit(func() {}, goworkers.JobOptions{Name: "import"})
+	gw.Stop(false)
+
+	var records []goworkers.JobRecord
+	if code := do(t, h, http.MethodGet, "/history?name=export&user=42", &records); code != http.StatusOK {
+		t.Fatalf("Expected %d, Got %d", http.StatusOK, code)
+	}
+	if len(records) != 1 || records[0].Name != "export" || records[0].Status != goworkers.JobSucceeded {
+		t.Errorf("Expected the record of job export, Got %+v", records)
+	}
+
+	if code := do(t, h, http.MethodGet, "/history?since=yesterday", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected %d, Got %d", http.StatusBadRequest, code)
+	}
+	plain := goworkers.New()
+	defer plain.Stop(false)
+	if code := do(t, Handler(plain), http.MethodGet, "/history", nil); code != http.StatusNotFound {
+		t.Errorf("Expected %d, Got %d", http.StatusNotFound, code)
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+// Delivery is the delivery semantics of the jobs consumed from a queue, e.g.
+// a Store or a broker through one of the adapter packages.
+//
+// The jobs submitted to a pool directly are delivered at most once: a job
+// is lost if the process exits before it ran, and a failed job is not
+// retried.
+type Delivery int
+
+const (
+	// AtLeastOnce acknowledges a job only once it ran successfully. A job
+	// that failed, or whose consumer crashed while running it, is delivered
+	// again, so it may run more than once and must be idempotent.
+	AtLeastOnce Delivery = iota
+	// AtMostOnce acknowledges a job right before it runs, and runs it only
+	// if the acknowledgement succeeded. A job that fails, or whose consumer
+	// crashes while running it, is lost, but a job never runs twice.
+	AtMostOnce
+)
+
+func (d Delivery) String() string {
+	switch d {
+	case AtLeastOnce:
+		return "at-least-once"
+	case AtMostOnce:
+		return "at-most-once"
+	}
+	return "unknown"
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeliveryString(t *testing.T) {
+	tables := []struct {
+		d    Delivery
+		want string
+	}{
+		{AtLeastOnce, "at-least-once"},
+		{AtMostOnce, "at-most-once"},
+		{Delivery(7), "unknown"},
+	}
+
+	for _, table := range tables {
+		if got := table.d.String(); got != table.want {
+			t.Errorf("Expected %s, Got %s", table.want, got)
+		}
+	}
+}
+
+func TestConsumeStoreAtMostOnce(t *testing.T) {
+	s := NewMemoryStore()
+	failing := enqueue(t, s, Envelope{Name: "greet", Payload: []byte("once")})
+
+	gw := New()
+	defer gw.Stop(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- gw.ConsumeStore(ctx, s, StoreOptions{
+			Lease:        10 * time.Millisecond,
+			PollInterval: time.Millisecond,
+			Delivery:     AtMostOnce,
+		})
+	}()
+
+	var je *JobError
+	if err := <-gw.ErrChan; !errors.As(err, &je) || je.Err.Error() != "hello once" {
+		t.Errorf("Expected hello once, Got %v", err)
+	}
+	// a failed job is not attempted again
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+
+	select {
+	case err := <-gw.ErrChan:
+		t.Errorf("Expected a single attempt, Got %v", err)
+	default:
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected the job to be acknowledged")
+	}
+	if _, ok := s.DeadLetters()[failing]; ok {
+		t.Errorf("Expected the job not to be dead-lettered")
+	}
+}
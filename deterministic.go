@@ -0,0 +1,61 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// deterministic holds the submitted jobs of a pool in deterministic mode
+// and runs them one at a time, in an order drawn from a seeded PRNG
+type deterministic struct {
+	mu      sync.Mutex
+	rng     *rand.Rand
+	pending []*task
+	// set while the pending jobs are run, during which jobs may still be
+	// submitted, e.g. by the running jobs
+	running int32
+}
+
+func newDeterministic(seed int64) *deterministic {
+	return &deterministic{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (d *deterministic) push(t *task) {
+	d.mu.Lock()
+	d.pending = append(d.pending, t)
+	d.mu.Unlock()
+}
+
+// pop removes a pending job at random, returning nil if none is pending
+func (d *deterministic) pop() *task {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+	if len(d.pending) == 0 {
+		return nil
+	}
+	i := d.rng.Intn(len(d.pending))
+	t := d.pending[i]
+	d.pending = append(d.pending[:i], d.pending[i+1:]...)
+	return t
+}
+
+func (d *deterministic) accepting() bool {
+	return atomic.LoadInt32(&d.running) == 1
+}
+
+// runPending runs the pending jobs of a pool in deterministic mode on the
+// calling goroutine, including the ones submitted meanwhile
+func (gw *GoWorkers) runPending() {
+	atomic.StoreInt32(&gw.det.running, 1)
+	defer atomic.StoreInt32(&gw.det.running, 0)
+
+	for t := gw.det.pop(); t != nil; t = gw.det.pop() {
+		gw.runTask(t)
+		atomic.AddUint32(&gw.numJobs, ^uint32(0))
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"fmt"
+	"testing"
+)
+
+// order runs jobs, some of which submit follow-up jobs, in deterministic mode
+func order(seed int64) string {
+	gw := New(Options{Deterministic: true, Seed: seed})
+
+	var got []string
+	for i := 0; i < 8; i++ {
+		n := i
+		gw.Submit(func() {
+			got = append(got, fmt.Sprint(n))
+			if n%4 == 0 {
+				gw.Submit(func() { got = append(got, fmt.Sprintf("%d'", n)) })
+			}
+		})
+	}
+
+	gw.Stop(false)
+	return fmt.Sprint(got)
+}
+
+func TestDeterministic(t *testing.T) {
+	first := order(42)
+	for i := 0; i < 5; i++ {
+		if got := order(42); got != first {
+			t.Errorf("Expected %s, Got %s", first, got)
+		}
+	}
+
+	orders := map[string]bool{}
+	for seed := int64(0); seed < 10; seed++ {
+		orders[order(seed)] = true
+	}
+	if len(orders) < 2 {
+		t.Errorf("Expected different seeds to yield different orders")
+	}
+}
+
+func TestDeterministicWait(t *testing.T) {
+	gw := New(Options{Deterministic: true})
+
+	ran := 0
+	for i := 0; i < 5; i++ {
+		gw.Submit(func() { ran++ })
+	}
+	if gw.JobNum() != 5 || ran != 0 {
+		t.Errorf("Expected the jobs to be held until Wait(), Got %d jobs and %d run", gw.JobNum(), ran)
+	}
+
+	gw.Wait(false)
+	if ran != 5 || gw.JobNum() != 0 {
+		t.Errorf("Expected 5 jobs to have run, Got %d", ran)
+	}
+	if st := gw.Stats(); st.Completed != 5 {
+		t.Errorf("Expected 5, Got %d", st.Completed)
+	}
+
+	gw.Stop(false)
+	if err := gw.Submit(func() {}); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+}
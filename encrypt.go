@@ -0,0 +1,156 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrDecrypt is returned by an Encrypter when a payload cannot be decrypted,
+// as it was tampered with or encrypted with another key.
+var ErrDecrypt = errors.New("goworkers: payload cannot be decrypted")
+
+// Encrypter encrypts the payloads of jobs at rest, such that the stores do
+// not hold them in plaintext, see EncryptStore().
+type Encrypter interface {
+	// Encrypt seals plaintext.
+	Encrypt(plaintext []byte) ([]byte, error)
+	// Decrypt opens a ciphertext sealed by Encrypt.
+	// Returns ErrDecrypt if it cannot be opened.
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+type aeadEncrypter struct {
+	aead cipher.AEAD
+}
+
+// NewAEADEncrypter creates an Encrypter sealing the payloads with aead,
+// each with a random nonce prepended to its ciphertext.
+func NewAEADEncrypter(aead cipher.AEAD) Encrypter {
+	return aeadEncrypter{aead: aead}
+}
+
+// NewAESGCM creates an Encrypter sealing the payloads with AES-GCM.
+// The key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or
+// AES-256.
+func NewAESGCM(key []byte) (Encrypter, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return NewAEADEncrypter(aead), nil
+}
+
+func (e aeadEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize(), e.aead.NonceSize()+len(plaintext)+e.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return e.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (e aeadEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	n := e.aead.NonceSize()
+	if len(ciphertext) < n {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := e.aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// encryptedStore encrypts the payloads of the jobs of a store
+type encryptedStore struct {
+	Store
+	e Encrypter
+}
+
+// EncryptStore wraps s such that the payloads and the checkpoints of its
+// jobs are encrypted with e before they reach s, and decrypted as they are
+// leased. A leased
+// job that cannot be decrypted is dead-lettered, with a reason wrapping
+// ErrCorruptedJob, and the next one is leased instead.
+//
+// The store returned is a LeaseExtender, or a Maintainer, if s is.
+func EncryptStore(s Store, e Encrypter) Store {
+	es := &encryptedStore{Store: s, e: e}
+	ext, isExt := s.(LeaseExtender)
+	m, isMaint := s.(Maintainer)
+	switch {
+	case isExt && isMaint:
+		return struct {
+			*encryptedStore
+			LeaseExtender
+			Maintainer
+		}{es, ext, m}
+	case isExt:
+		return struct {
+			*encryptedStore
+			LeaseExtender
+		}{es, ext}
+	case isMaint:
+		return struct {
+			*encryptedStore
+			Maintainer
+		}{es, m}
+	}
+	return es
+}
+
+// Enqueue encrypts data and adds it to the store.
+func (s *encryptedStore) Enqueue(data []byte) (string, error) {
+	ciphertext, err := s.e.Encrypt(data)
+	if err != nil {
+		return "", err
+	}
+	return s.Store.Enqueue(ciphertext)
+}
+
+// Lease hands out the oldest available job, decrypted.
+func (s *encryptedStore) Lease(d time.Duration) (StoredJob, error) {
+	for {
+		sj, err := s.Store.Lease(d)
+		if err != nil {
+			return sj, err
+		}
+		plaintext, err := s.e.Decrypt(sj.Data)
+		if (err == nil) && (sj.Checkpoint != nil) {
+			sj.Checkpoint, err = s.e.Decrypt(sj.Checkpoint)
+		}
+		if err == nil {
+			sj.Data = plaintext
+			return sj, nil
+		}
+		if err := s.Store.DeadLetter(sj.ID, CorruptedReason(err)); err != nil {
+			return StoredJob{}, err
+		}
+	}
+}
+
+// SaveCheckpoint encrypts state and records it as the last checkpoint of a
+// job.
+// Returns ErrNoCheckpoints if the wrapped store is not a CheckpointStore.
+func (s *encryptedStore) SaveCheckpoint(id string, state []byte) error {
+	cs, ok := s.Store.(CheckpointStore)
+	if !ok {
+		return ErrNoCheckpoints
+	}
+	ciphertext, err := s.e.Encrypt(state)
+	if err != nil {
+		return err
+	}
+	return cs.SaveCheckpoint(id, ciphertext)
+}
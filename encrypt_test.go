@@ -0,0 +1,139 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAESGCM(t *testing.T) {
+	e, err := NewAESGCM(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+
+	plaintext := []byte("jane@example.com")
+	c1, _ := e.Encrypt(plaintext)
+	c2, _ := e.Encrypt(plaintext)
+	if bytes.Contains(c1, plaintext) || bytes.Equal(c1, c2) {
+		t.Errorf("Expected distinct ciphertexts hiding the plaintext, Got %x and %x", c1, c2)
+	}
+
+	got, err := e.Decrypt(c1)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("Expected %q, Got %q and %v", plaintext, got, err)
+	}
+
+	tables := [][]byte{
+		nil,
+		[]byte("short"),
+		append(append([]byte(nil), c1[:len(c1)-1]...), c1[len(c1)-1]^1),
+	}
+	for _, ciphertext := range tables {
+		if _, err := e.Decrypt(ciphertext); err != ErrDecrypt {
+			t.Errorf("%x: Expected %v, Got %v", ciphertext, ErrDecrypt, err)
+		}
+	}
+
+	other, _ := NewAESGCM(bytes.Repeat([]byte{1}, 32))
+	if _, err := other.Decrypt(c1); err != ErrDecrypt {
+		t.Errorf("Expected %v, Got %v", ErrDecrypt, err)
+	}
+
+	if _, err := NewAESGCM(make([]byte, 7)); err == nil {
+		t.Errorf("Expected an error for an invalid key size")
+	}
+}
+
+// plainStore is a Store that is neither a LeaseExtender nor a Maintainer
+type plainStore struct{ Store }
+
+func TestEncryptStore(t *testing.T) {
+	e, _ := NewAESGCM(make([]byte, 16))
+	m := NewMemoryStore()
+	s := EncryptStore(m, e)
+
+	id, _ := s.Enqueue([]byte("jane@example.com"))
+	if m.jobs[id].job.Data == nil || strings.Contains(string(m.jobs[id].job.Data), "jane") {
+		t.Errorf("Expected the payload to be encrypted in the store")
+	}
+
+	// a job that cannot be decrypted is dead-lettered on the way
+	bad, _ := m.Enqueue([]byte("plaintext"))
+
+	sj, err := s.Lease(time.Hour)
+	if err != nil || sj.ID != id || string(sj.Data) != "jane@example.com" {
+		t.Errorf("Expected the decrypted job, Got %+v and %v", sj, err)
+	}
+	if _, err := s.Lease(time.Hour); err != ErrNoJob {
+		t.Errorf("Expected %v, Got %v", ErrNoJob, err)
+	}
+	if reason := m.DeadLetters()[bad]; !strings.HasPrefix(reason, ErrCorruptedJob.Error()) {
+		t.Errorf("Expected the job to be quarantined, Got %q", reason)
+	}
+
+	// the optional interfaces of the store are kept
+	if _, ok := s.(LeaseExtender); !ok {
+		t.Errorf("Expected a LeaseExtender")
+	}
+	if _, ok := s.(Maintainer); !ok {
+		t.Errorf("Expected a Maintainer")
+	}
+	plain := EncryptStore(plainStore{m}, e)
+	if _, ok := plain.(LeaseExtender); ok {
+		t.Errorf("Expected no LeaseExtender")
+	}
+	if _, ok := plain.(Maintainer); ok {
+		t.Errorf("Expected no Maintainer")
+	}
+}
+
+func TestEncryptStoreCheckpoints(t *testing.T) {
+	e, _ := NewAESGCM(make([]byte, 16))
+	m := NewMemoryStore()
+	s := EncryptStore(m, e).(CheckpointStore)
+
+	id, _ := s.(Store).Enqueue([]byte("job"))
+	if err := s.SaveCheckpoint(id, []byte("jane@example.com")); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if strings.Contains(string(m.jobs[id].job.Checkpoint), "jane") {
+		t.Errorf("Expected the checkpoint to be encrypted in the store")
+	}
+	if sj, err := s.(Store).Lease(time.Hour); err != nil || string(sj.Checkpoint) != "jane@example.com" {
+		t.Errorf("Expected the decrypted checkpoint, Got %+v and %v", sj, err)
+	}
+
+	plain := EncryptStore(plainStore{m}, e).(CheckpointStore)
+	if err := plain.SaveCheckpoint(id, nil); err != ErrNoCheckpoints {
+		t.Errorf("Expected %v, Got %v", ErrNoCheckpoints, err)
+	}
+}
+
+func TestConsumeEncryptedStore(t *testing.T) {
+	e, _ := NewAESGCM(make([]byte, 16))
+	s := EncryptStore(NewMemoryStore(), e)
+	gw := New()
+
+	enqueue(t, s, Envelope{Name: "noop"})
+	before := noopRuns
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- gw.ConsumeStore(ctx, s, StoreOptions{PollInterval: time.Millisecond})
+	}()
+	for atomic.LoadInt32(&noopRuns) == before {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	gw.Stop(false)
+}
@@ -0,0 +1,21 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+// Executor is the submission surface of a worker pool, satisfied by
+// *GoWorkers.
+//
+// Application code can depend on Executor instead of *GoWorkers, such that
+// it can be tested with a mock, e.g. goworkerstest.Mock, without real
+// concurrency.
+type Executor interface {
+	Submit(job func(), args ...JobOptions) error
+	SubmitCheckError(job func() error, args ...JobOptions) error
+	SubmitCheckResult(job func() (interface{}, error), args ...JobOptions) error
+	Wait(wait bool) error
+	Stop(wait bool) error
+}
+
+var _ Executor = (*GoWorkers)(nil)
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// StatsFormat is the format in which ExportStats() writes stat snapshots.
+type StatsFormat int
+
+const (
+	// StatsCSV writes a header followed by a CSV record per snapshot.
+	// The per-name stats are left out, and the runtime is in seconds.
+	StatsCSV StatsFormat = iota
+	// StatsJSON writes a JSON object per line per snapshot.
+	StatsJSON
+)
+
+var statsCSVHeader = []string{"time", "workers", "max_workers", "jobs", "running", "queued", "completed", "failed", "dropped", "runtime", "paused"}
+
+// Snapshot is a timestamped snapshot of the stats of a pool.
+type Snapshot struct {
+	Time time.Time `json:"time"`
+	Stats
+}
+
+// ExportStats appends a snapshot of the stats of the pool to w every
+// interval, creating a lightweight flight recorder for environments without
+// a metrics stack.
+//
+// This is a blocking call, typically run in its own goroutine. It returns nil
+// after writing a final snapshot once the pool is stopped, or the first
+// error of writing to w. An error wrapping ErrInvalidOptions is returned
+// right away if interval is not positive.
+func (gw *GoWorkers) ExportStats(w io.Writer, format StatsFormat, interval time.Duration) error {
+	if interval <= 0 {
+		return invalid("stats interval %v is not positive", interval)
+	}
+
+	var write func(Snapshot) error
+	switch format {
+	case StatsCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(statsCSVHeader); err != nil {
+			return err
+		}
+		write = func(s Snapshot) error {
+			_ = cw.Write([]string{
+				s.Time.Format(time.RFC3339Nano),
+				strconv.FormatUint(uint64(s.Workers), 10),
+				strconv.FormatUint(uint64(s.MaxWorkers), 10),
+				strconv.FormatUint(uint64(s.Jobs), 10),
+				strconv.FormatUint(uint64(s.Running), 10),
+				strconv.FormatUint(uint64(s.Queued), 10),
+				strconv.FormatUint(uint64(s.Completed), 10),
+				strconv.FormatUint(uint64(s.Failed), 10),
+				strconv.FormatUint(uint64(s.Dropped), 10),
+				strconv.FormatFloat(s.Runtime.Seconds(), 'f', -1, 64),
+				strconv.FormatBool(s.Paused),
+			})
+			cw.Flush()
+			return cw.Error()
+		}
+	case StatsJSON:
+		enc := json.NewEncoder(w)
+		write = func(s Snapshot) error {
+			return enc.Encode(s)
+		}
+	default:
+		return fmt.Errorf("goworkers: unknown stats format %d", format)
+	}
+
+	ticker := gw.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-gw.stopped:
+			return write(Snapshot{Time: gw.clock.Now(), Stats: gw.Stats()})
+		case now := <-ticker.C():
+			if err := write(Snapshot{Time: now, Stats: gw.Stats()}); err != nil {
+				return err
+			}
+		}
+	}
+}
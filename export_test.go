@@ -0,0 +1,100 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportStatsCSV(t *testing.T) {
+	gw := New(Options{Workers: 2})
+
+	var buf bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- gw.ExportStats(&buf, StatsCSV, 5*time.Millisecond)
+	}()
+
+	for i := 0; i < 3; i++ {
+		gw.Submit(func() {})
+	}
+	time.Sleep(20 * time.Millisecond)
+	gw.Stop(false)
+
+	if err := <-done; err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(records) < 3 {
+		t.Fatalf("Expected a header and at least 2 snapshots, Got %d records", len(records))
+	}
+	if strings.Join(records[0], ",") != strings.Join(statsCSVHeader, ",") {
+		t.Errorf("Unexpected header %v", records[0])
+	}
+
+	// the final snapshot is written after the pool stopped
+	last := records[len(records)-1]
+	if last[2] != "2" || last[3] != "0" || last[6] != "3" {
+		t.Errorf("Unexpected final snapshot %v", last)
+	}
+}
+
+func TestExportStatsJSON(t *testing.T) {
+	gw := New()
+
+	var buf bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- gw.ExportStats(&buf, StatsJSON, time.Hour)
+	}()
+
+	gw.SubmitCheckError(func() error { return errors.New("failed") })
+	gw.Stop(false)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+
+	var s Snapshot
+	if err := json.NewDecoder(&buf).Decode(&s); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if s.Completed != 1 || s.Failed != 1 || s.Time.IsZero() {
+		t.Errorf("Unexpected snapshot %+v", s)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestExportStatsErrors(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	if err := gw.ExportStats(failingWriter{}, StatsCSV, time.Millisecond); err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected disk full, Got %v", err)
+	}
+	if err := gw.ExportStats(failingWriter{}, StatsJSON, time.Millisecond); err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected disk full, Got %v", err)
+	}
+	if err := gw.ExportStats(&bytes.Buffer{}, StatsFormat(7), time.Millisecond); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := gw.ExportStats(&bytes.Buffer{}, StatsJSON, interval); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("Expected %v, Got %v", ErrInvalidOptions, err)
+		}
+	}
+}
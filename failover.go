@@ -0,0 +1,119 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import "sync/atomic"
+
+// Failover is an Executor submitting jobs to a primary pool, and failing
+// over to a standby, e.g. a pool with different options or a remote
+// backend, while the primary cannot take them, for graceful degradation.
+//
+// A job fails over when the primary is stopped, or is being stopped or
+// waited for, when its queue is full, when it sheds the job with
+// ErrOverloaded, or when it is unhealthy, see Healthy().
+//
+// The outputs of the jobs that failed over are delivered on the channels of
+// the standby, if any.
+type Failover struct {
+	primary   *GoWorkers
+	standby   Executor
+	health    HealthOptions
+	failovers uint32
+}
+
+var _ Executor = (*Failover)(nil)
+
+// NewFailover creates a new failover from primary to standby.
+//
+// Accepts optional HealthOptions{} argument, to tell when the primary is
+// unhealthy.
+func NewFailover(primary *GoWorkers, standby Executor, args ...HealthOptions) *Failover {
+	f := &Failover{primary: primary, standby: standby}
+	if len(args) == 1 {
+		f.health = args[0]
+	}
+	return f
+}
+
+// Failovers returns the number of jobs that failed over to the standby.
+func (f *Failover) Failovers() uint32 {
+	return atomic.LoadUint32(&f.failovers)
+}
+
+func (f *Failover) available() bool {
+	if atomic.LoadInt32(&f.primary.stopping) != stateRunning {
+		return false
+	}
+	if f.primary.Saturated() {
+		return false
+	}
+	return f.primary.Healthy(f.health) == nil
+}
+
+func (f *Failover) submit(fn func(Executor) error) error {
+	if f.available() {
+		err := fn(f.primary)
+		if (err != ErrStopped) && (err != ErrOverloaded) {
+			return err
+		}
+	}
+	atomic.AddUint32(&f.failovers, 1)
+	return fn(f.standby)
+}
+
+// Submit is a non-blocking call with arg of type `func()`
+//
+// Accepts optional JobOptions{} argument.
+// Returns the error of submitting to the standby, if the job failed over.
+func (f *Failover) Submit(job func(), args ...JobOptions) error {
+	return f.submit(func(e Executor) error {
+		return e.Submit(job, args...)
+	})
+}
+
+// SubmitCheckError is a non-blocking call with arg of type `func() error`
+//
+// Use ErrChan buffered channel of the pool that ran the job to read error,
+// if any.
+// Accepts optional JobOptions{} argument.
+// Returns the error of submitting to the standby, if the job failed over.
+func (f *Failover) SubmitCheckError(job func() error, args ...JobOptions) error {
+	return f.submit(func(e Executor) error {
+		return e.SubmitCheckError(job, args...)
+	})
+}
+
+// SubmitCheckResult is a non-blocking call with arg of type `func() (interface{}, error)`
+//
+// Use ErrChan and ResultChan buffered channels of the pool that ran the job
+// to read error and output, if any.
+// Accepts optional JobOptions{} argument.
+// Returns the error of submitting to the standby, if the job failed over.
+func (f *Failover) SubmitCheckResult(job func() (interface{}, error), args ...JobOptions) error {
+	return f.submit(func(e Executor) error {
+		return e.SubmitCheckResult(job, args...)
+	})
+}
+
+// Wait waits for the jobs of both the primary and the standby to finish
+// running.
+//
+// See GoWorkers.Wait() for the semantics of the 'wait' argument.
+func (f *Failover) Wait(wait bool) error {
+	if err := f.primary.Wait(wait); err != nil {
+		return err
+	}
+	return f.standby.Wait(wait)
+}
+
+// Stop stops both the primary and the standby.
+//
+// See GoWorkers.Stop() for the semantics of the 'wait' argument.
+func (f *Failover) Stop(wait bool) error {
+	if err := f.primary.Stop(wait); err != nil {
+		return err
+	}
+	return f.standby.Stop(wait)
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	primary, standby := New(), New()
+	f := NewFailover(primary, standby)
+	defer f.Stop(false)
+
+	var onPrimary, onStandby int32
+	if err := f.Submit(func() { atomic.AddInt32(&onPrimary, 1) }); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	primary.Stop(false)
+	if err := f.Submit(func() { atomic.AddInt32(&onStandby, 1) }); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	standby.Wait(false)
+
+	if n := atomic.LoadInt32(&onPrimary); n != 1 {
+		t.Errorf("Expected %v, Got %v", 1, n)
+	}
+	if n := atomic.LoadInt32(&onStandby); n != 1 {
+		t.Errorf("Expected the job to fail over, Got %v runs on the standby", n)
+	}
+	if n := f.Failovers(); n != 1 {
+		t.Errorf("Expected %v, Got %v", 1, n)
+	}
+}
+
+func TestFailoverUnhealthy(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	primary, standby := New(Options{Clock: clock}), New()
+	f := NewFailover(primary, standby, HealthOptions{StallAfter: time.Second})
+
+	release := make(chan struct{})
+	_ = f.Submit(func() { <-release })
+	clock.Advance(time.Minute)
+
+	var onStandby int32
+	_ = f.Submit(func() { atomic.AddInt32(&onStandby, 1) })
+	standby.Wait(false)
+	if n := atomic.LoadInt32(&onStandby); n != 1 {
+		t.Errorf("Expected the job to fail over from a stalled pool, Got %v runs on the standby", n)
+	}
+
+	close(release)
+	f.Stop(false)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// SubmitWithFallback is a non-blocking call with args of type
+// `func(context.Context) (interface{}, error)`
+//
+// If job does not complete within after, e.g. as its backend is slow,
+// fallback is submitted as well, e.g. to serve cached data. The outputs of
+// whichever completes first, successfully or not, are delivered on ErrChan
+// and ResultChan as with SubmitCheckResult(), and the context of the other is
+// cancelled. The outputs of the other are discarded.
+//
+// The fallback is not submitted if the pool is stopped, or is being stopped
+// or waited for, by then.
+// Accepts optional JobOptions{} argument, applied to both the jobs.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (gw *GoWorkers) SubmitWithFallback(job, fallback func(ctx context.Context) (interface{}, error), after time.Duration, args ...JobOptions) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	var completed int32
+
+	race := func(fn func(ctx context.Context) (interface{}, error)) *task {
+		t := &task{outputs: resultOutput, opts: jobOptions(args), ctx: ctx}
+		// the loser of the race is dropped silently once the winner completes
+		t.onSkip = func() { t.outputs = noOutputs }
+		t.run = func(ctx context.Context) (interface{}, error) {
+			if ctx.Err() == nil {
+				result, err := protect(fn)(ctx)
+				if atomic.CompareAndSwapInt32(&completed, 0, 1) {
+					cancel()
+					return result, err
+				}
+			}
+			// lost the race, the outputs are discarded
+			t.outputs = noOutputs
+			return nil, nil
+		}
+		return t
+	}
+
+	if err := gw.submit(race(job)); err != nil {
+		cancel()
+		return err
+	}
+
+	timer := gw.clock.NewTimer(after)
+	gw.goHelper(func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			_ = gw.submit(race(fallback))
+		case <-ctx.Done():
+		case <-gw.stopped:
+		}
+	})
+	return nil
+}
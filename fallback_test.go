@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubmitWithFallback(t *testing.T) {
+	tables := []struct {
+		name     string
+		after    time.Duration
+		expected string
+	}{
+		{"primary in time", time.Hour, "primary"},
+		{"fallback", time.Millisecond, "cached"},
+	}
+
+	for _, table := range tables {
+		gw := New()
+		release := make(chan struct{})
+		cancelled := make(chan struct{})
+
+		err := gw.SubmitWithFallback(func(ctx context.Context) (interface{}, error) {
+			select {
+			case <-release:
+				return "primary", nil
+			case <-ctx.Done():
+				close(cancelled)
+				return nil, ctx.Err()
+			}
+		}, func(context.Context) (interface{}, error) {
+			return "cached", nil
+		}, table.after)
+		if err != nil {
+			t.Fatalf("%s: Expected nil, Got %v", table.name, err)
+		}
+
+		if table.expected == "primary" {
+			close(release)
+		}
+		if res := <-gw.ResultChan; res != table.expected {
+			t.Errorf("%s: Expected %v, Got %v", table.name, table.expected, res)
+		}
+		if table.expected == "cached" {
+			<-cancelled
+		}
+		gw.Stop(false)
+
+		if n := len(gw.ErrChan) + len(gw.ResultChan); n != 0 {
+			t.Errorf("%s: Expected the outputs of the other job to be discarded, Got %d outputs", table.name, n)
+		}
+		if err := gw.VerifyShutdown(time.Second); err != nil {
+			t.Errorf("%s: Expected nil, Got %v", table.name, err)
+		}
+	}
+}
+
+func TestSubmitWithFallbackStopped(t *testing.T) {
+	gw := New()
+	gw.Stop(false)
+
+	noop := func(context.Context) (interface{}, error) { return nil, nil }
+	if err := gw.SubmitWithFallback(noop, noop, time.Second); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package fswatch implements a source that submits a goworkers job for each
+// file created or modified under watched directories, as in drop-folder
+// ingestion pipelines.
+//
+// Jobs are keyed by path: the events of a file are never processed
+// concurrently. Events for a file that arrive while a job for it is running
+// are coalesced into a single follow-up job, run once the first finishes.
+package fswatch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dpaks/goworkers"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Op describes the changes that happened to a file, as a bitmask.
+type Op uint8
+
+// Changes to a file.
+const (
+	Create Op = 1 << iota
+	Write
+)
+
+// Event is a change to a file.
+type Event struct {
+	Path string
+	Op   Op
+}
+
+// Handler processes an event. The error, if any, is delivered on the ErrChan
+// of the pool.
+type Handler func(ev Event) error
+
+// Options configures the watcher.
+//
+// Dirs are the directories to watch.
+//
+// Recursive specifies whether the subdirectories of Dirs, including the ones
+// created later on, are watched as well.
+type Options struct {
+	Dirs      []string
+	Recursive bool
+}
+
+// Watch submits the events of the watched directories to gw until ctx is done.
+//
+// This is a blocking call. It returns after the jobs handed over to the pool
+// have finished, with the context's error or the first error of the watcher.
+func Watch(ctx context.Context, gw *goworkers.GoWorkers, h Handler, opts Options) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	for _, dir := range opts.Dirs {
+		if err := add(w, dir, opts.Recursive); err != nil {
+			return err
+		}
+	}
+
+	k := newKeyed(gw, h)
+	defer k.wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-w.Errors:
+			return err
+		case ev := <-w.Events:
+			var op Op
+			if ev.Has(fsnotify.Create) {
+				op |= Create
+			}
+			if ev.Has(fsnotify.Write) {
+				op |= Write
+			}
+			if op == 0 {
+				continue
+			}
+
+			fi, err := os.Stat(ev.Name)
+			if err != nil {
+				// gone already
+				continue
+			}
+			if fi.IsDir() {
+				if opts.Recursive && (op&Create != 0) {
+					if err := add(w, ev.Name, true); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			k.dispatch(Event{Path: ev.Name, Op: op})
+		}
+	}
+}
+
+func add(w *fsnotify.Watcher, dir string, recursive bool) error {
+	if !recursive {
+		return w.Add(dir)
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return w.Add(path)
+		}
+		return nil
+	})
+}
+
+// keyed submits events to the pool such that the events of a path are
+// processed one at a time
+type keyed struct {
+	gw *goworkers.GoWorkers
+	h  Handler
+	wg sync.WaitGroup
+
+	mu sync.Mutex
+	// a path is present while a job for it is running, mapping to the
+	// coalesced events that arrived meanwhile, if any
+	paths map[string]*Event
+}
+
+func newKeyed(gw *goworkers.GoWorkers, h Handler) *keyed {
+	return &keyed{gw: gw, h: h, paths: make(map[string]*Event)}
+}
+
+func (k *keyed) dispatch(ev Event) {
+	k.mu.Lock()
+	if pending, running := k.paths[ev.Path]; running {
+		if pending == nil {
+			pending = &Event{Path: ev.Path}
+			k.paths[ev.Path] = pending
+		}
+		pending.Op |= ev.Op
+		k.mu.Unlock()
+		return
+	}
+	k.paths[ev.Path] = nil
+	k.mu.Unlock()
+
+	k.submit(ev)
+}
+
+func (k *keyed) submit(ev Event) {
+	k.wg.Add(1)
+	accepted := k.gw.TrySubmit(func() {
+		k.report(k.h(ev))
+	}, goworkers.JobOptions{
+		OnFinish: func(error) { k.done(ev.Path) },
+	})
+	if !accepted {
+		k.report(fmt.Errorf("fswatch: %s: %w", ev.Path, goworkers.ErrRejected))
+		k.done(ev.Path)
+	}
+}
+
+// done runs the coalesced events of path, if any, else forgets the path
+func (k *keyed) done(path string) {
+	defer k.wg.Done()
+
+	k.mu.Lock()
+	pending := k.paths[path]
+	if pending == nil {
+		delete(k.paths, path)
+		k.mu.Unlock()
+		return
+	}
+	k.paths[path] = nil
+	k.mu.Unlock()
+
+	k.submit(*pending)
+}
+
+func (k *keyed) report(err error) {
+	if err == nil {
+		return
+	}
+	select {
+	case k.gw.ErrChan <- err:
+	default:
+	}
+}
+
+func (k *keyed) wait() {
+	k.wg.Wait()
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package fswatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+func TestKeyedOrdering(t *testing.T) {
+	gw := goworkers.New(goworkers.Options{Workers: 4})
+	defer gw.Stop(false)
+
+	var mu sync.Mutex
+	running := make(map[string]bool)
+	var calls, overlaps int32
+	release := make(chan struct{})
+
+	var seen []Op
+	k := newKeyed(gw, func(ev Event) error {
+		mu.Lock()
+		if running[ev.Path] {
+			overlaps++
+		}
+		running[ev.Path] = true
+		if ev.Path == "a" {
+			seen = append(seen, ev.Op)
+		}
+		mu.Unlock()
+
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release
+		}
+
+		mu.Lock()
+		running[ev.Path] = false
+		mu.Unlock()
+		return nil
+	})
+
+	k.dispatch(Event{Path: "a", Op: Create})
+	for atomic.LoadInt32(&calls) != 1 {
+	}
+	// coalesced into a single follow-up job while the first one runs
+	k.dispatch(Event{Path: "a", Op: Write})
+	k.dispatch(Event{Path: "a", Op: Create})
+	k.dispatch(Event{Path: "b", Op: Write})
+	for atomic.LoadInt32(&calls) != 2 {
+	}
+
+	close(release)
+	k.wait()
+
+	if overlaps != 0 {
+		t.Errorf("Expected the events of a path not to overlap, Got %d overlaps", overlaps)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3, Got %d", calls)
+	}
+	if len(seen) != 2 || seen[0] != Create || seen[1] != Create|Write {
+		t.Errorf("Expected a create followed by a coalesced create and write, Got %v", seen)
+	}
+	if len(k.paths) != 0 {
+		t.Errorf("Expected the paths to be forgotten, Got %v", k.paths)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	events := make(chan Event, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, gw, func(ev Event) error {
+			events <- ev
+			return nil
+		}, Options{Dirs: []string{dir}, Recursive: true})
+	}()
+
+	// the watcher needs a moment to be set up
+	time.Sleep(100 * time.Millisecond)
+
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	want := filepath.Join(sub, "drop.csv")
+	if err := os.WriteFile(want, []byte("a,b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Path != want {
+				t.Errorf("Expected %s, Got %s", want, ev.Path)
+			}
+			if ev.Op&Create == 0 {
+				continue
+			}
+			cancel()
+			if err := <-done; err != context.Canceled {
+				t.Errorf("Expected %v, Got %v", context.Canceled, err)
+			}
+			return
+		case <-timeout:
+			t.Fatalf("Timed out waiting for the file to be created")
+		}
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/dpaks/goworkers/fswatch
+
+go 1.23
+
+require (
+	github.com/dpaks/goworkers v0.0.0
+	github.com/fsnotify/fsnotify v1.10.1
+)
+
+require golang.org/x/sys v0.13.0 // indirect
+
+replace github.com/dpaks/goworkers => ../
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+)
+
+// Future is the handle of a job submitted with SubmitFuture(), on which the
+// outcome of that very job is awaited.
+type Future struct {
+	// set with Options.BoostAwaited only
+	boost  func()
+	cancel context.CancelFunc
+	done   chan struct{}
+	result interface{}
+	err    error
+}
+
+// SubmitFuture is a non-blocking call with arg of type
+// `func(ctx context.Context) (interface{}, error)`
+//
+// The outputs of the job are delivered on the returned Future only, instead
+// of on ErrChan and ResultChan, such that the caller needs not tell them
+// apart from the outputs of the other jobs.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (gw *GoWorkers) SubmitFuture(job func(ctx context.Context) (interface{}, error), args ...JobOptions) (*Future, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &Future{cancel: cancel, done: make(chan struct{})}
+	t := &task{
+		run:     job,
+		outputs: noOutputs,
+		opts:    jobOptions(args),
+		ctx:     ctx,
+		onDone:  f.complete,
+	}
+	if gw.boostAwaited && (t.opts.Priority < Normal) {
+		// boosted on the lane holding it, which ReplaceWith() may have
+		// swapped for a new one since
+		f.boost = func() {
+			if l, ok := t.heldOn.Load().(*lane); ok {
+				l.boost(t)
+			}
+		}
+	}
+	err := gw.submit(t)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Future) complete(result interface{}, err error) {
+	f.result, f.err = result, err
+	close(f.done)
+	f.cancel()
+}
+
+// Done returns a channel that is closed once the job has finished, or was
+// dropped without running. Unlike Wait(), it does not boost the job, such
+// that the job can be selected on along with others.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the job to finish, or to be dropped without running. With
+// Options.BoostAwaited, a Low or Scavenger job still queued is boosted to
+// the High class.
+func (f *Future) Wait() {
+	f.await()
+	<-f.done
+}
+
+// Result waits for the job to finish and returns its output and error. The
+// error of a job dropped without running tells why, e.g. it wraps
+// ErrCancelled for a job cancelled before it ran.
+func (f *Future) Result() (interface{}, error) {
+	f.Wait()
+	return f.result, f.err
+}
+
+// await boosts the job if it is still queued
+func (f *Future) await() {
+	if f.boost == nil {
+		return
+	}
+	select {
+	case <-f.done:
+	default:
+		f.boost()
+	}
+}
+
+// Cancel cancels the context of the job: a queued job is dropped without
+// running, and a running job is told to give up. It does not wait for the
+// job to finish.
+func (f *Future) Cancel() {
+	f.cancel()
+}
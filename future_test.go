@@ -0,0 +1,175 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFuture(t *testing.T) {
+	gw := New(Options{Workers: 1})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	first, err := gw.SubmitFuture(func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		return 42, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	<-started
+
+	failed := errors.New("failed")
+	second, _ := gw.SubmitFuture(func(ctx context.Context) (interface{}, error) {
+		return nil, failed
+	})
+	// the queued job is dropped once cancelled
+	third, _ := gw.SubmitFuture(func(ctx context.Context) (interface{}, error) {
+		t.Errorf("Expected the job not to run")
+		return nil, nil
+	})
+	third.Cancel()
+
+	select {
+	case <-first.Done():
+		t.Errorf("Expected the job to be running")
+	default:
+	}
+	close(release)
+
+	if v, err := first.Result(); v != 42 || err != nil {
+		t.Errorf("Expected 42 and nil, Got %v and %v", v, err)
+	}
+	if _, err := second.Result(); err != failed {
+		t.Errorf("Expected %v, Got %v", failed, err)
+	}
+	third.Wait()
+	if _, err := third.Result(); !errors.Is(err, ErrCancelled) {
+		t.Errorf("Expected %v, Got %v", ErrCancelled, err)
+	}
+
+	// the outputs are delivered on the futures only
+	gw.Stop(false)
+	if err, ok := <-gw.ErrChan; ok {
+		t.Errorf("Expected no error, Got %v", err)
+	}
+	if v, ok := <-gw.ResultChan; ok {
+		t.Errorf("Expected no result, Got %v", v)
+	}
+	if st := gw.Stats(); st.Failed != 1 || st.Cancelled != 1 {
+		t.Errorf("Expected 1 failed and 1 cancelled job, Got %+v", st)
+	}
+
+	if _, err := gw.SubmitFuture(func(ctx context.Context) (interface{}, error) { return nil, nil }); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+}
+
+func TestFutureBoostAwaited(t *testing.T) {
+	tables := []struct {
+		wait     bool
+		expected []string
+	}{
+		// the awaited Scavenger job goes ahead of the Low one
+		{true, []string{"vacuum", "export"}},
+		// Done() does not boost it
+		{false, []string{"export", "vacuum"}},
+	}
+
+	for _, table := range tables {
+		gw := New(Options{Workers: 1, BoostAwaited: true})
+
+		var order []string
+		release := make(chan struct{})
+		started := make(chan struct{})
+		gw.Submit(func() {
+			close(started)
+			<-release
+		})
+		<-started
+
+		f, _ := gw.SubmitFuture(func(ctx context.Context) (interface{}, error) {
+			order = append(order, "vacuum")
+			return nil, nil
+		}, JobOptions{Priority: Scavenger})
+		gw.SubmitWithPriority(Low, func() { order = append(order, "export") })
+		done := f.Done()
+		if table.wait {
+			go f.Wait()
+			waitBoosted(t, gw.current())
+		}
+		close(release)
+		<-done
+		gw.Wait(false)
+
+		if !reflect.DeepEqual(order, table.expected) {
+			t.Errorf("Expected %v, Got %v", table.expected, order)
+		}
+		gw.Stop(false)
+	}
+}
+
+func TestFutureBoostAwaitedReplaced(t *testing.T) {
+	gw := New(Options{Workers: 1, BoostAwaited: true})
+	defer gw.Stop(false)
+
+	var order []string
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	f, _ := gw.SubmitFuture(func(ctx context.Context) (interface{}, error) {
+		order = append(order, "vacuum")
+		return nil, nil
+	}, JobOptions{Priority: Scavenger})
+	gw.SubmitWithPriority(Low, func() { order = append(order, "export") })
+
+	// the jobs are held on the replaced lane while the new one takes over
+	old := gw.current()
+	replaced := make(chan error)
+	go func() { replaced <- gw.ReplaceWith(Options{Workers: 1}) }()
+	for gw.current() == old {
+		time.Sleep(time.Millisecond)
+	}
+	go f.Wait()
+	waitBoosted(t, old)
+	close(release)
+	if err := <-replaced; err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+
+	if want := []string{"vacuum", "export"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected %v, Got %v", want, order)
+	}
+}
+
+// waitBoosted waits for a held job of l to be boosted to the High class
+func waitBoosted(t *testing.T, l *lane) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.heldMu.Lock()
+		n := len(l.high)
+		l.heldMu.Unlock()
+		if n != 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			// not fatal, such that the blocked worker is released
+			t.Errorf("Expected the awaited job to be boosted")
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
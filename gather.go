@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"sync"
+)
+
+// Result is the outcome of a job run by Gather().
+type Result struct {
+	Value interface{}
+	Err   error
+}
+
+// Gather submits all the jobs and waits for them to finish, or for ctx to be
+// done, whichever comes first, e.g. for the fan-out of a request handler.
+//
+// The results are in the order of the jobs. The jobs that did not finish in
+// time, or that were not accepted by the pool, report the error of ctx or of
+// the submission as their Err. Those not started yet are skipped, and the
+// running ones are told to give up through the context they are passed.
+// Jobs do not deliver their outputs on ErrChan and ResultChan.
+// Accepts optional JobOptions{} argument, applied to every job.
+// Returns the error of ctx if some jobs did not finish in time.
+func (gw *GoWorkers) Gather(ctx context.Context, jobs []func(ctx context.Context) (interface{}, error), args ...JobOptions) ([]Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		results  = make([]Result, len(jobs))
+		finished = make([]bool, len(jobs))
+		pending  = len(jobs)
+		done     = make(chan struct{})
+	)
+	finish := func(i int, res Result) {
+		defer mu.Unlock()
+		mu.Lock()
+		// too late, once ctx is done
+		if finished[i] || (ctx.Err() != nil) {
+			return
+		}
+		results[i], finished[i] = res, true
+		if pending--; pending == 0 {
+			close(done)
+		}
+	}
+	if pending == 0 {
+		close(done)
+	}
+
+	for i, job := range jobs {
+		i, job := i, job
+		err := gw.submit(&task{
+			run: func(ctx context.Context) (interface{}, error) {
+				v, err := protect(job)(ctx)
+				finish(i, Result{Value: v, Err: err})
+				return v, err
+			},
+			outputs: noOutputs,
+			opts:    jobOptions(args),
+			ctx:     ctx,
+		})
+		if err != nil {
+			finish(i, Result{Err: err})
+		}
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	defer mu.Unlock()
+	mu.Lock()
+	if pending == 0 {
+		return results, nil
+	}
+	err := ctx.Err()
+	for i := range results {
+		if !finished[i] {
+			results[i].Err = err
+			// the job finishing late must not write to the results returned
+			finished[i] = true
+		}
+	}
+	return results, err
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGather(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	errFailed := errors.New("failed")
+	results, err := gw.Gather(context.Background(), []func(context.Context) (interface{}, error){
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return nil, errFailed },
+		func(context.Context) (interface{}, error) { return 3, nil },
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+
+	tables := []struct {
+		value interface{}
+		err   error
+	}{
+		{1, nil},
+		{nil, errFailed},
+		{3, nil},
+	}
+	for i, table := range tables {
+		if results[i].Value != table.value || results[i].Err != table.err {
+			t.Errorf("Expected %v and %v, Got %v and %v", table.value, table.err, results[i].Value, results[i].Err)
+		}
+	}
+}
+
+func TestGatherTimeout(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results, err := gw.Gather(ctx, []func(context.Context) (interface{}, error){
+		func(context.Context) (interface{}, error) { return "fast", nil },
+		func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done()
+			return "slow", nil
+		},
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, Got %v", context.DeadlineExceeded, err)
+	}
+	if results[0].Value != "fast" || results[0].Err != nil {
+		t.Errorf("Expected the fast job to report its result, Got %+v", results[0])
+	}
+	if results[1].Value != nil || results[1].Err != context.DeadlineExceeded {
+		t.Errorf("Expected the slow job to report %v, Got %+v", context.DeadlineExceeded, results[1])
+	}
+}
+
+func TestGatherStopped(t *testing.T) {
+	gw := New()
+	gw.Stop(false)
+
+	results, err := gw.Gather(context.Background(), []func(context.Context) (interface{}, error){
+		func(context.Context) (interface{}, error) { return 1, nil },
+	})
+	if err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if results[0].Err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, results[0].Err)
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/dpaks/goworkers
 
-go 1.17
+go 1.18
@@ -7,8 +7,14 @@ Copyright 2020 Deepak S<deepaks@outlook.in>
 package goworkers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const (
@@ -20,18 +26,152 @@ const (
 	outputChanSize = 100
 )
 
+var (
+	// ErrWorkerNotFound is returned when no active worker has the given id.
+	ErrWorkerNotFound = errors.New("goworkers: worker not found")
+	// ErrRejected is returned when the pool does not accept a job, because
+	// it is stopping or its queue is full.
+	ErrRejected = errors.New("goworkers: job rejected")
+	// ErrStopped is returned when a job is submitted to a pool that is
+	// stopped, or that is being stopped or waited for.
+	ErrStopped = errors.New("goworkers: pool stopped")
+	// ErrNotStopped is returned by VerifyShutdown() for a pool that has not
+	// been stopped.
+	ErrNotStopped = errors.New("goworkers: pool not stopped")
+	// ErrLeaked is returned by VerifyShutdown() when goroutines of a stopped
+	// pool have not exited.
+	ErrLeaked = errors.New("goworkers: goroutines leaked")
+	// ErrCalledFromJob is returned when Wait() or Stop() is called from a job
+	// running on the same pool, which would otherwise wait for itself forever.
+	ErrCalledFromJob = errors.New("goworkers: Wait or Stop called from a job of the same pool")
+)
+
+// States of a pool
+const (
+	stateRunning int32 = iota
+	stateWaiting
+	stateStopping
+)
+
 // GoWorkers is a collection of worker goroutines.
 //
 // All workers will be killed after Stop() is called if their respective job finishes.
 type GoWorkers struct {
+	// 64-bit fields are kept first for the alignment required by sync/atomic.
+
+	// health tracking in unix nanoseconds, see Healthy()
+	lastProgress   int64
+	saturatedSince int64
+	// minimum spacing in nanoseconds between two jobs run by a worker, if
+	// rate limited
+	workerInterval int64
+	// total runtime of the jobs in nanoseconds
+	runtime int64
+	// moving average of the queue wait of the jobs in nanoseconds
+	queueWait int64
+	// Options.MaxQueueWait and Options.JobTimeout in nanoseconds, see
+	// ApplyOptions()
+	maxQueueWait int64
+	jobTimeout   int64
+	// total usage of the jobs, with MeasureUsage only
+	allocs uint64
+	cpu    int64
+	// sequence of the ids of the jobs, with Logger only
+	jobSeq uint64
+
 	numWorkers uint32
 	maxWorkers uint32
 	numJobs    uint32
-	workerQ    chan func()
-	bufferedQ  chan func()
-	jobQ       chan func()
-	stopping   int32
-	done       chan struct{}
+	numRunning uint32
+	numDone    uint32
+	numFailed  uint32
+	numDropped uint32
+	// jobs abandoned past their hard deadline
+	numAbandoned uint32
+	// jobs given up on past their timeout
+	numTimedOut uint32
+	// jobs dropped as their context was done
+	numCancelled uint32
+	// jobs discarded by StopWith()
+	numDiscarded uint32
+	// workers that recovered from a panicking job
+	numRestarts uint32
+	// the *lane taking the submissions, see ReplaceWith()
+	lane     atomic.Value
+	stopping int32
+	done     chan struct{}
+	stopped  chan struct{}
+
+	// goroutines of the dispatcher and short-lived helpers, see VerifyShutdown()
+	numDispatchers int32
+	numHelpers     int32
+
+	// submissions hold submitMu for reading while they hand over a job, so
+	// that Stop() can wait for the ones that raced with it
+	submitMu sync.RWMutex
+
+	// mx guards the worker registry and the spawning of workers
+	mx       sync.Mutex
+	workers  map[uint64]*worker
+	workerID uint64
+
+	// workers wait on resume, guarded by mx, while the pool is paused for
+	// any of the reasons of pauses, also guarded by mx
+	paused int32
+	pauses int
+	resume chan struct{}
+
+	// the submissions blocked while the queue is full wait on room, guarded
+	// by roomMu, see BlockPolicy
+	blocked int32
+	roomMu  sync.Mutex
+	room    chan struct{}
+
+	name  string
+	clock Clock
+	// set with StrictOutputs only
+	strict *strictOutputs
+	// set with Idempotency only
+	idempotency  *idempotency
+	overflow     OverflowPolicy
+	measureUsage bool
+	archive      Archive
+	decorate     func(ctx context.Context) context.Context
+	logger       Logger
+	// guarded by mx
+	burst burst
+	// set in deterministic mode only
+	det *deterministic
+	// ids of the goroutines of the workers
+	workerGoroutines sync.Map
+	named            namedCounters
+	rolling          rollingWindow
+	quotas           tenantQuotas
+	quarantine       quarantine
+	debouncer        debouncer
+	batchers         batchers
+	subscriptions    subscriptions
+	middleware       resultMiddleware
+	interceptors     interceptors
+	handlers         handlers
+	stopHooks        stopHooks
+	// the *StopOptions discarding the queued jobs, see StopWith()
+	discarding   atomic.Value
+	scratch      *scratch
+	backpressure *backpressure
+	resizes      queueResizes
+	aging        time.Duration
+	boostAwaited bool
+	panicHandler func(value interface{}, stack []byte)
+
+	// see Options.LoadThreshold, which cannot be changed
+	loadThreshold float64
+
+	// see StopProgress()
+	progress             int32
+	stopProgress         chan ShutdownProgress
+	stopProgressInterval time.Duration
+
 	// ErrChan is a safe buffered output channel of size 100 on which error
 	// returned by a job can be caught, if any. The channel will be closed
 	// after Stop() returns. Valid only for SubmitCheckError() and SubmitCheckResult().
@@ -50,14 +190,166 @@ type GoWorkers struct {
 
 // Options configures the behaviour of worker pool.
 //
+// Name identifies the pool in its String() summary. It is optional.
+//
 // Workers specifies the number of workers that will be spawned.
 // If unspecified or zero, workers will be spawned as per demand.
 //
 // QSize specifies the size of the queue that holds up incoming jobs.
-// Minimum value is 128.
+// If unspecified or zero, 128 is used. The queue is full once QSize jobs
+// wait for a worker: Saturated() then reports it and TrySubmit() rejects the
+// jobs, while Submit() handles them as per Overflow. Small queues, down to
+// 1, thus report backpressure as soon as the workers fall behind.
+// ResizeQueue() changes it on a running pool.
+//
+// DirectHandoff removes the queue: a submission blocks until a worker takes
+// its job, starting one if Workers allows, for the strictest backpressure
+// and no queueing latency. QSize must then be left zero. The High and Low
+// jobs are handed over as Normal ones, while Scavenger jobs still wait for
+// an idle worker. The jobs submitted by jobs, see Submit(), and those handed
+// over by a Batcher, SubmitDebounced() or a tenant quota do not block and
+// are queued instead. The pool is Saturated() while every worker holds a
+// job.
+//
+// WorkerRate limits each worker to at most WorkerRate jobs per second,
+// independently of the other workers. This is useful when every worker
+// owns a session to a rate-limited upstream.
+// If unspecified or zero, workers are not rate limited.
+//
+// Clock is used by the time-based features of the pool.
+// If unspecified, RealClock is used. Use a FakeClock in tests.
+//
+// Deterministic enables a test-only mode for reproducible fuzz and property
+// tests of job-ordering assumptions. Submitted jobs are held until Wait() or
+// Stop() is called, which then runs them one at a time on the calling
+// goroutine, in an order drawn from a PRNG seeded with Seed, until none is
+// left. A given seed always yields the same order for the same submissions.
+// Jobs must therefore not wait for one another, nor for Wait() or Stop()
+// to return.
+//
+// StrictOutputs makes the pool act on the outputs of jobs that would be
+// dropped as ErrChan or ResultChan is full, instead of silently dropping
+// them. DropAction specifies the action, see BlockOnDrop, PanicOnDrop and
+// ReportOnDrop. OnDrop is called with ReportOnDrop.
+//
+// Idempotency records the results of the jobs submitted with a
+// JobOptions.Key once they succeed, so that duplicates of a job, e.g.
+// re-delivered by an at-least-once queue, are skipped and deliver the
+// recorded result instead. Failed jobs are not recorded, so that they can be
+// retried. A duplicate of a running job waits for it to finish.
+//
+// MaxQueueWait enables load shedding: while the jobs wait in the queue for
+// longer than MaxQueueWait on average, submissions are rejected with
+// ErrOverloaded. If unspecified or zero, jobs are only rejected when the
+// queue is full, as with TrySubmit().
+//
+// MeasureUsage measures the heap allocations and the CPU time of every job,
+// see Usage. The runtime only measures them for the whole process, hence
+// the usage of a job includes that of the jobs running at the same time,
+// and of the rest of the process: it is exact for jobs running one at a
+// time only. Results are then delivered on ResultChan as JobResult carrying
+// the usage of their job.
+//
+// Archive, if set, records the outcome of every finished job, see
+// QueryHistory().
+//
+// HighWatermark and LowWatermark are the numbers of queued jobs at which the
+// pool turns Throttled and back Flowing, see Backpressure(). If unspecified
+// or zero, QSize and half of HighWatermark are used.
+//
+// BurstWorkers lets the pool start up to BurstWorkers workers beyond Workers
+// while jobs are waiting for a worker, e.g. on a spike of submissions. The
+// extra workers are retired once they finish their current job,
+// BurstDuration after the burst started, and no burst starts again for
+// another BurstDuration. If BurstDuration is unspecified or zero, 10 seconds
+// is used. BurstWorkers requires Workers.
+//
+// ContextDecorator, if set, decorates the context of every job, e.g. with
+// request ids, tenants or feature flags, so that the jobs see consistent
+// ambient values. The context it is given carries the description of the
+// job, see JobInfoFromContext(). The context of a job is seen by the
+// interceptors, by the jobs of Group, Gather() and SubmitWithFallback(),
+// and by registered jobs that are ContextJob.
+//
+// Logger, if set, is the logger every job's logger derives from, with the
+// fields pool, job_id, job and worker identifying the job, see
+// LoggerFromContext(). Fields that do not apply, e.g. job for unnamed jobs,
+// are left out.
+//
+// LoadThreshold, if set, throttles the pool on loaded hosts, so that batch
+// pools do not starve latency-critical neighbours: while the load sampled
+// every LoadInterval is above LoadThreshold, the workers do not pick up new
+// jobs, as if paused, see Stats.Throttled. They resume once the load is
+// back at or below it, or the load cannot be sampled. LoadSampler samples
+// the load. If unspecified, SystemLoad() is used, whose load is per CPU,
+// hence a LoadThreshold of 1 throttles the pool while there are more
+// runnable tasks than CPUs. If LoadInterval is unspecified or zero,
+// 5 seconds is used.
+//
+// ScratchDir is the directory the scratch directories of the jobs are
+// created in, see JobOptions.Scratch. If unspecified, os.TempDir() is used.
+// A ScratchDir must be dedicated to the pool: New() removes the scratch
+// directories left in it, e.g. by a process that crashed. ScratchQuota, if
+// set, is the size in bytes the scratch directories of the running jobs
+// may use up: jobs needing a scratch directory while it is used up fail
+// without running, with an error wrapping ErrScratchQuota.
+//
+// Overflow is what the pool does with a job submitted while its queue is
+// full, see OverflowPolicy. If unspecified, QueuePolicy is used. Overflow
+// is not honoured in deterministic mode, nor by TrySubmit(), which rejects
+// the job instead.
+//
+// PanicHandler, if set, is called with the value and the stack trace of
+// every panic of a job, instead of delivering it on ErrChan. A panicking
+// job fails, and its worker moves on to the next job. If unspecified, the
+// panic is delivered on ErrChan as a *PanicError.
+//
+// PriorityAging is how long a Low job may wait for a worker before it is
+// picked up ahead of the Normal jobs, see Low. If unspecified or zero,
+// 30 seconds is used.
+//
+// BoostAwaited boosts a Low or Scavenger job submitted with SubmitFuture()
+// to the High class once a caller waits for its Future, see Future.Wait(), such
+// that interactively awaited work goes ahead of the background backlog.
+//
+// JobTimeout is the timeout of the jobs submitted without a timeout of their
+// own, see JobOptions.Timeout. If unspecified or zero, jobs do not time out.
+//
+// StopProgressInterval is the interval at which the progress of Stop() is
+// delivered, see StopProgress(). If unspecified or zero, 1 second is used.
 type Options struct {
-	Workers uint32
-	QSize   uint32
+	Name                 string
+	Workers              uint32
+	QSize                uint32
+	DirectHandoff        bool
+	WorkerRate           float64
+	Clock                Clock
+	Deterministic        bool
+	Seed                 int64
+	StrictOutputs        bool
+	DropAction           DropAction
+	OnDrop               func(err error)
+	Idempotency          IdempotencyStore
+	MaxQueueWait         time.Duration
+	HighWatermark        uint32
+	LowWatermark         uint32
+	MeasureUsage         bool
+	Archive              Archive
+	BurstWorkers         uint32
+	BurstDuration        time.Duration
+	ContextDecorator     func(ctx context.Context) context.Context
+	Logger               Logger
+	LoadThreshold        float64
+	LoadSampler          func() (float64, error)
+	LoadInterval         time.Duration
+	StopProgressInterval time.Duration
+	JobTimeout           time.Duration
+	PriorityAging        time.Duration
+	BoostAwaited         bool
+	PanicHandler         func(value interface{}, stack []byte)
+	Overflow             OverflowPolicy
+	ScratchDir           string
+	ScratchQuota         int64
 }
 
 // New creates a new worker pool.
@@ -65,23 +357,77 @@ type Options struct {
 // Accepts optional Options{} argument.
 func New(args ...Options) *GoWorkers {
 	gw := &GoWorkers{
-		workerQ: make(chan func()),
-		// Do not remove jobQ. To stop receiving input once Stop() is called
-		jobQ:       make(chan func()),
 		ErrChan:    make(chan error, outputChanSize),
 		ResultChan: make(chan interface{}, outputChanSize),
-		done:       make(chan struct{}),
+		done:       make(chan struct{}, 1),
+		stopped:    make(chan struct{}),
+		workers:    make(map[uint64]*worker),
+		clock:      RealClock{},
+		aging:      defaultPriorityAging,
+		resizes:    queueResizes{c: make(chan QueueResize, 1)},
+
+		stopProgress:         make(chan ShutdownProgress, 1),
+		stopProgressInterval: defaultStopProgressInterval,
 	}
 
-	gw.bufferedQ = make(chan func(), defaultQSize)
+	var qsize uint32
+	var opts Options
 	if len(args) == 1 {
+		opts = args[0]
+		gw.name = args[0].Name
 		gw.maxWorkers = args[0].Workers
-		if args[0].QSize > defaultQSize {
-			gw.bufferedQ = make(chan func(), args[0].QSize)
+		gw.workerInterval = rateInterval(args[0].WorkerRate)
+		qsize = args[0].QSize
+		if args[0].Clock != nil {
+			gw.clock = args[0].Clock
+		}
+		if args[0].Deterministic {
+			gw.det = newDeterministic(args[0].Seed)
+		}
+		gw.strict = newStrictOutputs(args[0])
+		gw.idempotency = newIdempotency(args[0].Idempotency)
+		gw.maxQueueWait = int64(args[0].MaxQueueWait)
+		gw.measureUsage = args[0].MeasureUsage
+		gw.archive = args[0].Archive
+		gw.decorate = args[0].ContextDecorator
+		gw.logger = args[0].Logger
+		gw.overflow = args[0].Overflow
+		gw.jobTimeout = int64(args[0].JobTimeout)
+		if args[0].PriorityAging > 0 {
+			gw.aging = args[0].PriorityAging
+		}
+		gw.boostAwaited = args[0].BoostAwaited
+		gw.panicHandler = args[0].PanicHandler
+		if args[0].StopProgressInterval > 0 {
+			gw.stopProgressInterval = args[0].StopProgressInterval
 		}
 	}
+	gw.burst = newBurst(opts)
+	gw.scratch = newScratch(opts)
+	gw.backpressure = newBackpressure(opts)
+
+	l := newLane(qsize, opts.DirectHandoff)
+	gw.lane.Store(l)
+
+	// start a worker in advance
+	gw.mx.Lock()
+	gw.launchWorker(l)
+	gw.mx.Unlock()
+
+	atomic.AddInt32(&gw.numDispatchers, 1)
+	go gw.start(l)
 
-	go gw.start()
+	gw.loadThreshold = opts.LoadThreshold
+	if opts.LoadThreshold > 0 {
+		sample, interval := opts.LoadSampler, opts.LoadInterval
+		if sample == nil {
+			sample = SystemLoad
+		}
+		if interval <= 0 {
+			interval = defaultLoadInterval
+		}
+		gw.throttleOnLoad(opts.LoadThreshold, sample, interval)
+	}
 
 	return gw
 }
@@ -96,33 +442,457 @@ func (gw *GoWorkers) WorkerNum() uint32 {
 	return atomic.LoadUint32(&gw.numWorkers)
 }
 
-// Submit is a non-blocking call with arg of type `func()`
-func (gw *GoWorkers) Submit(job func()) {
-	if atomic.LoadInt32(&gw.stopping) == 1 {
-		return
+// MaxWorkers returns maximum number of workers, zero if workers are spawned as per demand
+func (gw *GoWorkers) MaxWorkers() uint32 {
+	return atomic.LoadUint32(&gw.maxWorkers)
+}
+
+// Ready reports whether the pool is ready to accept jobs, i.e. it is not
+// stopping and its queue is not full.
+func (gw *GoWorkers) Ready() bool {
+	return (atomic.LoadInt32(&gw.stopping) == stateRunning) && !gw.Saturated()
+}
+
+// QueueCap returns the size of the queue holding the jobs that wait for a
+// worker.
+func (gw *GoWorkers) QueueCap() uint32 {
+	return gw.qsize()
+}
+
+// QueueFree returns how many more jobs the queue can hold before it is
+// full, such that producers can decide on admission before submitting.
+// Running jobs do not take up the queue.
+func (gw *GoWorkers) QueueFree() uint32 {
+	queued, size := gw.queued(), gw.qsize()
+	if queued >= size {
+		// jobs submitted by jobs spill over the queue
+		return 0
 	}
-	atomic.AddUint32(&gw.numJobs, uint32(1))
-	gw.jobQ <- func() { job() }
+	return size - queued
 }
 
-// SubmitCheckError is a non-blocking call with arg of type `func() error`
+// Saturated reports whether the queue is full, i.e. whether TrySubmit()
+// would reject a job. Without a queue, see Options.DirectHandoff, it
+// reports whether every worker holds a job.
+func (gw *GoWorkers) Saturated() bool {
+	if gw.current().direct {
+		max := gw.MaxWorkers()
+		return (max != 0) && (gw.JobNum() >= max)
+	}
+	return gw.QueueFree() == 0
+}
+
+// queued returns number of jobs that are waiting for a worker
+func (gw *GoWorkers) queued() uint32 {
+	jobs, running := gw.JobNum(), atomic.LoadUint32(&gw.numRunning)
+	if running > jobs {
+		return 0
+	}
+	return jobs - running
+}
+
+// Stats is a point-in-time snapshot of the state of a pool.
+type Stats struct {
+	// Workers is the number of active workers.
+	Workers uint32 `json:"workers"`
+	// MaxWorkers is the maximum number of workers, zero if workers are spawned as per demand.
+	MaxWorkers uint32 `json:"max_workers"`
+	// Jobs is the number of active jobs, running or queued.
+	Jobs uint32 `json:"jobs"`
+	// Running is the number of jobs being run by a worker.
+	Running uint32 `json:"running"`
+	// Queued is the number of jobs waiting for a worker.
+	Queued uint32 `json:"queued"`
+	// Completed is the number of jobs finished since the pool was created.
+	// It wraps around on overflow.
+	Completed uint32 `json:"completed"`
+	// Failed is the number of finished jobs that returned an error.
+	// It wraps around on overflow.
+	Failed uint32 `json:"failed"`
+	// Abandoned is the number of failed jobs that were abandoned past
+	// their hard deadline, see JobOptions.HardDeadline. It wraps around on
+	// overflow.
+	Abandoned uint32 `json:"abandoned"`
+	// TimedOut is the number of failed jobs that timed out, see
+	// JobOptions.Timeout. It wraps around on overflow.
+	TimedOut uint32 `json:"timed_out"`
+	// Cancelled is the number of jobs that were dropped without running,
+	// as their context was done by the time a worker picked them up, e.g.
+	// the jobs of SubmitCtx() or of a Group whose budget was spent. They are neither
+	// completed nor failed. It wraps around on overflow.
+	Cancelled uint32 `json:"cancelled"`
+	// Discarded is the number of queued jobs that were discarded without
+	// running as the pool was stopped, see StopWith(). They are neither
+	// completed nor failed. It wraps around on overflow.
+	Discarded uint32 `json:"discarded"`
+	// Restarts is the number of times a worker recovered from a panicking
+	// job and moved on, where it would have been restarted if the panic
+	// took it down. It wraps around on overflow.
+	Restarts uint32 `json:"restarts"`
+	// Dropped is the number of outputs of jobs, errors and results, that
+	// were dropped as their channel was full. It wraps around on overflow.
+	Dropped uint32 `json:"dropped"`
+	// QueueWait is the moving average of the time the jobs waited in the
+	// queue. It is measured with Options.MaxQueueWait only.
+	QueueWait time.Duration `json:"queue_wait"`
+	// Runtime is the total time the finished jobs ran for.
+	Runtime time.Duration `json:"runtime"`
+	// Usage is the total usage of the finished jobs. It is measured with
+	// Options.MeasureUsage only.
+	Usage Usage `json:"usage"`
+	// Named holds the counters of the named jobs by name.
+	Named map[string]NamedStats `json:"named,omitempty"`
+	// Paused reports whether the pool is paused.
+	Paused bool `json:"paused"`
+	// Throttled reports whether the pool is paused as the host is loaded,
+	// see Options.LoadThreshold.
+	Throttled bool `json:"throttled"`
+	// Busy lists the workers that are running a job, oldest job first.
+	Busy []BusyWorker `json:"busy"`
+}
+
+// BusyWorker describes a worker that is running a job.
+type BusyWorker struct {
+	ID    uint64    `json:"id"`
+	Since time.Time `json:"since"`
+	// Job is the name of the job, if named.
+	Job string `json:"job,omitempty"`
+}
+
+// Stats returns a snapshot of the state of the pool.
+func (gw *GoWorkers) Stats() Stats {
+	st := Stats{
+		Workers:    gw.WorkerNum(),
+		MaxWorkers: gw.MaxWorkers(),
+		Jobs:       gw.JobNum(),
+		Running:    atomic.LoadUint32(&gw.numRunning),
+		Queued:     gw.queued(),
+		Completed:  atomic.LoadUint32(&gw.numDone),
+		Failed:     atomic.LoadUint32(&gw.numFailed),
+		Abandoned:  atomic.LoadUint32(&gw.numAbandoned),
+		TimedOut:   atomic.LoadUint32(&gw.numTimedOut),
+		Cancelled:  atomic.LoadUint32(&gw.numCancelled),
+		Discarded:  atomic.LoadUint32(&gw.numDiscarded),
+		Restarts:   atomic.LoadUint32(&gw.numRestarts),
+		Dropped:    atomic.LoadUint32(&gw.numDropped),
+		Runtime:    time.Duration(atomic.LoadInt64(&gw.runtime)),
+		Usage:      gw.usage(),
+		QueueWait:  time.Duration(atomic.LoadInt64(&gw.queueWait)),
+		Named:      gw.namedStats(),
+	}
+
+	gw.mx.Lock()
+	st.Paused = gw.pauses&pausedByUser != 0
+	st.Throttled = gw.pauses&pausedByLoad != 0
+	for id, w := range gw.workers {
+		if since := atomic.LoadInt64(&w.busySince); since != 0 {
+			t, _ := w.job.Load().(*task)
+			b := BusyWorker{ID: id, Since: time.Unix(0, since)}
+			if t != nil {
+				b.Job = t.opts.Name
+			}
+			st.Busy = append(st.Busy, b)
+		}
+	}
+	gw.mx.Unlock()
+	sort.Slice(st.Busy, func(i, j int) bool { return st.Busy[i].Since.Before(st.Busy[j].Since) })
+
+	return st
+}
+
+// String returns a one-line summary of the state of the pool, e.g. for
+// periodic status logs or panic handlers.
+func (gw *GoWorkers) String() string {
+	var b strings.Builder
+	b.WriteString("goworkers")
+	if gw.name != "" {
+		fmt.Fprintf(&b, " %q", gw.name)
+	}
+	fmt.Fprintf(&b, ": workers=%d", gw.WorkerNum())
+	if max := gw.MaxWorkers(); max != 0 {
+		fmt.Fprintf(&b, "/%d", max)
+	}
+	fmt.Fprintf(&b, " queued=%d running=%d completed=%d failed=%d",
+		gw.queued(), atomic.LoadUint32(&gw.numRunning), atomic.LoadUint32(&gw.numDone), atomic.LoadUint32(&gw.numFailed))
+	return b.String()
+}
+
+// Pause stops the workers from picking up new jobs. Jobs that are running
+// finish as usual and submitted jobs are queued until Resume() is called.
 //
-// Use this if your job returns 'error'.
-// Use ErrChan buffered channel to read error, if any.
-func (gw *GoWorkers) SubmitCheckError(job func() error) {
-	if atomic.LoadInt32(&gw.stopping) == 1 {
+// Stop() and Wait() resume a paused pool, throttled or not.
+func (gw *GoWorkers) Pause() {
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	gw.pause(pausedByUser)
+}
+
+// Resume lets the workers of a paused pool pick up jobs again, unless the
+// pool is throttled, see Options.LoadThreshold.
+func (gw *GoWorkers) Resume() {
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	gw.unpause(pausedByUser)
+}
+
+// Reasons to pause a pool
+const (
+	pausedByUser = 1 << iota
+	pausedByLoad
+)
+
+// pause must be called with mx held
+func (gw *GoWorkers) pause(reason int) {
+	if gw.pauses == 0 {
+		gw.resume = make(chan struct{})
+		atomic.StoreInt32(&gw.paused, 1)
+	}
+	gw.pauses |= reason
+}
+
+// unpause must be called with mx held. The workers resume once no reason is
+// left.
+func (gw *GoWorkers) unpause(reason int) {
+	if (gw.pauses == 0) || (gw.pauses&^reason != 0) {
+		gw.pauses &^= reason
 		return
 	}
-	atomic.AddUint32(&gw.numJobs, uint32(1))
-	gw.jobQ <- func() {
-		err := job()
+	gw.pauses = 0
+	atomic.StoreInt32(&gw.paused, 0)
+	close(gw.resume)
+	gw.resume = nil
+}
+
+// resumeAll lifts every reason to pause the pool
+func (gw *GoWorkers) resumeAll() {
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	gw.unpause(pausedByUser | pausedByLoad)
+}
+
+// rateInterval returns the spacing in nanoseconds between two jobs run by a
+// worker limited to rate jobs per second, zero if not rate limited
+func rateInterval(rate float64) int64 {
+	if rate <= 0 {
+		return 0
+	}
+	return int64(float64(time.Second) / rate)
+}
+
+// addJob accounts for a submitted job
+func (gw *GoWorkers) addJob() {
+	if atomic.AddUint32(&gw.numJobs, uint32(1)) == 1 {
+		// an idle pool has not stalled, the clock starts now
+		atomic.StoreInt64(&gw.lastProgress, gw.clock.Now().UnixNano())
+	}
+}
+
+// submit hands over t to the workers, unless the pool is stopping, once
+// the queue has room for it if the pool blocks submissions, see BlockPolicy
+func (gw *GoWorkers) submit(t *task) error {
+	if gw.det == nil {
+		gw.blockWhileFull(t)
+	}
+	return gw.submitNow(t)
+}
+
+// submitNow hands over t to the workers, unless the pool is stopping
+func (gw *GoWorkers) submitNow(t *task) error {
+	// a job run by the caller runs once submitMu is released, see
+	// CallerRunsPolicy
+	callerRuns := false
+	// a job handed off to a worker of a direct lane is handed off once
+	// submitMu is released, as the hand-off blocks until a worker takes it
+	// and a worker may itself wait for submitMu, e.g. to submit a job while
+	// Stop() waits for submitMu
+	var direct *lane
+	defer func() {
+		if callerRuns {
+			gw.runOnCaller(t)
+		}
+		if direct != nil {
+			direct.handOff(t)
+		}
+	}()
+	defer gw.submitMu.RUnlock()
+	gw.submitMu.RLock()
+	if gw.det != nil {
+		if (atomic.LoadInt32(&gw.stopping) != stateRunning) && !gw.det.accepting() {
+			return ErrStopped
+		}
+		held, err := gw.quotas.admit(t)
 		if err != nil {
-			select {
-			case gw.ErrChan <- err:
-			default:
-			}
+			return err
+		}
+		gw.addJob()
+		if !held {
+			gw.det.push(t)
+		}
+		gw.observeBackpressure()
+		return nil
+	}
+	// the jobs of a pool being stopped or waited for are still running, and
+	// so may submit follow-up jobs, which are waited for as well
+	if (atomic.LoadInt32(&gw.stopping) != stateRunning) && !gw.calledFromJob() {
+		return ErrStopped
+	}
+	if gw.overloaded() {
+		return ErrOverloaded
+	}
+	held, err := gw.quotas.admit(t)
+	if err != nil {
+		return err
+	}
+	if atomic.LoadInt64(&gw.maxQueueWait) != 0 {
+		t.submitted = gw.clock.Now()
+	}
+	callerRuns = !held && gw.overflows(t)
+	gw.addJob()
+	if callerRuns {
+		return nil
+	}
+	if !held {
+		direct = gw.push(t, true)
+	}
+	gw.observeBackpressure()
+	return nil
+}
+
+// handOver hands over a job without the checks of submit(). It must be
+// called with submitMu held for reading, or while no submission can race
+// with it.
+func (gw *GoWorkers) handOver(t *task) {
+	gw.addJob()
+	if gw.det != nil {
+		gw.det.push(t)
+	} else {
+		gw.push(t, false)
+	}
+	gw.observeBackpressure()
+}
+
+// push hands over t to the lane taking the submissions. With handOff, a
+// Normal job for a direct lane is left to the caller to hand off once it
+// releases submitMu, and push returns the lane to hand it off to.
+func (gw *GoWorkers) push(t *task, handOff bool) *lane {
+	l := gw.current()
+	if l.holds(t.opts.Priority) {
+		l.hold(t, gw.clock.Now())
+		// held jobs bypass the dispatcher, which starts the workers, and
+		// Scavenger jobs only fill the idle capacity
+		if (t.opts.Priority != Scavenger) || (gw.WorkerNum() == 0) {
+			gw.goHelper(func() { gw.spawnWorker(l) })
 		}
+		return nil
+	}
+	// the jobs submitted by jobs spill over to the dispatcher instead, as
+	// the worker they would wait for may be their own
+	if l.direct && handOff && !gw.calledFromJob() {
+		// without a dispatcher in between, the submission starts the worker
+		// it waits for
+		gw.spawnWorker(l)
+		l.reserve()
+		return l
 	}
+	l.push(t)
+	return nil
+}
+
+// enqueue hands over a job that was held back at submission
+func (gw *GoWorkers) enqueue(t *task) {
+	if gw.det != nil {
+		gw.det.push(t)
+		return
+	}
+	defer gw.submitMu.RUnlock()
+	gw.submitMu.RLock()
+	gw.push(t, false)
+}
+
+func plainTask(job func(), args []JobOptions) *task {
+	return &task{
+		run: func(context.Context) (interface{}, error) {
+			job()
+			return nil, nil
+		},
+		outputs: noOutputs,
+		opts:    jobOptions(args),
+	}
+}
+
+// Submit is a non-blocking call with arg of type `func()`
+//
+// A job may submit jobs to its own pool, e.g. for recursive fan-out. Such
+// submissions never block, even when all the workers are busy and the queue
+// is full, as the jobs in excess spill over the queue. They are accepted
+// while the pool is being stopped or waited for, and are waited for as well.
+// The other submissions to a full queue block with BlockPolicy, see
+// Options.Overflow.
+//
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (gw *GoWorkers) Submit(job func(), args ...JobOptions) error {
+	return gw.submit(plainTask(job, args))
+}
+
+// SubmitNamed is a non-blocking call with arg of type `func()`
+//
+// It is a shorthand for Submit() with JobOptions{Name: name}.
+func (gw *GoWorkers) SubmitNamed(name string, job func()) error {
+	return gw.Submit(job, JobOptions{Name: name})
+}
+
+// SubmitCtx is a non-blocking call with arg of type `func(ctx context.Context)`
+//
+// The job runs with a context deriving from ctx, such that it can abort
+// early once ctx is done, e.g. as the client of an HTTP handler went away.
+// A job whose ctx is done by the time a worker picks it up is dropped
+// without running, see Stats.Cancelled.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (gw *GoWorkers) SubmitCtx(ctx context.Context, job func(ctx context.Context), args ...JobOptions) error {
+	return gw.submit(&task{
+		run: func(ctx context.Context) (interface{}, error) {
+			job(ctx)
+			return nil, nil
+		},
+		outputs: noOutputs,
+		opts:    jobOptions(args),
+		ctx:     ctx,
+	})
+}
+
+// TrySubmit is a non-blocking call with arg of type `func()`
+//
+// Unlike Submit(), the job is rejected if the queue is full, i.e. if as many
+// jobs as the size of the queue are already waiting for a worker.
+// Accepts optional JobOptions{} argument.
+// Returns true if the job was accepted.
+func (gw *GoWorkers) TrySubmit(job func(), args ...JobOptions) bool {
+	return gw.trySubmit(plainTask(job, args))
+}
+
+func (gw *GoWorkers) trySubmit(t *task) bool {
+	if gw.Saturated() {
+		return false
+	}
+	return gw.submitNow(t) == nil
+}
+
+// SubmitCheckError is a non-blocking call with arg of type `func() error`
+//
+// Use this if your job returns 'error'.
+// Use ErrChan buffered channel to read error, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (gw *GoWorkers) SubmitCheckError(job func() error, args ...JobOptions) error {
+	return gw.submit(&task{
+		run: func(context.Context) (interface{}, error) {
+			return nil, job()
+		},
+		outputs: errOutput,
+		opts:    jobOptions(args),
+	})
 }
 
 // SubmitCheckResult is a non-blocking call with arg of type `func() (interface{}, error)`
@@ -131,25 +901,16 @@ func (gw *GoWorkers) SubmitCheckError(job func() error) {
 // Use ErrChan buffered channel to read error, if any.
 // Use ResultChan buffered channel to read output, if any.
 // For a job, either of error or output would be sent if available.
-func (gw *GoWorkers) SubmitCheckResult(job func() (interface{}, error)) {
-	if atomic.LoadInt32(&gw.stopping) == 1 {
-		return
-	}
-	atomic.AddUint32(&gw.numJobs, uint32(1))
-	gw.jobQ <- func() {
-		result, err := job()
-		if err != nil {
-			select {
-			case gw.ErrChan <- err:
-			default:
-			}
-		} else {
-			select {
-			case gw.ResultChan <- result:
-			default:
-			}
-		}
-	}
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (gw *GoWorkers) SubmitCheckResult(job func() (interface{}, error), args ...JobOptions) error {
+	return gw.submit(&task{
+		run: func(context.Context) (interface{}, error) {
+			return job()
+		},
+		outputs: resultOutput,
+		opts:    jobOptions(args),
+	})
 }
 
 // Wait waits for the jobs to finish running.
@@ -158,16 +919,28 @@ func (gw *GoWorkers) SubmitCheckResult(job func() (interface{}, error)) {
 // If 'wait' argument is set true, Wait() waits until the result and the error channels are emptied.
 // Setting 'wait' argument to true ensures that you can read all the values from the result and
 // the error channels before this function unblocks.
-// Jobs cannot be submitted until this function returns. If any, will be discarded.
-func (gw *GoWorkers) Wait(wait bool) {
-	if !atomic.CompareAndSwapInt32(&gw.stopping, 0, 1) {
-		return
+// Jobs cannot be submitted until this function returns, except by the running jobs.
+// If any, will be discarded.
+// Returns ErrCalledFromJob, instead of deadlocking, if called from a job of the pool.
+func (gw *GoWorkers) Wait(wait bool) error {
+	if gw.calledFromJob() {
+		return ErrCalledFromJob
+	}
+	if !atomic.CompareAndSwapInt32(&gw.stopping, stateRunning, stateWaiting) {
+		return nil
+	}
+	gw.awaitSubmissions()
+	gw.resumeAll()
+	gw.flushBatchers()
+	if gw.det != nil {
+		gw.runPending()
 	}
 
 	for {
 		if gw.JobNum() == 0 {
 			break
 		}
+		yield()
 	}
 
 	if wait {
@@ -175,104 +948,378 @@ func (gw *GoWorkers) Wait(wait bool) {
 			if len(gw.ResultChan)|len(gw.ErrChan) == 0 {
 				break
 			}
+			yield()
 		}
 	}
 
-	atomic.StoreInt32(&gw.stopping, 0)
+	atomic.StoreInt32(&gw.stopping, stateRunning)
+	return nil
 }
 
 // Stop gracefully waits for the jobs to finish running and releases the associated resources.
 //
 // This is a blocking call and returns when all the active and queued jobs are finished.
+// The hooks registered with OnStop() are called once the jobs are finished.
 // If wait is true, Stop() waits until the result and the error channels are emptied.
 // Setting wait to true ensures that you can read all the values from the result and the
 // error channels before your parent program exits.
-func (gw *GoWorkers) Stop(wait bool) {
-	if !atomic.CompareAndSwapInt32(&gw.stopping, 0, 1) {
-		return
+// Returns ErrCalledFromJob, instead of deadlocking, if called from a job of the pool.
+// See StopWith() to discard the low-priority queued jobs instead of running them.
+func (gw *GoWorkers) Stop(wait bool) error {
+	return gw.stop(wait, nil)
+}
+
+// stop stops the pool, discarding the queued jobs as per opts, if not nil
+func (gw *GoWorkers) stop(wait bool, opts *StopOptions) error {
+	if gw.calledFromJob() {
+		return ErrCalledFromJob
+	}
+	if !atomic.CompareAndSwapInt32(&gw.stopping, stateRunning, stateStopping) {
+		return nil
+	}
+	if (opts != nil) && opts.Discard {
+		gw.discarding.Store(opts)
+	}
+	gw.reportShutdown(gw.clock.Now())
+	gw.awaitSubmissions()
+	gw.resumeAll()
+	gw.flushBatchers()
+	if gw.det != nil {
+		gw.runPending()
 	}
 	if gw.JobNum() != 0 {
 		<-gw.done
 	}
+	gw.runStopHooks()
 
 	if wait {
 		for {
 			if len(gw.ResultChan)|len(gw.ErrChan) == 0 {
 				break
 			}
+			yield()
 		}
 	}
 
 	// close the input channel
-	close(gw.jobQ)
+	gw.current().close()
+	gw.backpressure.close()
+	gw.resizes.close()
+	close(gw.stopped)
+	return nil
+}
+
+// awaitSubmissions waits for the submissions that did not see the pool
+// stopping to finish handing over their jobs. Submissions that follow are
+// rejected.
+func (gw *GoWorkers) awaitSubmissions() {
+	gw.submitMu.Lock()
+	gw.submitMu.Unlock()
+}
+
+// Drain stops accepting jobs and waits up to timeout for the active and
+// queued jobs to finish running. Returns the number of jobs that did not
+// finish in time.
+//
+// The pool is stopped, as with Stop(false), once the remaining jobs finish.
+func (gw *GoWorkers) Drain(timeout time.Duration) uint32 {
+	go gw.Stop(false)
+
+	timer := gw.clock.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-gw.stopped:
+		return 0
+	case <-timer.C():
+		return gw.JobNum()
+	}
+}
+
+// VerifyShutdown confirms that all the goroutines created by a stopped pool
+// have exited, waiting up to timeout for them to do so. Returns an error
+// wrapping ErrLeaked naming the components that did not exit in time, or
+// ErrNotStopped if the pool has not been stopped.
+//
+// Use this along with goleak or alike in tests, right after Stop().
+func (gw *GoWorkers) VerifyShutdown(timeout time.Duration) error {
+	select {
+	case <-gw.stopped:
+	default:
+		return ErrNotStopped
+	}
+
+	// real time, as it is real goroutines being waited for
+	deadline := time.Now().Add(timeout)
+	for {
+		var leaked []string
+		if n := gw.WorkerNum(); n != 0 {
+			leaked = append(leaked, fmt.Sprintf("%d workers", n))
+		}
+		if n := atomic.LoadInt32(&gw.numDispatchers); n != 0 {
+			leaked = append(leaked, fmt.Sprintf("%d dispatchers", n))
+		}
+		if n := atomic.LoadInt32(&gw.numHelpers); n != 0 {
+			leaked = append(leaked, fmt.Sprintf("%d helpers", n))
+		}
+		if len(leaked) == 0 {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w: %s", ErrLeaked, strings.Join(leaked, ", "))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// PreSpawn eagerly starts workers such that at least n workers are active.
+//
+// The number of workers is bounded by Options.Workers, if specified.
+// Use this to warm up the pool, e.g. during service startup, before the
+// first burst of jobs arrives.
+func (gw *GoWorkers) PreSpawn(n uint32) {
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	if max := gw.MaxWorkers(); (max != 0) && (n > max) {
+		n = max
+	}
+	for gw.WorkerNum() < n {
+		gw.launchWorker(gw.current())
+	}
+}
+
+// WorkerIDs returns the ids of the active workers in ascending order.
+//
+// Workers that are retiring are not included.
+func (gw *GoWorkers) WorkerIDs() []uint64 {
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	ids := make([]uint64, 0, len(gw.workers))
+	for id := range gw.workers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// RetireWorker gracefully removes the worker with the given id.
+//
+// The worker finishes its current job, if any, and exits without affecting
+// the rest of the pool. A replacement is spawned only if the pending jobs
+// demand one. Returns ErrWorkerNotFound if no active worker has the id.
+func (gw *GoWorkers) RetireWorker(id uint64) error {
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	w, ok := gw.workers[id]
+	if !ok {
+		return ErrWorkerNotFound
+	}
+	gw.retire(w)
+	return nil
+}
+
+// Shrink gracefully removes up to n workers and returns the number of
+// workers that were retired.
+//
+// See RetireWorker() for how a retiring worker exits.
+func (gw *GoWorkers) Shrink(n uint32) uint32 {
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	var retired uint32
+	for _, w := range gw.workers {
+		if retired == n {
+			break
+		}
+		gw.retire(w)
+		retired++
+	}
+	return retired
 }
 
-var mx sync.Mutex
+// retire must be called with mx held
+func (gw *GoWorkers) retire(w *worker) {
+	delete(gw.workers, w.id)
+	close(w.quit)
+}
 
-func (gw *GoWorkers) spawnWorker() {
-	defer mx.Unlock()
-	mx.Lock()
-	if ((gw.maxWorkers == 0) || (gw.WorkerNum() < gw.maxWorkers)) && (gw.JobNum() > gw.WorkerNum()) {
-		go gw.startWorker()
+// spawnWorker starts a worker of l, if the pending jobs demand one
+func (gw *GoWorkers) spawnWorker(l *lane) {
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	if gw.JobNum() <= gw.WorkerNum() {
+		return
+	}
+	if max := gw.MaxWorkers(); (max == 0) || (gw.WorkerNum() < max) || gw.canBurst(max) {
+		gw.launchWorker(l)
 	}
 }
 
-func (gw *GoWorkers) start() {
+// launchWorker must be called with mx held. It accounts for the new worker
+// before starting it so that the callers see an accurate worker count.
+func (gw *GoWorkers) launchWorker(l *lane) {
+	gw.workerID++
+	w := &worker{id: gw.workerID, lane: l, quit: make(chan struct{}), exited: make(chan struct{})}
+	gw.workers[w.id] = w
+	atomic.AddUint32(&gw.numWorkers, 1)
+	go gw.startWorker(w)
+}
+
+func (gw *GoWorkers) start(l *lane) {
+	defer atomic.AddInt32(&gw.numDispatchers, -1)
 	defer func() {
-		close(gw.bufferedQ)
-		close(gw.workerQ)
-		close(gw.ErrChan)
-		close(gw.ResultChan)
+		close(l.bufferedQ)
+		close(l.workerQ)
+		// the lanes replaced by ReplaceWith() leave the outputs to the
+		// lane the pool is stopped with
+		if atomic.LoadInt32(&l.retired) == 0 {
+			close(gw.ErrChan)
+			close(gw.ResultChan)
+			gw.closeSubscriptions()
+		}
 	}()
 
-	// start a worker in advance
-	go gw.startWorker()
-
+	atomic.AddInt32(&gw.numDispatchers, 1)
 	go func() {
+		defer atomic.AddInt32(&gw.numDispatchers, -1)
 		for {
 			select {
 			// keep processing the queued jobs
-			case job, ok := <-gw.bufferedQ:
+			case job, ok := <-l.bufferedQ:
 				if !ok {
 					return
 				}
-				go func() {
-					gw.spawnWorker()
-					gw.workerQ <- job
-				}()
+				gw.goHelper(func() {
+					gw.spawnWorker(l)
+					l.workerQ <- job
+				})
 			}
 		}
 	}()
 
 	for {
 		select {
-		case job, ok := <-gw.jobQ:
+		case job, ok := <-l.jobQ:
 			if !ok {
 				return
 			}
 			select {
 			// if possible, process the job without queueing
-			case gw.workerQ <- job:
-				go gw.spawnWorker()
+			case l.workerQ <- job:
+				gw.goHelper(func() { gw.spawnWorker(l) })
 			// queue it if no workers are available
 			default:
-				gw.bufferedQ <- job
+				l.bufferedQ <- job
 			}
 		}
 	}
 }
 
-func (gw *GoWorkers) startWorker() {
+// finishJob accounts for a job that finished running
+func (gw *GoWorkers) finishJob() {
+	defer gw.signalRoom()
+	if (atomic.AddUint32(&gw.numJobs, ^uint32(0)) == 0) && (atomic.LoadInt32(&gw.stopping) == stateStopping) {
+		// buffered, as Stop() may have found no jobs left and not wait
+		select {
+		case gw.done <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// goHelper runs fn on a goroutine accounted for by VerifyShutdown()
+func (gw *GoWorkers) goHelper(fn func()) {
+	atomic.AddInt32(&gw.numHelpers, 1)
+	go func() {
+		defer atomic.AddInt32(&gw.numHelpers, -1)
+		fn()
+	}()
+}
+
+type worker struct {
+	// start time of the running job in unix nanoseconds, zero if idle.
+	// Kept first for the 64-bit alignment required by sync/atomic.
+	busySince int64
+	id        uint64
+	lane      *lane
+	quit      chan struct{}
+	// closed once the worker has exited
+	exited chan struct{}
+	// the running job, a nil *task if idle
+	job atomic.Value
+}
+
+func (gw *GoWorkers) startWorker(w *worker) {
+	id := goroutineID()
+	gw.workerGoroutines.Store(id, struct{}{})
+	defer gw.workerGoroutines.Delete(id)
+
+	retired := false
 	defer func() {
+		defer close(w.exited)
 		atomic.AddUint32(&gw.numWorkers, ^uint32(0))
+		if !retired {
+			gw.mx.Lock()
+			delete(gw.workers, w.id)
+			gw.mx.Unlock()
+			return
+		}
+		// jobs waiting for a worker may have relied on this one
+		gw.spawnWorker(w.lane)
 	}()
 
-	atomic.AddUint32(&gw.numWorkers, 1)
+	for {
+		// a retired worker must not pick up another job
+		select {
+		case <-w.quit:
+			retired = true
+			return
+		default:
+		}
+
+		if atomic.LoadInt32(&gw.paused) == 1 {
+			gw.mx.Lock()
+			resume := gw.resume
+			gw.mx.Unlock()
+			if resume != nil {
+				select {
+				case <-w.quit:
+					retired = true
+					return
+				case <-resume:
+				}
+			}
+		}
+
+		// the held jobs are picked up in the order of their priority
+		t := gw.pop(w.lane)
+		if t == nil {
+			select {
+			case <-w.quit:
+				retired = true
+				return
+			case j, ok := <-w.lane.workerQ:
+				if !ok {
+					return
+				}
+				atomic.AddInt32(&w.lane.pending, -1)
+				t = j
+			case <-w.lane.wake:
+				continue
+			}
+		}
 
-	for job := range gw.workerQ {
-		job()
-		if (atomic.AddUint32(&gw.numJobs, ^uint32(0)) == 0) && (atomic.LoadInt32(&gw.stopping) == 1) {
-			gw.done <- struct{}{}
+		started := gw.clock.Now()
+		t.worker = w.id
+		w.job.Store(t)
+		atomic.StoreInt64(&w.busySince, started.UnixNano())
+		gw.runTask(t)
+		atomic.StoreInt64(&w.busySince, 0)
+		w.job.Store((*task)(nil))
+		w.lane.finish()
+		gw.finishJob()
+		// honour the per-worker rate limit before picking up the next job
+		if interval := time.Duration(atomic.LoadInt64(&gw.workerInterval)); interval > 0 {
+			gw.clock.Sleep(interval - gw.clock.Now().Sub(started))
 		}
 	}
 }
@@ -5,9 +5,11 @@ Copyright 2020 Deepak S<deepaks@outlook.in>
 package goworkers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -319,25 +321,386 @@ func TestWorkerArg(t *testing.T) {
 		opts := Options{Workers: table.Given}
 		gw := New(opts)
 
-		if gw.maxWorkers != table.Expected {
-			t.Errorf("Expected %d, Got %d", table.Expected, gw.maxWorkers)
+		if gw.MaxWorkers() != table.Expected {
+			t.Errorf("Expected %d, Got %d", table.Expected, gw.MaxWorkers())
 		}
 	}
 }
 
+func TestPreSpawn(t *testing.T) {
+	tables := []struct {
+		Workers  uint32
+		Given    uint32
+		Expected uint32
+	}{
+		{0, 8, 8},
+		{4, 10, 4},
+		{10, 4, 4},
+	}
+
+	for _, table := range tables {
+		gw := New(Options{Workers: table.Workers})
+		gw.PreSpawn(table.Given)
+
+		if gw.WorkerNum() < table.Expected {
+			t.Errorf("Expected at least %d workers, Got %d", table.Expected, gw.WorkerNum())
+		}
+		if (table.Workers != 0) && (gw.WorkerNum() > table.Workers) {
+			t.Errorf("Expected at most %d workers, Got %d", table.Workers, gw.WorkerNum())
+		}
+
+		gw.Stop(false)
+	}
+}
+
+func TestWorkerRateArg(t *testing.T) {
+	gw := New(Options{Workers: 1, WorkerRate: 20})
+
+	tStart := time.Now()
+	for i := 0; i < 5; i++ {
+		gw.Submit(func() {})
+	}
+	gw.Wait(false)
+	gw.Stop(false)
+
+	// a single worker may only start a job every 50ms
+	if tDiff := time.Since(tStart); tDiff < 200*time.Millisecond {
+		t.Errorf("Expected 5 jobs to take at least 200ms, took %s", tDiff)
+	}
+}
+
+func TestRetireWorker(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	gw.PreSpawn(4)
+	ids := gw.WorkerIDs()
+	if len(ids) < 4 {
+		t.Fatalf("Expected at least 4 workers, Got %d", len(ids))
+	}
+
+	if err := gw.RetireWorker(ids[0]); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if err := gw.RetireWorker(ids[0]); err != ErrWorkerNotFound {
+		t.Errorf("Expected %v, Got %v", ErrWorkerNotFound, err)
+	}
+
+	waitWorkerNum(t, gw, uint32(len(ids)-1))
+
+	var done int32
+	for i := 0; i < 10; i++ {
+		gw.Submit(func() { atomic.AddInt32(&done, 1) })
+	}
+	gw.Wait(false)
+
+	if done != 10 {
+		t.Errorf("Expected 10 jobs to finish, Got %d", done)
+	}
+}
+
+func TestShrink(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	gw.PreSpawn(6)
+	before := gw.WorkerNum()
+
+	if n := gw.Shrink(2); n != 2 {
+		t.Errorf("Expected 2 workers to be retired, Got %d", n)
+	}
+	waitWorkerNum(t, gw, before-2)
+
+	if n := gw.Shrink(100); n != before-2 {
+		t.Errorf("Expected %d workers to be retired, Got %d", before-2, n)
+	}
+
+	gw.Submit(func() {})
+	gw.Wait(false)
+}
+
+// waitWorkerNum waits for gw to have n workers
+func waitWorkerNum(t *testing.T, gw *GoWorkers, n uint32) {
+	deadline := time.Now().Add(5 * time.Second)
+	for gw.WorkerNum() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d workers, Got %d", n, gw.WorkerNum())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 func TestBufferedQArg(t *testing.T) {
 	tables := []struct {
 		Given    uint32
 		Expected uint32
 	}{
+		{0, defaultQSize},
+		{1, 1},
+		{defaultQSize - 1, defaultQSize - 1},
 		{defaultQSize, defaultQSize},
-		{defaultQSize - 1, defaultQSize},
 		{defaultQSize + 1, defaultQSize + 1},
 	}
 
 	for _, table := range tables {
-		opts := Options{QSize: table.Given}
-		_ = New(opts)
+		gw := New(Options{QSize: table.Given})
+		if gw.QueueCap() != table.Expected {
+			t.Errorf("Expected %d, Got %d", table.Expected, gw.QueueCap())
+		}
+		gw.Stop(false)
+	}
+}
+
+func TestDirectHandoff(t *testing.T) {
+	gw := New(Options{Workers: 1, DirectHandoff: true})
+	if gw.QueueCap() != 0 {
+		t.Errorf("Expected 0, Got %d", gw.QueueCap())
+	}
+
+	release := make(chan struct{})
+	gw.Submit(func() { <-release })
+	// the worker took the job, and holds it
+	if !gw.Saturated() {
+		t.Errorf("Expected the pool to be saturated")
+	}
+	if gw.TrySubmit(func() {}) {
+		t.Errorf("Expected the job to be rejected")
+	}
+
+	// the submission waits for the worker
+	submitted := make(chan struct{})
+	go func() {
+		gw.Submit(func() {})
+		close(submitted)
+	}()
+	select {
+	case <-submitted:
+		t.Errorf("Expected the submission to block")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-submitted
+
+	gw.Stop(false)
+	if st := gw.Stats(); st.Completed != 2 {
+		t.Errorf("Expected 2 completed jobs, Got %+v", st)
+	}
+}
+
+func TestTrySubmit(t *testing.T) {
+	gw := New(Options{Workers: 1})
+
+	release := make(chan struct{})
+	if !gw.TrySubmit(func() { <-release }) {
+		t.Fatalf("Expected the job to be accepted")
+	}
+	for atomic.LoadUint32(&gw.numRunning) != 1 {
+	}
+
+	for i := 0; i < defaultQSize; i++ {
+		if !gw.TrySubmit(func() {}) {
+			t.Fatalf("Expected job %d to be accepted", i)
+		}
+	}
+	if gw.TrySubmit(func() {}) {
+		t.Errorf("Expected the job to be rejected as the queue is full")
+	}
+
+	close(release)
+	gw.Stop(false)
+
+	if gw.TrySubmit(func() {}) {
+		t.Errorf("Expected the job to be rejected after Stop()")
+	}
+}
+
+func TestReady(t *testing.T) {
+	gw := New(Options{Workers: 1})
+
+	if !gw.Ready() {
+		t.Errorf("Expected a new pool to be ready")
+	}
+
+	release := make(chan struct{})
+	gw.Submit(func() { <-release })
+	for atomic.LoadUint32(&gw.numRunning) != 1 {
+	}
+	for i := 0; i < defaultQSize; i++ {
+		gw.Submit(func() {})
+	}
+	if gw.Ready() {
+		t.Errorf("Expected a saturated pool not to be ready")
+	}
+
+	close(release)
+	gw.Stop(false)
+
+	if gw.Ready() {
+		t.Errorf("Expected a stopped pool not to be ready")
+	}
+}
+
+func TestQueueCapacity(t *testing.T) {
+	gw := New(Options{Workers: 1, QSize: 200})
+
+	if gw.QueueCap() != 200 || gw.QueueFree() != 200 || gw.Saturated() {
+		t.Errorf("Expected an empty queue of 200, Got %d free of %d", gw.QueueFree(), gw.QueueCap())
+	}
+
+	release := make(chan struct{})
+	gw.Submit(func() { <-release })
+	for atomic.LoadUint32(&gw.numRunning) != 1 {
+	}
+	// the running job does not take up the queue
+	if free := gw.QueueFree(); free != 200 {
+		t.Errorf("Expected %d, Got %d", 200, free)
+	}
+
+	for i := 0; i < 150; i++ {
+		gw.Submit(func() {})
+	}
+	if free := gw.QueueFree(); free != 50 {
+		t.Errorf("Expected %d, Got %d", 50, free)
+	}
+	for i := 0; i < 50; i++ {
+		gw.Submit(func() {})
+	}
+	if !gw.Saturated() || gw.QueueFree() != 0 {
+		t.Errorf("Expected the queue to be saturated, Got %d free", gw.QueueFree())
+	}
+
+	close(release)
+	gw.Stop(false)
+}
+
+func TestDrain(t *testing.T) {
+	gw := New()
+
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		gw.Submit(func() { <-release })
+	}
+
+	if n := gw.Drain(50 * time.Millisecond); n != 3 {
+		t.Errorf("Expected 3 leftover jobs, Got %d", n)
+	}
+	if gw.Ready() {
+		t.Errorf("Expected a draining pool not to be ready")
+	}
+
+	close(release)
+	if n := gw.Drain(time.Second); n != 0 {
+		t.Errorf("Expected no leftover jobs, Got %d", n)
+	}
+}
+
+func TestStats(t *testing.T) {
+	gw := New(Options{Workers: 2})
+
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		gw.Submit(func() { <-release })
+	}
+
+	for {
+		if st := gw.Stats(); len(st.Busy) == 2 {
+			break
+		}
+	}
+
+	st := gw.Stats()
+	if st.MaxWorkers != 2 || st.Jobs != 3 || st.Running != 2 || st.Queued != 1 {
+		t.Errorf("Unexpected stats %+v", st)
+	}
+	if st.Busy[0].Since.After(st.Busy[1].Since) {
+		t.Errorf("Expected the oldest job first, Got %+v", st.Busy)
+	}
+
+	close(release)
+	gw.Stop(false)
+}
+
+func TestString(t *testing.T) {
+	gw := New(Options{Name: "thumbnails", Workers: 2})
+
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		gw.Submit(func() { <-release })
+	}
+	for len(gw.Stats().Busy) != 2 {
+	}
+
+	want := `goworkers "thumbnails": workers=2/2 queued=1 running=2 completed=0 failed=0`
+	if got := gw.String(); got != want {
+		t.Errorf("Expected %s, Got %s", want, got)
+	}
+
+	close(release)
+	gw.Stop(false)
+
+	gw = New()
+	gw.SubmitCheckError(func() error { return fmt.Errorf("failed") })
+	gw.Stop(false)
+
+	got := fmt.Sprint(gw)
+	if !strings.HasPrefix(got, "goworkers: workers=") || !strings.HasSuffix(got, " queued=0 running=0 completed=1 failed=1") {
+		t.Errorf("Unexpected summary %s", got)
+	}
+}
+
+func TestStatsCounters(t *testing.T) {
+	gw := New()
+
+	for i := 0; i < 4; i++ {
+		n := i
+		gw.Submit(func() {})
+		gw.SubmitCheckError(func() error {
+			if n%2 == 0 {
+				return fmt.Errorf("e%d", n)
+			}
+			return nil
+		})
+	}
+
+	gw.Stop(false)
+
+	if st := gw.Stats(); st.Completed != 8 || st.Failed != 2 {
+		t.Errorf("Expected 8 completed and 2 failed, Got %d and %d", st.Completed, st.Failed)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	gw := New(Options{Workers: 2})
+
+	gw.Pause()
+	gw.Pause()
+	if !gw.Stats().Paused {
+		t.Errorf("Expected the pool to be paused")
+	}
+
+	var count int32
+	for i := 0; i < 5; i++ {
+		gw.Submit(func() { atomic.AddInt32(&count, 1) })
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&count); n > 1 {
+		t.Errorf("Expected at most the job picked up before pausing to run, Got %d", n)
+	}
+
+	gw.Resume()
+	for {
+		if atomic.LoadInt32(&count) == 5 {
+			break
+		}
+	}
+
+	// stopping a paused pool must not hang
+	gw.Pause()
+	gw.Submit(func() { atomic.AddInt32(&count, 1) })
+	gw.Stop(false)
+	if count != 6 {
+		t.Errorf("Expected 6, Got %d", count)
 	}
 }
 
@@ -352,7 +715,222 @@ func TestSubmitAfterStop(t *testing.T) {
 	})
 
 	gw.Stop(false)
+	if err := gw.Submit(func() {}); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+	if err := gw.SubmitCheckError(func() error { return nil }); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+	if err := gw.SubmitCheckResult(func() (interface{}, error) { return nil, nil }); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+	if gw.TrySubmit(func() {}) {
+		t.Errorf("Expected the job to be rejected")
+	}
+}
+
+func TestSubmitRacingStop(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		gw := New()
+
+		var accepted, ran int32
+		var wg sync.WaitGroup
+		for j := 0; j < 8; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					if gw.Submit(func() { atomic.AddInt32(&ran, 1) }) != nil {
+						return
+					}
+					atomic.AddInt32(&accepted, 1)
+				}
+			}()
+		}
+
+		gw.Stop(false)
+		wg.Wait()
+
+		if accepted != ran {
+			t.Errorf("Expected every accepted job to run, Got %d accepted and %d run", accepted, ran)
+		}
+	}
+}
+
+func TestStopAfterWait(t *testing.T) {
+	gw := New()
+
 	gw.Submit(func() {})
+	gw.Wait(false)
+
+	var ran int32
+	release := make(chan struct{})
+	gw.Submit(func() {
+		<-release
+		atomic.StoreInt32(&ran, 1)
+	})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	gw.Stop(false)
+
+	if atomic.LoadInt32(&ran) != 1 {
+		t.Errorf("Expected Stop() to wait for the job submitted after Wait()")
+	}
+}
+
+func TestVerifyShutdown(t *testing.T) {
+	gw := New(Options{Workers: 4})
+
+	if err := gw.VerifyShutdown(time.Second); err != ErrNotStopped {
+		t.Errorf("Expected %v, Got %v", ErrNotStopped, err)
+	}
+
+	for i := 0; i < 200; i++ {
+		gw.Submit(func() { time.Sleep(time.Millisecond) })
+	}
+	gw.Stop(false)
+
+	if err := gw.VerifyShutdown(time.Second); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	// pretend a helper goroutine is stuck
+	atomic.AddInt32(&gw.numHelpers, 1)
+	err := gw.VerifyShutdown(10 * time.Millisecond)
+	if !errors.Is(err, ErrLeaked) || !strings.Contains(err.Error(), "1 helpers") {
+		t.Errorf("Expected the helper to be reported leaked, Got %v", err)
+	}
+}
+
+func TestReentrantSubmit(t *testing.T) {
+	gw := New(Options{Workers: 2})
+
+	// a tree of fan-out 4 and depth 4 keeps both the workers busy and
+	// overflows the queue
+	var visited int32
+	var visit func(depth int)
+	visit = func(depth int) {
+		atomic.AddInt32(&visited, 1)
+		if depth == 4 {
+			return
+		}
+		for i := 0; i < 4; i++ {
+			if err := gw.Submit(func() { visit(depth + 1) }); err != nil {
+				t.Errorf("Expected nil, Got %v", err)
+			}
+		}
+	}
+
+	gw.Submit(func() { visit(0) })
+	gw.Wait(false)
+
+	if visited != 341 {
+		t.Errorf("Expected 341, Got %d", visited)
+	}
+
+	gw.Stop(false)
+}
+
+func TestReentrantSubmitDirectHandoff(t *testing.T) {
+	gw := New(Options{Workers: 1, DirectHandoff: true})
+
+	// the only worker is busy with the job submitting the follow-up job
+	done := make(chan struct{})
+	gw.Submit(func() {
+		if err := gw.Submit(func() { close(done) }); err != nil {
+			t.Errorf("Expected nil, Got %v", err)
+		}
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the follow-up job to run")
+	}
+
+	gw.Stop(false)
+	if st := gw.Stats(); st.Completed != 2 {
+		t.Errorf("Expected 2 completed jobs, Got %+v", st)
+	}
+}
+
+func TestDirectHandoffBlockedDuringStop(t *testing.T) {
+	tables := []struct {
+		name string
+		stop func(gw *GoWorkers) error
+	}{
+		{"Stop", func(gw *GoWorkers) error { return gw.Stop(false) }},
+		{"ReplaceWith", func(gw *GoWorkers) error {
+			err := gw.ReplaceWith(Options{Workers: 1, DirectHandoff: true})
+			gw.Stop(false)
+			return err
+		}},
+	}
+
+	for _, table := range tables {
+		gw := New(Options{Workers: 1, DirectHandoff: true})
+
+		release := make(chan struct{})
+		started := make(chan struct{})
+		gw.Submit(func() {
+			close(started)
+			<-release
+			// submits while a blocked hand-off and the stop are pending
+			gw.Submit(func() {})
+		})
+		<-started
+
+		// blocks until the only worker is free
+		go gw.Submit(func() {})
+		for gw.JobNum() != 2 {
+			time.Sleep(time.Millisecond)
+		}
+		stopped := make(chan error)
+		go func() { stopped <- table.stop(gw) }()
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+
+		select {
+		case err := <-stopped:
+			if err != nil {
+				t.Errorf("%s: Expected nil, Got %v", table.name, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: Expected the pool not to deadlock", table.name)
+		}
+	}
+}
+
+func TestReentrantSubmitDuringStop(t *testing.T) {
+	gw := New(Options{Workers: 1})
+
+	var ran int32
+	release := make(chan struct{})
+	gw.Submit(func() {
+		<-release
+		for i := 0; i < 10; i++ {
+			if err := gw.Submit(func() { atomic.AddInt32(&ran, 1) }); err != nil {
+				t.Errorf("Expected nil, Got %v", err)
+			}
+		}
+	})
+
+	go func() {
+		for atomic.LoadInt32(&gw.stopping) != stateStopping {
+		}
+		// the pool is now rejecting other submissions
+		if err := gw.Submit(func() {}); err != ErrStopped {
+			t.Errorf("Expected %v, Got %v", ErrStopped, err)
+		}
+		close(release)
+	}()
+
+	gw.Stop(false)
+	if ran != 10 {
+		t.Errorf("Expected the follow-up jobs to be waited for, Got %d of 10", ran)
+	}
 }
 
 func TestStopAfterDelay(t *testing.T) {
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package goworkerstest provides helpers for testing code that submits jobs
+// to a goworkers pool.
+//
+// The helpers wait on the stats and the output channels of the pool instead
+// of on sleeps, and fail the test with a descriptive message if the expected
+// outcome is not reached within Timeout. Mock stands in for a pool in tests
+// of code depending on a goworkers.Executor.
+package goworkerstest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// Timeout is how long the helpers wait before failing the test.
+var Timeout = 10 * time.Second
+
+// pollInterval is how often the stats of the pool are polled
+const pollInterval = time.Millisecond
+
+// WaitForCompleted waits until at least n jobs of gw have completed,
+// successfully or not.
+func WaitForCompleted(t testing.TB, gw *goworkers.GoWorkers, n uint32) {
+	t.Helper()
+	waitFor(t, gw, func(st goworkers.Stats) bool { return st.Completed >= n },
+		fmt.Sprintf("%d completed jobs", n))
+}
+
+// WaitForIdle waits until gw has no job running or queued.
+func WaitForIdle(t testing.TB, gw *goworkers.GoWorkers) {
+	t.Helper()
+	waitFor(t, gw, func(st goworkers.Stats) bool { return st.Jobs == 0 }, "an idle pool")
+}
+
+func waitFor(t testing.TB, gw *goworkers.GoWorkers, cond func(goworkers.Stats) bool, what string) {
+	t.Helper()
+	deadline := time.Now().Add(Timeout)
+	for !cond(gw.Stats()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("goworkerstest: timed out after %v waiting for %s, Got %s", Timeout, what, gw)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// CollectResults reads n outputs from the ResultChan of gw.
+//
+// The test fails if the channel is closed or if fewer than n outputs arrive
+// within Timeout.
+func CollectResults(t testing.TB, gw *goworkers.GoWorkers, n int) []interface{} {
+	t.Helper()
+	results := make([]interface{}, 0, n)
+	timer := time.NewTimer(Timeout)
+	defer timer.Stop()
+	for len(results) < n {
+		select {
+		case res, ok := <-gw.ResultChan:
+			if !ok {
+				t.Fatalf("goworkerstest: ResultChan closed after %d of %d results", len(results), n)
+			}
+			results = append(results, res)
+		case <-timer.C:
+			t.Fatalf("goworkerstest: timed out after %v with %d of %d results", Timeout, len(results), n)
+		}
+	}
+	return results
+}
+
+// CollectErrors reads n errors from the ErrChan of gw.
+//
+// The test fails if the channel is closed or if fewer than n errors arrive
+// within Timeout.
+func CollectErrors(t testing.TB, gw *goworkers.GoWorkers, n int) []error {
+	t.Helper()
+	errs := make([]error, 0, n)
+	timer := time.NewTimer(Timeout)
+	defer timer.Stop()
+	for len(errs) < n {
+		select {
+		case err, ok := <-gw.ErrChan:
+			if !ok {
+				t.Fatalf("goworkerstest: ErrChan closed after %d of %d errors", len(errs), n)
+			}
+			errs = append(errs, err)
+		case <-timer.C:
+			t.Fatalf("goworkerstest: timed out after %v with %d of %d errors", Timeout, len(errs), n)
+		}
+	}
+	return errs
+}
+
+// AssertCounts checks the number of completed and failed jobs of gw.
+func AssertCounts(t testing.TB, gw *goworkers.GoWorkers, completed, failed uint32) {
+	t.Helper()
+	st := gw.Stats()
+	if st.Completed != completed || st.Failed != failed {
+		t.Errorf("Expected %d completed and %d failed jobs, Got %d and %d", completed, failed, st.Completed, st.Failed)
+	}
+}
+
+// AssertNamedCounts checks the number of completed and failed jobs of gw
+// submitted with the given name.
+func AssertNamedCounts(t testing.TB, gw *goworkers.GoWorkers, name string, completed, failed uint32) {
+	t.Helper()
+	st := gw.Stats().Named[name]
+	if st.Completed != completed || st.Failed != failed {
+		t.Errorf("%s: Expected %d completed and %d failed jobs, Got %d and %d", name, completed, failed, st.Completed, st.Failed)
+	}
+}
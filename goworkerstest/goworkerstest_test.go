@@ -0,0 +1,116 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkerstest
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+func TestHelpers(t *testing.T) {
+	gw := goworkers.New(goworkers.Options{Workers: 4})
+	defer gw.Stop(false)
+
+	for i := 0; i < 6; i++ {
+		n := i
+		gw.SubmitCheckResult(func() (interface{}, error) {
+			if n%3 == 0 {
+				return nil, errors.New("failed")
+			}
+			return n, nil
+		}, goworkers.JobOptions{Name: "square"})
+	}
+
+	WaitForCompleted(t, gw, 6)
+	WaitForIdle(t, gw)
+
+	results := CollectResults(t, gw, 4)
+	got := make([]int, 0, len(results))
+	for _, res := range results {
+		got = append(got, res.(int))
+	}
+	sort.Ints(got)
+	want := []int{1, 2, 4, 5}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, Got %v", want, got)
+			break
+		}
+	}
+
+	for _, err := range CollectErrors(t, gw, 2) {
+		if !strings.HasSuffix(err.Error(), "failed") {
+			t.Errorf("Expected failed, Got %v", err)
+		}
+	}
+
+	AssertCounts(t, gw, 6, 2)
+	AssertNamedCounts(t, gw, "square", 6, 2)
+}
+
+// recorder records the failures reported by the helpers
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failed = true
+}
+
+// Fatalf exits the goroutine, as with testing.T
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed = true
+	panic(r)
+}
+
+func run(fn func(r *recorder)) *recorder {
+	r := &recorder{}
+	func() {
+		defer func() {
+			if v := recover(); v != nil && v != r {
+				panic(v)
+			}
+		}()
+		fn(r)
+	}()
+	return r
+}
+
+func TestTimeout(t *testing.T) {
+	defer func(d time.Duration) { Timeout = d }(Timeout)
+	Timeout = 10 * time.Millisecond
+
+	gw := goworkers.New()
+	release := make(chan struct{})
+	gw.Submit(func() { <-release })
+
+	tables := []struct {
+		name string
+		fn   func(r *recorder)
+	}{
+		{"WaitForCompleted", func(r *recorder) { WaitForCompleted(r, gw, 1) }},
+		{"WaitForIdle", func(r *recorder) { WaitForIdle(r, gw) }},
+		{"CollectResults", func(r *recorder) { CollectResults(r, gw, 1) }},
+		{"CollectErrors", func(r *recorder) { CollectErrors(r, gw, 1) }},
+		{"AssertCounts", func(r *recorder) { AssertCounts(r, gw, 1, 0) }},
+	}
+
+	for _, table := range tables {
+		if r := run(table.fn); !r.failed {
+			t.Errorf("%s: Expected a failure", table.name)
+		}
+	}
+
+	close(release)
+	gw.Stop(false)
+}
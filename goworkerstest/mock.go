@@ -0,0 +1,145 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkerstest
+
+import (
+	"sync"
+
+	"github.com/dpaks/goworkers"
+)
+
+// mockChanSize matches the size of the output channels of a pool
+const mockChanSize = 100
+
+// Method is a submission method of an Executor.
+type Method int
+
+const (
+	// Submit is Executor.Submit.
+	Submit Method = iota
+	// SubmitCheckError is Executor.SubmitCheckError.
+	SubmitCheckError
+	// SubmitCheckResult is Executor.SubmitCheckResult.
+	SubmitCheckResult
+)
+
+// Call is a submission recorded by a Mock.
+type Call struct {
+	Method Method
+	Opts   goworkers.JobOptions
+	// Result and Err are the outputs of the job
+	Result interface{}
+	Err    error
+}
+
+// Mock is a goworkers.Executor that records the submitted jobs and runs them
+// on the submitting goroutine, such that code depending on an Executor can
+// be tested deterministically.
+//
+// As with a pool, the outputs of the jobs are delivered on ErrChan and
+// ResultChan, which are closed by Stop().
+type Mock struct {
+	// SubmitErr, if set, is returned by the submissions instead of running
+	// the job, e.g. to simulate a pool that is being stopped.
+	SubmitErr error
+
+	ErrChan    chan error
+	ResultChan chan interface{}
+
+	mu      sync.Mutex
+	calls   []Call
+	stopped bool
+}
+
+var _ goworkers.Executor = (*Mock)(nil)
+
+// NewMock creates a new mock executor.
+func NewMock() *Mock {
+	return &Mock{
+		ErrChan:    make(chan error, mockChanSize),
+		ResultChan: make(chan interface{}, mockChanSize),
+	}
+}
+
+// Calls returns the recorded submissions in order.
+func (m *Mock) Calls() []Call {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	return append([]Call(nil), m.calls...)
+}
+
+// Submit runs job and records it.
+func (m *Mock) Submit(job func(), args ...goworkers.JobOptions) error {
+	return m.run(Submit, func() (interface{}, error) {
+		job()
+		return nil, nil
+	}, args)
+}
+
+// SubmitCheckError runs job, records it and delivers its error on ErrChan.
+func (m *Mock) SubmitCheckError(job func() error, args ...goworkers.JobOptions) error {
+	return m.run(SubmitCheckError, func() (interface{}, error) {
+		return nil, job()
+	}, args)
+}
+
+// SubmitCheckResult runs job, records it and delivers its outputs on
+// ErrChan and ResultChan.
+func (m *Mock) SubmitCheckResult(job func() (interface{}, error), args ...goworkers.JobOptions) error {
+	return m.run(SubmitCheckResult, job, args)
+}
+
+func (m *Mock) run(method Method, job func() (interface{}, error), args []goworkers.JobOptions) error {
+	m.mu.Lock()
+	stopped := m.stopped
+	m.mu.Unlock()
+	if stopped {
+		return goworkers.ErrStopped
+	}
+	if m.SubmitErr != nil {
+		return m.SubmitErr
+	}
+
+	c := Call{Method: method}
+	if len(args) == 1 {
+		c.Opts = args[0]
+	}
+	c.Result, c.Err = job()
+
+	m.mu.Lock()
+	m.calls = append(m.calls, c)
+	m.mu.Unlock()
+
+	if c.Err != nil && method != Submit {
+		select {
+		case m.ErrChan <- c.Err:
+		default:
+		}
+	} else if c.Err == nil && method == SubmitCheckResult {
+		select {
+		case m.ResultChan <- c.Result:
+		default:
+		}
+	}
+	return nil
+}
+
+// Wait returns right away as the jobs are run on submission.
+func (m *Mock) Wait(wait bool) error {
+	return nil
+}
+
+// Stop rejects further submissions with goworkers.ErrStopped and closes the
+// output channels.
+func (m *Mock) Stop(wait bool) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	if !m.stopped {
+		m.stopped = true
+		close(m.ErrChan)
+		close(m.ResultChan)
+	}
+	return nil
+}
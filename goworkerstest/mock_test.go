@@ -0,0 +1,87 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkerstest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dpaks/goworkers"
+)
+
+// resize is application code depending on an Executor
+func resize(ex goworkers.Executor, images []string) error {
+	for _, img := range images {
+		name := img
+		if err := ex.SubmitCheckResult(func() (interface{}, error) {
+			if name == "" {
+				return nil, errors.New("empty name")
+			}
+			return name + ".small", nil
+		}, goworkers.JobOptions{Name: "resize"}); err != nil {
+			return err
+		}
+	}
+	return ex.Wait(false)
+}
+
+func TestMock(t *testing.T) {
+	m := NewMock()
+
+	if err := resize(m, []string{"a", "", "b"}); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+
+	calls := m.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3, Got %d", len(calls))
+	}
+	tables := []struct {
+		result interface{}
+		failed bool
+	}{
+		{"a.small", false},
+		{nil, true},
+		{"b.small", false},
+	}
+	for i, table := range tables {
+		c := calls[i]
+		if c.Method != SubmitCheckResult || c.Opts.Name != "resize" {
+			t.Errorf("%d: Unexpected call %+v", i, c)
+		}
+		if c.Result != table.result || (c.Err != nil) != table.failed {
+			t.Errorf("%d: Expected %v and failed %t, Got %v and %v", i, table.result, table.failed, c.Result, c.Err)
+		}
+	}
+
+	if res := <-m.ResultChan; res != "a.small" {
+		t.Errorf("Expected a.small, Got %v", res)
+	}
+	if err := <-m.ErrChan; err == nil {
+		t.Errorf("Expected an error")
+	}
+
+	m.Stop(false)
+	if err := m.Submit(func() {}); err != goworkers.ErrStopped {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrStopped, err)
+	}
+	if _, ok := <-m.ErrChan; ok {
+		t.Errorf("Expected ErrChan to be closed")
+	}
+}
+
+func TestMockSubmitErr(t *testing.T) {
+	m := NewMock()
+	defer m.Stop(false)
+	m.SubmitErr = goworkers.ErrStopped
+
+	ran := false
+	if err := m.Submit(func() { ran = true }); err != goworkers.ErrStopped {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrStopped, err)
+	}
+	if ran || len(m.Calls()) != 0 {
+		t.Errorf("Expected the job to be rejected")
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned by Group.Wait() when the time budget of the
+// group ran out before its jobs finished.
+var ErrBudgetExceeded = errors.New("goworkers: group budget exceeded")
+
+// Group is a group of jobs sharing a total time budget, e.g. for the
+// fan-out of a request that must answer within its SLA.
+//
+// Once the budget is spent, the jobs of the group that have not started are
+// skipped, the contexts of the running ones are cancelled, and further
+// submissions are rejected with ErrBudgetExceeded.
+type Group struct {
+	gw     *GoWorkers
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	timer  Timer
+
+	mu       sync.Mutex
+	err      error
+	exceeded bool
+}
+
+// NewGroup creates a new group of jobs run by the pool, with budget counted
+// from now. The contexts of the jobs derive from ctx.
+func (gw *GoWorkers) NewGroup(ctx context.Context, budget time.Duration) *Group {
+	g := &Group{gw: gw, timer: gw.clock.NewTimer(budget)}
+	g.ctx, g.cancel = context.WithCancel(ctx)
+
+	gw.goHelper(func() {
+		select {
+		case <-g.timer.C():
+			g.mu.Lock()
+			g.exceeded = true
+			g.mu.Unlock()
+			g.cancel()
+		case <-g.ctx.Done():
+		}
+	})
+	return g
+}
+
+// Submit is a non-blocking call with arg of type `func(context.Context) error`
+//
+// The context of the job is cancelled once the budget is spent, and the jobs
+// still queued by then are dropped with an error wrapping ErrCancelled. The
+// error of a failed job is delivered on ErrChan of the pool, as with
+// SubmitCheckError().
+// Accepts optional JobOptions{} argument.
+// Returns ErrBudgetExceeded if the budget is spent, or the error of
+// submitting to the pool.
+func (g *Group) Submit(job func(ctx context.Context) error, args ...JobOptions) error {
+	if g.ctx.Err() != nil {
+		return ErrBudgetExceeded
+	}
+
+	g.wg.Add(1)
+	err := g.gw.submit(&task{
+		run: func(ctx context.Context) (interface{}, error) {
+			return nil, job(ctx)
+		},
+		outputs: errOutput,
+		opts:    jobOptions(args),
+		ctx:     g.ctx,
+		// even if the job does not run, e.g. as an interceptor skipped it
+		onFinish: func(err error) {
+			if err != nil {
+				g.mu.Lock()
+				if g.err == nil {
+					g.err = err
+				}
+				g.mu.Unlock()
+			}
+			g.wg.Done()
+		},
+	})
+	if err != nil {
+		g.wg.Done()
+	}
+	return err
+}
+
+// Wait waits for the jobs of the group to finish, or to be skipped, and
+// releases the resources of the group.
+// Returns ErrBudgetExceeded if the budget was spent before, or else the
+// error of the job that failed first, if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.timer.Stop()
+	g.cancel()
+
+	defer g.mu.Unlock()
+	g.mu.Lock()
+	if g.exceeded {
+		return ErrBudgetExceeded
+	}
+	return g.err
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	gw := New()
+	g := gw.NewGroup(context.Background(), time.Hour)
+
+	var runs int32
+	for i := 0; i < 10; i++ {
+		g.Submit(func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if runs != 10 {
+		t.Errorf("Expected 10, Got %d", runs)
+	}
+
+	gw.Stop(false)
+	if err := gw.VerifyShutdown(time.Second); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+}
+
+func TestGroupError(t *testing.T) {
+	gw := New()
+	g := gw.NewGroup(context.Background(), time.Hour)
+
+	errFailed := errors.New("failed")
+	g.Submit(func(ctx context.Context) error { return errFailed })
+
+	if err := g.Wait(); err != errFailed {
+		t.Errorf("Expected %v, Got %v", errFailed, err)
+	}
+	if err := <-gw.ErrChan; err != errFailed {
+		t.Errorf("Expected %v, Got %v", errFailed, err)
+	}
+
+	gw.Stop(false)
+}
+
+func TestGroupBudgetExceeded(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Workers: 1, Clock: clock})
+	g := gw.NewGroup(context.Background(), time.Second)
+
+	var cancelled, skipped int32
+	started := make(chan struct{})
+	g.Submit(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		atomic.AddInt32(&cancelled, 1)
+		return nil
+	})
+	<-started
+	for i := 0; i < 5; i++ {
+		g.Submit(func(ctx context.Context) error {
+			atomic.AddInt32(&skipped, 1)
+			return nil
+		})
+	}
+
+	for clock.Waiters() == 0 {
+	}
+	clock.Advance(time.Second)
+
+	if err := g.Wait(); err != ErrBudgetExceeded {
+		t.Errorf("Expected %v, Got %v", ErrBudgetExceeded, err)
+	}
+	if cancelled != 1 || skipped != 0 {
+		t.Errorf("Expected the running job to be cancelled and the others skipped, Got %d and %d runs", cancelled, skipped)
+	}
+	if err := g.Submit(func(ctx context.Context) error { return nil }); err != ErrBudgetExceeded {
+		t.Errorf("Expected %v, Got %v", ErrBudgetExceeded, err)
+	}
+
+	gw.Stop(false)
+}
+
+func TestGroupJobNotRun(t *testing.T) {
+	gw := New(Options{Workers: 2, Idempotency: NewMemoryIdempotencyStore()})
+	defer gw.Stop(false)
+
+	gw.Use(func(ctx context.Context, job JobInfo, next func(ctx context.Context) error) error {
+		if job.Name == "skipped" {
+			return nil
+		}
+		return next(ctx)
+	})
+
+	var ran int32
+	job := func(ctx context.Context) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	}
+	g := gw.NewGroup(context.Background(), time.Minute)
+	// skipped by the interceptor
+	g.Submit(job, JobOptions{Name: "skipped"})
+	if err := g.Wait(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	// the duplicate of a job that succeeded does not run
+	for i := 0; i < 2; i++ {
+		g = gw.NewGroup(context.Background(), time.Minute)
+		g.Submit(job, JobOptions{Key: "report-1"})
+		if err := g.Wait(); err != nil {
+			t.Errorf("Expected nil, Got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&ran); n != 1 {
+		t.Errorf("Expected 1 run, Got %d", n)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dpaks/goworkers/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ErrUnauthenticated is returned by an Authenticator that cannot identify
+// the caller of a call.
+var ErrUnauthenticated = errors.New("grpcapi: unauthenticated")
+
+// Authenticator identifies the principal making the call of ctx.
+// Returns ErrUnauthenticated, or another error, if it cannot.
+type Authenticator func(ctx context.Context) (string, error)
+
+// Authorizer reports whether a principal may submit the job of a name.
+type Authorizer func(principal, job string) bool
+
+// TokenAuth authenticates the calls bearing a token of tokens, which maps
+// tokens to principals, in an "authorization: Bearer <token>" metadata.
+func TokenAuth(tokens map[string]string) Authenticator {
+	return func(ctx context.Context) (string, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, v := range md.Get("authorization") {
+			token, ok := auth.Bearer(v)
+			if !ok {
+				continue
+			}
+			if principal, ok := auth.LookupToken(tokens, token); ok {
+				return principal, nil
+			}
+		}
+		return "", ErrUnauthenticated
+	}
+}
+
+// MTLSAuth authenticates the calls made over TLS with a verified client
+// certificate, whose subject common name is the principal. The server must
+// verify client certificates, e.g. with tls.RequireAndVerifyClientCert.
+func MTLSAuth() Authenticator {
+	return func(ctx context.Context) (string, error) {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return "", ErrUnauthenticated
+		}
+		info, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok {
+			return "", ErrUnauthenticated
+		}
+		principal, ok := auth.PeerName(&info.State)
+		if !ok {
+			return "", ErrUnauthenticated
+		}
+		return principal, nil
+	}
+}
+
+// AllowJobs authorizes the principals to submit the jobs granted to them by
+// grants, which maps principals to job names. The name "*" grants every job.
+func AllowJobs(grants map[string][]string) Authorizer {
+	return func(principal, job string) bool {
+		return auth.Allowed(grants, principal, job)
+	}
+}
+
+// authenticate returns the principal making the call of ctx
+func (s *Server) authenticate(ctx context.Context) (string, error) {
+	if s.Authenticate == nil {
+		return "", nil
+	}
+	principal, err := s.Authenticate(ctx)
+	if err != nil {
+		return "", status.Error(codes.Unauthenticated, err.Error())
+	}
+	return principal, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func submitJob(ctx context.Context, conn *grpc.ClientConn, name string) error {
+	req, _ := structpb.NewStruct(map[string]interface{}{"name": name})
+	return conn.Invoke(ctx, "/"+ServiceName+"/Submit", req, new(structpb.Struct))
+}
+
+func TestTokenAuth(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	s := New(gw)
+	s.Authenticate = TokenAuth(map[string]string{"b-token": "billing", "r-token": "reports"})
+	s.Authorize = AllowJobs(map[string][]string{"billing": {"invoice"}, "reports": {"*"}})
+	for _, name := range []string{"invoice", "report"} {
+		job := name
+		s.Register(name, func(payload json.RawMessage) (interface{}, error) { return job, nil })
+	}
+	conn := dial(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tables := []struct {
+		token, job string
+		code       codes.Code
+	}{
+		{"", "invoice", codes.Unauthenticated},
+		{"wrong", "invoice", codes.Unauthenticated},
+		{"b-token", "report", codes.PermissionDenied},
+		{"r-token", "invoice", codes.OK},
+	}
+	for _, table := range tables {
+		if err := submitJob(withToken(ctx, table.token), conn, table.job); status.Code(err) != table.code {
+			t.Errorf("%s %s: Expected %v, Got %v", table.token, table.job, table.code, err)
+		}
+	}
+
+	// the results of a principal are streamed to that principal only
+	stream, err := conn.NewStream(withToken(ctx, "b-token"), &serviceDesc.Streams[0], "/"+ServiceName+"/StreamResults")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	for {
+		s.mu.Lock()
+		n := len(s.subs)
+		s.mu.Unlock()
+		if n == 1 {
+			break
+		}
+	}
+
+	if err := submitJob(withToken(ctx, "r-token"), conn, "report"); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	gw.Wait(false)
+	if err := submitJob(withToken(ctx, "b-token"), conn, "invoice"); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+
+	msg := new(structpb.Struct)
+	if err := stream.RecvMsg(msg); err != nil {
+		t.Fatalf("Failed to receive: %v", err)
+	}
+	if name := msg.GetFields()["name"].GetStringValue(); name != "invoice" {
+		t.Errorf("Expected invoice, Got %s", name)
+	}
+}
+
+func TestMTLSAuth(t *testing.T) {
+	authenticate := MTLSAuth()
+
+	if _, err := authenticate(context.Background()); err != ErrUnauthenticated {
+		t.Errorf("Expected %v, Got %v", ErrUnauthenticated, err)
+	}
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+	}})
+	if principal, err := authenticate(ctx); err != nil || principal != "billing" {
+		t.Errorf("Expected billing, Got %q and %v", principal, err)
+	}
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package grpcapi implements a gRPC service, described in goworkers.proto,
+// for submitting registered jobs to a goworkers pool, streaming their
+// results and streaming the stats of the pool.
+//
+// Calls are authenticated with Server.Authenticate, e.g. TokenAuth() or
+// MTLSAuth(), and the submissions authorized with Server.Authorize, e.g.
+// AllowJobs(). The results of the jobs of a principal are streamed to that
+// principal only.
+//
+// A Server without Authenticate is open: anyone who can reach it may submit
+// jobs. Set Authenticate unless only trusted callers can reach the server.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dpaks/goworkers"
+	"github.com/dpaks/goworkers/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ServiceName is the fully qualified name of the gRPC service.
+	ServiceName = "goworkers.Workers"
+	// DefaultStatsInterval is used by StreamStats if no interval is requested.
+	DefaultStatsInterval = time.Second
+	// A comfortable size for the buffered channel of every result subscriber
+	// such that chances for a slow receiver to miss updates are minute
+	subscriberChanSize = 100
+)
+
+// Handler executes a registered job with the JSON payload it was submitted with.
+type Handler func(payload json.RawMessage) (interface{}, error)
+
+// Server implements the goworkers.Workers gRPC service.
+type Server struct {
+	gw *goworkers.GoWorkers
+	// Authenticate identifies the principal of every call.
+	// If unspecified, calls are NOT authenticated: every call is accepted as
+	// the anonymous principal "", and a warning is logged as the service is
+	// registered.
+	Authenticate Authenticator
+	// Authorize decides which jobs a principal may submit.
+	// If unspecified, every job may be submitted.
+	Authorize Authorizer
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	// principals of the result subscribers
+	subs   map[chan *structpb.Struct]string
+	lastID uint64
+}
+
+// New creates a new server that submits jobs to gw.
+func New(gw *goworkers.GoWorkers) *Server {
+	return &Server{
+		gw:       gw,
+		handlers: make(map[string]Handler),
+		subs:     make(map[chan *structpb.Struct]string),
+	}
+}
+
+// Register makes the job name available for submission.
+func (s *Server) Register(name string, h Handler) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	s.handlers[name] = h
+}
+
+// RegisterService registers the service on gs. Set Authenticate before.
+func (s *Server) RegisterService(gs *grpc.Server) {
+	if s.Authenticate == nil {
+		auth.WarnUnauthenticated("grpcapi")
+	}
+	gs.RegisterService(&serviceDesc, s)
+}
+
+// Submit submits the job named in the request and returns its id.
+func (s *Server) Submit(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	principal, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := req.GetFields()["name"].GetStringValue()
+	if (s.Authorize != nil) && !s.Authorize(principal, name) {
+		return nil, status.Errorf(codes.PermissionDenied, "forbidden job: %s", name)
+	}
+
+	payload := json.RawMessage("null")
+	if v, ok := req.GetFields()["payload"]; ok {
+		b, err := protojson.Marshal(v)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid payload: %v", err)
+		}
+		payload = b
+	}
+
+	s.mu.Lock()
+	h, ok := s.handlers[name]
+	if !ok {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.NotFound, "unknown job: %s", name)
+	}
+	s.lastID++
+	id := strconv.FormatUint(s.lastID, 10)
+	s.mu.Unlock()
+
+	accepted := s.gw.TrySubmit(func() {
+		result, err := h(payload)
+		s.publish(principal, id, name, result, err)
+	})
+	if !accepted {
+		return nil, status.Error(codes.ResourceExhausted, "the pool is not accepting jobs")
+	}
+
+	return structpb.NewStruct(map[string]interface{}{"id": id})
+}
+
+// StreamResults streams the outcome of every job finished after the call
+// until the client goes away.
+func (s *Server) StreamResults(_ *emptypb.Empty, stream grpc.ServerStream) error {
+	principal, err := s.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	ch := make(chan *structpb.Struct, subscriberChanSize)
+	s.mu.Lock()
+	s.subs[ch] = principal
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, ch)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg := <-ch:
+			if err := stream.SendMsg(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// StreamStats streams the stats of the pool at the requested interval until
+// the client goes away.
+func (s *Server) StreamStats(req *durationpb.Duration, stream grpc.ServerStream) error {
+	if _, err := s.authenticate(stream.Context()); err != nil {
+		return err
+	}
+	interval := req.AsDuration()
+	if interval <= 0 {
+		interval = DefaultStatsInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		msg, err := structpb.NewStruct(map[string]interface{}{
+			"workers": float64(s.gw.WorkerNum()),
+			"jobs":    float64(s.gw.JobNum()),
+		})
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMsg(msg); err != nil {
+			return err
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) publish(principal, id, name string, result interface{}, err error) {
+	msg := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"id":   structpb.NewStringValue(id),
+		"name": structpb.NewStringValue(name),
+	}}
+	if err != nil {
+		msg.Fields["error"] = structpb.NewStringValue(err.Error())
+	} else if v, verr := toValue(result); verr != nil {
+		msg.Fields["error"] = structpb.NewStringValue("invalid result: " + verr.Error())
+	} else {
+		msg.Fields["result"] = v
+	}
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for ch, sub := range s.subs {
+		if sub != principal {
+			continue
+		}
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// toValue converts any JSON-encodable value to a protobuf value
+func toValue(v interface{}) (*structpb.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	pv := &structpb.Value{}
+	if err := protojson.Unmarshal(b, pv); err != nil {
+		return nil, err
+	}
+	return pv, nil
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Submit", Handler: submitHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "StreamResults", Handler: streamResultsHandler, ServerStreams: true},
+		{StreamName: "StreamStats", Handler: streamStatsHandler, ServerStreams: true},
+	},
+	Metadata: "goworkers.proto",
+}
+
+func submitHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).Submit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/Submit"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).Submit(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func streamResultsHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(emptypb.Empty)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(*Server).StreamResults(in, stream)
+}
+
+func streamStatsHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(durationpb.Duration)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(*Server).StreamStats(in, stream)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func dial(t *testing.T, s *Server) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	s.RegisterService(gs)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestSubmitAndStreamResults(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	s := New(gw)
+	s.Register("echo", func(payload json.RawMessage) (interface{}, error) {
+		var v map[string]interface{}
+		err := json.Unmarshal(payload, &v)
+		return v, err
+	})
+	s.Register("fail", func(payload json.RawMessage) (interface{}, error) {
+		return nil, fmt.Errorf("failed")
+	})
+	conn := dial(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &serviceDesc.Streams[0], "/"+ServiceName+"/StreamResults")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	// make sure that the subscription is in place before submitting
+	for {
+		s.mu.Lock()
+		n := len(s.subs)
+		s.mu.Unlock()
+		if n == 1 {
+			break
+		}
+	}
+
+	for _, name := range []string{"echo", "fail"} {
+		req, _ := structpb.NewStruct(map[string]interface{}{
+			"name":    name,
+			"payload": map[string]interface{}{"msg": "hello"},
+		})
+		resp := new(structpb.Struct)
+		if err := conn.Invoke(ctx, "/"+ServiceName+"/Submit", req, resp); err != nil {
+			t.Fatalf("Failed to submit: %v", err)
+		}
+		if resp.GetFields()["id"].GetStringValue() == "" {
+			t.Errorf("Expected a job id, Got %v", resp)
+		}
+	}
+
+	got := map[string]*structpb.Struct{}
+	for len(got) < 2 {
+		msg := new(structpb.Struct)
+		if err := stream.RecvMsg(msg); err != nil {
+			t.Fatalf("Failed to receive: %v", err)
+		}
+		got[msg.GetFields()["name"].GetStringValue()] = msg
+	}
+
+	echo := got["echo"].GetFields()["result"].GetStructValue().GetFields()["msg"].GetStringValue()
+	if echo != "hello" {
+		t.Errorf("Expected hello, Got %s", echo)
+	}
+	if e := got["fail"].GetFields()["error"].GetStringValue(); e != "failed" {
+		t.Errorf("Expected failed, Got %s", e)
+	}
+}
+
+func TestSubmitUnknownJob(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	conn := dial(t, New(gw))
+
+	req, _ := structpb.NewStruct(map[string]interface{}{"name": "unknown"})
+	err := conn.Invoke(context.Background(), "/"+ServiceName+"/Submit", req, new(structpb.Struct))
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected %v, Got %v", codes.NotFound, err)
+	}
+}
+
+func TestStreamStats(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	conn := dial(t, New(gw))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &serviceDesc.Streams[1], "/"+ServiceName+"/StreamStats")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	if err := stream.SendMsg(durationpb.New(10 * time.Millisecond)); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		msg := new(structpb.Struct)
+		if err := stream.RecvMsg(msg); err != nil {
+			t.Fatalf("Failed to receive: %v", err)
+		}
+		if _, ok := msg.GetFields()["workers"]; !ok {
+			t.Errorf("Expected workers in stats, Got %v", msg)
+		}
+	}
+}
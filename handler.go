@@ -0,0 +1,72 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Handler runs the jobs submitted to a pool with SubmitPayload() under the
+// name it is registered with, see Handle(). ctx is the context of the job.
+type Handler func(ctx context.Context, payload []byte) (interface{}, error)
+
+// handlers holds the handlers registered with a pool
+type handlers struct {
+	mu sync.RWMutex
+	m  map[string]Handler
+}
+
+// Handle registers fn as the handler of the jobs named name, such that jobs
+// are (name, payload) pairs, see SubmitPayload(). Unlike RegisterJobType(),
+// handlers are registered with a pool rather than with the process.
+//
+// As with http.ServeMux, registering a name twice or registering a nil
+// handler panics.
+func (gw *GoWorkers) Handle(name string, fn Handler) {
+	defer gw.handlers.mu.Unlock()
+	gw.handlers.mu.Lock()
+	if fn == nil {
+		panic("goworkers: Handle handler is nil")
+	}
+	if _, dup := gw.handlers.m[name]; dup {
+		panic("goworkers: Handle called twice for " + name)
+	}
+	if gw.handlers.m == nil {
+		gw.handlers.m = make(map[string]Handler)
+	}
+	gw.handlers.m[name] = fn
+}
+
+// SubmitPayload is a non-blocking call submitting a job run by the handler
+// registered as name, with payload.
+//
+// The job is named name unless named otherwise in its JobOptions.
+// Use ErrChan buffered channel to read error, if any.
+// Use ResultChan buffered channel to read output, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrUnknownJobType if no handler is registered as name, or
+// ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (gw *GoWorkers) SubmitPayload(name string, payload []byte, args ...JobOptions) error {
+	gw.handlers.mu.RLock()
+	fn, ok := gw.handlers.m[name]
+	gw.handlers.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJobType, name)
+	}
+
+	opts := jobOptions(args)
+	if opts.Name == "" {
+		opts.Name = name
+	}
+	return gw.submit(&task{
+		run: func(ctx context.Context) (interface{}, error) {
+			return fn(ctx, payload)
+		},
+		outputs: resultOutput,
+		opts:    opts,
+	})
+}
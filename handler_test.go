@@ -0,0 +1,56 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	gw.Handle("resize", func(ctx context.Context, payload []byte) (interface{}, error) {
+		if len(payload) == 0 {
+			return nil, errors.New("empty image")
+		}
+		info, _ := JobInfoFromContext(ctx)
+		return info.Name + " " + string(payload), nil
+	})
+
+	if err := gw.SubmitPayload("resize", []byte("cat.png")); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	if res := <-gw.ResultChan; res != "resize cat.png" {
+		t.Errorf("Expected %q, Got %v", "resize cat.png", res)
+	}
+
+	// the job is named after its handler
+	var je *JobError
+	gw.SubmitPayload("resize", nil)
+	if err := <-gw.ErrChan; !errors.As(err, &je) || je.Name != "resize" {
+		t.Errorf("Expected the error of the resize job, Got %v", err)
+	}
+
+	if err := gw.SubmitPayload("crop", nil); !errors.Is(err, ErrUnknownJobType) {
+		t.Errorf("Expected %v, Got %v", ErrUnknownJobType, err)
+	}
+}
+
+func TestHandleTwice(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	fn := func(context.Context, []byte) (interface{}, error) { return nil, nil }
+	gw.Handle("noop", fn)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic")
+		}
+	}()
+	gw.Handle("noop", fn)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of HealthOptions, unless specified.
+const (
+	defaultSaturation    = 0.9
+	defaultSaturatedFor  = 30 * time.Second
+	defaultStallAfter    = time.Minute
+	defaultRestartWindow = time.Minute
+)
+
+var (
+	// ErrSaturated is returned by Healthy() when the queue has stayed
+	// saturated for too long.
+	ErrSaturated = errors.New("goworkers: queue saturated")
+	// ErrStalled is returned by Healthy() when the pool has jobs but none
+	// finished for too long.
+	ErrStalled = errors.New("goworkers: no job completed")
+	// ErrRestarting is returned by Healthy() when too many workers
+	// recovered from a panicking job lately.
+	ErrRestarting = errors.New("goworkers: workers restarting")
+)
+
+// HealthOptions configures the conditions under which a pool is unhealthy.
+//
+// Saturation is the fraction of the queue size, above which the queue is
+// considered saturated. If unspecified or zero, 0.9 is used.
+//
+// SaturatedFor is how long the queue may stay saturated.
+// If unspecified or zero, 30 seconds is used.
+//
+// StallAfter is how long a pool that has jobs may go without completing one.
+// If unspecified or zero, 1 minute is used.
+//
+// MaxRestarts is how many times the workers may recover from a panicking job
+// within the last RestartWindow, see Stats.Restarts. If unspecified or zero,
+// restarts are not checked.
+//
+// RestartWindow is the window MaxRestarts applies to, rounded up to 10
+// seconds and capped at 15 minutes, as with RollingStats().
+// If unspecified or zero, 1 minute is used.
+type HealthOptions struct {
+	Saturation    float64
+	SaturatedFor  time.Duration
+	StallAfter    time.Duration
+	MaxRestarts   uint32
+	RestartWindow time.Duration
+}
+
+// Healthy returns nil if the pool is healthy, else an error wrapping
+// ErrSaturated, ErrStalled or ErrRestarting describing why not. Wire it into a health
+// endpoint so that load balancers stop sending traffic before things melt.
+//
+// Saturation is observed when Healthy() is called, hence the queue is
+// considered saturated for as long as successive calls find it saturated.
+//
+// Accepts optional HealthOptions{} argument.
+func (gw *GoWorkers) Healthy(args ...HealthOptions) error {
+	var opts HealthOptions
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if opts.Saturation <= 0 {
+		opts.Saturation = defaultSaturation
+	}
+	if opts.SaturatedFor <= 0 {
+		opts.SaturatedFor = defaultSaturatedFor
+	}
+	if opts.StallAfter <= 0 {
+		opts.StallAfter = defaultStallAfter
+	}
+	if opts.RestartWindow <= 0 {
+		opts.RestartWindow = defaultRestartWindow
+	}
+
+	now := gw.clock.Now()
+
+	queued, size := gw.queued(), gw.qsize()
+	if (queued == 0) || (float64(queued) < opts.Saturation*float64(size)) {
+		atomic.StoreInt64(&gw.saturatedSince, 0)
+	} else {
+		atomic.CompareAndSwapInt64(&gw.saturatedSince, 0, now.UnixNano())
+		since := time.Unix(0, atomic.LoadInt64(&gw.saturatedSince))
+		if d := now.Sub(since); d >= opts.SaturatedFor {
+			return fmt.Errorf("%w: %d of %d queued for %s", ErrSaturated, queued, size, d.Round(time.Millisecond))
+		}
+	}
+
+	if gw.JobNum() != 0 {
+		last := time.Unix(0, atomic.LoadInt64(&gw.lastProgress))
+		if d := now.Sub(last); d >= opts.StallAfter {
+			return fmt.Errorf("%w: %d jobs, last progress %s ago", ErrStalled, gw.JobNum(), d.Round(time.Millisecond))
+		}
+	}
+
+	if opts.MaxRestarts != 0 {
+		n := int64((opts.RestartWindow + rollingBucket - 1) / rollingBucket)
+		if n > rollingBuckets {
+			n = rollingBuckets
+		}
+		if st := gw.rolling.window(now, n); st.Restarts > opts.MaxRestarts {
+			return fmt.Errorf("%w: %d restarts within %s", ErrRestarting, st.Restarts, st.Window)
+		}
+	}
+
+	return nil
+}
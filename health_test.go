@@ -0,0 +1,100 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthyIdle(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	if err := gw.Healthy(HealthOptions{StallAfter: time.Nanosecond}); err != nil {
+		t.Errorf("Expected an idle pool to be healthy, Got %v", err)
+	}
+}
+
+func TestHealthyStalled(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	gw := New(Options{Workers: 1, Clock: clock})
+
+	release := make(chan struct{})
+	gw.Submit(func() { <-release })
+
+	opts := HealthOptions{StallAfter: time.Minute}
+	if err := gw.Healthy(opts); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	if err := gw.Healthy(opts); !errors.Is(err, ErrStalled) {
+		t.Errorf("Expected %v, Got %v", ErrStalled, err)
+	}
+
+	close(release)
+	gw.Stop(false)
+}
+
+func TestHealthySaturated(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	gw := New(Options{Workers: 1, Clock: clock})
+
+	release := make(chan struct{})
+	for i := 0; i < defaultQSize+1; i++ {
+		gw.Submit(func() { <-release })
+	}
+	for gw.queued() != defaultQSize {
+	}
+
+	opts := HealthOptions{SaturatedFor: 30 * time.Second, StallAfter: time.Hour}
+	if err := gw.Healthy(opts); err != nil {
+		t.Errorf("Expected a freshly saturated pool to be healthy, Got %v", err)
+	}
+
+	clock.Advance(30 * time.Second)
+	if err := gw.Healthy(opts); !errors.Is(err, ErrSaturated) {
+		t.Errorf("Expected %v, Got %v", ErrSaturated, err)
+	}
+
+	close(release)
+	for gw.JobNum() != 0 {
+	}
+	if err := gw.Healthy(opts); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	gw.Stop(false)
+}
+
+func TestHealthyRestarting(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1000, 0))
+	gw := New(Options{Workers: 1, Clock: clock, PanicHandler: func(interface{}, []byte) {}})
+	defer gw.Stop(false)
+
+	for i := 0; i < 3; i++ {
+		gw.Submit(func() { panic("corrupted") })
+	}
+	gw.Wait(false)
+	if n := gw.Stats().Restarts; n != 3 {
+		t.Errorf("Expected 3, Got %d", n)
+	}
+
+	opts := HealthOptions{MaxRestarts: 2, RestartWindow: time.Minute}
+	if err := gw.Healthy(opts); !errors.Is(err, ErrRestarting) {
+		t.Errorf("Expected %v, Got %v", ErrRestarting, err)
+	}
+	if err := gw.Healthy(HealthOptions{MaxRestarts: 3}); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	// the restarts age out of the window
+	clock.Advance(time.Minute)
+	if err := gw.Healthy(opts); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+}
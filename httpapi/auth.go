@@ -0,0 +1,76 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dpaks/goworkers/internal/auth"
+)
+
+// ErrUnauthenticated is returned by an Authenticator that cannot identify
+// the caller of a request.
+var ErrUnauthenticated = errors.New("httpapi: unauthenticated")
+
+// Authenticator identifies the principal making a request.
+// Returns ErrUnauthenticated, or another error, if it cannot.
+type Authenticator func(r *http.Request) (string, error)
+
+// Authorizer reports whether a principal may submit the job of a name.
+type Authorizer func(principal, job string) bool
+
+// TokenAuth authenticates the requests bearing a token of tokens, which maps
+// tokens to principals, in an "Authorization: Bearer <token>" header.
+func TokenAuth(tokens map[string]string) Authenticator {
+	return func(r *http.Request) (string, error) {
+		token, ok := auth.Bearer(r.Header.Get("Authorization"))
+		if !ok {
+			return "", ErrUnauthenticated
+		}
+		principal, ok := auth.LookupToken(tokens, token)
+		if !ok {
+			return "", ErrUnauthenticated
+		}
+		return principal, nil
+	}
+}
+
+// MTLSAuth authenticates the requests made over TLS with a verified client
+// certificate, whose subject common name is the principal. The server must
+// verify client certificates, e.g. with tls.RequireAndVerifyClientCert.
+func MTLSAuth() Authenticator {
+	return func(r *http.Request) (string, error) {
+		principal, ok := auth.PeerName(r.TLS)
+		if !ok {
+			return "", ErrUnauthenticated
+		}
+		return principal, nil
+	}
+}
+
+// AllowJobs authorizes the principals to submit the jobs granted to them by
+// grants, which maps principals to job names. The name "*" grants every job.
+func AllowJobs(grants map[string][]string) Authorizer {
+	return func(principal, job string) bool {
+		return auth.Allowed(grants, principal, job)
+	}
+}
+
+// authenticate returns the principal making r, if authenticated, else it
+// replies with an error
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if s.Authenticate == nil {
+		s.warnOpen.Do(func() { auth.WarnUnauthenticated("httpapi") })
+		return "", true
+	}
+	principal, err := s.Authenticate(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return "", false
+	}
+	return principal, true
+}
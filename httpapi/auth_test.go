@@ -0,0 +1,111 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package httpapi
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dpaks/goworkers"
+)
+
+func authRequest(s *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTokenAuth(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	s := New(gw)
+	s.Authenticate = TokenAuth(map[string]string{"b-token": "billing", "r-token": "reports"})
+	s.Authorize = AllowJobs(map[string][]string{"billing": {"invoice"}, "reports": {"*"}})
+	for _, name := range []string{"invoice", "report"} {
+		s.Register(name, func(payload json.RawMessage) (interface{}, error) { return "ok", nil })
+	}
+
+	tables := []struct {
+		token, job string
+		code       int
+	}{
+		{"", "invoice", http.StatusUnauthorized},
+		{"wrong", "invoice", http.StatusUnauthorized},
+		{"b-token", "report", http.StatusForbidden},
+		{"b-token", "invoice", http.StatusAccepted},
+		{"r-token", "invoice", http.StatusAccepted},
+	}
+	for _, table := range tables {
+		rec := authRequest(s, http.MethodPost, "/jobs", table.token, `{"name": "`+table.job+`"}`)
+		if rec.Code != table.code {
+			t.Errorf("%s %s: Expected %d, Got %d", table.token, table.job, table.code, rec.Code)
+		}
+		if table.code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("Expected a Bearer challenge, Got %q", rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	// the jobs of a principal are hidden from the others
+	rec := authRequest(s, http.MethodPost, "/jobs", "b-token", `{"name": "invoice"}`)
+	var job Job
+	if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if rec := authRequest(s, http.MethodGet, "/jobs/"+job.ID, "r-token", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected %d, Got %d", http.StatusNotFound, rec.Code)
+	}
+	if rec := authRequest(s, http.MethodGet, "/jobs/"+job.ID, "b-token", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected %d, Got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestMTLSAuth(t *testing.T) {
+	authenticate := MTLSAuth()
+	req := httptest.NewRequest(http.MethodGet, "/jobs/1", nil)
+
+	if _, err := authenticate(req); err != ErrUnauthenticated {
+		t.Errorf("Expected %v, Got %v", ErrUnauthenticated, err)
+	}
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	if principal, err := authenticate(req); err != nil || principal != "billing" {
+		t.Errorf("Expected billing, Got %q and %v", principal, err)
+	}
+}
+
+func TestNoAuthWarning(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	s := New(gw)
+
+	for i := 0; i < 2; i++ {
+		if rec := authRequest(s, http.MethodGet, "/jobs/1", "", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected %d, Got %d", http.StatusNotFound, rec.Code)
+		}
+	}
+	// warned once only
+	if n := strings.Count(buf.String(), "WARNING"); n != 1 {
+		t.Errorf("Expected 1 warning, Got %d in %q", n, buf.String())
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package httpapi implements an HTTP server component that lets non-Go
+// components submit registered jobs to a goworkers pool.
+//
+// The following endpoints are served:
+//
+//	POST /jobs               submit a job, body: {"name": "...", "payload": {...}}
+//	GET  /jobs/{id}          query the status of a job, including its result
+//	GET  /jobs/{id}/result   fetch the result of a finished job
+//
+// Requests are authenticated with Server.Authenticate, e.g. TokenAuth() or
+// MTLSAuth(), and the submissions authorized with Server.Authorize, e.g.
+// AllowJobs(). The jobs of a principal are visible to that principal only.
+//
+// A Server without Authenticate is open: anyone who can reach it may submit
+// jobs. Set Authenticate unless only trusted callers can reach the server.
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// DefaultRetention is how long the outcome of a finished job is retained,
+// unless specified.
+const DefaultRetention = 10 * time.Minute
+
+// Status of a submitted job.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Handler executes a registered job with the JSON payload it was submitted with.
+type Handler func(payload json.RawMessage) (interface{}, error)
+
+// Job is the JSON representation of a submitted job.
+type Job struct {
+	ID     string      `json:"id"`
+	Name   string      `json:"name"`
+	Status string      `json:"status"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+
+	principal string
+	finished  time.Time
+}
+
+type request struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Server is an http.Handler accepting submissions of registered jobs.
+type Server struct {
+	gw *goworkers.GoWorkers
+	// Retention is how long the outcome of a finished job is retained.
+	// If unspecified or zero, DefaultRetention is used.
+	Retention time.Duration
+	// Authenticate identifies the principal of every request.
+	// If unspecified, requests are NOT authenticated: every request is
+	// accepted as the anonymous principal "", and a warning is logged as
+	// the first one is served.
+	Authenticate Authenticator
+	// Authorize decides which jobs a principal may submit.
+	// If unspecified, every job may be submitted.
+	Authorize Authorizer
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	jobs     map[string]*Job
+	lastID   uint64
+	// warns of a server without Authenticate
+	warnOpen sync.Once
+}
+
+// New creates a new server that submits jobs to gw.
+func New(gw *goworkers.GoWorkers) *Server {
+	return &Server{
+		gw:       gw,
+		handlers: make(map[string]Handler),
+		jobs:     make(map[string]*Job),
+	}
+}
+
+// Register makes the job name available for submission.
+func (s *Server) Register(name string, h Handler) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	s.handlers[name] = h
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "jobs" {
+		http.NotFound(w, r)
+		return
+	}
+
+	principal, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		s.submit(w, r, principal)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.status(w, principal, parts[1])
+	case len(parts) == 3 && parts[2] == "result" && r.Method == http.MethodGet:
+		s.result(w, principal, parts[1])
+	case len(parts) <= 3:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) submit(w http.ResponseWriter, r *http.Request, principal string) {
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if (s.Authorize != nil) && !s.Authorize(principal, req.Name) {
+		http.Error(w, "forbidden job: "+req.Name, http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	h, ok := s.handlers[req.Name]
+	if !ok {
+		s.mu.Unlock()
+		http.Error(w, "unknown job: "+req.Name, http.StatusNotFound)
+		return
+	}
+	s.expire()
+	s.lastID++
+	job := &Job{ID: strconv.FormatUint(s.lastID, 10), Name: req.Name, Status: StatusQueued, principal: principal}
+	s.jobs[job.ID] = job
+	s.mu.Unlock()
+
+	accepted := s.gw.TrySubmit(func() {
+		s.setStatus(job, StatusRunning, nil, nil)
+		result, err := h(req.Payload)
+		if err != nil {
+			s.setStatus(job, StatusFailed, nil, err)
+			return
+		}
+		s.setStatus(job, StatusSucceeded, result, nil)
+	})
+	if !accepted {
+		s.mu.Lock()
+		delete(s.jobs, job.ID)
+		s.mu.Unlock()
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, s.snapshot(job))
+}
+
+func (s *Server) status(w http.ResponseWriter, principal, id string) {
+	job, ok := s.lookup(principal, id)
+	if !ok {
+		http.Error(w, "unknown job id: "+id, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) result(w http.ResponseWriter, principal, id string) {
+	job, ok := s.lookup(principal, id)
+	if !ok {
+		http.Error(w, "unknown job id: "+id, http.StatusNotFound)
+		return
+	}
+
+	switch job.Status {
+	case StatusSucceeded:
+		writeJSON(w, http.StatusOK, job.Result)
+	case StatusFailed:
+		http.Error(w, job.Error, http.StatusUnprocessableEntity)
+	default:
+		// not finished yet, try again later
+		writeJSON(w, http.StatusAccepted, job)
+	}
+}
+
+func (s *Server) setStatus(job *Job, status string, result interface{}, err error) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	job.Status = status
+	job.Result = result
+	if err != nil {
+		job.Error = err.Error()
+	}
+	if status == StatusSucceeded || status == StatusFailed {
+		job.finished = time.Now()
+	}
+}
+
+// lookup returns the job of the id, if submitted by principal
+func (s *Server) lookup(principal, id string) (Job, bool) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	s.expire()
+	job, ok := s.jobs[id]
+	if !ok || (job.principal != principal) {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func (s *Server) snapshot(job *Job) Job {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	return *job
+}
+
+// expire must be called with mu held
+func (s *Server) expire() {
+	retention := s.Retention
+	if retention == 0 {
+		retention = DefaultRetention
+	}
+	for id, job := range s.jobs {
+		if !job.finished.IsZero() && time.Since(job.finished) > retention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dpaks/goworkers"
+)
+
+func submit(t *testing.T, s *Server, body string) (int, Job) {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body)))
+
+	var job Job
+	if rec.Code == http.StatusAccepted {
+		if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+			t.Fatalf("Invalid response: %v", err)
+		}
+	}
+	return rec.Code, job
+}
+
+func get(s *Server, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestSubmitAndFetch(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	s := New(gw)
+	s.Register("sum", func(payload json.RawMessage) (interface{}, error) {
+		var nums []int
+		if err := json.Unmarshal(payload, &nums); err != nil {
+			return nil, err
+		}
+		total := 0
+		for _, n := range nums {
+			total += n
+		}
+		return total, nil
+	})
+
+	code, job := submit(t, s, `{"name": "sum", "payload": [1, 2, 3]}`)
+	if code != http.StatusAccepted {
+		t.Fatalf("Expected %d, Got %d", http.StatusAccepted, code)
+	}
+
+	gw.Wait(false)
+
+	rec := get(s, "/jobs/"+job.ID)
+	if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if job.Status != StatusSucceeded {
+		t.Errorf("Expected %s, Got %s", StatusSucceeded, job.Status)
+	}
+
+	rec = get(s, "/jobs/"+job.ID+"/result")
+	if got := strings.TrimSpace(rec.Body.String()); rec.Code != http.StatusOK || got != "6" {
+		t.Errorf("Expected 200 and 6, Got %d and %s", rec.Code, got)
+	}
+}
+
+func TestSubmitFailure(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	s := New(gw)
+	s.Register("fail", func(payload json.RawMessage) (interface{}, error) {
+		return nil, fmt.Errorf("bad payload")
+	})
+
+	_, job := submit(t, s, `{"name": "fail"}`)
+	gw.Wait(false)
+
+	rec := get(s, "/jobs/"+job.ID+"/result")
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "bad payload") {
+		t.Errorf("Expected %d with the job's error, Got %d: %s", http.StatusUnprocessableEntity, rec.Code, rec.Body)
+	}
+}
+
+func TestSubmitErrors(t *testing.T) {
+	gw := goworkers.New()
+	s := New(gw)
+	s.Register("noop", func(payload json.RawMessage) (interface{}, error) { return nil, nil })
+
+	if code, _ := submit(t, s, `{`); code != http.StatusBadRequest {
+		t.Errorf("Expected %d, Got %d", http.StatusBadRequest, code)
+	}
+	if code, _ := submit(t, s, `{"name": "unknown"}`); code != http.StatusNotFound {
+		t.Errorf("Expected %d, Got %d", http.StatusNotFound, code)
+	}
+	if rec := get(s, "/jobs/42"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected %d, Got %d", http.StatusNotFound, rec.Code)
+	}
+
+	gw.Stop(false)
+	if code, _ := submit(t, s, `{"name": "noop"}`); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected %d, Got %d", http.StatusServiceUnavailable, code)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"fmt"
+	"sync"
+)
+
+// IdempotencyStore records the results of the jobs that succeeded by their
+// idempotency key, see Options.Idempotency and JobOptions.Key.
+//
+// Implementations backed by a shared database make the deduplication span
+// processes, e.g. for jobs re-delivered to another consumer.
+type IdempotencyStore interface {
+	// Get returns the recorded result of key, and whether there is one.
+	Get(key string) (result interface{}, ok bool, err error)
+	// Put records the result of key.
+	Put(key string, result interface{}) error
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore held in memory.
+//
+// It is not durable and only deduplicates the jobs of one process.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	results map[string]interface{}
+}
+
+// NewMemoryIdempotencyStore creates a new in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{results: make(map[string]interface{})}
+}
+
+// Get returns the recorded result of key, and whether there is one.
+func (m *MemoryIdempotencyStore) Get(key string) (interface{}, bool, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	result, ok := m.results[key]
+	return result, ok, nil
+}
+
+// Put records the result of key.
+func (m *MemoryIdempotencyStore) Put(key string, result interface{}) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	m.results[key] = result
+	return nil
+}
+
+// idempotency deduplicates the jobs of a pool by key
+type idempotency struct {
+	store IdempotencyStore
+
+	mu       sync.Mutex
+	inflight map[string]chan struct{}
+}
+
+func newIdempotency(store IdempotencyStore) *idempotency {
+	if store == nil {
+		return nil
+	}
+	return &idempotency{store: store, inflight: make(map[string]chan struct{})}
+}
+
+// run runs job unless a result is recorded for key, in which case the
+// recorded result is returned instead. Jobs of the same key run one at a
+// time, so that a duplicate of a running job waits for its result.
+func (i *idempotency) run(key string, job func() (interface{}, error)) (interface{}, error) {
+	for {
+		i.mu.Lock()
+		running, ok := i.inflight[key]
+		if !ok {
+			running = make(chan struct{})
+			i.inflight[key] = running
+		}
+		i.mu.Unlock()
+		if !ok {
+			break
+		}
+		<-running
+	}
+	defer func() {
+		i.mu.Lock()
+		close(i.inflight[key])
+		delete(i.inflight, key)
+		i.mu.Unlock()
+	}()
+
+	result, ok, err := i.store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("goworkers: looking up idempotency key %s: %w", key, err)
+	}
+	if ok {
+		return result, nil
+	}
+
+	result, err = job()
+	if err != nil {
+		return nil, err
+	}
+	if err := i.store.Put(key, result); err != nil {
+		return nil, fmt.Errorf("goworkers: recording idempotency key %s: %w", key, err)
+	}
+	return result, nil
+}
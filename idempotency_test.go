@@ -0,0 +1,153 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	gw := New(Options{Workers: 4, Idempotency: NewMemoryIdempotencyStore()})
+
+	var runs int32
+	job := func() (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		return atomic.AddInt32(&runs, 1), nil
+	}
+	for i := 0; i < 5; i++ {
+		gw.SubmitCheckResult(job, JobOptions{Key: "payment-1"})
+	}
+	gw.SubmitCheckResult(job, JobOptions{Key: "payment-2"})
+	gw.SubmitCheckResult(job)
+	gw.Wait(false)
+
+	if n := atomic.LoadInt32(&runs); n != 3 {
+		t.Errorf("Expected 3 runs, Got %d", n)
+	}
+	if len(gw.ResultChan) != 7 {
+		t.Errorf("Expected 7 results, Got %d", len(gw.ResultChan))
+	}
+
+	gw.Stop(false)
+}
+
+func TestIdempotencyOnFinish(t *testing.T) {
+	gw := New(Options{Workers: 1, Idempotency: NewMemoryIdempotencyStore()})
+	defer gw.Stop(false)
+
+	var runs int32
+	finished := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		gw.Submit(func() { atomic.AddInt32(&runs, 1) }, JobOptions{
+			Key:      "payment-1",
+			OnFinish: func(error) { finished <- struct{}{} },
+		})
+	}
+	// the duplicate does not run but finishes all the same
+	for i := 0; i < 2; i++ {
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected OnFinish to be called for every job")
+		}
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("Expected 1 run, Got %d", n)
+	}
+}
+
+func TestIdempotencyRetriesFailedJobs(t *testing.T) {
+	gw := New(Options{Workers: 1, Idempotency: NewMemoryIdempotencyStore()})
+
+	tables := []struct {
+		err  error
+		want interface{}
+	}{
+		{errors.New("declined"), nil},
+		{nil, "paid"},
+		{errors.New("not run"), "paid"},
+	}
+
+	for _, table := range tables {
+		err := table.err
+		gw.SubmitCheckResult(func() (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+			return "paid", nil
+		}, JobOptions{Key: "payment"})
+
+		select {
+		case res := <-gw.ResultChan:
+			if res != table.want {
+				t.Errorf("Expected %v, Got %v", table.want, res)
+			}
+		case err := <-gw.ErrChan:
+			if table.want != nil {
+				t.Errorf("Expected %v, Got %v", table.want, err)
+			}
+		}
+	}
+
+	gw.Stop(false)
+}
+
+type failingIdempotencyStore struct{}
+
+var errUnavailable = errors.New("unavailable")
+
+func (failingIdempotencyStore) Get(key string) (interface{}, bool, error) {
+	return nil, false, errUnavailable
+}
+
+func (failingIdempotencyStore) Put(key string, result interface{}) error {
+	return errUnavailable
+}
+
+func TestIdempotencyStoreError(t *testing.T) {
+	gw := New(Options{Idempotency: failingIdempotencyStore{}})
+
+	ran := false
+	gw.SubmitCheckError(func() error { ran = true; return nil }, JobOptions{Key: "payment"})
+	if err := <-gw.ErrChan; !errors.Is(err, errUnavailable) {
+		t.Errorf("Expected %v, Got %v", errUnavailable, err)
+	}
+	gw.Stop(false)
+
+	if ran {
+		t.Errorf("Expected the job not to run")
+	}
+}
+
+func TestConsumeStoreIdempotency(t *testing.T) {
+	s := NewMemoryStore()
+	for i := 0; i < 3; i++ {
+		enqueue(t, s, Envelope{Name: "noop", Key: "once"})
+	}
+
+	gw := New(Options{Idempotency: NewMemoryIdempotencyStore()})
+	defer gw.Stop(false)
+
+	atomic.StoreInt32(&noopRuns, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- gw.ConsumeStore(ctx, s, StoreOptions{PollInterval: time.Millisecond})
+	}()
+
+	for s.Len() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if n := atomic.LoadInt32(&noopRuns); n != 1 {
+		t.Errorf("Expected 1 run, Got %d", n)
+	}
+}
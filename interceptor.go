@@ -0,0 +1,72 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"sync"
+)
+
+// JobInfo describes the job an Interceptor is running.
+type JobInfo struct {
+	Name     string
+	Metadata map[string]string
+	Tenant   string
+	Key      string
+	Tags     []string
+}
+
+// Interceptor wraps the run of every job of a pool, see Use(). It must call
+// next to run the job, unless it skips it, e.g. as it is not authorised,
+// and returns the error of the job, which it may replace. ctx is the context
+// of the job, and the context passed to next is the one the job sees.
+type Interceptor func(ctx context.Context, job JobInfo, next func(ctx context.Context) error) error
+
+// interceptors holds the interceptors of a pool
+type interceptors struct {
+	mu    sync.RWMutex
+	chain []Interceptor
+}
+
+// Use appends the interceptors to the chain wrapping the run of every job
+// of the pool, the first one outermost, e.g. for logging, metrics, tracing
+// or fault injection without changing the submissions.
+//
+// The error returned by the chain is the error of the job. A job skipped by
+// an interceptor has no result.
+func (gw *GoWorkers) Use(interceptor ...Interceptor) {
+	defer gw.interceptors.mu.Unlock()
+	gw.interceptors.mu.Lock()
+	gw.interceptors.chain = append(gw.interceptors.chain, interceptor...)
+}
+
+// intercept returns run wrapped in the interceptors of the pool. The job
+// runs with the context passed on by the innermost interceptor.
+func (gw *GoWorkers) intercept(t *task, run func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	gw.interceptors.mu.RLock()
+	chain := gw.interceptors.chain
+	gw.interceptors.mu.RUnlock()
+	if len(chain) == 0 {
+		return run
+	}
+
+	info := t.info()
+	return func(ctx context.Context) (interface{}, error) {
+		var result interface{}
+		next := func(ctx context.Context) error {
+			var err error
+			result, err = run(ctx)
+			return err
+		}
+		for i := len(chain) - 1; i >= 0; i-- {
+			interceptor, inner := chain[i], next
+			next = func(ctx context.Context) error {
+				return interceptor(ctx, info, inner)
+			}
+		}
+		err := next(ctx)
+		return result, err
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUse(t *testing.T) {
+	gw := New()
+
+	var mu sync.Mutex
+	var trace []string
+	log := func(s string) {
+		mu.Lock()
+		trace = append(trace, s)
+		mu.Unlock()
+	}
+	errDenied := errors.New("denied")
+	gw.Use(
+		func(ctx context.Context, job JobInfo, next func(context.Context) error) error {
+			log("outer " + job.Name)
+			err := next(ctx)
+			log("outer done")
+			return err
+		},
+		func(ctx context.Context, job JobInfo, next func(context.Context) error) error {
+			if job.Metadata["role"] != "admin" {
+				return errDenied
+			}
+			log("inner")
+			return next(ctx)
+		},
+	)
+
+	_ = gw.SubmitCheckResult(func() (interface{}, error) {
+		log("job")
+		return 42, nil
+	}, JobOptions{Name: "purge", Metadata: map[string]string{"role": "admin"}})
+	if res, _ := (<-gw.ResultChan).(JobResult); res.Value != 42 {
+		t.Errorf("Expected %v, Got %v", 42, res.Value)
+	}
+	if s := strings.Join(trace, ", "); s != "outer purge, inner, job, outer done" {
+		t.Errorf("Expected the interceptors to wrap the job in order, Got %v", s)
+	}
+
+	ran := false
+	_ = gw.SubmitCheckError(func() error {
+		ran = true
+		return nil
+	}, JobOptions{Name: "purge"})
+	if err := <-gw.ErrChan; !errors.Is(err, errDenied) {
+		t.Errorf("Expected %v, Got %v", errDenied, err)
+	}
+	gw.Stop(false)
+	if ran {
+		t.Errorf("Expected the job to be skipped")
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package auth holds the authentication and authorization helpers shared by
+// the remote submission components.
+package auth
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"log"
+	"strings"
+)
+
+// Wildcard grants a principal every job type.
+const Wildcard = "*"
+
+// WarnUnauthenticated logs that the server of component accepts every
+// caller, as it has no authenticator.
+func WarnUnauthenticated(component string) {
+	log.Printf("%s: WARNING: no Authenticate is set, every caller is accepted as the anonymous principal", component)
+}
+
+// Bearer extracts the token of an Authorization header value of the Bearer
+// scheme.
+func Bearer(header string) (string, bool) {
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
+}
+
+// LookupToken returns the principal of token in tokens, mapping tokens to
+// principals. Every token is compared in constant time, such that the
+// timing of a lookup does not leak the tokens.
+func LookupToken(tokens map[string]string, token string) (string, bool) {
+	var principal string
+	found := 0
+	for t, p := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			principal = p
+			found = 1
+		}
+	}
+	return principal, found == 1
+}
+
+// PeerName returns the common name of the verified client certificate of a
+// TLS connection, if any.
+func PeerName(state *tls.ConnectionState) (string, bool) {
+	if (state == nil) || (len(state.VerifiedChains) == 0) || (len(state.VerifiedChains[0]) == 0) {
+		return "", false
+	}
+	name := state.VerifiedChains[0][0].Subject.CommonName
+	return name, name != ""
+}
+
+// Allowed reports whether grants, mapping principals to job types, allow
+// principal to submit job.
+func Allowed(grants map[string][]string, principal, job string) bool {
+	for _, granted := range grants[principal] {
+		if (granted == Wildcard) || (granted == job) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+)
+
+func TestBearer(t *testing.T) {
+	tables := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"Bearer abc", "abc", true},
+		{"bearer  abc ", "abc", true},
+		{"Basic abc", "", false},
+		{"Bearer ", "", false},
+		{"", "", false},
+	}
+
+	for _, table := range tables {
+		token, ok := Bearer(table.header)
+		if token != table.token || ok != table.ok {
+			t.Errorf("%q: Expected %q %v, Got %q %v", table.header, table.token, table.ok, token, ok)
+		}
+	}
+}
+
+func TestLookupToken(t *testing.T) {
+	tokens := map[string]string{"s3cret": "billing", "t0ken": "reports"}
+
+	if p, ok := LookupToken(tokens, "t0ken"); !ok || p != "reports" {
+		t.Errorf("Expected reports, Got %q %v", p, ok)
+	}
+	for _, token := range []string{"", "t0ke", "t0kenn"} {
+		if p, ok := LookupToken(tokens, token); ok {
+			t.Errorf("%q: Expected no principal, Got %q", token, p)
+		}
+	}
+}
+
+func TestPeerName(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}
+	tables := []struct {
+		state *tls.ConnectionState
+		name  string
+		ok    bool
+	}{
+		{&tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}, "billing", true},
+		{&tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, "", false},
+		{nil, "", false},
+	}
+
+	for _, table := range tables {
+		name, ok := PeerName(table.state)
+		if name != table.name || ok != table.ok {
+			t.Errorf("Expected %q %v, Got %q %v", table.name, table.ok, name, ok)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	grants := map[string][]string{"billing": {"invoice", "refund"}, "admin": {Wildcard}}
+	tables := []struct {
+		principal, job string
+		allowed        bool
+	}{
+		{"billing", "invoice", true},
+		{"billing", "resize", false},
+		{"admin", "resize", true},
+		{"reports", "invoice", false},
+	}
+
+	for _, table := range tables {
+		if allowed := Allowed(grants, table.principal, table.job); allowed != table.allowed {
+			t.Errorf("%s %s: Expected %v, Got %v", table.principal, table.job, table.allowed, allowed)
+		}
+	}
+}
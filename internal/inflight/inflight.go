@@ -0,0 +1,93 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package inflight bounds the number of jobs that source adapters hand over
+// to a pool but which have not finished yet.
+package inflight
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dpaks/goworkers"
+)
+
+// defaultSize is used for pools that have neither a maximum number of workers
+// nor a queue
+const defaultSize = 64
+
+// Size returns the default number of in-flight jobs for gw: its maximum
+// number of workers, or else the size of its queue if it spawns workers as
+// per demand, such that the messages held back by an adapter are the ones
+// the pool can run or queue right away.
+func Size(gw *goworkers.GoWorkers) uint32 {
+	if n := gw.MaxWorkers(); n != 0 {
+		return n
+	}
+	if n := gw.QueueCap(); n != 0 {
+		return n
+	}
+	return defaultSize
+}
+
+// Limiter bounds the number of in-flight jobs.
+type Limiter struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// New creates a new limiter allowing up to n in-flight jobs.
+func New(n uint32) *Limiter {
+	return &Limiter{slots: make(chan struct{}, n)}
+}
+
+// Acquire blocks until at least one slot is free and then acquires all the
+// free slots. It returns the number of slots acquired.
+func (l *Limiter) Acquire(ctx context.Context) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case l.slots <- struct{}{}:
+	}
+	n := 1
+	for len(l.slots) < cap(l.slots) {
+		select {
+		case l.slots <- struct{}{}:
+			n++
+		default:
+			return n, nil
+		}
+	}
+	return n, nil
+}
+
+// Release frees n slots.
+func (l *Limiter) Release(n int) {
+	for i := 0; i < n; i++ {
+		<-l.slots
+	}
+}
+
+// Submit submits job to gw holding an acquired slot, which is freed once the
+// job finishes, even if it panics or is skipped without running. The slot is
+// freed right away if gw rejects the job, and false is returned.
+func (l *Limiter) Submit(gw *goworkers.GoWorkers, job func()) bool {
+	l.wg.Add(1)
+	accepted := gw.TrySubmit(job, goworkers.JobOptions{
+		OnFinish: func(error) {
+			l.Release(1)
+			l.wg.Done()
+		},
+	})
+	if !accepted {
+		l.wg.Done()
+		l.Release(1)
+	}
+	return accepted
+}
+
+// Wait waits for the in-flight jobs to finish.
+func (l *Limiter) Wait() {
+	l.wg.Wait()
+}
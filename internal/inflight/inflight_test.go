@@ -0,0 +1,111 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package inflight
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dpaks/goworkers"
+)
+
+func TestAcquire(t *testing.T) {
+	l := New(4)
+
+	if n, err := l.Acquire(context.Background()); n != 4 || err != nil {
+		t.Errorf("Expected 4 slots, Got %d and %v", n, err)
+	}
+
+	l.Release(1)
+	if n, _ := l.Acquire(context.Background()); n != 1 {
+		t.Errorf("Expected 1 slot, Got %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Acquire(ctx); err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+}
+
+func TestSize(t *testing.T) {
+	tables := []struct {
+		opts     goworkers.Options
+		expected uint32
+	}{
+		{goworkers.Options{Workers: 4}, 4},
+		{goworkers.Options{QSize: 16}, 16},
+		{goworkers.Options{DirectHandoff: true}, defaultSize},
+	}
+
+	for _, table := range tables {
+		gw := goworkers.New(table.opts)
+		if n := Size(gw); n != table.expected {
+			t.Errorf("%+v: Expected %d, Got %d", table.opts, table.expected, n)
+		}
+		gw.Stop(false)
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	gw := goworkers.New()
+	l := New(1)
+
+	_, _ = l.Acquire(context.Background())
+	ran := false
+	if !l.Submit(gw, func() { ran = true }) {
+		t.Fatalf("Expected the job to be accepted")
+	}
+	l.Wait()
+	if !ran {
+		t.Errorf("Expected the job to have run")
+	}
+
+	gw.Stop(false)
+
+	_, _ = l.Acquire(context.Background())
+	if l.Submit(gw, func() {}) {
+		t.Errorf("Expected the job to be rejected")
+	}
+	// the slot must have been freed
+	if n, _ := l.Acquire(context.Background()); n != 1 {
+		t.Errorf("Expected 1 slot, Got %d", n)
+	}
+}
+
+func TestSubmitNotRun(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	gw.Use(func(ctx context.Context, job goworkers.JobInfo, next func(ctx context.Context) error) error {
+		return nil
+	})
+	l := New(1)
+
+	_, _ = l.Acquire(context.Background())
+	if !l.Submit(gw, func() {}) {
+		t.Fatalf("Expected the job to be accepted")
+	}
+	// the slot of the skipped job must be freed
+	l.Wait()
+	if n, _ := l.Acquire(context.Background()); n != 1 {
+		t.Errorf("Expected 1 slot, Got %d", n)
+	}
+}
+
+func TestSubmitPanic(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	l := New(1)
+
+	_, _ = l.Acquire(context.Background())
+	if !l.Submit(gw, func() { panic("boom") }) {
+		t.Fatalf("Expected the job to be accepted")
+	}
+	// the slot of the panicked job must be freed
+	l.Wait()
+	if n, _ := l.Acquire(context.Background()); n != 1 {
+		t.Errorf("Expected 1 slot, Got %d", n)
+	}
+}
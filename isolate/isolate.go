@@ -0,0 +1,388 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package isolate runs the jobs of registered types in supervised child
+// processes, such that untrusted or crash-prone job code cannot take down
+// the service running the pool.
+//
+// A child process is a copy of the service, or of a dedicated binary, that
+// calls Main() first thing in main(). The jobs are sent to it as
+// goworkers.Envelope over its stdin and their errors are sent back over its
+// stdout. A child that crashes, or that is killed as its job exceeds the
+// time or memory limit, is replaced by a new one for the next job.
+//
+//	func main() {
+//		isolate.Main()
+//		p, err := isolate.New(isolate.Options{Processes: 4, TimeLimit: time.Minute})
+//		...
+//		gw.SubmitJob(p.Job(goworkers.Envelope{Name: "thumbnail", Payload: image}))
+//	}
+package isolate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// childEnv is set in the environment of the child processes
+const childEnv = "GOWORKERS_ISOLATE_CHILD"
+
+// memoryInterval is how often the memory of a child running a job is
+// checked against Options.MemoryLimit
+const memoryInterval = 50 * time.Millisecond
+
+var (
+	// ErrClosed is returned by Run() once the pool is closed.
+	ErrClosed = errors.New("isolate: pool closed")
+	// ErrCrashed is wrapped by the error returned by Run() when the child
+	// running the job exits before reporting its outcome, e.g. as it
+	// panicked.
+	ErrCrashed = errors.New("isolate: child process crashed")
+	// ErrTimeLimit is returned by Run() when the job exceeds
+	// Options.TimeLimit.
+	ErrTimeLimit = errors.New("isolate: job exceeded the time limit")
+	// ErrMemoryLimit is returned by Run() when the child running the job
+	// exceeds Options.MemoryLimit.
+	ErrMemoryLimit = errors.New("isolate: job exceeded the memory limit")
+	// ErrMemoryUnavailable is returned by New() with a MemoryLimit on the
+	// systems whose memory usage of processes cannot be read.
+	ErrMemoryUnavailable = errors.New("isolate: memory usage unavailable")
+)
+
+// Options configures a Pool.
+//
+// Command is the command starting a child process, which must call Main().
+// If unspecified, the executable of the current process is started again.
+// Env is the environment of the children. If unspecified, the environment
+// of the current process is used.
+//
+// Processes is the number of children, hence of jobs run at once. If
+// unspecified or zero, 1 is used.
+//
+// TimeLimit, if set, is the time a job may run for before its child is
+// killed. MemoryLimit, if set, is the resident memory in bytes a child may
+// use while running a job before it is killed. MemoryLimit is supported on
+// Linux only.
+//
+// Stderr receives the stderr of the children, along with whatever their
+// jobs write to stdout. If unspecified, os.Stderr is used.
+type Options struct {
+	Command     []string
+	Env         []string
+	Processes   int
+	TimeLimit   time.Duration
+	MemoryLimit uint64
+	Stderr      io.Writer
+}
+
+// request is sent to a child for every job
+type request struct {
+	Envelope goworkers.Envelope `json:"envelope"`
+}
+
+// response is sent back by a child once its job returns
+type response struct {
+	Err string `json:"err,omitempty"`
+	// the type of the job is not registered in the child
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// Main serves the jobs of the parent process, and exits once the parent
+// closes the pool, if the current process is a child started by a Pool.
+// Otherwise it returns immediately. Call it first thing in main(), once
+// the job types are registered, see goworkers.RegisterJobType().
+//
+// The jobs of a child write to stderr what they write to os.Stdout, as its
+// stdout carries the outcomes of the jobs.
+func Main() {
+	if os.Getenv(childEnv) == "" {
+		return
+	}
+	// the jobs may start pools of their own
+	os.Unsetenv(childEnv)
+	out := os.Stdout
+	os.Stdout = os.Stderr
+	serve(os.Stdin, out)
+	os.Exit(0)
+}
+
+// serve runs the jobs read from r until it is closed
+func serve(r io.Reader, w io.Writer) {
+	dec, enc := json.NewDecoder(r), json.NewEncoder(w)
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+
+		var resp response
+		job, err := req.Envelope.Job()
+		if err != nil {
+			resp.Unknown = true
+		} else {
+			err = job.Run()
+		}
+		if err != nil {
+			resp.Err = err.Error()
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// Pool runs jobs in child processes.
+type Pool struct {
+	opts     Options
+	respawns uint32
+
+	// holds a slot per child, the nil ones yet to be started
+	slots     chan *child
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New creates a pool of child processes. The children are started as
+// they are first needed.
+//
+// Accepts optional Options{} argument.
+// Returns ErrMemoryUnavailable if a MemoryLimit is set on systems other
+// than Linux.
+func New(args ...Options) (*Pool, error) {
+	var opts Options
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if (opts.MemoryLimit != 0) && !memorySupported {
+		return nil, ErrMemoryUnavailable
+	}
+	if len(opts.Command) == 0 {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		opts.Command = []string{exe}
+	}
+	if opts.Env == nil {
+		opts.Env = os.Environ()
+	}
+	if opts.Processes <= 0 {
+		opts.Processes = 1
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+
+	p := &Pool{
+		opts:  opts,
+		slots: make(chan *child, opts.Processes),
+		done:  make(chan struct{}),
+	}
+	for i := 0; i < opts.Processes; i++ {
+		p.slots <- nil
+	}
+	return p, nil
+}
+
+// Respawns returns the number of children that were replaced as they
+// crashed or were killed.
+func (p *Pool) Respawns() uint32 {
+	return atomic.LoadUint32(&p.respawns)
+}
+
+// Run runs the job described by e in a child process, waiting for a child
+// to be available, and returns its error. The child is killed if ctx is
+// done before the job returns, and ctx.Err() is returned.
+//
+// The type of the job must be registered in the child. Returns an error
+// wrapping goworkers.ErrUnknownJobType otherwise.
+func (p *Pool) Run(ctx context.Context, e goworkers.Envelope) error {
+	var c *child
+	select {
+	case <-p.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	case c = <-p.slots:
+	}
+	// a slot may have been taken while the pool was being closed
+	select {
+	case <-p.done:
+		p.slots <- c
+		return ErrClosed
+	default:
+	}
+
+	if c == nil {
+		var err error
+		if c, err = p.start(); err != nil {
+			p.slots <- nil
+			return err
+		}
+	}
+	healthy, err := p.run(ctx, c, e)
+	if !healthy {
+		c.kill()
+		atomic.AddUint32(&p.respawns, 1)
+		c = nil
+	}
+	p.slots <- c
+	return err
+}
+
+// run runs the job described by e on c, reporting whether c may run
+// another job
+func (p *Pool) run(ctx context.Context, c *child, e goworkers.Envelope) (bool, error) {
+	if err := c.enc.Encode(request{Envelope: e}); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrCrashed, err)
+	}
+	responses := make(chan error, 1)
+	go func() {
+		var resp response
+		if err := c.dec.Decode(&resp); err != nil {
+			responses <- fmt.Errorf("%w: %v", ErrCrashed, err)
+			return
+		}
+		switch {
+		case resp.Unknown:
+			responses <- fmt.Errorf("%w: %s", goworkers.ErrUnknownJobType, e.Name)
+		case resp.Err != "":
+			responses <- errors.New(resp.Err)
+		default:
+			responses <- nil
+		}
+	}()
+	c.responses = responses
+
+	var timeLimit <-chan time.Time
+	if p.opts.TimeLimit > 0 {
+		timer := time.NewTimer(p.opts.TimeLimit)
+		defer timer.Stop()
+		timeLimit = timer.C
+	}
+	var memory <-chan time.Time
+	if p.opts.MemoryLimit != 0 {
+		ticker := time.NewTicker(memoryInterval)
+		defer ticker.Stop()
+		memory = ticker.C
+	}
+
+	for {
+		select {
+		case err := <-responses:
+			c.responses = nil
+			return !errors.Is(err, ErrCrashed), err
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-timeLimit:
+			return false, ErrTimeLimit
+		case <-memory:
+			if rss, err := residentMemory(c.cmd.Process.Pid); (err == nil) && (rss > p.opts.MemoryLimit) {
+				return false, ErrMemoryLimit
+			}
+		}
+	}
+}
+
+// Close stops the children once their running jobs return, and waits for
+// them to exit. The jobs waiting for a child return ErrClosed.
+func (p *Pool) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		for i := 0; i < p.opts.Processes; i++ {
+			if c := <-p.slots; c != nil {
+				c.stop()
+			}
+		}
+	})
+	return nil
+}
+
+// Job returns a job running the job described by e in a child process, as
+// with Run(), e.g. for goworkers.SubmitJob(). The job implements
+// goworkers.ContextJob, so that its child is killed once the context of
+// the job is done.
+func (p *Pool) Job(e goworkers.Envelope) goworkers.Job {
+	return isolated{p: p, e: e}
+}
+
+type isolated struct {
+	p *Pool
+	e goworkers.Envelope
+}
+
+var _ goworkers.ContextJob = isolated{}
+
+func (j isolated) Run() error {
+	return j.p.Run(context.Background(), j.e)
+}
+
+func (j isolated) RunContext(ctx context.Context) error {
+	return j.p.Run(ctx, j.e)
+}
+
+// child is a child process serving jobs
+type child struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	enc   *json.Encoder
+	dec   *json.Decoder
+	// the response to the job being run, pending until read
+	responses chan error
+}
+
+func (p *Pool) start() (*child, error) {
+	cmd := exec.Command(p.opts.Command[0], p.opts.Command[1:]...)
+	cmd.Env = append(append([]string{}, p.opts.Env...), childEnv+"=1")
+	cmd.Stderr = p.opts.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &child{
+		cmd:   cmd,
+		stdin: stdin,
+		enc:   json.NewEncoder(stdin),
+		dec:   json.NewDecoder(stdout),
+	}, nil
+}
+
+// kill kills c, waiting for it to exit
+func (c *child) kill() {
+	c.cmd.Process.Kill()
+	c.wait()
+}
+
+// stop tells c to exit once it has no more jobs to run, and waits for it
+// to exit
+func (c *child) stop() {
+	c.stdin.Close()
+	c.wait()
+}
+
+// wait reaps c once the response to its job, if any, has been read from
+// its stdout, which Wait() closes
+func (c *child) wait() {
+	if c.responses != nil {
+		<-c.responses
+	}
+	c.cmd.Wait()
+}
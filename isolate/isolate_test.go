@@ -0,0 +1,143 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package isolate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+type testJob struct {
+	run func() error
+}
+
+func (j testJob) Run() error {
+	return j.run()
+}
+
+func init() {
+	jobs := map[string]func(payload []byte) error{
+		"isolate.greet": func(payload []byte) error {
+			// stdout is not the channel to the parent
+			fmt.Println("hello")
+			return fmt.Errorf("hello %s", payload)
+		},
+		"isolate.ok": func([]byte) error { return nil },
+		"isolate.crash": func([]byte) error {
+			panic("crashed")
+		},
+		"isolate.sleep": func([]byte) error {
+			time.Sleep(time.Minute)
+			return nil
+		},
+		"isolate.alloc": func([]byte) error {
+			b := make([]byte, 256<<20)
+			for i := range b {
+				b[i] = 1
+			}
+			time.Sleep(time.Minute)
+			return nil
+		},
+	}
+	for name, run := range jobs {
+		run := run
+		goworkers.RegisterJobType(name, func(payload []byte) goworkers.Job {
+			return testJob{run: func() error { return run(payload) }}
+		})
+	}
+}
+
+// TestMain turns the test binary into a child process when started by a
+// Pool
+func TestMain(m *testing.M) {
+	Main()
+	os.Exit(m.Run())
+}
+
+func TestRun(t *testing.T) {
+	p, err := New(Options{Processes: 2, TimeLimit: time.Second, Stderr: io.Discard})
+	if err != nil {
+		t.Fatalf("Failed to create the pool: %v", err)
+	}
+	defer p.Close()
+
+	tables := []struct {
+		name    string
+		err     error
+		message string
+		respawn bool
+	}{
+		{"isolate.greet", nil, "hello gopher", false},
+		{"isolate.ok", nil, "", false},
+		{"isolate.unknown", goworkers.ErrUnknownJobType, "", false},
+		{"isolate.crash", ErrCrashed, "", true},
+		{"isolate.sleep", ErrTimeLimit, "", true},
+		// the children were replaced
+		{"isolate.greet", nil, "hello gopher", false},
+	}
+	for _, table := range tables {
+		respawns := p.Respawns()
+		err := p.Run(context.Background(), goworkers.Envelope{Name: table.name, Payload: []byte("gopher")})
+		switch {
+		case table.err != nil:
+			if !errors.Is(err, table.err) {
+				t.Errorf("Expected %v for %s, Got %v", table.err, table.name, err)
+			}
+		case table.message != "":
+			if (err == nil) || (err.Error() != table.message) {
+				t.Errorf("Expected %v for %s, Got %v", table.message, table.name, err)
+			}
+		case err != nil:
+			t.Errorf("Expected nil for %s, Got %v", table.name, err)
+		}
+		if respawned := p.Respawns() != respawns; respawned != table.respawn {
+			t.Errorf("Expected respawned %v for %s, Got %v", table.respawn, table.name, respawned)
+		}
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	p, err := New(Options{MemoryLimit: 64 << 20, TimeLimit: 10 * time.Second})
+	if err == ErrMemoryUnavailable {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Failed to create the pool: %v", err)
+	}
+	defer p.Close()
+
+	if err := p.Run(context.Background(), goworkers.Envelope{Name: "isolate.alloc"}); err != ErrMemoryLimit {
+		t.Errorf("Expected %v, Got %v", ErrMemoryLimit, err)
+	}
+}
+
+func TestJob(t *testing.T) {
+	p, err := New()
+	if err != nil {
+		t.Fatalf("Failed to create the pool: %v", err)
+	}
+
+	gw := goworkers.New()
+	// the child is killed once the budget of the group is spent
+	g := gw.NewGroup(context.Background(), 100*time.Millisecond)
+	job := p.Job(goworkers.Envelope{Name: "isolate.sleep"}).(goworkers.ContextJob)
+	g.Submit(job.RunContext)
+	if err := g.Wait(); err != goworkers.ErrBudgetExceeded {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrBudgetExceeded, err)
+	}
+	gw.Stop(false)
+
+	p.Close()
+	if err := p.Run(context.Background(), goworkers.Envelope{Name: "isolate.ok"}); err != ErrClosed {
+		t.Errorf("Expected %v, Got %v", ErrClosed, err)
+	}
+}
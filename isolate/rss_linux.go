@@ -0,0 +1,33 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package isolate
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+const memorySupported = true
+
+// residentMemory returns the resident memory of the process pid in bytes
+func residentMemory(pid int) (uint64, error) {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/statm")
+	if err != nil {
+		return 0, err
+	}
+	fields := bytes.Fields(b)
+	if len(fields) < 2 {
+		return 0, ErrMemoryUnavailable
+	}
+	pages, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return pages * uint64(os.Getpagesize()), nil
+}
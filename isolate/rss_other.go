@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package isolate
+
+const memorySupported = false
+
+// residentMemory is unavailable on the systems other than Linux
+func residentMemory(pid int) (uint64, error) {
+	return 0, ErrMemoryUnavailable
+}
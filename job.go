@@ -0,0 +1,334 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// JobOptions configures a submitted job.
+//
+// Name is a human-readable name of the job. It is reported in the stats of
+// the pool, e.g. in the list of busy workers and in the per-name counters,
+// and the error of a named job is delivered on ErrChan as a *JobError.
+//
+// Metadata is carried along with the outcome of the job, e.g. tenant or
+// request ids. The error of a job with metadata is delivered on ErrChan as a
+// *JobError and its output is delivered on ResultChan as a JobResult.
+//
+// Tenant is the tenant the job is run on behalf of, see SetQuota().
+//
+// Key is the idempotency key of the job, see Options.Idempotency. Jobs
+// submitted with the same key run once, and the duplicates deliver the
+// result of the job that succeeded instead of running.
+//
+// Tags label the job for the subscriptions to its outputs, see
+// SubscribeResults().
+//
+// Limiter, if set, limits the number of jobs sharing it that run at once,
+// see AdaptiveLimiter. A job waits for the limiter on its worker.
+//
+// HardDeadline, if set, is how long the job may run before the pool
+// abandons it: the job keeps running on a goroutine of its own, but its
+// worker moves on to the next job, the job is accounted for as failed, and
+// an error wrapping ErrAbandoned is delivered on ErrChan. Whatever the job
+// returns afterwards is discarded. Use it for third-party code that ignores
+// cancellation, as an abandoned job still holds its resources.
+//
+// Timeout, if set, is how long the job may run before it times out: the
+// context of the job is cancelled, the job is accounted for as failed, and
+// an error wrapping ErrTimeout is delivered on ErrChan, always as a
+// *JobError, such that the timeout of an unnamed job still tells the worker
+// it timed out on. The worker moves on right
+// away, while the job winds down on a goroutine of its own, and whatever it
+// returns is discarded. If unspecified or zero, Options.JobTimeout is used.
+//
+// Priority is the priority class of the job, see High, Low and Scavenger.
+// If unspecified, Normal is used.
+//
+// Scratch gives the job a scratch directory of its own, see
+// ScratchDirFromContext(), created under Options.ScratchDir before the job
+// runs and removed with its content once the job returns.
+//
+// OnFinish, if set, is called once the job is finished, however it
+// finished: it ran, failed or panicked, or it was skipped, e.g. by an
+// interceptor, as a duplicate of its Key, or as its context was done. It is
+// passed the error of the job, nil if the job was dropped without running.
+// It is not called if the submission fails. Code waiting for a job must
+// rely on it rather than on the end of the job, which may never run.
+type JobOptions struct {
+	Name         string
+	Metadata     map[string]string
+	Tenant       string
+	Key          string
+	Tags         []string
+	Limiter      *AdaptiveLimiter
+	HardDeadline time.Duration
+	Timeout      time.Duration
+	Priority     Priority
+	Scratch      bool
+	OnFinish     func(err error)
+}
+
+// JobError is delivered on ErrChan in place of the error returned by a job
+// with a name or metadata, and of the timeout of every job.
+//
+// Worker is the id of the worker that ran the job, zero if none, e.g. in
+// deterministic mode or for a job dropped without running. The error of a
+// job with neither a name nor metadata is prefixed with it.
+type JobError struct {
+	Name     string
+	Metadata map[string]string
+	Worker   uint64
+	Err      error
+}
+
+func (e *JobError) Error() string {
+	switch {
+	case e.Name != "":
+		return e.Name + ": " + e.Err.Error()
+	case (e.Worker != 0) && (len(e.Metadata) == 0):
+		return "worker " + strconv.FormatUint(e.Worker, 10) + ": " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the error returned by the job.
+func (e *JobError) Unwrap() error {
+	return e.Err
+}
+
+// JobResult is delivered on ResultChan in place of the output of a job with
+// metadata, or of every job with Options.MeasureUsage.
+type JobResult struct {
+	Name     string
+	Metadata map[string]string
+	Value    interface{}
+	Usage    Usage
+}
+
+// NamedStats are the counters of the jobs of a name.
+type NamedStats struct {
+	Completed uint32 `json:"completed"`
+	Failed    uint32 `json:"failed"`
+	// Runtime is the total time the jobs of the name ran for.
+	Runtime time.Duration `json:"runtime"`
+	// Usage is the total usage of the jobs of the name, with
+	// Options.MeasureUsage only.
+	Usage Usage `json:"usage"`
+}
+
+// outputs tells which outputs of a job are delivered
+type outputs int
+
+const (
+	noOutputs outputs = iota
+	errOutput
+	resultOutput
+)
+
+// task is a submitted job along with its options
+type task struct {
+	run     func(ctx context.Context) (interface{}, error)
+	outputs outputs
+	opts    JobOptions
+	// the context the context of the job derives from, Background if nil
+	ctx context.Context
+	// called instead of run if the job is skipped, e.g. dropped as ctx is
+	// done, see skip()
+	onSkip func()
+	// called with the outputs of the job instead of delivering them, e.g.
+	// by a Future
+	onDone func(result interface{}, err error)
+	// called once the job is finished, however it finished, e.g. skipped
+	// by an interceptor or as a duplicate, with its error if it ran
+	onFinish func(err error)
+	// the *lane holding the job, once held, see Future
+	heldOn atomic.Value
+	// id of the worker running the job, zero if none, e.g. in deterministic
+	// mode
+	worker uint64
+	// set with Options.MaxQueueWait only
+	submitted time.Time
+}
+
+func jobOptions(args []JobOptions) JobOptions {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return JobOptions{}
+}
+
+// runTask runs t and delivers its outputs
+func (gw *GoWorkers) runTask(t *task) {
+	// the next held job of the tenant is counted in numJobs already
+	defer func() {
+		if next := gw.quotas.release(t.opts.Tenant); next != nil {
+			gw.enqueue(next)
+		}
+	}()
+	var jobErr error
+	if t.onFinish != nil {
+		defer func() { t.onFinish(jobErr) }()
+	}
+	if t.opts.OnFinish != nil {
+		defer func() { t.opts.OnFinish(jobErr) }()
+	}
+
+	if t.cancelled() {
+		gw.dropCancelled(t)
+		return
+	}
+	if gw.discards(t) {
+		return
+	}
+
+	// innermost, such that the idempotency records and the interceptors see
+	// the panic of the job as its error
+	run := protect(t.run)
+	if t.opts.Scratch {
+		dir, err := gw.scratch.create()
+		if err != nil {
+			jobErr = err
+			gw.failUnrun(t, err)
+			return
+		}
+		run = gw.withScratch(dir, run)
+	}
+	if t.opts.HardDeadline > 0 {
+		run = gw.withHardDeadline(t.opts.HardDeadline, run)
+	}
+	if d := gw.timeout(t); d > 0 {
+		run = gw.withTimeout(d, run)
+	}
+	if (gw.idempotency != nil) && (t.opts.Key != "") {
+		job := run
+		run = func(ctx context.Context) (interface{}, error) {
+			return gw.idempotency.run(t.opts.Key, func() (interface{}, error) {
+				return job(ctx)
+			})
+		}
+	}
+	run = gw.intercept(t, run)
+
+	if t.opts.Limiter != nil {
+		t.opts.Limiter.Acquire()
+	}
+	atomic.AddUint32(&gw.numRunning, 1)
+	gw.signalRoom()
+	gw.observeBackpressure()
+	var usage Usage
+	if gw.measureUsage {
+		usage = readUsage()
+	}
+	started := gw.clock.Now()
+	gw.observeQueueWait(t, started)
+	result, err := protect(run)(gw.jobContext(t))
+	jobErr = err
+	finished := gw.clock.Now()
+	if gw.measureUsage {
+		usage = usage.since()
+		gw.countUsage(usage)
+	}
+	if t.opts.Limiter != nil {
+		t.opts.Limiter.Release(finished.Sub(started), err != nil)
+	}
+	atomic.AddUint32(&gw.numRunning, ^uint32(0))
+	atomic.AddUint32(&gw.numDone, 1)
+	atomic.StoreInt64(&gw.lastProgress, finished.UnixNano())
+	elapsed := finished.Sub(started)
+	atomic.AddInt64(&gw.runtime, int64(elapsed))
+	pe, panicked := err.(*PanicError)
+	gw.countNamed(t.opts.Name, err != nil, elapsed, usage)
+	gw.rolling.observe(finished, elapsed, err != nil, panicked)
+	if gw.archive != nil {
+		gw.record(t, started, elapsed, err)
+	}
+
+	if err != nil {
+		atomic.AddUint32(&gw.numFailed, 1)
+	}
+	if panicked {
+		atomic.AddUint32(&gw.numRestarts, 1)
+	}
+	if panicked && (gw.panicHandler != nil) {
+		gw.panicHandler(pe.Value, pe.Stack)
+	}
+	if t.onDone != nil {
+		t.onDone(result, err)
+		return
+	}
+
+	if err != nil {
+		// reported to the panic handler instead
+		if panicked && (gw.panicHandler != nil) {
+			return
+		}
+		if t.opts.Name != "" || len(t.opts.Metadata) != 0 || errors.Is(err, ErrTimeout) {
+			err = &JobError{Name: t.opts.Name, Metadata: t.opts.Metadata, Worker: t.worker, Err: err}
+		}
+		if !gw.deliverErr(t.opts.Tags, err) {
+			gw.sendErr(err)
+		}
+		return
+	}
+	if t.outputs == resultOutput {
+		r := gw.transformResult(JobResult{Name: t.opts.Name, Metadata: t.opts.Metadata, Value: result, Usage: usage})
+		if (len(t.opts.Metadata) != 0) || gw.measureUsage {
+			result = r
+		} else {
+			result = r.Value
+		}
+		if !gw.deliverResult(t.opts.Tags, result) {
+			gw.sendResult(result)
+		}
+	}
+}
+
+// namedCounters holds the counters of the named jobs of a pool
+type namedCounters struct {
+	mu    sync.Mutex
+	names map[string]*NamedStats
+}
+
+func (gw *GoWorkers) countNamed(name string, failed bool, runtime time.Duration, usage Usage) {
+	if name == "" {
+		return
+	}
+	defer gw.named.mu.Unlock()
+	gw.named.mu.Lock()
+	if gw.named.names == nil {
+		gw.named.names = make(map[string]*NamedStats)
+	}
+	st, ok := gw.named.names[name]
+	if !ok {
+		st = &NamedStats{}
+		gw.named.names[name] = st
+	}
+	st.Completed++
+	if failed {
+		st.Failed++
+	}
+	st.Runtime += runtime
+	st.Usage.add(usage)
+}
+
+func (gw *GoWorkers) namedStats() map[string]NamedStats {
+	defer gw.named.mu.Unlock()
+	gw.named.mu.Lock()
+	if len(gw.named.names) == 0 {
+		return nil
+	}
+	stats := make(map[string]NamedStats, len(gw.named.names))
+	for name, st := range gw.named.names {
+		stats[name] = *st
+	}
+	return stats
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSubmitNamed(t *testing.T) {
+	gw := New()
+
+	release := make(chan struct{})
+	gw.SubmitNamed("resize-image", func() { <-release })
+	for len(gw.Stats().Busy) != 1 {
+	}
+	if job := gw.Stats().Busy[0].Job; job != "resize-image" {
+		t.Errorf("Expected resize-image, Got %q", job)
+	}
+	close(release)
+
+	gw.Stop(true)
+
+	st := gw.Stats().Named["resize-image"]
+	if st.Completed != 1 || st.Failed != 0 {
+		t.Errorf("Expected 1 completed and 0 failed, Got %d and %d", st.Completed, st.Failed)
+	}
+}
+
+func TestNamedJobError(t *testing.T) {
+	gw := New()
+	errFailed := errors.New("failed")
+
+	gw.SubmitCheckError(func() error { return errFailed }, JobOptions{Name: "sync"})
+	err := <-gw.ErrChan
+	var je *JobError
+	if !errors.As(err, &je) || je.Name != "sync" {
+		t.Errorf("Expected a *JobError of job sync, Got %v", err)
+	}
+	if !errors.Is(err, errFailed) {
+		t.Errorf("Expected %v, Got %v", errFailed, err)
+	}
+	if err.Error() != "sync: failed" {
+		t.Errorf("Expected sync: failed, Got %v", err)
+	}
+
+	gw.SubmitCheckError(func() error { return errFailed })
+	if err := <-gw.ErrChan; err != errFailed {
+		t.Errorf("Expected %v, Got %v", errFailed, err)
+	}
+
+	gw.Stop(true)
+
+	tables := []struct {
+		name      string
+		completed uint32
+		failed    uint32
+	}{
+		{"sync", 1, 1},
+		{"", 0, 0},
+	}
+	named := gw.Stats().Named
+	for _, table := range tables {
+		st := named[table.name]
+		if st.Completed != table.completed || st.Failed != table.failed {
+			t.Errorf("%q: Expected %d completed and %d failed, Got %d and %d", table.name, table.completed, table.failed, st.Completed, st.Failed)
+		}
+	}
+}
+
+func TestJobMetadata(t *testing.T) {
+	gw := New()
+	metadata := map[string]string{"tenant": "acme", "request": "42"}
+	opts := JobOptions{Name: "report", Metadata: metadata}
+
+	gw.SubmitCheckResult(func() (interface{}, error) { return 7, nil }, opts)
+	res, ok := (<-gw.ResultChan).(JobResult)
+	if !ok {
+		t.Fatalf("Expected a JobResult")
+	}
+	if res.Name != "report" || res.Metadata["tenant"] != "acme" || res.Value != 7 {
+		t.Errorf("Unexpected result %+v", res)
+	}
+
+	gw.SubmitCheckResult(func() (interface{}, error) { return nil, errors.New("failed") }, JobOptions{Metadata: metadata})
+	err := <-gw.ErrChan
+	var je *JobError
+	if !errors.As(err, &je) || je.Metadata["request"] != "42" {
+		t.Errorf("Expected a *JobError with the metadata, Got %v", err)
+	}
+	if err.Error() != "failed" {
+		t.Errorf("Expected failed, Got %v", err)
+	}
+
+	gw.SubmitCheckResult(func() (interface{}, error) { return 7, nil }, JobOptions{Name: "report"})
+	if res := <-gw.ResultChan; res != 7 {
+		t.Errorf("Expected 7, Got %v", res)
+	}
+
+	gw.Stop(true)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package k8s ties the state of a goworkers pool to the lifecycle of a
+// Kubernetes pod.
+//
+// ReadinessHandler serves a readiness probe that fails while the pool is
+// stopping or saturated, so that the pod stops receiving traffic before
+// things melt. PreStopHandler serves a preStop httpGet hook that stops
+// intake and drains the pool within the termination grace period, and
+// DrainOnSignal does the same upon SIGTERM, on the platforms having signals.
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// DrainReport is the outcome of draining a pool.
+type DrainReport struct {
+	// Leftover is the number of jobs that did not finish within the grace period.
+	Leftover uint32 `json:"leftover"`
+	// Duration is how long the drain took.
+	Duration time.Duration `json:"duration"`
+}
+
+// ReadinessHandler responds with 200 OK while gw is ready to accept jobs, and
+// with 503 Service Unavailable otherwise.
+func ReadinessHandler(gw *goworkers.GoWorkers) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !gw.Ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
+
+// Drain stops intake and waits up to grace for the jobs of gw to finish.
+//
+// grace should be comfortably lower than the pod's terminationGracePeriodSeconds.
+func Drain(gw *goworkers.GoWorkers, grace time.Duration) DrainReport {
+	start := time.Now()
+	leftover := gw.Drain(grace)
+	return DrainReport{Leftover: leftover, Duration: time.Since(start)}
+}
+
+// PreStopHandler drains gw within grace when invoked by a preStop hook and
+// responds with the DrainReport as JSON.
+func PreStopHandler(gw *goworkers.GoWorkers, grace time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := Drain(gw, grace)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
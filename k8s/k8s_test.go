@@ -0,0 +1,53 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	gw := goworkers.New()
+	h := ReadinessHandler(gw)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected %d, Got %d", http.StatusOK, rec.Code)
+	}
+
+	gw.Stop(false)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected %d, Got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+}
+
+func TestPreStopHandler(t *testing.T) {
+	gw := goworkers.New()
+
+	release := make(chan struct{})
+	defer close(release)
+	gw.Submit(func() { <-release })
+
+	rec := httptest.NewRecorder()
+	PreStopHandler(gw, 20*time.Millisecond).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/prestop", nil))
+
+	var report DrainReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if report.Leftover != 1 {
+		t.Errorf("Expected 1 leftover job, Got %d", report.Leftover)
+	}
+}
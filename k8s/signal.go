@@ -0,0 +1,32 @@
+//go:build !js && !wasip1
+// +build !js,!wasip1
+
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package k8s
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// DrainOnSignal drains gw within grace once the process receives SIGTERM or
+// SIGINT. The report is delivered on the returned channel.
+func DrainOnSignal(gw *goworkers.GoWorkers, grace time.Duration) <-chan DrainReport {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, os.Interrupt)
+
+	reports := make(chan DrainReport, 1)
+	go func() {
+		<-sigs
+		signal.Stop(sigs)
+		reports <- Drain(gw, grace)
+	}()
+	return reports
+}
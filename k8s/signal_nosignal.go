@@ -0,0 +1,20 @@
+//go:build js || wasip1
+// +build js wasip1
+
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package k8s
+
+import (
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// DrainOnSignal never drains gw, as the browser and edge runtimes deliver
+// no signals to the process. Use PreStopHandler or Drain instead.
+func DrainOnSignal(gw *goworkers.GoWorkers, grace time.Duration) <-chan DrainReport {
+	return make(chan DrainReport)
+}
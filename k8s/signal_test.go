@@ -0,0 +1,35 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package k8s
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+func TestDrainOnSignal(t *testing.T) {
+	gw := goworkers.New()
+	gw.Submit(func() {})
+
+	reports := DrainOnSignal(gw, time.Second)
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("Failed to signal: %v", err)
+	}
+
+	select {
+	case report := <-reports:
+		if report.Leftover != 0 {
+			t.Errorf("Expected no leftover jobs, Got %d", report.Leftover)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The pool was not drained")
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+const defaultLoadInterval = 5 * time.Second
+
+// ErrLoadUnavailable is returned by SystemLoad() on the systems whose load
+// cannot be sampled.
+var ErrLoadUnavailable = errors.New("goworkers: system load unavailable")
+
+// throttleOnLoad pauses the pool while sample reports a load above
+// threshold, until the pool is stopped
+func (gw *GoWorkers) throttleOnLoad(threshold float64, sample func() (float64, error), interval time.Duration) {
+	ticker := gw.clock.NewTicker(interval)
+	gw.goHelper(func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-gw.stopped:
+				return
+			case <-ticker.C():
+			}
+
+			load, err := sample()
+			gw.mx.Lock()
+			// Wait() and Stop() must not be held up by the load
+			if (err == nil) && (load > threshold) && (atomic.LoadInt32(&gw.stopping) == stateRunning) {
+				gw.pause(pausedByLoad)
+			} else {
+				gw.unpause(pausedByLoad)
+			}
+			gw.mx.Unlock()
+		}
+	})
+}
@@ -0,0 +1,33 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// SystemLoad returns the 1-minute load average of the host per CPU, e.g. 1
+// when there are as many runnable tasks as CPUs on average.
+func SystemLoad() (float64, error) {
+	b, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, ErrLoadUnavailable
+	}
+	fields := bytes.Fields(b)
+	if len(fields) == 0 {
+		return 0, ErrLoadUnavailable
+	}
+	load, err := strconv.ParseFloat(string(fields[0]), 64)
+	if err != nil {
+		return 0, ErrLoadUnavailable
+	}
+	return load / float64(runtime.NumCPU()), nil
+}
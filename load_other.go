@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+// SystemLoad returns the 1-minute load average of the host per CPU. It is
+// only available on Linux, and returns ErrLoadUnavailable elsewhere.
+func SystemLoad() (float64, error) {
+	return 0, ErrLoadUnavailable
+}
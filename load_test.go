@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottleOnLoad(t *testing.T) {
+	var load uint64
+	setLoad := func(l float64) { atomic.StoreUint64(&load, math.Float64bits(l)) }
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{
+		Clock:         clock,
+		LoadThreshold: 1,
+		LoadSampler: func() (float64, error) {
+			l := math.Float64frombits(atomic.LoadUint64(&load))
+			if l < 0 {
+				return 0, errors.New("unavailable")
+			}
+			return l, nil
+		},
+	})
+	defer gw.Stop(false)
+
+	// sample has the next sample of the load taken, and waits for the pool
+	// to be throttled or not
+	sample := func(l float64, throttled bool) {
+		setLoad(l)
+		clock.Advance(defaultLoadInterval)
+		deadline := time.Now().Add(time.Second)
+		for gw.Stats().Throttled != throttled {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected throttled %v on a load of %v", throttled, l)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	sample(1.5, true)
+	var ran int32
+	for i := 0; i < 3; i++ {
+		gw.Submit(func() { atomic.AddInt32(&ran, 1) })
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := atomic.LoadInt32(&ran); n > 1 {
+		t.Errorf("Expected at most the job picked up before throttling to run, Got %d", n)
+	}
+
+	// the pool stays throttled when resumed, and paused once the load drops
+	gw.Pause()
+	gw.Resume()
+	if st := gw.Stats(); !st.Throttled || st.Paused {
+		t.Errorf("Expected the pool to be throttled only, Got %+v", st)
+	}
+	gw.Pause()
+	sample(1, false)
+	if !gw.Stats().Paused {
+		t.Errorf("Expected the pool to be paused")
+	}
+	gw.Resume()
+	for atomic.LoadInt32(&ran) != 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// a load that cannot be sampled does not throttle the pool
+	sample(2, true)
+	sample(-1, false)
+}
+
+func TestSystemLoad(t *testing.T) {
+	load, err := SystemLoad()
+	if (err != nil) && (err != ErrLoadUnavailable) {
+		t.Errorf("Expected nil or %v, Got %v", ErrLoadUnavailable, err)
+	}
+	if load < 0 {
+		t.Errorf("Expected a non-negative load, Got %v", load)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Logger is a structured logger, see Options.Logger. Adapters of the
+// logging libraries of choice can implement it, or see NewStdLogger().
+type Logger interface {
+	// Log logs msg along with the fields of the logger and keyvals, which
+	// alternate keys and values.
+	Log(msg string, keyvals ...interface{})
+	// With returns a logger adding keyvals to the fields of the logger.
+	With(keyvals ...interface{}) Logger
+}
+
+type stdLogger struct {
+	l      *log.Logger
+	fields []interface{}
+}
+
+// NewStdLogger creates a Logger writing to l every message followed by its
+// fields as key=value pairs. If l is nil, the standard logger is used.
+func NewStdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return stdLogger{l: l}
+}
+
+func (s stdLogger) Log(msg string, keyvals ...interface{}) {
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, kvs := range [][]interface{}{s.fields, keyvals} {
+		for i := 0; i < len(kvs); i += 2 {
+			var v interface{} = "(missing)"
+			if i+1 < len(kvs) {
+				v = kvs[i+1]
+			}
+			fmt.Fprintf(&b, " %v=%v", kvs[i], v)
+		}
+	}
+	s.l.Output(2, b.String())
+}
+
+func (s stdLogger) With(keyvals ...interface{}) Logger {
+	fields := make([]interface{}, 0, len(s.fields)+len(keyvals))
+	fields = append(append(fields, s.fields...), keyvals...)
+	return stdLogger{l: s.l, fields: fields}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(string, ...interface{}) {}
+func (nopLogger) With(...interface{}) Logger { return nopLogger{} }
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying l, e.g. for a
+// ContextDecorator or an Interceptor adding fields to the logger of a job.
+func ContextWithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext returns the logger of the job whose context ctx is, or
+// derives from, see Options.Logger. It returns a logger discarding
+// everything if ctx carries none, so that jobs may log unconditionally.
+func LoggerFromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l
+	}
+	return nopLogger{}
+}
+
+// jobLogger derives the logger of t from the logger of the pool
+func (gw *GoWorkers) jobLogger(t *task) Logger {
+	keyvals := make([]interface{}, 0, 8)
+	if gw.name != "" {
+		keyvals = append(keyvals, "pool", gw.name)
+	}
+	keyvals = append(keyvals, "job_id", atomic.AddUint64(&gw.jobSeq, 1))
+	if t.opts.Name != "" {
+		keyvals = append(keyvals, "job", t.opts.Name)
+	}
+	if t.worker != 0 {
+		keyvals = append(keyvals, "worker", t.worker)
+	}
+	return gw.logger.With(keyvals...)
+}
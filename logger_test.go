@@ -0,0 +1,59 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestJobLogger(t *testing.T) {
+	var buf bytes.Buffer
+	gw := New(Options{Name: "mailer", Logger: NewStdLogger(log.New(&buf, "", 0))})
+	defer gw.Stop(false)
+
+	for _, name := range []string{"welcome", ""} {
+		g := gw.NewGroup(context.Background(), time.Minute)
+		g.Submit(func(ctx context.Context) error {
+			LoggerFromContext(ctx).Log("sent", "to", "jane")
+			return nil
+		}, JobOptions{Name: name})
+		g.Wait()
+	}
+
+	want := regexp.MustCompile(`^sent pool=mailer job_id=1 job=welcome worker=\d+ to=jane
+sent pool=mailer job_id=2 worker=\d+ to=jane
+$`)
+	if !want.Match(buf.Bytes()) {
+		t.Errorf("Unexpected logs %q", buf.String())
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStdLogger(log.New(&buf, "", 0)).With("a", 1)
+	l.With("b", 2).Log("msg", "c")
+	l.Log("other")
+
+	if want := "msg a=1 b=2 c=(missing)\nother a=1\n"; buf.String() != want {
+		t.Errorf("Expected %q, Got %q", want, buf.String())
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	// outside of a job, or without a logger, logs are discarded
+	LoggerFromContext(context.Background()).With("a", 1).Log("discarded")
+
+	var buf bytes.Buffer
+	ctx := ContextWithLogger(context.Background(), NewStdLogger(log.New(&buf, "", 0)))
+	LoggerFromContext(ctx).Log("kept")
+	if buf.String() != "kept\n" {
+		t.Errorf("Expected %q, Got %q", "kept\n", buf.String())
+	}
+}
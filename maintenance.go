@@ -0,0 +1,100 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultMaintenanceInterval = time.Hour
+
+var (
+	// ErrJobExpired is the reason recorded for the jobs dead-lettered by
+	// Maintainer.Vacuum for outliving their TTL.
+	ErrJobExpired = errors.New("goworkers: job expired")
+	// ErrCorruptedJob is wrapped by the reason recorded for the jobs
+	// quarantined by a Store as their record cannot be read.
+	ErrCorruptedJob = errors.New("goworkers: corrupted job")
+)
+
+// CorruptedReason returns the reason recorded for a job quarantined as its
+// record cannot be read, for the Store implementations.
+func CorruptedReason(err error) string {
+	return fmt.Errorf("%w: %v", ErrCorruptedJob, err).Error()
+}
+
+// VacuumStats reports the jobs moved out of the queue of a store by Vacuum.
+type VacuumStats struct {
+	// Expired is the number of jobs dead-lettered for outliving the TTL.
+	Expired int
+	// Quarantined is the number of jobs dead-lettered as their record is
+	// corrupted.
+	Quarantined int
+}
+
+// Maintainer is implemented by the durable stores that need maintenance,
+// such that the stores of long-running daemons do not grow unboundedly,
+// see Maintain().
+type Maintainer interface {
+	// Vacuum dead-letters the jobs that are not leased and were enqueued
+	// more than ttl ago, if ttl is not zero, and quarantines the jobs whose
+	// record is corrupted.
+	Vacuum(ttl time.Duration) (VacuumStats, error)
+	// Compact reclaims the storage left by the acknowledged jobs.
+	Compact() error
+}
+
+// MaintenanceOptions configures the maintenance of a store.
+//
+// Interval specifies how often the store is maintained.
+// If unspecified or zero, 1 hour is used.
+//
+// TTL specifies for how long a job may wait in the store before it is
+// dead-lettered with ErrJobExpired. If unspecified or zero, jobs never
+// expire.
+type MaintenanceOptions struct {
+	Interval time.Duration
+	TTL      time.Duration
+}
+
+// Maintain vacuums, then compacts, m every interval until ctx is done or
+// the pool is stopped. Errors of the store are delivered on ErrChan, while
+// the pool is not being stopped, and do not stop the maintenance.
+//
+// This is a blocking call. It returns the context's error, or ErrStopped
+// if the pool was stopped.
+// Accepts optional MaintenanceOptions{} argument.
+func (gw *GoWorkers) Maintain(ctx context.Context, m Maintainer, args ...MaintenanceOptions) error {
+	var opts MaintenanceOptions
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if opts.Interval == 0 {
+		opts.Interval = defaultMaintenanceInterval
+	}
+
+	ticker := gw.clock.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-gw.stopped:
+			return ErrStopped
+		case <-ticker.C():
+		}
+
+		_, err := m.Vacuum(opts.TTL)
+		if err == nil {
+			err = m.Compact()
+		}
+		if err != nil {
+			gw.reportErr(err)
+		}
+	}
+}
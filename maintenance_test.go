@@ -0,0 +1,108 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// the memory store must be maintainable as the durable ones
+var _ Maintainer = (*MemoryStore)(nil)
+
+func TestMemoryStoreVacuum(t *testing.T) {
+	s := NewMemoryStore()
+	leased, _ := s.Enqueue([]byte("1"))
+	stale, _ := s.Enqueue([]byte("2"))
+	_, _ = s.Lease(time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	fresh, _ := s.Enqueue([]byte("3"))
+
+	st, err := s.Vacuum(10 * time.Millisecond)
+	if err != nil || st != (VacuumStats{Expired: 1}) {
+		t.Fatalf("Expected one expired job, Got %+v and %v", st, err)
+	}
+	if s.Len() != 2 {
+		t.Errorf("Expected 2, Got %d", s.Len())
+	}
+	if reason := s.DeadLetters()[stale]; reason != ErrJobExpired.Error() {
+		t.Errorf("Expected %v, Got %q", ErrJobExpired, reason)
+	}
+	for _, id := range []string{leased, fresh} {
+		if _, ok := s.DeadLetters()[id]; ok {
+			t.Errorf("Expected job %s not to expire", id)
+		}
+	}
+
+	// without a TTL, jobs never expire
+	if st, _ := s.Vacuum(0); st.Expired != 0 {
+		t.Errorf("Expected 0, Got %d", st.Expired)
+	}
+}
+
+func TestCorruptedReason(t *testing.T) {
+	reason := CorruptedReason(errors.New("unexpected EOF"))
+	if reason != "goworkers: corrupted job: unexpected EOF" {
+		t.Errorf("Unexpected reason %q", reason)
+	}
+}
+
+// failingStore fails its maintenance
+type failingStore struct{ *MemoryStore }
+
+func (failingStore) Compact() error { return errors.New("disk full") }
+
+func TestMaintain(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Clock: clock})
+	defer gw.Stop(false)
+
+	s := NewMemoryStore()
+	_, _ = s.Enqueue([]byte("1"))
+	time.Sleep(time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- gw.Maintain(ctx, failingStore{s}, MaintenanceOptions{Interval: time.Minute, TTL: time.Nanosecond})
+	}()
+
+	for clock.Waiters() == 0 {
+	}
+	clock.Advance(time.Minute)
+	if err := <-gw.ErrChan; err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected the error of the store, Got %v", err)
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected 0, Got %d", s.Len())
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+}
+
+func TestMaintainStopped(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Clock: clock})
+
+	done := make(chan error)
+	go func() {
+		done <- gw.Maintain(context.Background(), failingStore{NewMemoryStore()}, MaintenanceOptions{Interval: time.Minute})
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// a tick failing as the pool stops does not send on the closed ErrChan
+	gw.Stop(false)
+	clock.Advance(time.Minute)
+	if err := <-done; err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import "sync"
+
+// ResultMiddleware transforms the result of a job before it is delivered,
+// e.g. to redact, enrich or convert it, see UseResultMiddleware().
+type ResultMiddleware func(JobResult) JobResult
+
+// resultMiddleware holds the result middleware of a pool
+type resultMiddleware struct {
+	mu    sync.RWMutex
+	chain []ResultMiddleware
+}
+
+// UseResultMiddleware appends mw to the result middleware of the pool,
+// which is applied in order to the result of every job that succeeded
+// before it is delivered on ResultChan or to the subscriptions, such that
+// cross-cutting post-processing stays out of the jobs.
+//
+// The middleware is passed the result as a JobResult. The Value it returns
+// is delivered, or the JobResult itself for the jobs that deliver one, see
+// JobResult.
+func (gw *GoWorkers) UseResultMiddleware(mw ...ResultMiddleware) {
+	defer gw.middleware.mu.Unlock()
+	gw.middleware.mu.Lock()
+	gw.middleware.chain = append(gw.middleware.chain, mw...)
+}
+
+// transformResult applies the result middleware to r
+func (gw *GoWorkers) transformResult(r JobResult) JobResult {
+	gw.middleware.mu.RLock()
+	chain := gw.middleware.chain
+	gw.middleware.mu.RUnlock()
+	for _, mw := range chain {
+		r = mw(r)
+	}
+	return r
+}
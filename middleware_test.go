@@ -0,0 +1,38 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUseResultMiddleware(t *testing.T) {
+	gw := New()
+	gw.UseResultMiddleware(
+		func(r JobResult) JobResult {
+			r.Value = strings.ReplaceAll(r.Value.(string), "secret", "******")
+			return r
+		},
+		func(r JobResult) JobResult {
+			if r.Metadata != nil {
+				r.Metadata["redacted"] = "true"
+			}
+			return r
+		},
+	)
+
+	_ = gw.SubmitCheckResult(func() (interface{}, error) { return "token secret", nil })
+	if res := <-gw.ResultChan; res != "token ******" {
+		t.Errorf("Expected %v, Got %v", "token ******", res)
+	}
+
+	_ = gw.SubmitCheckResult(func() (interface{}, error) { return "secret", nil }, JobOptions{Metadata: map[string]string{"user": "42"}})
+	res, ok := (<-gw.ResultChan).(JobResult)
+	if !ok || res.Value != "******" || res.Metadata["redacted"] != "true" {
+		t.Errorf("Expected the redacted JobResult, Got %+v", res)
+	}
+	gw.Stop(false)
+}
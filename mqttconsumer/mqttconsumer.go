@@ -0,0 +1,135 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package mqttconsumer implements an adapter that submits the messages of MQTT
+// topic subscriptions to a goworkers pool.
+//
+// Acknowledgement is QoS-aware. A QoS 1 or 2 message is acked only after its
+// handler succeeds, so that the broker re-delivers it upon reconnection of a
+// persistent session otherwise, unless Options.Delivery is
+// goworkers.AtMostOnce. A QoS 0 message is delivered at most once and is
+// never acked.
+//
+// The number of messages being processed is bounded. Once the bound is hit
+// the subscription callback blocks, which holds back the client's network
+// loop and hence the broker. Consume a device fleet's topics with a separate
+// call to bound the processing of each fleet independently.
+//
+// The package does not depend on an MQTT client. Wrap the client of your
+// choice to implement Client and Message, e.g. around paho.mqtt.golang with
+// ClientOptions.SetAutoAckDisabled(true) and SetOrderMatters(true).
+package mqttconsumer
+
+import (
+	"context"
+
+	"github.com/dpaks/goworkers"
+	"github.com/dpaks/goworkers/internal/inflight"
+)
+
+const defaultMaxInFlight = 64
+
+// Message is a message delivered by the broker.
+type Message interface {
+	Topic() string
+	Payload() []byte
+	Qos() byte
+	// Ack acknowledges the message.
+	Ack()
+}
+
+// Client is the subset of an MQTT client used by the adapter.
+type Client interface {
+	// Subscribe subscribes to the topic filter with the given maximum QoS and
+	// calls callback for each message received, one at a time.
+	Subscribe(topic string, qos byte, callback func(Message)) error
+	// Unsubscribe ends the subscriptions to the topic filters.
+	Unsubscribe(topics ...string) error
+}
+
+// Handler processes a message.
+type Handler func(topic string, payload []byte) error
+
+// Options configures the adapter.
+//
+// Topics are the topic filters subscribed to, with QoS as the maximum QoS.
+//
+// MaxInFlight specifies the maximum number of messages handed over to the
+// pool and not finished yet. If unspecified or zero, 64 is used.
+//
+// AckFailed specifies whether a QoS 1 or 2 message whose handler failed, or
+// which the pool rejected, is acked nonetheless. Unacked messages count
+// towards the broker's in-flight window of the session, so set this if a
+// failed message must not hold up the rest.
+//
+// Delivery specifies the delivery semantics of QoS 1 and 2 messages. Default
+// is goworkers.AtLeastOnce. With goworkers.AtMostOnce, a message is acked
+// right before its handler runs and AckFailed is not used.
+type Options struct {
+	Topics      []string
+	QoS         byte
+	MaxInFlight uint32
+	AckFailed   bool
+	Delivery    goworkers.Delivery
+}
+
+// Consume subscribes to the topics and submits the messages received to gw
+// until ctx is done.
+//
+// This is a blocking call. It returns after unsubscribing and after the jobs
+// handed over to the pool have finished, with the context's error or the
+// error of subscribing.
+func Consume(ctx context.Context, gw *goworkers.GoWorkers, c Client, h Handler, opts Options) error {
+	if opts.MaxInFlight == 0 {
+		opts.MaxInFlight = defaultMaxInFlight
+	}
+
+	limiter := inflight.New(opts.MaxInFlight)
+	defer limiter.Wait()
+
+	callback := func(msg Message) {
+		n, err := limiter.Acquire(ctx)
+		if err != nil {
+			// shutting down, leave the message for re-delivery
+			return
+		}
+		limiter.Release(n - 1)
+
+		accepted := limiter.Submit(gw, func() {
+			if opts.Delivery == goworkers.AtMostOnce {
+				ack(msg, true)
+				_ = h(msg.Topic(), msg.Payload())
+				return
+			}
+			err := h(msg.Topic(), msg.Payload())
+			ack(msg, err == nil || opts.AckFailed)
+		})
+		if !accepted {
+			ack(msg, opts.AckFailed)
+		}
+	}
+
+	subscribed := make([]string, 0, len(opts.Topics))
+	defer func() {
+		if len(subscribed) != 0 {
+			_ = c.Unsubscribe(subscribed...)
+		}
+	}()
+
+	for _, topic := range opts.Topics {
+		if err := c.Subscribe(topic, opts.QoS, callback); err != nil {
+			return err
+		}
+		subscribed = append(subscribed, topic)
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func ack(msg Message, ok bool) {
+	if ok && msg.Qos() > 0 {
+		msg.Ack()
+	}
+}
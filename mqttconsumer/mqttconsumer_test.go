@@ -0,0 +1,180 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package mqttconsumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dpaks/goworkers"
+)
+
+type fakeMessage struct {
+	topic   string
+	payload string
+	qos     byte
+	acked   *int32
+}
+
+func (m fakeMessage) Topic() string   { return m.topic }
+func (m fakeMessage) Payload() []byte { return []byte(m.payload) }
+func (m fakeMessage) Qos() byte       { return m.qos }
+func (m fakeMessage) Ack()            { atomic.AddInt32(m.acked, 1) }
+
+type fakeClient struct {
+	mu           sync.Mutex
+	callbacks    map[string]func(Message)
+	unsubscribed []string
+	fail         string
+}
+
+func (c *fakeClient) Subscribe(topic string, qos byte, callback func(Message)) error {
+	if topic == c.fail {
+		return errors.New("not authorized")
+	}
+	c.mu.Lock()
+	c.callbacks[topic] = callback
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeClient) Unsubscribe(topics ...string) error {
+	c.mu.Lock()
+	c.unsubscribed = append(c.unsubscribed, topics...)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeClient) callback(topic string) func(Message) {
+	for {
+		c.mu.Lock()
+		cb := c.callbacks[topic]
+		c.mu.Unlock()
+		if cb != nil {
+			return cb
+		}
+	}
+}
+
+func TestConsume(t *testing.T) {
+	tables := []struct {
+		qos       byte
+		ackFailed bool
+		acked     int32
+	}{
+		{0, false, 0},
+		{1, false, 9},
+		{2, true, 10},
+	}
+
+	for _, table := range tables {
+		gw := goworkers.New(goworkers.Options{Workers: 4})
+		c := &fakeClient{callbacks: make(map[string]func(Message))}
+
+		var handled, acked int32
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- Consume(ctx, gw, c, func(topic string, payload []byte) error {
+				atomic.AddInt32(&handled, 1)
+				if string(payload) == "3" {
+					return fmt.Errorf("failed")
+				}
+				return nil
+			}, Options{Topics: []string{"fleet/+/telemetry"}, QoS: table.qos, MaxInFlight: 2, AckFailed: table.ackFailed})
+		}()
+
+		cb := c.callback("fleet/+/telemetry")
+		for i := 0; i < 10; i++ {
+			cb(fakeMessage{topic: "fleet/1/telemetry", payload: fmt.Sprint(i), qos: table.qos, acked: &acked})
+		}
+		for atomic.LoadInt32(&handled) != 10 {
+		}
+
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("Expected %v, Got %v", context.Canceled, err)
+		}
+		if acked != table.acked {
+			t.Errorf("QoS %d: Expected %d acked, Got %d", table.qos, table.acked, acked)
+		}
+		if len(c.unsubscribed) != 1 {
+			t.Errorf("Expected the topic to be unsubscribed, Got %v", c.unsubscribed)
+		}
+		gw.Stop(false)
+	}
+}
+
+func TestConsumeBounded(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	c := &fakeClient{callbacks: make(map[string]func(Message))}
+
+	var running, peak int32
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, gw, c, func(topic string, payload []byte) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			<-release
+			atomic.AddInt32(&running, -1)
+			return nil
+		}, Options{Topics: []string{"a"}, QoS: 1, MaxInFlight: 3})
+	}()
+
+	cb := c.callback("a")
+	var acked int32
+	delivered := make(chan struct{})
+	go func() {
+		for i := 0; i < 6; i++ {
+			cb(fakeMessage{topic: "a", qos: 1, acked: &acked})
+		}
+		close(delivered)
+	}()
+
+	for atomic.LoadInt32(&running) != 3 {
+	}
+	select {
+	case <-delivered:
+		t.Errorf("Expected the callback to block once 3 messages are in flight")
+	default:
+	}
+
+	close(release)
+	<-delivered
+	for atomic.LoadInt32(&acked) != 6 {
+	}
+	cancel()
+	<-done
+
+	if peak != 3 {
+		t.Errorf("Expected 3, Got %d", peak)
+	}
+}
+
+func TestSubscribeError(t *testing.T) {
+	gw := goworkers.New()
+	defer gw.Stop(false)
+	c := &fakeClient{callbacks: make(map[string]func(Message)), fail: "b"}
+
+	err := Consume(context.Background(), gw, c, func(string, []byte) error { return nil }, Options{Topics: []string{"a", "b"}})
+	if err == nil || err.Error() != "not authorized" {
+		t.Errorf("Expected subscribing to fail, Got %v", err)
+	}
+	if len(c.unsubscribed) != 1 || c.unsubscribed[0] != "a" {
+		t.Errorf("Expected a to be unsubscribed, Got %v", c.unsubscribed)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package natsconsumer implements an adapter that feeds the messages of a
+// NATS subscription, or of a JetStream consumer, into a goworkers pool.
+//
+// Messages are pulled in batches no larger than the number of jobs the
+// adapter may still have in flight, so that the pool's concurrency drives
+// the number of messages pending on the subscription. A message is acked if
+// its handler succeeds and nacked otherwise, unless Options.Delivery is
+// goworkers.AtMostOnce.
+//
+// The package does not depend on a NATS client. Wrap the client of your
+// choice to implement Fetcher and Msg, e.g. around jetstream.Consumer.Fetch.
+package natsconsumer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dpaks/goworkers"
+	"github.com/dpaks/goworkers/internal/inflight"
+)
+
+// Msg is a message delivered by NATS.
+type Msg interface {
+	Subject() string
+	Data() []byte
+	// Ack acknowledges the message.
+	Ack() error
+	// Nak negatively acknowledges the message so that it is re-delivered.
+	Nak() error
+}
+
+// Fetcher pulls messages from a subscription.
+type Fetcher interface {
+	// Fetch returns up to batch messages, waiting for at least one until
+	// ctx is done. It may return fewer messages, or none.
+	Fetch(ctx context.Context, batch int) ([]Msg, error)
+}
+
+// Handler processes a message. The message is acked if the handler returns
+// nil, and nacked otherwise.
+type Handler func(subject string, data []byte) error
+
+// Options configures the consumer.
+//
+// MaxPending specifies the maximum number of messages handed over to the
+// pool but not yet acked or nacked. If unspecified or zero, the maximum
+// number of workers of the pool is used, or the size of its queue if it
+// spawns workers as per demand.
+//
+// Delivery specifies the delivery semantics. Default is
+// goworkers.AtLeastOnce. With goworkers.AtMostOnce, a message is acked right
+// before its handler runs.
+type Options struct {
+	MaxPending uint32
+	Delivery   goworkers.Delivery
+}
+
+// Consume submits the messages pulled from f to gw until ctx is done.
+//
+// This is a blocking call. It returns after the jobs handed over to the pool
+// have finished, with the context's error or the first error of f.
+// Accepts optional Options{} argument.
+func Consume(ctx context.Context, gw *goworkers.GoWorkers, f Fetcher, h Handler, args ...Options) error {
+	var opts Options
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if opts.MaxPending == 0 {
+		opts.MaxPending = inflight.Size(gw)
+	}
+
+	limiter := inflight.New(opts.MaxPending)
+	defer limiter.Wait()
+
+	for {
+		n, err := limiter.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		msgs, err := f.Fetch(ctx, n)
+		limiter.Release(n - len(msgs))
+		if err != nil {
+			if cerr := ctx.Err(); cerr != nil {
+				return cerr
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				// an idle fetch timed out, try again
+				continue
+			}
+			return err
+		}
+
+		for _, msg := range msgs {
+			m := msg
+			accepted := limiter.Submit(gw, func() {
+				if opts.Delivery == goworkers.AtMostOnce {
+					if m.Ack() == nil {
+						_ = h(m.Subject(), m.Data())
+					}
+					return
+				}
+				if h(m.Subject(), m.Data()) != nil {
+					_ = m.Nak()
+					return
+				}
+				_ = m.Ack()
+			})
+			if !accepted {
+				_ = m.Nak()
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package natsconsumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+type fakeMsg struct {
+	data  string
+	acked *int32
+	naked *int32
+}
+
+func (m fakeMsg) Subject() string { return "jobs" }
+func (m fakeMsg) Data() []byte    { return []byte(m.data) }
+func (m fakeMsg) Ack() error      { atomic.AddInt32(m.acked, 1); return nil }
+func (m fakeMsg) Nak() error      { atomic.AddInt32(m.naked, 1); return nil }
+
+type fakeFetcher struct {
+	mu       sync.Mutex
+	msgs     []Msg
+	maxBatch int
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, batch int) ([]Msg, error) {
+	f.mu.Lock()
+	if batch > f.maxBatch {
+		f.maxBatch = batch
+	}
+	if len(f.msgs) > 0 {
+		if batch > len(f.msgs) {
+			batch = len(f.msgs)
+		}
+		msgs := f.msgs[:batch]
+		f.msgs = f.msgs[batch:]
+		f.mu.Unlock()
+		return msgs, nil
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(10 * time.Millisecond):
+		return nil, context.DeadlineExceeded
+	}
+}
+
+func TestConsume(t *testing.T) {
+	var acked, naked int32
+	f := &fakeFetcher{}
+	for i := 0; i < 100; i++ {
+		f.msgs = append(f.msgs, fakeMsg{data: fmt.Sprint(i), acked: &acked, naked: &naked})
+	}
+
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, gw, f, func(subject string, data []byte) error {
+			var n int
+			fmt.Sscan(string(data), &n)
+			if n%2 == 0 {
+				return fmt.Errorf("failed")
+			}
+			return nil
+		}, Options{MaxPending: 8})
+	}()
+
+	for atomic.LoadInt32(&acked)+atomic.LoadInt32(&naked) != 100 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+	if acked != 50 || naked != 50 {
+		t.Errorf("Expected 50 acks and 50 nacks, Got %d and %d", acked, naked)
+	}
+	if f.maxBatch > 8 {
+		t.Errorf("Expected batches of at most 8, Got %d", f.maxBatch)
+	}
+}
+
+func TestConsumeDefaultMaxPending(t *testing.T) {
+	var acked, naked int32
+	f := &fakeFetcher{}
+	for i := 0; i < 20; i++ {
+		f.msgs = append(f.msgs, fakeMsg{data: fmt.Sprint(i), acked: &acked, naked: &naked})
+	}
+
+	gw := goworkers.New(goworkers.Options{Workers: 4})
+	defer gw.Stop(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, gw, f, func(subject string, data []byte) error { return nil })
+	}()
+
+	for atomic.LoadInt32(&acked) != 20 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// bounded by the workers of the pool
+	if f.maxBatch != 4 {
+		t.Errorf("Expected batches of at most 4, Got %d", f.maxBatch)
+	}
+}
+
+func TestConsumeAtMostOnce(t *testing.T) {
+	var acked, naked int32
+	f := &fakeFetcher{}
+	for i := 0; i < 10; i++ {
+		f.msgs = append(f.msgs, fakeMsg{data: fmt.Sprint(i), acked: &acked, naked: &naked})
+	}
+
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	var handled int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, gw, f, func(subject string, data []byte) error {
+			atomic.AddInt32(&handled, 1)
+			return fmt.Errorf("failed")
+		}, Options{Delivery: goworkers.AtMostOnce})
+	}()
+
+	for atomic.LoadInt32(&handled) != 10 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if acked != 10 || naked != 0 {
+		t.Errorf("Expected 10 acks and no nack, Got %d and %d", acked, naked)
+	}
+}
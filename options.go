@@ -0,0 +1,243 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables read by
+// OptionsFromEnv(), unless specified.
+const DefaultEnvPrefix = "GOWORKERS"
+
+// ErrInvalidOptions is wrapped by the errors returned by Options.Validate().
+var ErrInvalidOptions = errors.New("goworkers: invalid options")
+
+// Validate reports the first inconsistency in the options, if any, with an
+// error wrapping ErrInvalidOptions.
+//
+// New() does not validate its options but coerces them to sensible values,
+// e.g. a LowWatermark not below HighWatermark is replaced by half of it.
+// Call Validate() before New() to be told about such surprises instead.
+func (o Options) Validate() error {
+	if math.IsNaN(o.WorkerRate) || math.IsInf(o.WorkerRate, 0) || (o.WorkerRate < 0) {
+		return invalid("WorkerRate %v is not a non-negative number", o.WorkerRate)
+	}
+	if o.Deterministic && (o.WorkerRate > 0) {
+		return invalid("WorkerRate is not honoured in deterministic mode")
+	}
+	if !o.Deterministic && (o.Seed != 0) {
+		return invalid("Seed is used in deterministic mode only")
+	}
+	if !o.StrictOutputs && ((o.DropAction != BlockOnDrop) || (o.OnDrop != nil)) {
+		return invalid("DropAction and OnDrop are used with StrictOutputs only")
+	}
+	if (o.DropAction < BlockOnDrop) || (o.DropAction > ReportOnDrop) {
+		return invalid("unknown DropAction %d", o.DropAction)
+	}
+	if (o.DropAction == ReportOnDrop) && (o.OnDrop == nil) {
+		return invalid("OnDrop is required with ReportOnDrop")
+	}
+	if (o.HighWatermark != 0) && (o.LowWatermark >= o.HighWatermark) {
+		return invalid("LowWatermark %d is not below HighWatermark %d", o.LowWatermark, o.HighWatermark)
+	}
+	if (o.QSize != 0) && (o.HighWatermark > o.QSize) {
+		return invalid("HighWatermark %d is above QSize %d", o.HighWatermark, o.QSize)
+	}
+	if o.DirectHandoff && (o.QSize != 0) {
+		return invalid("QSize %d is not used with DirectHandoff", o.QSize)
+	}
+	if o.Deterministic && o.DirectHandoff {
+		return invalid("DirectHandoff is not honoured in deterministic mode")
+	}
+	if (o.BurstWorkers != 0) && (o.Workers == 0) {
+		return invalid("BurstWorkers requires Workers")
+	}
+	if o.BurstDuration < 0 {
+		return invalid("BurstDuration %v is negative", o.BurstDuration)
+	}
+	if math.IsNaN(o.LoadThreshold) || math.IsInf(o.LoadThreshold, 0) || (o.LoadThreshold < 0) {
+		return invalid("LoadThreshold %v is not a non-negative number", o.LoadThreshold)
+	}
+	if (o.LoadThreshold == 0) && ((o.LoadSampler != nil) || (o.LoadInterval != 0)) {
+		return invalid("LoadSampler and LoadInterval are used with LoadThreshold only")
+	}
+	if o.LoadInterval < 0 {
+		return invalid("LoadInterval %v is negative", o.LoadInterval)
+	}
+	if (o.Overflow < QueuePolicy) || (o.Overflow > CallerRunsPolicy) {
+		return invalid("unknown Overflow %d", o.Overflow)
+	}
+	if o.Deterministic && (o.Overflow != QueuePolicy) {
+		return invalid("Overflow is not honoured in deterministic mode")
+	}
+	if o.ScratchQuota < 0 {
+		return invalid("ScratchQuota %d is negative", o.ScratchQuota)
+	}
+	if o.PriorityAging < 0 {
+		return invalid("PriorityAging %v is negative", o.PriorityAging)
+	}
+	if o.JobTimeout < 0 {
+		return invalid("JobTimeout %v is negative", o.JobTimeout)
+	}
+	if o.StopProgressInterval < 0 {
+		return invalid("StopProgressInterval %v is negative", o.StopProgressInterval)
+	}
+	return nil
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidOptions, fmt.Sprintf(format, args...))
+}
+
+// OptionsFromEnv reads the options from the following environment
+// variables, such that containerized deployments can tune their pools
+// without recompiling:
+//
+//	<prefix>_WORKERS       Options.Workers
+//	<prefix>_QSIZE         Options.QSize
+//	<prefix>_RATE_LIMIT    Options.WorkerRate
+//
+// If prefix is empty, DefaultEnvPrefix is used. Unset or empty variables
+// leave the option to its default. The options read are validated, and an
+// error wrapping ErrInvalidOptions names the offending variable, if any.
+func OptionsFromEnv(prefix string) (Options, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+
+	var opts Options
+	if err := envUint32(prefix+"WORKERS", &opts.Workers); err != nil {
+		return Options{}, err
+	}
+	if err := envUint32(prefix+"QSIZE", &opts.QSize); err != nil {
+		return Options{}, err
+	}
+	if v := os.Getenv(prefix + "RATE_LIMIT"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Options{}, invalid("%sRATE_LIMIT %q is not a number", prefix, v)
+		}
+		opts.WorkerRate = rate
+	}
+
+	if err := opts.Validate(); err != nil {
+		return Options{}, err
+	}
+	return opts, nil
+}
+
+func envUint32(name string, dst *uint32) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return invalid("%s %q is not a non-negative integer", name, v)
+	}
+	*dst = uint32(n)
+	return nil
+}
+
+// ApplyOptions changes the tunable settings of a running pool, such that
+// limits can be tuned, e.g. during an incident, without a restart.
+//
+// Workers, WorkerRate, MaxQueueWait, HighWatermark, LowWatermark and
+// JobTimeout take effect right away, for the jobs submitted from then on as
+// far as JobTimeout is concerned. Lowering Workers retires the excess
+// workers once they finish their current job, see RetireWorker(). Raising
+// it starts workers for the jobs waiting for one. A QSize other than zero
+// resizes the queue, see ResizeQueue(). DirectHandoff, Deterministic and
+// LoadThreshold cannot be changed and must be equal to the current
+// settings. The other options are ignored.
+//
+// The options are validated first, and nothing is changed if they are invalid
+// or the queue cannot be resized, e.g. as ResizeQueue() returns
+// ErrQueueNotDrained.
+func (gw *GoWorkers) ApplyOptions(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.DirectHandoff != gw.current().direct {
+		return invalid("DirectHandoff cannot be changed on a running pool")
+	}
+	if opts.Deterministic != (gw.det != nil) {
+		return invalid("Deterministic cannot be changed on a running pool")
+	}
+	if opts.LoadThreshold != gw.loadThreshold {
+		return invalid("LoadThreshold cannot be changed on a running pool")
+	}
+	size := opts.QSize
+	if size == 0 {
+		size = gw.qsize()
+	}
+	if !opts.DirectHandoff && (opts.HighWatermark > size) {
+		return invalid("HighWatermark %d is above QSize %d", opts.HighWatermark, size)
+	}
+	// the queue is resized against the new watermarks
+	high, low := gw.backpressure.watermarks()
+	gw.backpressure.setWatermarks(opts.HighWatermark, opts.LowWatermark)
+	if (opts.QSize != 0) && (opts.QSize != gw.qsize()) {
+		if err := gw.ResizeQueue(opts.QSize); err != nil {
+			gw.backpressure.setWatermarks(high, low)
+			return err
+		}
+	}
+	gw.observeBackpressure()
+
+	atomic.StoreInt64(&gw.workerInterval, rateInterval(opts.WorkerRate))
+	atomic.StoreInt64(&gw.maxQueueWait, int64(opts.MaxQueueWait))
+	atomic.StoreInt64(&gw.jobTimeout, int64(opts.JobTimeout))
+
+	defer gw.mx.Unlock()
+	gw.mx.Lock()
+	atomic.StoreUint32(&gw.maxWorkers, opts.Workers)
+	if opts.Workers != 0 {
+		for _, w := range gw.workers {
+			if uint32(len(gw.workers)) <= opts.Workers {
+				break
+			}
+			gw.retire(w)
+		}
+	}
+	for ((opts.Workers == 0) || (gw.WorkerNum() < opts.Workers)) && (gw.JobNum() > gw.WorkerNum()) {
+		gw.launchWorker(gw.current())
+	}
+	return nil
+}
+
+// WatchOptions applies every Options received on updates, as with
+// ApplyOptions(), until ctx is done, updates is closed or the pool is
+// stopped. Errors of applying invalid options are delivered on ErrChan, while
+// the pool is not being stopped, and do not stop the watch.
+//
+// This is a blocking call. It returns the context's error, if any, or
+// ErrStopped if the pool was stopped.
+func (gw *GoWorkers) WatchOptions(ctx context.Context, updates <-chan Options) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-gw.stopped:
+			return ErrStopped
+		case opts, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := gw.ApplyOptions(opts); err != nil {
+				gw.reportErr(err)
+			}
+		}
+	}
+}
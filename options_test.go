@@ -0,0 +1,312 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tables := []struct {
+		opts  Options
+		valid bool
+	}{
+		{Options{}, true},
+		{Options{Workers: 8, QSize: 128, WorkerRate: 2.5}, true},
+		{Options{QSize: 1024}, true},
+		{Options{QSize: 16}, true},
+		{Options{QSize: 16, HighWatermark: 32}, false},
+		{Options{WorkerRate: -1}, false},
+		{Options{WorkerRate: math.NaN()}, false},
+		{Options{WorkerRate: math.Inf(1)}, false},
+		{Options{Deterministic: true, Seed: 7}, true},
+		{Options{Deterministic: true, WorkerRate: 1}, false},
+		{Options{Seed: 7}, false},
+		{Options{StrictOutputs: true}, true},
+		{Options{StrictOutputs: true, DropAction: ReportOnDrop, OnDrop: func(error) {}}, true},
+		{Options{StrictOutputs: true, DropAction: ReportOnDrop}, false},
+		{Options{StrictOutputs: true, DropAction: 7}, false},
+		{Options{DropAction: PanicOnDrop}, false},
+		{Options{HighWatermark: 64, LowWatermark: 16}, true},
+		{Options{HighWatermark: 64, LowWatermark: 64}, false},
+		{Options{Workers: 2, BurstWorkers: 2, BurstDuration: time.Second}, true},
+		{Options{BurstWorkers: 2}, false},
+		{Options{Workers: 2, BurstDuration: -time.Second}, false},
+		{Options{LoadThreshold: 1.5, LoadInterval: time.Second}, true},
+		{Options{LoadThreshold: -1}, false},
+		{Options{LoadThreshold: math.NaN()}, false},
+		{Options{LoadInterval: time.Second}, false},
+		{Options{LoadThreshold: 1, LoadInterval: -time.Second}, false},
+		{Options{StopProgressInterval: -time.Second}, false},
+		{Options{Overflow: CallerRunsPolicy}, true},
+		{Options{ScratchQuota: -1}, false},
+		{Options{JobTimeout: time.Second}, true},
+		{Options{JobTimeout: -time.Second}, false},
+		{Options{PriorityAging: -time.Second}, false},
+		{Options{Overflow: CallerRunsPolicy + 1}, false},
+		{Options{Deterministic: true, Overflow: CallerRunsPolicy}, false},
+		{Options{Deterministic: true, Overflow: BlockPolicy}, false},
+		{Options{DirectHandoff: true}, true},
+		{Options{DirectHandoff: true, QSize: 1}, false},
+		{Options{DirectHandoff: true, Deterministic: true}, false},
+	}
+
+	for _, table := range tables {
+		err := table.opts.Validate()
+		if table.valid && err != nil {
+			t.Errorf("%+v: Expected nil, Got %v", table.opts, err)
+		}
+		if !table.valid && !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("%+v: Expected %v, Got %v", table.opts, ErrInvalidOptions, err)
+		}
+	}
+}
+
+func TestOptionsValidateMessage(t *testing.T) {
+	err := Options{QSize: 16, HighWatermark: 32}.Validate()
+	if err == nil || err.Error() != "goworkers: invalid options: HighWatermark 32 is above QSize 16" {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("GOWORKERS_WORKERS", "8")
+	t.Setenv("GOWORKERS_QSIZE", "512")
+	t.Setenv("GOWORKERS_RATE_LIMIT", "2.5")
+	t.Setenv("EMAILS_WORKERS", "3")
+
+	opts, err := OptionsFromEnv("")
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if opts.Workers != 8 || opts.QSize != 512 || opts.WorkerRate != 2.5 {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+
+	opts, err = OptionsFromEnv("EMAILS_")
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if opts.Workers != 3 || opts.QSize != 0 || opts.WorkerRate != 0 {
+		t.Errorf("Expected unset variables to leave the defaults, Got %+v", opts)
+	}
+}
+
+func TestOptionsFromEnvInvalid(t *testing.T) {
+	tables := []struct {
+		name, value string
+	}{
+		{"APP_WORKERS", "-1"},
+		{"APP_WORKERS", "many"},
+		{"APP_QSIZE", "99999999999"},
+		{"APP_RATE_LIMIT", "fast"},
+		{"APP_RATE_LIMIT", "-3"},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name+"="+table.value, func(t *testing.T) {
+			t.Setenv(table.name, table.value)
+			if _, err := OptionsFromEnv("APP"); !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("Expected %v, Got %v", ErrInvalidOptions, err)
+			}
+		})
+	}
+}
+
+func TestApplyOptions(t *testing.T) {
+	gw := New(Options{Workers: 1})
+
+	release := make(chan struct{})
+	var ran int32
+	for i := 0; i < 4; i++ {
+		gw.Submit(func() {
+			<-release
+			atomic.AddInt32(&ran, 1)
+		})
+	}
+	for gw.Stats().Running != 1 {
+	}
+
+	// raising the cap starts workers for the waiting jobs
+	if err := gw.ApplyOptions(Options{Workers: 4, WorkerRate: 100}); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	for gw.Stats().Running != 4 {
+	}
+	if gw.MaxWorkers() != 4 {
+		t.Errorf("Expected 4, Got %d", gw.MaxWorkers())
+	}
+	if interval := time.Duration(atomic.LoadInt64(&gw.workerInterval)); interval != 10*time.Millisecond {
+		t.Errorf("Expected %v, Got %v", 10*time.Millisecond, interval)
+	}
+
+	// lowering it retires the excess workers
+	if err := gw.ApplyOptions(Options{Workers: 2}); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if n := len(gw.WorkerIDs()); n != 2 {
+		t.Errorf("Expected 2, Got %d", n)
+	}
+
+	close(release)
+	waitWorkerNum(t, gw, 2)
+	gw.Stop(false)
+	if ran != 4 {
+		t.Errorf("Expected 4, Got %d", ran)
+	}
+}
+
+func TestApplyOptionsInvalid(t *testing.T) {
+	gw := New(Options{Workers: 3, WorkerRate: 10})
+	defer gw.Stop(false)
+
+	tables := []Options{
+		{Workers: 1, Deterministic: true},
+		{Workers: 1, DirectHandoff: true},
+		{Workers: 1, WorkerRate: -1},
+		{Workers: 1, LoadThreshold: 2},
+		{Workers: 1, HighWatermark: defaultQSize + 1},
+	}
+
+	for _, opts := range tables {
+		if err := gw.ApplyOptions(opts); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("%+v: Expected %v, Got %v", opts, ErrInvalidOptions, err)
+		}
+	}
+	if gw.MaxWorkers() != 3 || atomic.LoadInt64(&gw.workerInterval) != int64(100*time.Millisecond) {
+		t.Errorf("Expected invalid options not to change anything")
+	}
+}
+
+func TestApplyOptionsTunables(t *testing.T) {
+	gw := New(Options{QSize: 64, HighWatermark: 64})
+	defer gw.Stop(false)
+
+	opts := Options{
+		QSize:         16,
+		MaxQueueWait:  time.Second,
+		HighWatermark: 8,
+		LowWatermark:  2,
+		JobTimeout:    time.Minute,
+	}
+	if err := gw.ApplyOptions(opts); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if d := time.Duration(atomic.LoadInt64(&gw.maxQueueWait)); d != time.Second {
+		t.Errorf("Expected %v, Got %v", time.Second, d)
+	}
+	if d := gw.timeout(&task{}); d != time.Minute {
+		t.Errorf("Expected %v, Got %v", time.Minute, d)
+	}
+	if high, low := gw.watermarks(); high != 8 || low != 2 {
+		t.Errorf("Expected 8 and 2, Got %d and %d", high, low)
+	}
+
+	// unspecified, they are reset to their defaults
+	if err := gw.ApplyOptions(Options{}); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if gw.overloaded() || gw.timeout(&task{}) != 0 {
+		t.Errorf("Expected no load shedding and no timeout")
+	}
+	if high, low := gw.watermarks(); high != 16 || low != 8 {
+		t.Errorf("Expected 16 and 8, Got %d and %d", high, low)
+	}
+}
+
+func TestApplyOptionsQSize(t *testing.T) {
+	gw := New(Options{Workers: 1, QSize: 2})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	gw.Submit(func() {})
+	gw.Submit(func() {})
+
+	if err := gw.ApplyOptions(Options{Workers: 2, QSize: 16}); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if gw.QueueCap() != 16 || gw.MaxWorkers() != 2 {
+		t.Errorf("Expected 16 and 2, Got %d and %d", gw.QueueCap(), gw.MaxWorkers())
+	}
+	if r := <-gw.QueueResizes(); r != (QueueResize{From: 2, To: 16}) {
+		t.Errorf("Expected %v, Got %v", QueueResize{From: 2, To: 16}, r)
+	}
+
+	// nothing is changed if the queue cannot shrink
+	for gw.queued() < 2 {
+		gw.Submit(func() { <-release })
+	}
+	if err := gw.ApplyOptions(Options{Workers: 1, QSize: 1}); err != ErrQueueNotDrained {
+		t.Errorf("Expected %v, Got %v", ErrQueueNotDrained, err)
+	}
+	if gw.QueueCap() != 16 || gw.MaxWorkers() != 2 {
+		t.Errorf("Expected 16 and 2, Got %d and %d", gw.QueueCap(), gw.MaxWorkers())
+	}
+
+	close(release)
+	gw.Stop(false)
+}
+
+func TestWatchOptions(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	updates := make(chan Options)
+	done := make(chan error)
+	go func() {
+		done <- gw.WatchOptions(context.Background(), updates)
+	}()
+
+	updates <- Options{Workers: 5}
+	updates <- Options{Workers: 5, Deterministic: true}
+	close(updates)
+	if err := <-done; err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	if gw.MaxWorkers() != 5 {
+		t.Errorf("Expected 5, Got %d", gw.MaxWorkers())
+	}
+	if err := <-gw.ErrChan; !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected %v, Got %v", ErrInvalidOptions, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := gw.WatchOptions(ctx, make(chan Options)); err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+}
+
+func TestWatchOptionsStopped(t *testing.T) {
+	gw := New()
+
+	updates := make(chan Options, 1)
+	done := make(chan error)
+	go func() {
+		done <- gw.WatchOptions(context.Background(), updates)
+	}()
+	gw.Stop(false)
+	if err := <-done; err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+
+	// the errors of invalid options are not sent on the closed ErrChan
+	updates <- Options{Deterministic: true}
+	if err := gw.WatchOptions(context.Background(), updates); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+	gw.reportErr(errors.New("late"))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrDropped is wrapped by the errors reported for outputs that are dropped
+// by a pool with StrictOutputs.
+var ErrDropped = errors.New("goworkers: output dropped")
+
+// DropAction is what a pool with StrictOutputs does with an output of a job,
+// error or result, that would be dropped as its channel is full.
+type DropAction int
+
+const (
+	// BlockOnDrop blocks the worker until the output is read. Stop() and
+	// Wait() then wait for the outputs to be read, even if wait is false.
+	BlockOnDrop DropAction = iota
+	// PanicOnDrop panics with a *DropError.
+	PanicOnDrop
+	// ReportOnDrop drops the output and passes a *DropError to
+	// Options.OnDrop.
+	ReportOnDrop
+)
+
+// DropError describes an output dropped by a pool with StrictOutputs.
+type DropError struct {
+	// Output is the dropped error or result.
+	Output interface{}
+}
+
+func (e *DropError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrDropped, e.Output)
+}
+
+// Unwrap returns ErrDropped.
+func (e *DropError) Unwrap() error {
+	return ErrDropped
+}
+
+// strictOutputs holds the settings of a pool with StrictOutputs
+type strictOutputs struct {
+	action DropAction
+	onDrop func(err error)
+}
+
+func newStrictOutputs(opts Options) *strictOutputs {
+	if !opts.StrictOutputs {
+		return nil
+	}
+	return &strictOutputs{action: opts.DropAction, onDrop: opts.OnDrop}
+}
+
+// sendErr delivers the error of a job on ErrChan
+func (gw *GoWorkers) sendErr(err error) {
+	select {
+	case gw.ErrChan <- err:
+	default:
+		gw.drop(err, func() { gw.ErrChan <- err })
+	}
+}
+
+// reportErr delivers an error of the pool itself, rather than of a job, on
+// ErrChan, unless ErrChan is full or the pool is stopped, or is being
+// stopped, such that it is not sent on once closed
+func (gw *GoWorkers) reportErr(err error) {
+	// Stop() waits for submitMu before closing ErrChan
+	defer gw.submitMu.RUnlock()
+	gw.submitMu.RLock()
+	if atomic.LoadInt32(&gw.stopping) == stateStopping {
+		return
+	}
+	select {
+	case gw.ErrChan <- err:
+	default:
+	}
+}
+
+// sendResult delivers the result of a job on ResultChan
+func (gw *GoWorkers) sendResult(result interface{}) {
+	select {
+	case gw.ResultChan <- result:
+	default:
+		gw.drop(result, func() { gw.ResultChan <- result })
+	}
+}
+
+// drop handles an output that cannot be delivered right away. block
+// delivers it, blocking.
+func (gw *GoWorkers) drop(output interface{}, block func()) {
+	if (gw.strict != nil) && (gw.strict.action == BlockOnDrop) {
+		block()
+		return
+	}
+	atomic.AddUint32(&gw.numDropped, 1)
+	if gw.strict == nil {
+		return
+	}
+	switch gw.strict.action {
+	case PanicOnDrop:
+		panic(&DropError{Output: output})
+	case ReportOnDrop:
+		if gw.strict.onDrop != nil {
+			gw.strict.onDrop(&DropError{Output: output})
+		}
+	}
+}
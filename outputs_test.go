@@ -0,0 +1,92 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// fillErrChan submits jobs whose errors fill up ErrChan
+func fillErrChan(gw *GoWorkers) {
+	for i := 0; i < outputChanSize; i++ {
+		gw.SubmitCheckError(func() error { return errors.New("failed") })
+	}
+	for len(gw.ErrChan) != outputChanSize {
+	}
+}
+
+func TestDroppedOutputs(t *testing.T) {
+	gw := New()
+
+	fillErrChan(gw)
+	gw.SubmitCheckError(func() error { return errors.New("dropped") })
+	gw.SubmitCheckResult(func() (interface{}, error) { return 1, nil })
+	gw.Wait(false)
+
+	if st := gw.Stats(); st.Dropped != 1 {
+		t.Errorf("Expected 1, Got %d", st.Dropped)
+	}
+
+	gw.Stop(false)
+}
+
+func TestStrictOutputsReport(t *testing.T) {
+	var reported int32
+	var dropErr error
+	gw := New(Options{StrictOutputs: true, DropAction: ReportOnDrop, OnDrop: func(err error) {
+		dropErr = err
+		atomic.AddInt32(&reported, 1)
+	}})
+
+	fillErrChan(gw)
+	gw.SubmitCheckError(func() error { return errors.New("dropped") })
+	gw.Wait(false)
+
+	if atomic.LoadInt32(&reported) != 1 {
+		t.Fatalf("Expected 1 report, Got %d", reported)
+	}
+	var de *DropError
+	if !errors.Is(dropErr, ErrDropped) || !errors.As(dropErr, &de) || de.Output.(error).Error() != "dropped" {
+		t.Errorf("Expected the dropped error, Got %v", dropErr)
+	}
+	if st := gw.Stats(); st.Dropped != 1 {
+		t.Errorf("Expected 1, Got %d", st.Dropped)
+	}
+
+	gw.Stop(false)
+}
+
+func TestStrictOutputsBlock(t *testing.T) {
+	gw := New(Options{StrictOutputs: true})
+
+	fillErrChan(gw)
+	gw.SubmitCheckError(func() error { return errors.New("blocked") })
+
+	for i := 0; i < outputChanSize; i++ {
+		<-gw.ErrChan
+	}
+	if err := <-gw.ErrChan; err.Error() != "blocked" {
+		t.Errorf("Expected blocked, Got %v", err)
+	}
+
+	gw.Stop(false)
+	if st := gw.Stats(); st.Dropped != 0 {
+		t.Errorf("Expected 0, Got %d", st.Dropped)
+	}
+}
+
+func TestStrictOutputsPanic(t *testing.T) {
+	gw := New(Options{StrictOutputs: true, DropAction: PanicOnDrop})
+	defer gw.Stop(false)
+
+	defer func() {
+		if _, ok := recover().(*DropError); !ok {
+			t.Errorf("Expected a panic with a *DropError")
+		}
+	}()
+	gw.drop(errors.New("dropped"), func() {})
+}
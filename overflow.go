@@ -0,0 +1,95 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync/atomic"
+)
+
+// OverflowPolicy is what a pool does with a job submitted while its queue
+// is full, see Options.Overflow.
+type OverflowPolicy int
+
+const (
+	// QueuePolicy queues the job all the same, past the size of the queue,
+	// such that Submit() never blocks.
+	QueuePolicy OverflowPolicy = iota
+	// BlockPolicy blocks the submission until the queue has room for the
+	// job, for strict backpressure. The pool being stopped or waited for
+	// unblocks the submission, which fails with ErrStopped. The jobs
+	// submitted by jobs and the Scavenger jobs do not block.
+	BlockPolicy
+	// CallerRunsPolicy runs the job on the goroutine submitting it, which
+	// returns once the job has finished. This throttles the producers to
+	// the pace of the pool while guaranteeing that the jobs run.
+	CallerRunsPolicy
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case QueuePolicy:
+		return "queue"
+	case BlockPolicy:
+		return "block"
+	case CallerRunsPolicy:
+		return "caller-runs"
+	}
+	return "unknown"
+}
+
+// blockWhileFull blocks the submission of t while the queue is full, see
+// BlockPolicy. It must be called without submitMu held, such that Stop()
+// is not held up.
+func (gw *GoWorkers) blockWhileFull(t *task) {
+	if (gw.overflow != BlockPolicy) || (t.opts.Priority == Scavenger) || gw.calledFromJob() {
+		return
+	}
+	atomic.AddInt32(&gw.blocked, 1)
+	defer atomic.AddInt32(&gw.blocked, -1)
+	for {
+		// taken before checking, such that no signal is missed
+		room := gw.roomSignal()
+		if !gw.Saturated() || (atomic.LoadInt32(&gw.stopping) != stateRunning) {
+			return
+		}
+		<-room
+	}
+}
+
+func (gw *GoWorkers) roomSignal() chan struct{} {
+	defer gw.roomMu.Unlock()
+	gw.roomMu.Lock()
+	if gw.room == nil {
+		gw.room = make(chan struct{})
+	}
+	return gw.room
+}
+
+// signalRoom wakes up the submissions blocked by blockWhileFull(), if any,
+// once a job left the queue
+func (gw *GoWorkers) signalRoom() {
+	if atomic.LoadInt32(&gw.blocked) == 0 {
+		return
+	}
+	defer gw.roomMu.Unlock()
+	gw.roomMu.Lock()
+	if gw.room != nil {
+		close(gw.room)
+		gw.room = nil
+	}
+}
+
+// overflows reports whether t must be run by its caller as the queue is
+// full
+func (gw *GoWorkers) overflows(t *task) bool {
+	return (gw.overflow == CallerRunsPolicy) && (t.opts.Priority != Scavenger) && gw.Saturated()
+}
+
+// runOnCaller runs t, accounted for as submitted already, on the calling
+// goroutine
+func (gw *GoWorkers) runOnCaller(t *task) {
+	gw.runTask(t)
+	gw.finishJob()
+}
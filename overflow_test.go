@@ -0,0 +1,119 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBlockPolicy(t *testing.T) {
+	gw := New(Options{Workers: 1, QSize: 1, Overflow: BlockPolicy})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	gw.Submit(func() {})
+	if !gw.Saturated() {
+		t.Fatalf("Expected the queue to be full")
+	}
+
+	submitted := make(chan error)
+	go func() { submitted <- gw.Submit(func() {}) }()
+	select {
+	case err := <-submitted:
+		t.Fatalf("Expected the submission to block, Got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-submitted:
+		if err != nil {
+			t.Errorf("Expected nil, Got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the submission to unblock once the queue has room")
+	}
+
+	gw.Wait(false)
+	if st := gw.Stats(); st.Completed != 3 {
+		t.Errorf("Expected 3 completed jobs, Got %+v", st)
+	}
+	gw.Stop(false)
+}
+
+func TestBlockPolicyStop(t *testing.T) {
+	gw := New(Options{Workers: 1, QSize: 1, Overflow: BlockPolicy})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	gw.Submit(func() {})
+
+	submitted := make(chan error)
+	go func() { submitted <- gw.Submit(func() {}) }()
+	for atomic.LoadInt32(&gw.blocked) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	stopped := make(chan error)
+	go func() { stopped <- gw.Stop(false) }()
+	for atomic.LoadInt32(&gw.stopping) == stateRunning {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	select {
+	case err := <-submitted:
+		if err != ErrStopped {
+			t.Errorf("Expected %v, Got %v", ErrStopped, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected Stop() to unblock the submission")
+	}
+	<-stopped
+}
+
+func TestCallerRunsPolicy(t *testing.T) {
+	gw := New(Options{Workers: 1, QSize: 1, Overflow: CallerRunsPolicy})
+	defer gw.Stop(false)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	gw.Submit(func() {})
+	if !gw.Saturated() {
+		t.Fatalf("Expected the queue to be full")
+	}
+
+	// the job runs on the caller, which is not a worker of the pool
+	var onWorker, ran bool
+	gw.Submit(func() {
+		onWorker = gw.calledFromJob()
+		ran = true
+	}, JobOptions{Name: "overflow"})
+	if !ran || onWorker {
+		t.Errorf("Expected the job to run on the caller, Got ran %v and on a worker %v", ran, onWorker)
+	}
+
+	close(release)
+	gw.Wait(false)
+	if st := gw.Stats(); st.Completed != 3 || st.Named["overflow"].Completed != 1 {
+		t.Errorf("Expected 3 completed jobs, Got %+v", st)
+	}
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError describes a panic recovered from a job.
+type PanicError struct {
+	// Value is the value the job panicked with.
+	Value interface{}
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("goworkers: job panicked: %v", e.Value)
+}
+
+// protect runs run, turning a panic into a *PanicError, such that a
+// panicking job fails instead of crashing the process from a worker
+func protect(run func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (result interface{}, err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				result, err = nil, &PanicError{Value: v, Stack: debug.Stack()}
+			}
+		}()
+		return run(ctx)
+	}
+}
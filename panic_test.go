@@ -0,0 +1,69 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	defer gw.Stop(false)
+
+	gw.Submit(func() { panic("boom") }, JobOptions{Name: "crash"})
+	var pe *PanicError
+	if err := <-gw.ErrChan; !errors.As(err, &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("Expected the panic of the job, Got %v", err)
+	}
+
+	// the worker moves on to the next job
+	ran := make(chan struct{})
+	gw.Submit(func() { close(ran) })
+	<-ran
+	gw.Wait(false)
+	if st := gw.Stats(); st.Failed != 1 || st.Completed != 2 || st.Jobs != 0 || st.Workers != 1 {
+		t.Errorf("Expected 1 failed job out of 2, Got %+v", st)
+	}
+
+	// the jobs of a group see the panic as the error of the job
+	g := gw.NewGroup(context.Background(), time.Minute)
+	g.Submit(func(ctx context.Context) error { panic("boom") })
+	if err := g.Wait(); !errors.As(err, &pe) {
+		t.Errorf("Expected a *PanicError, Got %v", err)
+	}
+	<-gw.ErrChan
+}
+
+func TestPanicHandler(t *testing.T) {
+	type report struct {
+		value interface{}
+		stack []byte
+	}
+	reports := make(chan report, 1)
+	gw := New(Options{PanicHandler: func(value interface{}, stack []byte) {
+		reports <- report{value, stack}
+	}})
+
+	gw.SubmitCheckError(func() error { panic("boom") })
+	if r := <-reports; r.value != "boom" || len(r.stack) == 0 {
+		t.Errorf("Expected the panic of the job, Got %v", r.value)
+	}
+
+	// futures still see the panic as the error of their job
+	f, _ := gw.SubmitFuture(func(ctx context.Context) (interface{}, error) { panic("bang") })
+	var pe *PanicError
+	if _, err := f.Result(); !errors.As(err, &pe) || pe.Value != "bang" {
+		t.Errorf("Expected the panic of the job, Got %v", err)
+	}
+	<-reports
+
+	gw.Stop(false)
+	if err, ok := <-gw.ErrChan; ok {
+		t.Errorf("Expected no error, Got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pipeline ties the lifecycles of pools connected in stages, where the jobs
+// of a stage submit jobs to the next one, such that they are torn down in
+// the right order.
+//
+// Stopping any stage, cancelling the context of the pipeline, or reporting
+// a fatal error with Fail() stops all the stages, upstream first: every
+// stage is stopped once the stages upstream of it have finished their jobs,
+// so that the jobs they handed downstream are not rejected.
+type Pipeline struct {
+	stages []*GoWorkers
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+	done   chan struct{}
+
+	mu      sync.Mutex
+	err     error
+	stopped bool
+}
+
+// NewPipeline creates a new pipeline of the stages, upstream first. It is
+// stopped as ctx is done.
+func NewPipeline(ctx context.Context, stages ...*GoWorkers) *Pipeline {
+	p := &Pipeline{stages: stages, parent: ctx, done: make(chan struct{})}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	for _, gw := range stages {
+		gw := gw
+		go func() {
+			select {
+			case <-gw.stopped:
+			case <-p.ctx.Done():
+			}
+			p.once.Do(func() { go p.shutdown() })
+		}()
+	}
+	return p
+}
+
+// Context returns the context of the pipeline, which is cancelled as the
+// pipeline is stopped. Jobs should give up as it is done.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Fail reports a fatal error of a stage and stops the pipeline. Jobs may
+// call it. Only the first error is kept.
+func (p *Pipeline) Fail(err error) {
+	p.mu.Lock()
+	if (p.err == nil) && !p.stopped {
+		p.err = err
+	}
+	p.mu.Unlock()
+	p.cancel()
+}
+
+// Stop stops the pipeline and waits for all its stages to stop.
+// Returns ErrCalledFromJob, instead of deadlocking, if called from a job of
+// a stage.
+func (p *Pipeline) Stop() error {
+	for _, gw := range p.stages {
+		if gw.calledFromJob() {
+			return ErrCalledFromJob
+		}
+	}
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	p.cancel()
+	<-p.done
+	return nil
+}
+
+// Drain stops the pipeline and waits up to timeout for all its stages to
+// stop. Returns the number of jobs of all the stages that did not finish in
+// time.
+func (p *Pipeline) Drain(timeout time.Duration) uint32 {
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	p.cancel()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-p.done:
+		return 0
+	case <-timer.C:
+		var left uint32
+		for _, gw := range p.stages {
+			left += gw.JobNum()
+		}
+		return left
+	}
+}
+
+// Wait waits for the pipeline to stop.
+// Returns the error reported with Fail(), if any, else the error of the
+// context of the pipeline if it was cancelled by its parent.
+func (p *Pipeline) Wait() error {
+	<-p.done
+	return p.Err()
+}
+
+// Err returns the error Wait() would return, nil while the pipeline runs.
+func (p *Pipeline) Err() error {
+	select {
+	case <-p.done:
+	default:
+		return nil
+	}
+	defer p.mu.Unlock()
+	p.mu.Lock()
+	return p.err
+}
+
+func (p *Pipeline) shutdown() {
+	p.cancel()
+	for _, gw := range p.stages {
+		_ = gw.Stop(false)
+		// the stage may be stopping on its own
+		<-gw.stopped
+	}
+
+	p.mu.Lock()
+	if (p.err == nil) && !p.stopped {
+		// stopping a stage is not an error, unlike cancelling the parent
+		p.err = p.parent.Err()
+	}
+	p.mu.Unlock()
+	close(p.done)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPipelineStopUpstream(t *testing.T) {
+	fetch, store := New(), New()
+	p := NewPipeline(context.Background(), fetch, store)
+
+	var stored int32
+	for i := 0; i < 10; i++ {
+		_ = fetch.Submit(func() {
+			time.Sleep(time.Millisecond)
+			if err := store.Submit(func() { atomic.AddInt32(&stored, 1) }); err != nil {
+				t.Errorf("Expected nil, Got %v", err)
+			}
+		})
+	}
+
+	// stopping the upstream stage stops the downstream one once drained
+	fetch.Stop(false)
+	if err := p.Wait(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if n := atomic.LoadInt32(&stored); n != 10 {
+		t.Errorf("Expected %v, Got %v", 10, n)
+	}
+	if err := store.Submit(func() {}); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+}
+
+func TestPipelineFail(t *testing.T) {
+	fetch, store := New(), New()
+	p := NewPipeline(context.Background(), fetch, store)
+
+	errFatal := errors.New("disk full")
+	_ = store.Submit(func() { p.Fail(errFatal) })
+
+	if err := p.Wait(); err != errFatal {
+		t.Errorf("Expected %v, Got %v", errFatal, err)
+	}
+	if p.Context().Err() == nil {
+		t.Errorf("Expected the context of the pipeline to be cancelled")
+	}
+	if err := fetch.Submit(func() {}); err != ErrStopped {
+		t.Errorf("Expected the upstream stage to be stopped, Got %v", err)
+	}
+}
+
+func TestPipelineContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewPipeline(ctx, New(), New())
+	if err := p.Err(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	cancel()
+	if err := p.Wait(); err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+}
+
+func TestPipelineDrain(t *testing.T) {
+	fetch, store := New(), New()
+	p := NewPipeline(context.Background(), fetch, store)
+
+	release := make(chan struct{})
+	_ = store.Submit(func() { <-release })
+	if n := p.Drain(10 * time.Millisecond); n != 1 {
+		t.Errorf("Expected %v, Got %v", 1, n)
+	}
+
+	close(release)
+	if err := p.Stop(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	for _, gw := range []*GoWorkers{fetch, store} {
+		if err := gw.VerifyShutdown(time.Second); err != nil {
+			t.Errorf("Expected nil, Got %v", err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package pondshim implements the API of the pools of alitto/pond on top of
+// goworkers, such that code written against pond keeps its call sites:
+//
+//	pool := pondshim.New(10, 1000)
+//	defer pool.StopAndWait()
+//	group := pool.Group()
+//	group.Submit(func() { ... })
+//	group.Wait()
+//
+// The functional options of pond are not supported.
+package pondshim
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dpaks/goworkers"
+)
+
+// WorkerPool is a pool of workers with the API of pond.WorkerPool.
+type WorkerPool struct {
+	gw *goworkers.GoWorkers
+}
+
+// New creates a new pool of up to maxWorkers workers. A positive
+// maxCapacity sizes the queue.
+func New(maxWorkers, maxCapacity int) *WorkerPool {
+	opts := goworkers.Options{}
+	if maxWorkers > 0 {
+		opts.Workers = uint32(maxWorkers)
+	}
+	if maxCapacity > 0 {
+		opts.QSize = uint32(maxCapacity)
+	}
+	return &WorkerPool{gw: goworkers.New(opts)}
+}
+
+// Pool returns the goworkers pool backing p.
+func (p *WorkerPool) Pool() *goworkers.GoWorkers {
+	return p.gw
+}
+
+// Submit submits a task to the pool. Tasks submitted once the pool is
+// stopped are discarded, as with pond.
+func (p *WorkerPool) Submit(task func()) {
+	_ = p.gw.Submit(task)
+}
+
+// TrySubmit submits a task to the pool unless its queue is full, and
+// reports whether it did.
+func (p *WorkerPool) TrySubmit(task func()) bool {
+	return p.gw.TrySubmit(task)
+}
+
+// SubmitAndWait submits a task to the pool and waits for it to finish.
+func (p *WorkerPool) SubmitAndWait(task func()) {
+	done := make(chan struct{})
+	if err := p.gw.Submit(task, goworkers.JobOptions{
+		OnFinish: func(error) { close(done) },
+	}); err != nil {
+		return
+	}
+	<-done
+}
+
+// RunningWorkers returns the number of workers running a task.
+func (p *WorkerPool) RunningWorkers() int {
+	return int(p.gw.Stats().Running)
+}
+
+// WaitingTasks returns the number of tasks waiting for a worker.
+func (p *WorkerPool) WaitingTasks() uint64 {
+	return uint64(p.gw.Stats().Queued)
+}
+
+// SubmittedTasks returns the number of tasks submitted and not finished.
+func (p *WorkerPool) SubmittedTasks() uint64 {
+	return uint64(p.gw.JobNum())
+}
+
+// StopAndWait waits for the submitted tasks to finish and stops the pool.
+func (p *WorkerPool) StopAndWait() {
+	_ = p.gw.Stop(false)
+}
+
+// Group creates a new group of tasks.
+func (p *WorkerPool) Group() *TaskGroup {
+	return &TaskGroup{pool: p}
+}
+
+// GroupContext creates a new group of tasks that may fail. The context
+// passed along is cancelled as soon as a task fails or ctx is done.
+func (p *WorkerPool) GroupContext(ctx context.Context) (*TaskGroupWithContext, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &TaskGroupWithContext{pool: p, ctx: ctx, cancel: cancel}, ctx
+}
+
+// TaskGroup is a group of tasks, with the API of pond.TaskGroup.
+type TaskGroup struct {
+	pool *WorkerPool
+	wg   sync.WaitGroup
+}
+
+// Submit submits a task of the group.
+func (g *TaskGroup) Submit(task func()) {
+	g.wg.Add(1)
+	if err := g.pool.gw.Submit(task, goworkers.JobOptions{
+		OnFinish: func(error) { g.wg.Done() },
+	}); err != nil {
+		g.wg.Done()
+	}
+}
+
+// Wait waits for the tasks of the group to finish.
+func (g *TaskGroup) Wait() {
+	g.wg.Wait()
+}
+
+// TaskGroupWithContext is a group of tasks that may fail, with the API of
+// pond.TaskGroupWithContext.
+type TaskGroupWithContext struct {
+	pool   *WorkerPool
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	once sync.Once
+	err  error
+}
+
+// Submit submits a task of the group. Tasks not started by the time the
+// group failed are skipped. A panicking task fails the group.
+func (g *TaskGroupWithContext) Submit(task func() error) {
+	g.wg.Add(1)
+	if err := g.pool.gw.Submit(func() {
+		if g.ctx.Err() != nil {
+			return
+		}
+		if err := task(); err != nil {
+			g.fail(err)
+		}
+	}, goworkers.JobOptions{
+		OnFinish: func(err error) {
+			if err != nil {
+				g.fail(err)
+			}
+			g.wg.Done()
+		},
+	}); err != nil {
+		g.wg.Done()
+		g.fail(err)
+	}
+}
+
+func (g *TaskGroupWithContext) fail(err error) {
+	g.once.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
+
+// Wait waits for the tasks of the group to finish, or to be skipped.
+// Returns the error of the task that failed first, if any, else the error
+// of the context of the group if it is done.
+func (g *TaskGroupWithContext) Wait() error {
+	g.wg.Wait()
+	defer g.cancel()
+	g.once.Do(func() {
+		g.err = g.ctx.Err()
+	})
+	return g.err
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package pondshim
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	pool := New(4, 1000)
+	defer pool.StopAndWait()
+
+	var ran int32
+	group := pool.Group()
+	for i := 0; i < 10; i++ {
+		group.Submit(func() { atomic.AddInt32(&ran, 1) })
+	}
+	group.Wait()
+	if n := atomic.LoadInt32(&ran); n != 10 {
+		t.Errorf("Expected %v, Got %v", 10, n)
+	}
+
+	done := false
+	pool.SubmitAndWait(func() { done = true })
+	if !done {
+		t.Errorf("Expected the task to be done")
+	}
+}
+
+func TestGroupContext(t *testing.T) {
+	pool := New(2, 0)
+	defer pool.StopAndWait()
+
+	errFailed := errors.New("failed")
+	group, ctx := pool.GroupContext(context.Background())
+	group.Submit(func() error { return errFailed })
+	group.Submit(func() error {
+		<-ctx.Done()
+		return nil
+	})
+	if err := group.Wait(); err != errFailed {
+		t.Errorf("Expected %v, Got %v", errFailed, err)
+	}
+
+	group, _ = pool.GroupContext(context.Background())
+	group.Submit(func() error { return nil })
+	if err := group.Wait(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// defaultPriorityAging is the aging of the Low jobs, unless specified
+const defaultPriorityAging = 30 * time.Second
+
+// Priority is the priority class of a job, see JobOptions.Priority.
+type Priority int
+
+const (
+	// Scavenger is the class of the background jobs filling the idle
+	// capacity of a pool, e.g. maintenance work. A Scavenger job is only
+	// picked up by a worker while no job of another class is waiting for a
+	// worker, so that it does not add to the latency of the regular jobs.
+	// Running Scavenger jobs are not interrupted.
+	Scavenger Priority = iota - 2
+	// Low is the class of bulk jobs, picked up by the workers while no High
+	// or Normal job is waiting for one. A Low job that waited for longer
+	// than Options.PriorityAging is picked up ahead of the Normal jobs, so
+	// that it is not starved.
+	Low
+	// Normal is the class of the jobs, unless specified.
+	Normal
+	// High is the class of latency-sensitive jobs, picked up by the workers
+	// ahead of the jobs of the other classes.
+	High
+)
+
+func (p Priority) String() string {
+	switch p {
+	case Scavenger:
+		return "scavenger"
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	}
+	return "unknown"
+}
+
+// SubmitWithPriority is a non-blocking call with arg of type `func()`
+//
+// It is a shorthand for Submit() with JobOptions{Priority: p}.
+// Accepts optional JobOptions{} argument, whose Priority is overridden.
+func (gw *GoWorkers) SubmitWithPriority(p Priority, job func(), args ...JobOptions) error {
+	opts := jobOptions(args)
+	opts.Priority = p
+	return gw.Submit(job, opts)
+}
+
+// lowJob is a held Low job along with the time it was pushed at
+type lowJob struct {
+	t     *task
+	since time.Time
+}
+
+// holds reports whether the jobs of class p are held instead of being
+// dispatched. Without a queue, all of them but the Scavenger jobs are
+// handed over to the workers.
+func (l *lane) holds(p Priority) bool {
+	return (p == Scavenger) || ((p != Normal) && !l.direct)
+}
+
+// hold holds t until a worker of l picks it up. It must be called with
+// submitMu held for reading, such that the lane is not swapped meanwhile.
+func (l *lane) hold(t *task, now time.Time) {
+	atomic.AddInt32(&l.jobs, 1)
+	t.heldOn.Store(l)
+	l.heldMu.Lock()
+	switch p := t.opts.Priority; {
+	case p > Normal:
+		l.high = append(l.high, t)
+	case p == Low:
+		l.low = append(l.low, lowJob{t, now})
+	default:
+		l.scav = append(l.scav, t)
+	}
+	atomic.AddInt32(&l.held, 1)
+	l.heldMu.Unlock()
+	l.signal()
+}
+
+// boost moves t, if held in the Low or Scavenger class, to the High class,
+// such that it is picked up next
+func (l *lane) boost(t *task) {
+	defer l.heldMu.Unlock()
+	l.heldMu.Lock()
+	for i, j := range l.low {
+		if j.t == t {
+			l.low = append(l.low[:i], l.low[i+1:]...)
+			l.high = append(l.high, t)
+			return
+		}
+	}
+	for i, j := range l.scav {
+		if j == t {
+			l.scav = append(l.scav[:i], l.scav[i+1:]...)
+			l.high = append(l.high, t)
+			return
+		}
+	}
+}
+
+// signal wakes up an idle worker to pick up a held job
+func (l *lane) signal() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the held job of l to run next, if any: the oldest High job,
+// or else a Low job that waited for longer than the aging, or else, while
+// no Normal job is waiting for a worker, the oldest Low or Scavenger job
+func (gw *GoWorkers) pop(l *lane) *task {
+	if atomic.LoadInt32(&l.held) == 0 {
+		return nil
+	}
+	defer l.heldMu.Unlock()
+	l.heldMu.Lock()
+
+	var t *task
+	switch {
+	case len(l.high) != 0:
+		t = l.high[0]
+		l.high[0] = nil
+		l.high = l.high[1:]
+	case (len(l.low) != 0) && (gw.clock.Now().Sub(l.low[0].since) >= gw.aging):
+		t = l.popLow()
+	case atomic.LoadInt32(&l.pending) != 0:
+		return nil
+	case len(l.low) != 0:
+		t = l.popLow()
+	case len(l.scav) != 0:
+		t = l.scav[0]
+		l.scav[0] = nil
+		l.scav = l.scav[1:]
+	default:
+		return nil
+	}
+	// the other idle workers may pick up the next ones
+	if atomic.AddInt32(&l.held, -1) != 0 {
+		l.signal()
+	}
+	return t
+}
+
+// popLow must be called with heldMu held
+func (l *lane) popLow() *task {
+	t := l.low[0].t
+	l.low[0] = lowJob{}
+	l.low = l.low[1:]
+	return t
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScavenger(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	defer gw.Stop(false)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func() {
+		return func() {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	// the regular jobs submitted after the Scavenger jobs run first
+	gw.Submit(record("vacuum"), JobOptions{Priority: Scavenger})
+	gw.Submit(record("compact"), JobOptions{Priority: Scavenger})
+	gw.Submit(record("request"))
+	gw.Submit(record("report"))
+	close(release)
+	gw.Wait(false)
+
+	// the order of the regular jobs among themselves is not guaranteed
+	if (len(order) == 4) && (order[0] == "report") {
+		order[0], order[1] = order[1], order[0]
+	}
+	if want := []string{"request", "report", "vacuum", "compact"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected %v, Got %v", want, order)
+	}
+}
+
+func TestScavengerIdle(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	// a Scavenger job starts a worker if none is left
+	gw.Shrink(gw.WorkerNum())
+	waitWorkerNum(t, gw, 0)
+
+	ran := make(chan struct{})
+	gw.Submit(func() { close(ran) }, JobOptions{Priority: Scavenger})
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the Scavenger job to run on an idle pool")
+	}
+}
+
+func TestSubmitWithPriority(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	defer gw.Stop(false)
+
+	var order []string
+	record := func(name string) func() {
+		return func() { order = append(order, name) }
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	gw.SubmitWithPriority(Scavenger, record("vacuum"))
+	gw.SubmitWithPriority(Low, record("export"))
+	gw.SubmitWithPriority(Normal, record("request"))
+	gw.SubmitWithPriority(High, record("checkout"))
+	close(release)
+	gw.Wait(false)
+
+	if want := []string{"checkout", "request", "export", "vacuum"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected %v, Got %v", want, order)
+	}
+}
+
+func TestPriorityAging(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1000, 0))
+	gw := New(Options{Workers: 1, Clock: clock, PriorityAging: time.Minute})
+	defer gw.Stop(false)
+
+	var order []string
+	record := func(name string) func() {
+		return func() { order = append(order, name) }
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	// the Low job waited long enough to go ahead of the Normal ones
+	gw.SubmitWithPriority(Low, record("export"))
+	clock.Advance(time.Minute)
+	gw.Submit(record("request"))
+	close(release)
+	gw.Wait(false)
+
+	if want := []string{"export", "request"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected %v, Got %v", want, order)
+	}
+}
+
+func TestPriorityString(t *testing.T) {
+	tables := []struct {
+		Given    Priority
+		Expected string
+	}{
+		{Scavenger, "scavenger"},
+		{Low, "low"},
+		{Normal, "normal"},
+		{High, "high"},
+		{High + 1, "unknown"},
+	}
+
+	for _, table := range tables {
+		if got := table.Given.String(); got != table.Expected {
+			t.Errorf("Expected %s, Got %s", table.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrQuarantined is wrapped by the errors reported for the store jobs that
+// are quarantined by ConsumeStore().
+var ErrQuarantined = errors.New("goworkers: job quarantined")
+
+// quarantine holds the idempotency keys of the poison pills of a pool
+type quarantine struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// Quarantined reports whether the jobs of the idempotency key are
+// quarantined, see ConsumeStore().
+func (gw *GoWorkers) Quarantined(key string) bool {
+	defer gw.quarantine.mu.Unlock()
+	gw.quarantine.mu.Lock()
+	_, ok := gw.quarantine.keys[key]
+	return ok
+}
+
+// Unquarantine lets the jobs of the idempotency key run again, e.g. once the
+// bug they triggered is fixed.
+func (gw *GoWorkers) Unquarantine(key string) {
+	defer gw.quarantine.mu.Unlock()
+	gw.quarantine.mu.Lock()
+	delete(gw.quarantine.keys, key)
+}
+
+func (gw *GoWorkers) quarantineKey(key string) {
+	if key == "" {
+		return
+	}
+	defer gw.quarantine.mu.Unlock()
+	gw.quarantine.mu.Lock()
+	if gw.quarantine.keys == nil {
+		gw.quarantine.keys = make(map[string]struct{})
+	}
+	gw.quarantine.keys[key] = struct{}{}
+}
+
+// poisonPill returns why the store job sj should be quarantined, if it
+// should be
+func (gw *GoWorkers) poisonPill(e Envelope, sj StoredJob, maxAttempts uint32) error {
+	switch {
+	case (e.Key != "") && gw.Quarantined(e.Key):
+		return fmt.Errorf("%w: key %s is quarantined", ErrQuarantined, e.Key)
+	case sj.Attempts > maxAttempts:
+		// failed runs are dead-lettered after maxAttempts, so the others
+		// crashed their consumer or outlived their lease
+		return fmt.Errorf("%w: leased %d times without finishing", ErrQuarantined, sj.Attempts-1)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConsumeStoreQuarantine(t *testing.T) {
+	s := NewMemoryStore()
+	pill := enqueue(t, s, Envelope{Name: "noop", Key: "pill"})
+	// leases of consumers that crashed while running the job
+	for i := 0; i < 3; i++ {
+		if _, err := s.Lease(0); err != nil {
+			t.Fatalf("Expected nil, Got %v", err)
+		}
+	}
+	duplicate := enqueue(t, s, Envelope{Name: "noop", Key: "pill"})
+	healthy := enqueue(t, s, Envelope{Name: "noop", Key: "healthy"})
+
+	gw := New()
+	defer gw.Stop(false)
+
+	atomic.StoreInt32(&noopRuns, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- gw.ConsumeStore(ctx, s, StoreOptions{MaxAttempts: 3, PollInterval: time.Millisecond})
+	}()
+
+	for s.Len() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if n := atomic.LoadInt32(&noopRuns); n != 1 {
+		t.Errorf("Expected only the healthy job to run, Got %d runs", n)
+	}
+
+	dead := s.DeadLetters()
+	tables := []struct {
+		id     string
+		reason string
+	}{
+		{pill, "goworkers: job quarantined: leased 3 times without finishing"},
+		{duplicate, "goworkers: job quarantined: key pill is quarantined"},
+		{healthy, ""},
+	}
+	for _, table := range tables {
+		if dead[table.id] != table.reason {
+			t.Errorf("%s: Expected %q, Got %q", table.id, table.reason, dead[table.id])
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		var je *JobError
+		if err := <-gw.ErrChan; !errors.As(err, &je) || !errors.Is(err, ErrQuarantined) || !strings.HasPrefix(je.Error(), "noop: ") {
+			t.Errorf("Expected a quarantined noop job, Got %v", err)
+		}
+	}
+
+	if !gw.Quarantined("pill") || gw.Quarantined("healthy") {
+		t.Errorf("Expected only the key pill to be quarantined")
+	}
+	gw.Unquarantine("pill")
+	if gw.Quarantined("pill") {
+		t.Errorf("Expected the key pill to be released")
+	}
+}
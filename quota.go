@@ -0,0 +1,146 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrQuotaExceeded is wrapped by the errors returned for jobs rejected by
+// the quota of their tenant.
+var ErrQuotaExceeded = errors.New("goworkers: quota exceeded")
+
+// Quota caps the jobs of a tenant, see GoWorkers.SetQuota().
+//
+// Running is the maximum number of jobs of the tenant handed over to the
+// workers at once, running or waiting for a worker.
+// If zero, the jobs of the tenant are not capped.
+//
+// Queued is the maximum number of jobs of the tenant held back, in
+// submission order, while Running jobs of the tenant are in progress.
+// If zero, jobs in excess of Running are rejected.
+type Quota struct {
+	Running uint32
+	Queued  uint32
+}
+
+// QuotaError is returned for a job rejected by the quota of its tenant.
+type QuotaError struct {
+	Tenant string
+	Quota  Quota
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%v for tenant %s (running %d, queued %d)", ErrQuotaExceeded, e.Tenant, e.Quota.Running, e.Quota.Queued)
+}
+
+// Unwrap returns ErrQuotaExceeded.
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// tenantQuotas holds the quotas and the jobs in progress by tenant
+type tenantQuotas struct {
+	mu      sync.Mutex
+	quotas  map[string]Quota
+	tenants map[string]*tenantJobs
+}
+
+type tenantJobs struct {
+	running uint32
+	held    []*task
+}
+
+// SetQuota enforces q on the jobs submitted with JobOptions{Tenant: tenant},
+// such that a tenant cannot monopolize a pool shared by many.
+//
+// Jobs rejected by the quota are not run and their submission returns a
+// *QuotaError, or false for TrySubmit(). A zero Quota removes the quota of
+// the tenant. Raising or removing a quota releases the held jobs it allows.
+func (gw *GoWorkers) SetQuota(tenant string, q Quota) {
+	tq := &gw.quotas
+	tq.mu.Lock()
+	if q.Running == 0 {
+		delete(tq.quotas, tenant)
+	} else {
+		if tq.quotas == nil {
+			tq.quotas = make(map[string]Quota)
+		}
+		tq.quotas[tenant] = q
+	}
+
+	var released []*task
+	if st := tq.tenants[tenant]; st != nil {
+		for (len(st.held) != 0) && ((q.Running == 0) || (st.running < q.Running)) {
+			released = append(released, st.held[0])
+			st.held = st.held[1:]
+			st.running++
+		}
+	}
+	tq.mu.Unlock()
+
+	for _, t := range released {
+		gw.enqueue(t)
+	}
+}
+
+// admit applies the quota of the tenant of t. It reports whether t is held
+// back, or returns a *QuotaError if t is rejected.
+func (tq *tenantQuotas) admit(t *task) (bool, error) {
+	tenant := t.opts.Tenant
+	if tenant == "" {
+		return false, nil
+	}
+	defer tq.mu.Unlock()
+	tq.mu.Lock()
+	q, ok := tq.quotas[tenant]
+	st := tq.tenants[tenant]
+	if !ok && (st == nil) {
+		return false, nil
+	}
+	if st == nil {
+		st = &tenantJobs{}
+		if tq.tenants == nil {
+			tq.tenants = make(map[string]*tenantJobs)
+		}
+		tq.tenants[tenant] = st
+	}
+
+	switch {
+	case !ok || (st.running < q.Running):
+		st.running++
+		return false, nil
+	case uint32(len(st.held)) < q.Queued:
+		st.held = append(st.held, t)
+		return true, nil
+	}
+	return false, &QuotaError{Tenant: tenant, Quota: q}
+}
+
+// release accounts for a finished job of the tenant and returns the next
+// held job of the tenant to hand over, if any
+func (tq *tenantQuotas) release(tenant string) *task {
+	if tenant == "" {
+		return nil
+	}
+	defer tq.mu.Unlock()
+	tq.mu.Lock()
+	st := tq.tenants[tenant]
+	if st == nil {
+		return nil
+	}
+	if len(st.held) != 0 {
+		t := st.held[0]
+		st.held = st.held[1:]
+		return t
+	}
+	st.running--
+	if st.running == 0 {
+		delete(tq.tenants, tenant)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQuota(t *testing.T) {
+	gw := New(Options{Workers: 8})
+	gw.SetQuota("acme", Quota{Running: 2, Queued: 1})
+
+	var running, maxRunning int32
+	release := make(chan struct{})
+	job := func() {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+	}
+	acme := JobOptions{Tenant: "acme"}
+
+	tables := []struct {
+		opts JobOptions
+		err  error
+	}{
+		{acme, nil},
+		{acme, nil},
+		{acme, nil},
+		{acme, ErrQuotaExceeded},
+		{JobOptions{Tenant: "globex"}, nil},
+		{JobOptions{}, nil},
+	}
+	for i, table := range tables {
+		if err := gw.Submit(job, table.opts); !errors.Is(err, table.err) {
+			t.Errorf("%d: Expected %v, Got %v", i, table.err, err)
+		}
+	}
+	for atomic.LoadInt32(&running) != 4 {
+	}
+	if ok := gw.TrySubmit(job, acme); ok {
+		t.Errorf("Expected the job to be rejected")
+	}
+
+	close(release)
+	gw.Stop(false)
+
+	if st := gw.Stats(); st.Completed != 5 {
+		t.Errorf("Expected 5, Got %d", st.Completed)
+	}
+	if max := atomic.LoadInt32(&maxRunning); max > 4 {
+		t.Errorf("Expected at most 2 jobs of acme along with the others, Got %d running", max)
+	}
+}
+
+func TestQuotaError(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+	gw.SetQuota("acme", Quota{Running: 1})
+
+	release := make(chan struct{})
+	defer close(release)
+	gw.Submit(func() { <-release }, JobOptions{Tenant: "acme"})
+
+	err := gw.Submit(func() {}, JobOptions{Tenant: "acme"})
+	var qe *QuotaError
+	if !errors.As(err, &qe) || qe.Tenant != "acme" || qe.Quota.Running != 1 {
+		t.Errorf("Expected a *QuotaError for acme, Got %v", err)
+	}
+	if err.Error() != "goworkers: quota exceeded for tenant acme (running 1, queued 0)" {
+		t.Errorf("Unexpected message %v", err)
+	}
+}
+
+func TestSetQuotaReleasesHeldJobs(t *testing.T) {
+	gw := New()
+	gw.SetQuota("acme", Quota{Running: 1, Queued: 2})
+
+	var ran int32
+	release := make(chan struct{})
+	gw.Submit(func() { <-release }, JobOptions{Tenant: "acme"})
+	for i := 0; i < 2; i++ {
+		gw.Submit(func() { atomic.AddInt32(&ran, 1) }, JobOptions{Tenant: "acme"})
+	}
+
+	gw.SetQuota("acme", Quota{})
+	for atomic.LoadInt32(&ran) != 2 {
+	}
+
+	close(release)
+	gw.Stop(false)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// RecycleWorkers replaces the workers of the pool with new ones, one at a
+// time, such that the per-worker resources, e.g. connections or caches
+// keyed by the worker ids, can be refreshed after an upstream failover
+// without recreating the pool.
+//
+// Every worker is replaced by a new one first, and is then retired as with
+// RetireWorker(), so that the jobs keep being picked up meanwhile. This is
+// a blocking call and returns once every worker active when it was called
+// finished its current job, if any, and exited.
+//
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited
+// for, and ErrCalledFromJob, instead of deadlocking, if called from a job of
+// the pool.
+func (gw *GoWorkers) RecycleWorkers() error {
+	if gw.calledFromJob() {
+		return ErrCalledFromJob
+	}
+	if atomic.LoadInt32(&gw.stopping) != stateRunning {
+		return ErrStopped
+	}
+
+	gw.mx.Lock()
+	l := gw.current()
+	old := make([]*worker, 0, len(gw.workers))
+	for _, w := range gw.workers {
+		// the workers of a lane replaced by ReplaceWith() are exiting
+		// already
+		if w.lane == l {
+			old = append(old, w)
+		}
+	}
+	gw.mx.Unlock()
+	sort.Slice(old, func(i, j int) bool { return old[i].id < old[j].id })
+
+	for _, w := range old {
+		gw.mx.Lock()
+		// the workers of a stopped pool are exiting already
+		if atomic.LoadInt32(&gw.stopping) != stateRunning {
+			gw.mx.Unlock()
+			return ErrStopped
+		}
+		// the worker may have been retired meanwhile
+		if _, ok := gw.workers[w.id]; ok {
+			gw.launchWorker(l)
+			gw.retire(w)
+		}
+		gw.mx.Unlock()
+		<-w.exited
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"testing"
+)
+
+func TestRecycleWorkers(t *testing.T) {
+	gw := New(Options{Workers: 2})
+	gw.PreSpawn(2)
+	old := gw.WorkerIDs()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	recycled := make(chan error)
+	go func() { recycled <- gw.RecycleWorkers() }()
+
+	// the jobs keep being picked up while the running job finishes
+	ran := make(chan struct{})
+	gw.Submit(func() { close(ran) })
+	<-ran
+	select {
+	case <-recycled:
+		t.Errorf("Expected RecycleWorkers to wait for the running job")
+	default:
+	}
+	close(release)
+	if err := <-recycled; err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	ids := gw.WorkerIDs()
+	if len(ids) != 2 {
+		t.Errorf("Expected 2 workers, Got %v", ids)
+	}
+	for _, id := range ids {
+		for _, o := range old {
+			if id == o {
+				t.Errorf("Expected worker %d to be replaced", id)
+			}
+		}
+	}
+
+	gw.Submit(func() {
+		if err := gw.RecycleWorkers(); err != ErrCalledFromJob {
+			t.Errorf("Expected %v, Got %v", ErrCalledFromJob, err)
+		}
+	})
+	gw.Stop(false)
+	if err := gw.RecycleWorkers(); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+}
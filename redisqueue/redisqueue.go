@@ -0,0 +1,201 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package redisqueue implements a durable queue shared by several processes
+// running goworkers, backed by a Redis stream and a consumer group.
+//
+// A job is acknowledged only after its handler succeeds, unless
+// Options.Delivery is goworkers.AtMostOnce. Jobs that were
+// delivered to a consumer that crashed, or whose handler failed, stay pending
+// in the consumer group and are re-delivered to a live consumer once they
+// have been idle for Options.MinIdle.
+//
+// The package does not depend on a Redis driver. Wrap the driver of your
+// choice to implement Client; it takes a handful of lines with go-redis.
+package redisqueue
+
+import (
+	"context"
+	"time"
+
+	"github.com/dpaks/goworkers"
+	"github.com/dpaks/goworkers/internal/inflight"
+)
+
+const (
+	defaultPrefetch = 16
+	defaultMinIdle  = 30 * time.Second
+	defaultBlock    = time.Second
+)
+
+// Message is an entry of the stream.
+type Message struct {
+	ID      string
+	Payload []byte
+}
+
+// Client is the subset of Redis stream commands used by the queue.
+type Client interface {
+	// XAdd appends payload to the stream and returns the id of the entry.
+	XAdd(ctx context.Context, stream string, payload []byte) (string, error)
+	// XGroupCreate creates the consumer group, and the stream if it does not
+	// exist. It must not fail if the group already exists.
+	XGroupCreate(ctx context.Context, stream, group string) error
+	// XReadGroup reads up to count new entries for consumer, blocking for up
+	// to block if there are none.
+	XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]Message, error)
+	// XAck acknowledges the entries.
+	XAck(ctx context.Context, stream, group string, ids ...string) error
+	// XAutoClaim transfers up to count entries that are pending for longer
+	// than minIdle to consumer, scanning from start. It returns the claimed
+	// entries and the id to start the next scan from.
+	XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]Message, string, error)
+}
+
+// Handler processes the payload of a job. The job is acknowledged if the
+// handler returns nil.
+type Handler func(payload []byte) error
+
+// Options configures the queue.
+//
+// Stream and Group name the Redis stream and its consumer group.
+//
+// Consumer uniquely names this process within the group.
+//
+// Prefetch specifies the maximum number of jobs handed over to the pool but
+// not yet acknowledged. If unspecified or zero, 16 is used.
+//
+// MinIdle specifies for how long a job must stay unacknowledged before it is
+// re-delivered. If unspecified or zero, 30 seconds is used.
+//
+// Block specifies for how long a read waits for new jobs.
+// If unspecified or zero, 1 second is used.
+//
+// Delivery specifies the delivery semantics. Default is
+// goworkers.AtLeastOnce. With goworkers.AtMostOnce, a job is acknowledged
+// right before its handler runs.
+//
+// Encrypter, if set, encrypts the payloads before they are added to the
+// stream, and decrypts them before they are handed to the handler. A payload
+// that cannot be decrypted is not acknowledged, as if its handler failed.
+type Options struct {
+	Stream    string
+	Group     string
+	Consumer  string
+	Prefetch  uint32
+	MinIdle   time.Duration
+	Block     time.Duration
+	Delivery  goworkers.Delivery
+	Encrypter goworkers.Encrypter
+}
+
+// Queue is a durable queue backed by a Redis stream.
+type Queue struct {
+	client Client
+	opts   Options
+}
+
+// New creates a new queue.
+func New(client Client, opts Options) *Queue {
+	if opts.Prefetch == 0 {
+		opts.Prefetch = defaultPrefetch
+	}
+	if opts.MinIdle == 0 {
+		opts.MinIdle = defaultMinIdle
+	}
+	if opts.Block == 0 {
+		opts.Block = defaultBlock
+	}
+	return &Queue{client: client, opts: opts}
+}
+
+// Enqueue durably adds a job to the queue and returns its id.
+func (q *Queue) Enqueue(ctx context.Context, payload []byte) (string, error) {
+	if q.opts.Encrypter != nil {
+		ciphertext, err := q.opts.Encrypter.Encrypt(payload)
+		if err != nil {
+			return "", err
+		}
+		payload = ciphertext
+	}
+	return q.client.XAdd(ctx, q.opts.Stream, payload)
+}
+
+// Consume submits the jobs of the queue to gw until ctx is done.
+//
+// This is a blocking call. It returns after the jobs handed over to the pool
+// have finished, with the context's error or the first error of the client.
+func (q *Queue) Consume(ctx context.Context, gw *goworkers.GoWorkers, h Handler) error {
+	if err := q.client.XGroupCreate(ctx, q.opts.Stream, q.opts.Group); err != nil {
+		return err
+	}
+	h = q.decrypt(h)
+
+	// bounds the number of unacknowledged jobs of this consumer
+	limiter := inflight.New(q.opts.Prefetch)
+	defer limiter.Wait()
+
+	claimFrom := "0-0"
+	lastClaim := time.Time{}
+
+	for {
+		n, err := limiter.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		free := int64(n)
+
+		var msgs []Message
+		// reclaim the jobs of crashed consumers first
+		if time.Since(lastClaim) >= q.opts.MinIdle/2 {
+			msgs, claimFrom, err = q.client.XAutoClaim(ctx, q.opts.Stream, q.opts.Group, q.opts.Consumer, q.opts.MinIdle, claimFrom, free)
+			if claimFrom == "0-0" || claimFrom == "" {
+				claimFrom = "0-0"
+				lastClaim = time.Now()
+			}
+		}
+		if err == nil && len(msgs) == 0 {
+			msgs, err = q.client.XReadGroup(ctx, q.opts.Stream, q.opts.Group, q.opts.Consumer, free, q.opts.Block)
+		}
+
+		// give back the slots that were not used
+		limiter.Release(n - len(msgs))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		for _, msg := range msgs {
+			m := msg
+			// if rejected, the job is left pending and re-delivered after MinIdle
+			limiter.Submit(gw, func() {
+				if q.opts.Delivery == goworkers.AtMostOnce {
+					if q.client.XAck(context.Background(), q.opts.Stream, q.opts.Group, m.ID) == nil {
+						_ = h(m.Payload)
+					}
+					return
+				}
+				if h(m.Payload) == nil {
+					_ = q.client.XAck(context.Background(), q.opts.Stream, q.opts.Group, m.ID)
+				}
+			})
+		}
+	}
+}
+
+// decrypt wraps h to decrypt the payloads, if encrypted
+func (q *Queue) decrypt(h Handler) Handler {
+	if q.opts.Encrypter == nil {
+		return h
+	}
+	return func(payload []byte) error {
+		plaintext, err := q.opts.Encrypter.Decrypt(payload)
+		if err != nil {
+			return err
+		}
+		return h(plaintext)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package redisqueue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+type pending struct {
+	msg       Message
+	consumer  string
+	delivered time.Time
+}
+
+// fakeClient is an in-memory stream with a single consumer group
+type fakeClient struct {
+	mu      sync.Mutex
+	entries []Message
+	next    int
+	pending map[string]*pending
+	acked   map[string]int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{pending: make(map[string]*pending), acked: make(map[string]int)}
+}
+
+func (c *fakeClient) XAdd(ctx context.Context, stream string, payload []byte) (string, error) {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+	id := strconv.Itoa(len(c.entries)+1) + "-0"
+	c.entries = append(c.entries, Message{ID: id, Payload: payload})
+	return id, nil
+}
+
+func (c *fakeClient) XGroupCreate(ctx context.Context, stream, group string) error {
+	return nil
+}
+
+func (c *fakeClient) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]Message, error) {
+	c.mu.Lock()
+	var msgs []Message
+	for ; c.next < len(c.entries) && int64(len(msgs)) < count; c.next++ {
+		m := c.entries[c.next]
+		c.pending[m.ID] = &pending{msg: m, consumer: consumer, delivered: time.Now()}
+		msgs = append(msgs, m)
+	}
+	c.mu.Unlock()
+
+	if len(msgs) == 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(block):
+		}
+	}
+	return msgs, nil
+}
+
+func (c *fakeClient) XAck(ctx context.Context, stream, group string, ids ...string) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+	for _, id := range ids {
+		delete(c.pending, id)
+		c.acked[id]++
+	}
+	return nil
+}
+
+func (c *fakeClient) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]Message, string, error) {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+	var msgs []Message
+	for _, p := range c.pending {
+		if int64(len(msgs)) < count && time.Since(p.delivered) >= minIdle {
+			p.consumer = consumer
+			p.delivered = time.Now()
+			msgs = append(msgs, p.msg)
+		}
+	}
+	return msgs, "0-0", nil
+}
+
+func (c *fakeClient) ackedNum() int {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+	return len(c.acked)
+}
+
+func TestConsume(t *testing.T) {
+	client := newFakeClient()
+	q := New(client, Options{Stream: "jobs", Group: "workers", Consumer: "c1", Block: 10 * time.Millisecond})
+
+	for i := 0; i < 50; i++ {
+		if _, err := q.Enqueue(context.Background(), []byte(fmt.Sprint(i))); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- q.Consume(ctx, gw, func(payload []byte) error { return nil })
+	}()
+
+	for client.ackedNum() != 50 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+}
+
+func TestRedelivery(t *testing.T) {
+	client := newFakeClient()
+	opts := Options{Stream: "jobs", Group: "workers", MinIdle: 50 * time.Millisecond, Block: 10 * time.Millisecond}
+
+	id, _ := New(client, opts).Enqueue(context.Background(), []byte("job"))
+
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	// the first consumer fails to process the job
+	opts.Consumer = "c1"
+	ctx, cancel := context.WithCancel(context.Background())
+	go New(client, opts).Consume(ctx, gw, func(payload []byte) error {
+		cancel()
+		return fmt.Errorf("crashed")
+	})
+	<-ctx.Done()
+
+	// the second consumer gets the job re-delivered
+	opts.Consumer = "c2"
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel2()
+	redelivered := make(chan string, 1)
+	go New(client, opts).Consume(ctx2, gw, func(payload []byte) error {
+		redelivered <- string(payload)
+		return nil
+	})
+
+	select {
+	case p := <-redelivered:
+		if p != "job" {
+			t.Errorf("Expected job, Got %s", p)
+		}
+	case <-ctx2.Done():
+		t.Fatalf("The job was not re-delivered")
+	}
+
+	for client.ackedNum() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.acked[id] != 1 {
+		t.Errorf("Expected %s to be acknowledged once", id)
+	}
+}
+
+func TestEncrypter(t *testing.T) {
+	e, err := goworkers.NewAESGCM(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	client := newFakeClient()
+	q := New(client, Options{Stream: "jobs", Group: "workers", Consumer: "c1", Block: 10 * time.Millisecond, Encrypter: e})
+
+	if _, err := q.Enqueue(context.Background(), []byte("jane@example.com")); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if p := string(client.entries[0].Payload); strings.Contains(p, "jane") {
+		t.Errorf("Expected the payload to be encrypted in the stream, Got %q", p)
+	}
+
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	payloads := make(chan string, 1)
+	go func() {
+		_ = q.Consume(ctx, gw, func(payload []byte) error {
+			payloads <- string(payload)
+			return nil
+		})
+	}()
+
+	if p := <-payloads; p != "jane@example.com" {
+		t.Errorf("Expected %q, Got %q", "jane@example.com", p)
+	}
+}
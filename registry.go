@@ -0,0 +1,141 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownJobType is returned when a job type has not been registered.
+var ErrUnknownJobType = errors.New("goworkers: unknown job type")
+
+// Job is a job built from a registered job type.
+//
+// Unlike closures, jobs of registered types can be persisted, snapshotted
+// and submitted remotely as they are fully described by their type name and
+// payload.
+type Job interface {
+	Run() error
+}
+
+// Format is the serialization format of an Envelope.
+type Format int
+
+const (
+	// JSON encodes envelopes with encoding/json.
+	JSON Format = iota
+	// Gob encodes envelopes with encoding/gob.
+	Gob
+)
+
+// Envelope is the serializable description of a job: its registered type
+// name, its payload, the metadata carried along with its outcome and its
+// idempotency key, if any.
+type Envelope struct {
+	Name     string            `json:"name"`
+	Payload  []byte            `json:"payload"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Key      string            `json:"key,omitempty"`
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]func(payload []byte) Job)
+)
+
+// RegisterJobType makes the job type name available for decoding.
+//
+// factory builds a job from its payload. As with database/sql drivers,
+// registering a name twice or registering a nil factory panics.
+func RegisterJobType(name string, factory func(payload []byte) Job) {
+	defer registryMu.Unlock()
+	registryMu.Lock()
+	if factory == nil {
+		panic("goworkers: RegisterJobType factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("goworkers: RegisterJobType called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// Job builds the job described by the envelope using the factory registered
+// for its type. Returns ErrUnknownJobType if the type is not registered.
+func (e Envelope) Job() (Job, error) {
+	registryMu.RLock()
+	factory, ok := registry[e.Name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, e.Name)
+	}
+	return factory(e.Payload), nil
+}
+
+// options returns the job options for the job described by the envelope
+func (e Envelope) options() JobOptions {
+	return JobOptions{Name: e.Name, Metadata: e.Metadata, Key: e.Key}
+}
+
+// Encode serializes the envelope in format f.
+func (e Envelope) Encode(f Format) ([]byte, error) {
+	switch f {
+	case JSON:
+		return json.Marshal(e)
+	case Gob:
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("goworkers: unknown format %d", f)
+}
+
+// DecodeEnvelope deserializes an envelope encoded in format f.
+func DecodeEnvelope(f Format, data []byte) (Envelope, error) {
+	var e Envelope
+	switch f {
+	case JSON:
+		err := json.Unmarshal(data, &e)
+		return e, err
+	case Gob:
+		err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e)
+		return e, err
+	}
+	return e, fmt.Errorf("goworkers: unknown format %d", f)
+}
+
+// SubmitJob is a non-blocking call with arg of type Job
+//
+// Use ErrChan buffered channel to read error, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (gw *GoWorkers) SubmitJob(job Job, args ...JobOptions) error {
+	run := runContext(job)
+	return gw.submit(&task{
+		run: func(ctx context.Context) (interface{}, error) {
+			return nil, run(ctx)
+		},
+		outputs: errOutput,
+		opts:    jobOptions(args),
+	})
+}
+
+// SubmitEnvelope builds the job described by e and submits it, as with
+// SubmitJob(), named after its type and with the metadata of e.
+// Returns ErrUnknownJobType if the type of the job is not registered.
+func (gw *GoWorkers) SubmitEnvelope(e Envelope) error {
+	job, err := e.Job()
+	if err != nil {
+		return err
+	}
+	return gw.SubmitJob(job, e.options())
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type greetJob struct {
+	name string
+}
+
+func (j greetJob) Run() error {
+	return fmt.Errorf("hello %s", j.name)
+}
+
+func init() {
+	RegisterJobType("greet", func(payload []byte) Job {
+		return greetJob{name: string(payload)}
+	})
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	for _, f := range []Format{JSON, Gob} {
+		data, err := Envelope{Name: "greet", Payload: []byte("gopher"), Metadata: map[string]string{"tenant": "acme"}}.Encode(f)
+		if err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+
+		e, err := DecodeEnvelope(f, data)
+		if err != nil {
+			t.Fatalf("Failed to decode: %v", err)
+		}
+		if e.Metadata["tenant"] != "acme" {
+			t.Errorf("Expected acme, Got %q", e.Metadata["tenant"])
+		}
+
+		job, err := e.Job()
+		if err != nil {
+			t.Fatalf("Failed to build job: %v", err)
+		}
+		if err := job.Run(); err.Error() != "hello gopher" {
+			t.Errorf("Expected hello gopher, Got %v", err)
+		}
+	}
+}
+
+func TestUnknownJobType(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	if err := gw.SubmitEnvelope(Envelope{Name: "unknown"}); !errors.Is(err, ErrUnknownJobType) {
+		t.Errorf("Expected %v, Got %v", ErrUnknownJobType, err)
+	}
+}
+
+func TestRegisterJobTypeTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic")
+		}
+	}()
+	RegisterJobType("greet", func(payload []byte) Job { return nil })
+}
+
+func TestSubmitEnvelope(t *testing.T) {
+	gw := New()
+
+	if err := gw.SubmitEnvelope(Envelope{Name: "greet", Payload: []byte("pool")}); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	var je *JobError
+	if err := <-gw.ErrChan; !errors.As(err, &je) || je.Name != "greet" || je.Err.Error() != "hello pool" {
+		t.Errorf("Expected hello pool from job greet, Got %v", err)
+	}
+
+	gw.Stop(false)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// lane is a set of queues along with the workers reading them. A pool has a
+// single lane taking the submissions, and ReplaceWith() swaps it for a new
+// one while the old one drains.
+type lane struct {
+	workerQ   chan *task
+	bufferedQ chan *task
+	// direct lanes have no queue, the submissions hand over their jobs to
+	// the workers on workerQ, see Options.DirectHandoff
+	direct bool
+	// the size of the queue, see ResizeQueue(). The jobs are handed over
+	// from bufferedQ right away, so its capacity does not bound the queue.
+	size uint32
+	// Do not remove jobQ. To stop receiving input once Stop() is called
+	jobQ chan *task
+	// jobs pushed to the lane and not finished yet
+	jobs int32
+	// Normal jobs pushed to the lane and not picked up by a worker yet
+	pending int32
+	// the jobs of the other priority classes wait in queues of their own,
+	// see hold(), and idle workers are signalled on wake
+	held   int32
+	heldMu sync.Mutex
+	high   []*task
+	low    []lowJob
+	scav   []*task
+	wake   chan struct{}
+
+	retired int32
+	once    sync.Once
+	drained chan struct{}
+}
+
+func newLane(qsize uint32, direct bool) *lane {
+	if (qsize == 0) && !direct {
+		qsize = defaultQSize
+	}
+	return &lane{
+		workerQ:   make(chan *task),
+		bufferedQ: make(chan *task, qsize),
+		direct:    direct,
+		size:      qsize,
+		jobQ:      make(chan *task),
+		drained:   make(chan struct{}),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// push pushes a Normal job. It must be called with submitMu held for
+// reading, such that the lane is not swapped meanwhile.
+func (l *lane) push(t *task) {
+	atomic.AddInt32(&l.jobs, 1)
+	atomic.AddInt32(&l.pending, 1)
+	l.jobQ <- t
+}
+
+// reserve accounts for a Normal job to be handed off to a worker of a
+// direct lane. It must be called with submitMu held for reading, such that
+// the lane is not drained meanwhile.
+func (l *lane) reserve() {
+	atomic.AddInt32(&l.jobs, 1)
+	atomic.AddInt32(&l.pending, 1)
+}
+
+// handOff hands over a reserved job, blocking until a worker takes it. It
+// must be called without submitMu held, see submit().
+func (l *lane) handOff(t *task) {
+	l.workerQ <- t
+}
+
+// finish accounts for a job of the lane that finished running, and closes
+// a retired lane once it is drained
+func (l *lane) finish() {
+	if (atomic.AddInt32(&l.jobs, -1) == 0) && (atomic.LoadInt32(&l.retired) == 1) {
+		l.close()
+	}
+}
+
+func (l *lane) retire() {
+	atomic.StoreInt32(&l.retired, 1)
+	if atomic.LoadInt32(&l.jobs) == 0 {
+		l.close()
+	}
+}
+
+// close stops the dispatcher and the workers of the lane
+func (l *lane) close() {
+	l.once.Do(func() {
+		close(l.jobQ)
+		close(l.drained)
+	})
+}
+
+// current returns the lane taking the submissions
+func (gw *GoWorkers) current() *lane {
+	return gw.lane.Load().(*lane)
+}
+
+// qsize returns the size of the queue
+func (gw *GoWorkers) qsize() uint32 {
+	return atomic.LoadUint32(&gw.current().size)
+}
+
+// ReplaceWith replaces the workers and the queue of the pool with new ones
+// configured with opts, such that settings that ApplyOptions() cannot change,
+// e.g. DirectHandoff, can be changed without stopping the pool.
+//
+// The submissions are routed to the new workers right away, while the old
+// workers finish the jobs submitted before, which are neither dropped nor
+// rejected. This is a blocking call and returns once the old workers are
+// done.
+//
+// Workers, QSize, DirectHandoff, WorkerRate and MaxQueueWait are replaced. The other
+// options are ignored and the pool keeps its own. Deterministic pools cannot
+// be replaced.
+//
+// The options are validated first, and nothing is changed if they are invalid.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited
+// for, and ErrCalledFromJob, instead of deadlocking, if called from a job of
+// the pool.
+func (gw *GoWorkers) ReplaceWith(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.Deterministic || (gw.det != nil) {
+		return invalid("ReplaceWith is not supported in deterministic mode")
+	}
+	if gw.calledFromJob() {
+		return ErrCalledFromJob
+	}
+
+	gw.submitMu.Lock()
+	if atomic.LoadInt32(&gw.stopping) != stateRunning {
+		gw.submitMu.Unlock()
+		return ErrStopped
+	}
+
+	l := newLane(opts.QSize, opts.DirectHandoff)
+	atomic.AddInt32(&gw.numDispatchers, 1)
+	go gw.start(l)
+
+	gw.mx.Lock()
+	old := gw.current()
+	gw.lane.Store(l)
+	atomic.StoreUint32(&gw.maxWorkers, opts.Workers)
+	atomic.StoreInt64(&gw.workerInterval, rateInterval(opts.WorkerRate))
+	atomic.StoreInt64(&gw.maxQueueWait, int64(opts.MaxQueueWait))
+	// start a worker in advance
+	gw.launchWorker(l)
+	gw.mx.Unlock()
+	gw.submitMu.Unlock()
+
+	old.retire()
+	<-old.drained
+	return nil
+}
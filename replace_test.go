@@ -0,0 +1,81 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplaceWith(t *testing.T) {
+	gw := New(Options{Workers: 2})
+
+	release := make(chan struct{})
+	var old int32
+	for i := 0; i < 4; i++ {
+		_ = gw.Submit(func() {
+			<-release
+			atomic.AddInt32(&old, 1)
+		})
+	}
+
+	replaced := make(chan error)
+	go func() {
+		replaced <- gw.ReplaceWith(Options{Workers: 4, QSize: 512})
+	}()
+
+	// submissions are taken by the new workers while the old ones are busy
+	ran := make(chan struct{})
+	for gw.qsize() != 512 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := gw.Submit(func() { close(ran) }); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	<-ran
+
+	select {
+	case err := <-replaced:
+		t.Fatalf("Expected ReplaceWith to wait for the old workers, Got %v", err)
+	default:
+	}
+	close(release)
+	if err := <-replaced; err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	if n := atomic.LoadInt32(&old); n != 4 {
+		t.Errorf("Expected the jobs of the old workers to run, Got %v of %v", n, 4)
+	}
+	if max := gw.MaxWorkers(); max != 4 {
+		t.Errorf("Expected %v, Got %v", 4, max)
+	}
+
+	gw.Stop(false)
+	if err := gw.VerifyShutdown(time.Second); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+}
+
+func TestReplaceWithErrors(t *testing.T) {
+	gw := New()
+	replaced := make(chan error, 1)
+	_ = gw.Submit(func() { replaced <- gw.ReplaceWith(Options{}) })
+	if err := <-replaced; err != ErrCalledFromJob {
+		t.Errorf("Expected %v, Got %v", ErrCalledFromJob, err)
+	}
+	gw.Stop(false)
+	if err := gw.ReplaceWith(Options{}); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+
+	det := New(Options{Deterministic: true})
+	defer det.Stop(false)
+	if err := det.ReplaceWith(Options{}); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected %v, Got %v", ErrInvalidOptions, err)
+	}
+}
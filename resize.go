@@ -0,0 +1,108 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrQueueNotDrained is returned by ResizeQueue() when the queue holds more
+// jobs than the size it would be shrunk to.
+var ErrQueueNotDrained = errors.New("goworkers: queue not drained enough to shrink")
+
+// QueueResize describes a change of the size of the queue of a pool, see
+// QueueResizes().
+type QueueResize struct {
+	From uint32
+	To   uint32
+}
+
+// queueResizes delivers the changes of the size of the queue
+type queueResizes struct {
+	mu     sync.Mutex
+	closed bool
+	c      chan QueueResize
+}
+
+// ResizeQueue changes the size of the queue of a running pool to n, such
+// that the queue can grow with the load instead of being sized for the
+// worst case up front. QueueCap(), Saturated(), TrySubmit() and the
+// watermarks defaulting to QSize follow the new size right away.
+//
+// Shrinking the queue requires it to hold at most n jobs, otherwise
+// ErrQueueNotDrained is returned and the size is left unchanged. Pools
+// without a queue, see Options.DirectHandoff, cannot be resized.
+//
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited
+// for.
+func (gw *GoWorkers) ResizeQueue(n uint32) error {
+	if n == 0 {
+		return invalid("queue size 0 is not supported, see DirectHandoff")
+	}
+	if high, _ := gw.backpressure.watermarks(); (high != 0) && (high > n) {
+		return invalid("HighWatermark %d is above QSize %d", high, n)
+	}
+
+	// such that the lane is not swapped meanwhile, see ReplaceWith()
+	defer gw.submitMu.RUnlock()
+	gw.submitMu.RLock()
+	if atomic.LoadInt32(&gw.stopping) != stateRunning {
+		return ErrStopped
+	}
+	l := gw.current()
+	if l.direct {
+		return invalid("ResizeQueue is not supported with DirectHandoff")
+	}
+
+	gw.resizes.mu.Lock()
+	from := atomic.LoadUint32(&l.size)
+	if (n < from) && (gw.queued() > n) {
+		gw.resizes.mu.Unlock()
+		return ErrQueueNotDrained
+	}
+	atomic.StoreUint32(&l.size, n)
+	if n != from {
+		gw.resizes.send(QueueResize{From: from, To: n})
+	}
+	gw.resizes.mu.Unlock()
+
+	gw.signalRoom()
+	gw.observeBackpressure()
+	return nil
+}
+
+// QueueResizes returns a channel on which the changes of the size of the
+// queue made by ResizeQueue() are delivered.
+//
+// The channel holds the latest change only, merging in the ones a slow
+// receiver has not read yet, and is closed after Stop() returns.
+func (gw *GoWorkers) QueueResizes() <-chan QueueResize {
+	return gw.resizes.c
+}
+
+// send must be called with mu held
+func (r *queueResizes) send(resize QueueResize) {
+	if r.closed {
+		return
+	}
+	// replace the change not read yet, if any
+	select {
+	case old := <-r.c:
+		resize.From = old.From
+	default:
+	}
+	r.c <- resize
+}
+
+func (r *queueResizes) close() {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.c)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResizeQueue(t *testing.T) {
+	gw := New(Options{Workers: 1, QSize: 2})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	gw.Submit(func() {})
+	gw.Submit(func() {})
+	if !gw.Saturated() {
+		t.Fatalf("Expected the queue to be full")
+	}
+
+	// growing the queue makes room right away
+	if err := gw.ResizeQueue(8); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if gw.QueueCap() != 8 || gw.QueueFree() != 6 {
+		t.Errorf("Expected 8 and 6, Got %d and %d", gw.QueueCap(), gw.QueueFree())
+	}
+	if !gw.TrySubmit(func() {}) {
+		t.Errorf("Expected the job to be accepted")
+	}
+	if r := <-gw.QueueResizes(); r != (QueueResize{From: 2, To: 8}) {
+		t.Errorf("Expected %v, Got %v", QueueResize{From: 2, To: 8}, r)
+	}
+
+	// shrinking it requires it to be drained enough
+	if err := gw.ResizeQueue(1); err != ErrQueueNotDrained {
+		t.Errorf("Expected %v, Got %v", ErrQueueNotDrained, err)
+	}
+	if gw.QueueCap() != 8 {
+		t.Errorf("Expected 8, Got %d", gw.QueueCap())
+	}
+	close(release)
+	gw.Wait(false)
+	if err := gw.ResizeQueue(1); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+
+	// unread changes are merged
+	gw.ResizeQueue(4)
+	if r := <-gw.QueueResizes(); r != (QueueResize{From: 8, To: 4}) {
+		t.Errorf("Expected %v, Got %v", QueueResize{From: 8, To: 4}, r)
+	}
+
+	if err := gw.ResizeQueue(0); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected %v, Got %v", ErrInvalidOptions, err)
+	}
+	gw.Stop(false)
+	if err := gw.ResizeQueue(16); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+	if _, ok := <-gw.QueueResizes(); ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+}
+
+func TestResizeQueueDirectHandoff(t *testing.T) {
+	gw := New(Options{DirectHandoff: true})
+	defer gw.Stop(false)
+
+	if err := gw.ResizeQueue(16); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected %v, Got %v", ErrInvalidOptions, err)
+	}
+}
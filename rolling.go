@@ -0,0 +1,154 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// granularity of the rolling windows
+	rollingBucket = 10 * time.Second
+	// the longest rolling window, 15 minutes
+	rollingBuckets = 90
+)
+
+// WindowStats are the aggregates of the jobs that finished within a rolling
+// window.
+type WindowStats struct {
+	// Window is the length of the window.
+	Window time.Duration `json:"window"`
+	// Completed is the number of jobs finished within the window.
+	Completed uint32 `json:"completed"`
+	// Failed is the number of jobs finished within the window that returned
+	// an error.
+	Failed uint32 `json:"failed"`
+	// Restarts is the number of times a worker recovered from a panicking
+	// job within the window, see Stats.Restarts.
+	Restarts uint32 `json:"restarts"`
+	// Throughput is the number of jobs finished per second.
+	Throughput float64 `json:"throughput"`
+	// ErrorRate is the fraction of the finished jobs that failed, zero if
+	// none finished.
+	ErrorRate float64 `json:"error_rate"`
+	// Latency is the average time the finished jobs ran for, zero if none
+	// finished.
+	Latency time.Duration `json:"latency"`
+}
+
+// RollingStats are the aggregates of the jobs that finished within the last
+// 1, 5 and 15 minutes, e.g. for dashboards and alerting, where the lifetime
+// counters of a long-lived pool hardly move.
+type RollingStats struct {
+	Last1m  WindowStats `json:"last_1m"`
+	Last5m  WindowStats `json:"last_5m"`
+	Last15m WindowStats `json:"last_15m"`
+}
+
+// rollingWindow counts the finished jobs in buckets of rollingBucket
+type rollingWindow struct {
+	mu      sync.Mutex
+	buckets [rollingBuckets]rollingCounts
+}
+
+type rollingCounts struct {
+	// index of the bucket since the epoch, the bucket is stale otherwise
+	index     int64
+	completed uint32
+	failed    uint32
+	restarts  uint32
+	runtime   time.Duration
+}
+
+// observe counts a job that finished at now after running for runtime, and
+// whether its worker recovered from its panic
+func (r *rollingWindow) observe(now time.Time, runtime time.Duration, failed, restarted bool) {
+	index := now.UnixNano() / int64(rollingBucket)
+	defer r.mu.Unlock()
+	r.mu.Lock()
+	b := &r.buckets[index%rollingBuckets]
+	if b.index != index {
+		*b = rollingCounts{index: index}
+	}
+	b.completed++
+	if failed {
+		b.failed++
+	}
+	if restarted {
+		b.restarts++
+	}
+	b.runtime += runtime
+}
+
+// window aggregates the last n buckets up to now, the current one included
+func (r *rollingWindow) window(now time.Time, n int64) WindowStats {
+	index := now.UnixNano() / int64(rollingBucket)
+	st := WindowStats{Window: time.Duration(n) * rollingBucket}
+	var runtime time.Duration
+	r.mu.Lock()
+	for i := index - n + 1; i <= index; i++ {
+		if i < 0 {
+			continue
+		}
+		if b := r.buckets[i%rollingBuckets]; b.index == i {
+			st.Completed += b.completed
+			st.Failed += b.failed
+			st.Restarts += b.restarts
+			runtime += b.runtime
+		}
+	}
+	r.mu.Unlock()
+
+	st.Throughput = float64(st.Completed) / st.Window.Seconds()
+	if st.Completed != 0 {
+		st.ErrorRate = float64(st.Failed) / float64(st.Completed)
+		st.Latency = runtime / time.Duration(st.Completed)
+	}
+	return st
+}
+
+func (r *rollingWindow) reset() {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+	r.buckets = [rollingBuckets]rollingCounts{}
+}
+
+// RollingStats returns the aggregates of the jobs that finished within the
+// last 1, 5 and 15 minutes. The windows move in steps of 10 seconds, hence
+// the current step is counted in full.
+func (gw *GoWorkers) RollingStats() RollingStats {
+	now := gw.clock.Now()
+	perMinute := int64(time.Minute / rollingBucket)
+	return RollingStats{
+		Last1m:  gw.rolling.window(now, perMinute),
+		Last5m:  gw.rolling.window(now, 5*perMinute),
+		Last15m: gw.rolling.window(now, 15*perMinute),
+	}
+}
+
+// ResetStats zeroes the lifetime counters of Stats(), i.e. Completed,
+// Failed, Abandoned, TimedOut, Cancelled, Discarded, Restarts, Dropped, Runtime,
+// Usage and Named, along with the rolling windows, e.g. at the start of a benchmark or of a
+// reporting period. The gauges, e.g. Workers and Jobs, and QueueWait, which drives
+// the load shedding, are kept.
+func (gw *GoWorkers) ResetStats() {
+	atomic.StoreUint32(&gw.numDone, 0)
+	atomic.StoreUint32(&gw.numFailed, 0)
+	atomic.StoreUint32(&gw.numAbandoned, 0)
+	atomic.StoreUint32(&gw.numTimedOut, 0)
+	atomic.StoreUint32(&gw.numCancelled, 0)
+	atomic.StoreUint32(&gw.numDiscarded, 0)
+	atomic.StoreUint32(&gw.numRestarts, 0)
+	atomic.StoreUint32(&gw.numDropped, 0)
+	atomic.StoreInt64(&gw.runtime, 0)
+	atomic.StoreUint64(&gw.allocs, 0)
+	atomic.StoreInt64(&gw.cpu, 0)
+	gw.named.mu.Lock()
+	gw.named.names = nil
+	gw.named.mu.Unlock()
+	gw.rolling.reset()
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRollingStats(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1000, 0))
+	gw := New(Options{Clock: clock})
+	defer gw.Stop(false)
+
+	run := func(n int, err error) {
+		for i := 0; i < n; i++ {
+			gw.SubmitCheckError(func() error { return err })
+		}
+		gw.Wait(false)
+		for len(gw.ErrChan) != 0 {
+			<-gw.ErrChan
+		}
+	}
+
+	// 4 jobs 10 minutes ago, then 6 jobs now of which 3 failed
+	run(4, nil)
+	clock.Advance(10 * time.Minute)
+	run(3, nil)
+	run(3, errors.New("failed"))
+
+	st := gw.RollingStats()
+	tables := []struct {
+		window    WindowStats
+		length    time.Duration
+		completed uint32
+		failed    uint32
+	}{
+		{st.Last1m, time.Minute, 6, 3},
+		{st.Last5m, 5 * time.Minute, 6, 3},
+		{st.Last15m, 15 * time.Minute, 10, 3},
+	}
+	for _, table := range tables {
+		w := table.window
+		if w.Window != table.length || w.Completed != table.completed || w.Failed != table.failed {
+			t.Errorf("Expected %d completed and %d failed in %s, Got %+v", table.completed, table.failed, table.length, w)
+		}
+		if want := float64(table.completed) / table.length.Seconds(); w.Throughput != want {
+			t.Errorf("Expected %v, Got %v", want, w.Throughput)
+		}
+	}
+	if st.Last1m.ErrorRate != 0.5 {
+		t.Errorf("Expected %v, Got %v", 0.5, st.Last1m.ErrorRate)
+	}
+
+	// the jobs slide out of the windows
+	clock.Advance(6 * time.Minute)
+	st = gw.RollingStats()
+	if st.Last5m.Completed != 0 || st.Last15m.Completed != 6 {
+		t.Errorf("Expected 0 and 6 completed, Got %d and %d", st.Last5m.Completed, st.Last15m.Completed)
+	}
+	if st.Last5m.ErrorRate != 0 || st.Last5m.Latency != 0 {
+		t.Errorf("Expected no error rate nor latency without jobs, Got %+v", st.Last5m)
+	}
+}
+
+func TestResetStats(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	gw.SubmitCheckError(func() error { return errors.New("failed") }, JobOptions{Name: "report"})
+	gw.Wait(false)
+	<-gw.ErrChan
+
+	gw.ResetStats()
+	st := gw.Stats()
+	if st.Completed != 0 || st.Failed != 0 || st.Runtime != 0 || st.Named != nil {
+		t.Errorf("Expected the counters to be reset, Got %+v", st)
+	}
+	if rs := gw.RollingStats(); rs.Last15m.Completed != 0 {
+		t.Errorf("Expected the windows to be reset, Got %+v", rs.Last15m)
+	}
+}
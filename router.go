@@ -0,0 +1,100 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import "sync"
+
+// Router dispatches jobs to worker pools by job name, such that workloads
+// with different needs, e.g. pool sizes or rate limits, are submitted
+// through a single surface. Jobs are named after the name they are
+// dispatched by, unless JobOptions{} with a name is given.
+//
+// The pools are owned by the caller; the router neither waits for nor stops
+// them.
+type Router struct {
+	def *GoWorkers
+
+	mu     sync.RWMutex
+	routes map[string]*GoWorkers
+}
+
+// NewRouter creates a new router.
+//
+// Jobs whose name has no route are dispatched to def, which must not be nil.
+func NewRouter(def *GoWorkers) *Router {
+	return &Router{def: def, routes: make(map[string]*GoWorkers)}
+}
+
+// Route dispatches the jobs named name to gw, replacing the previous route
+// of name, if any.
+func (r *Router) Route(name string, gw *GoWorkers) {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+	r.routes[name] = gw
+}
+
+// Pool returns the pool the jobs named name are dispatched to.
+func (r *Router) Pool(name string) *GoWorkers {
+	defer r.mu.RUnlock()
+	r.mu.RLock()
+	if gw, ok := r.routes[name]; ok {
+		return gw
+	}
+	return r.def
+}
+
+// named returns the job options for a job dispatched by name
+func named(name string, args []JobOptions) JobOptions {
+	opts := jobOptions(args)
+	if opts.Name == "" {
+		opts.Name = name
+	}
+	return opts
+}
+
+// Submit is a non-blocking call with arg of type `func()`
+//
+// The job is dispatched to the pool routed for name.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (r *Router) Submit(name string, job func(), args ...JobOptions) error {
+	return r.Pool(name).Submit(job, named(name, args))
+}
+
+// TrySubmit is a non-blocking call with arg of type `func()`
+//
+// The job is dispatched to the pool routed for name.
+// See GoWorkers.TrySubmit() for when a job is rejected.
+// Accepts optional JobOptions{} argument.
+func (r *Router) TrySubmit(name string, job func(), args ...JobOptions) bool {
+	return r.Pool(name).TrySubmit(job, named(name, args))
+}
+
+// SubmitCheckError is a non-blocking call with arg of type `func() error`
+//
+// The job is dispatched to the pool routed for name.
+// Use ErrChan buffered channel of the pool to read error, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (r *Router) SubmitCheckError(name string, job func() error, args ...JobOptions) error {
+	return r.Pool(name).SubmitCheckError(job, named(name, args))
+}
+
+// SubmitCheckResult is a non-blocking call with arg of type `func() (interface{}, error)`
+//
+// The job is dispatched to the pool routed for name.
+// Use ErrChan and ResultChan buffered channels of the pool to read error
+// and output, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (r *Router) SubmitCheckResult(name string, job func() (interface{}, error), args ...JobOptions) error {
+	return r.Pool(name).SubmitCheckResult(job, named(name, args))
+}
+
+// SubmitEnvelope builds the job described by e and dispatches it to the
+// pool routed for the job type name, as with GoWorkers.SubmitEnvelope().
+func (r *Router) SubmitEnvelope(e Envelope) error {
+	return r.Pool(e.Name).SubmitEnvelope(e)
+}
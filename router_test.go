@@ -0,0 +1,77 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	def := New()
+	emails := New(Options{Workers: 2})
+	reports := New(Options{Workers: 1})
+
+	r := NewRouter(def)
+	r.Route("email", emails)
+	r.Route("report", reports)
+
+	tables := []struct {
+		name string
+		want *GoWorkers
+	}{
+		{"email", emails},
+		{"report", reports},
+		{"thumbnail", def},
+	}
+
+	for _, table := range tables {
+		if gw := r.Pool(table.name); gw != table.want {
+			t.Errorf("%s: Expected %p, Got %p", table.name, table.want, gw)
+		}
+	}
+
+	var ran int32
+	r.Submit("email", func() { atomic.AddInt32(&ran, 1) })
+	if !r.TrySubmit("report", func() { atomic.AddInt32(&ran, 1) }) {
+		t.Errorf("Expected the job to be accepted")
+	}
+	r.SubmitCheckError("thumbnail", func() error { atomic.AddInt32(&ran, 1); return nil })
+	r.SubmitCheckResult("email", func() (interface{}, error) { atomic.AddInt32(&ran, 1); return nil, nil })
+
+	for _, gw := range []*GoWorkers{def, emails, reports} {
+		gw.Stop(false)
+	}
+	if ran != 4 {
+		t.Errorf("Expected 4, Got %d", ran)
+	}
+	if done := emails.Stats().Completed; done != 2 {
+		t.Errorf("Expected 2 email jobs, Got %d", done)
+	}
+}
+
+func TestRouterEnvelope(t *testing.T) {
+	def := New()
+	greeter := New()
+
+	r := NewRouter(def)
+	r.Route("greet", greeter)
+
+	if err := r.SubmitEnvelope(Envelope{Name: "greet", Payload: []byte("router")}); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if err := r.SubmitEnvelope(Envelope{Name: "unknown"}); !errors.Is(err, ErrUnknownJobType) {
+		t.Errorf("Expected %v, Got %v", ErrUnknownJobType, err)
+	}
+
+	var je *JobError
+	if err := <-greeter.ErrChan; !errors.As(err, &je) || je.Name != "greet" || je.Err.Error() != "hello router" {
+		t.Errorf("Expected hello router from job greet, Got %v", err)
+	}
+
+	def.Stop(false)
+	greeter.Stop(false)
+}
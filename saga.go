@@ -0,0 +1,133 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrSagaAborted is returned when submitting to a saga of which a job failed.
+var ErrSagaAborted = errors.New("goworkers: saga aborted")
+
+// Saga is a group of jobs that is aborted as a whole if one of its jobs
+// fails, undoing the effects of its jobs with the compensations they
+// registered, e.g. for multi-step workflows that must not be left half done.
+//
+// Once a job of the saga fails, its jobs that have not started are skipped
+// and further submissions are rejected with ErrSagaAborted. When the jobs
+// running at that time have finished, the pool runs the compensations
+// registered by the jobs of the saga, in the reverse order of their
+// registration.
+type Saga struct {
+	gw *GoWorkers
+	wg sync.WaitGroup
+
+	mu        sync.Mutex
+	pending   int
+	err       error
+	rollbacks []func()
+}
+
+// Step is handed to a job of a saga, to register its compensations.
+type Step struct {
+	saga *Saga
+}
+
+// OnRollback registers fn to be run if the saga aborts.
+func (st *Step) OnRollback(fn func()) {
+	defer st.saga.mu.Unlock()
+	st.saga.mu.Lock()
+	st.saga.rollbacks = append(st.saga.rollbacks, fn)
+}
+
+// NewSaga creates a new saga of jobs run by the pool.
+func (gw *GoWorkers) NewSaga() *Saga {
+	return &Saga{gw: gw}
+}
+
+// Submit is a non-blocking call with arg of type `func(*Step) error`
+//
+// The error of a failed job is delivered on ErrChan of the pool, as with
+// SubmitCheckError(), and aborts the saga.
+// Accepts optional JobOptions{} argument.
+// Returns ErrSagaAborted if the saga aborted, or the error of submitting to
+// the pool.
+func (s *Saga) Submit(job func(st *Step) error, args ...JobOptions) error {
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return ErrSagaAborted
+	}
+	s.pending++
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	err := s.gw.submit(&task{
+		run: func(context.Context) (interface{}, error) {
+			if s.aborted() {
+				return nil, nil
+			}
+			return nil, job(&Step{saga: s})
+		},
+		// a panicking step aborts the saga as a failed one does, and a
+		// skipped one still counts as finished
+		onFinish: func(err error) {
+			if err != nil {
+				s.abort(err)
+			}
+			s.finish()
+		},
+		outputs: errOutput,
+		opts:    jobOptions(args),
+	})
+	if err != nil {
+		s.finish()
+	}
+	return err
+}
+
+// Wait waits for the jobs of the saga to finish and, if it aborted, for its
+// compensations to run. Returns the error of the job that failed first, if any.
+func (s *Saga) Wait() error {
+	s.wg.Wait()
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	return s.err
+}
+
+func (s *Saga) aborted() bool {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	return s.err != nil
+}
+
+func (s *Saga) abort(err error) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// finish accounts for a finished job, and runs the compensations if it was
+// the last job of an aborted saga
+func (s *Saga) finish() {
+	defer s.wg.Done()
+
+	s.mu.Lock()
+	s.pending--
+	var rollbacks []func()
+	if (s.pending == 0) && (s.err != nil) {
+		rollbacks = s.rollbacks
+		s.rollbacks = nil
+	}
+	s.mu.Unlock()
+
+	for i := len(rollbacks) - 1; i >= 0; i-- {
+		rollbacks[i]()
+	}
+}
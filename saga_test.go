@@ -0,0 +1,144 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSaga(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	s := gw.NewSaga()
+
+	var mu sync.Mutex
+	var undone []string
+	step := func(name string, err error) func(st *Step) error {
+		return func(st *Step) error {
+			st.OnRollback(func() {
+				mu.Lock()
+				undone = append(undone, name)
+				mu.Unlock()
+			})
+			return err
+		}
+	}
+
+	errDeclined := errors.New("declined")
+	for i, name := range []string{"reserve", "charge"} {
+		s.Submit(step(name, nil))
+		for gw.Stats().Completed != uint32(i+1) {
+		}
+	}
+	s.Submit(step("ship", errDeclined))
+	for gw.Stats().Completed != 3 {
+	}
+	s.Submit(step("notify", nil))
+
+	if err := s.Wait(); err != errDeclined {
+		t.Errorf("Expected %v, Got %v", errDeclined, err)
+	}
+	if got := strings.Join(undone, ","); got != "ship,charge,reserve" {
+		t.Errorf("Expected ship,charge,reserve, Got %s", got)
+	}
+	if err := s.Submit(step("late", nil)); err != ErrSagaAborted {
+		t.Errorf("Expected %v, Got %v", ErrSagaAborted, err)
+	}
+	if err := <-gw.ErrChan; err != errDeclined {
+		t.Errorf("Expected %v, Got %v", errDeclined, err)
+	}
+
+	gw.Stop(false)
+}
+
+func TestSagaSucceeds(t *testing.T) {
+	gw := New()
+	s := gw.NewSaga()
+
+	rolledBack := false
+	for i := 0; i < 10; i++ {
+		s.Submit(func(st *Step) error {
+			st.OnRollback(func() { rolledBack = true })
+			return nil
+		})
+	}
+
+	if err := s.Wait(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if rolledBack {
+		t.Errorf("Expected no compensation to run")
+	}
+
+	gw.Stop(false)
+}
+
+func TestSagaStoppedPool(t *testing.T) {
+	gw := New()
+	gw.Stop(false)
+
+	s := gw.NewSaga()
+	if err := s.Submit(func(st *Step) error { return nil }); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+	if err := s.Wait(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+}
+
+func TestSagaPanic(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	s := gw.NewSaga()
+
+	rolledBack := false
+	s.Submit(func(st *Step) error {
+		st.OnRollback(func() { rolledBack = true })
+		return nil
+	})
+	for gw.Stats().Completed != 1 {
+	}
+	s.Submit(func(st *Step) error {
+		panic("out of stock")
+	})
+
+	var pe *PanicError
+	if err := s.Wait(); !errors.As(err, &pe) || pe.Value != "out of stock" {
+		t.Errorf("Expected a *PanicError, Got %v", err)
+	}
+	if !rolledBack {
+		t.Errorf("Expected the compensation to run")
+	}
+	if err := <-gw.ErrChan; !errors.As(err, &pe) {
+		t.Errorf("Expected a *PanicError, Got %v", err)
+	}
+
+	gw.Stop(false)
+}
+
+func TestSagaJobNotRun(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	defer gw.Stop(false)
+
+	gw.Use(func(ctx context.Context, job JobInfo, next func(ctx context.Context) error) error {
+		return nil
+	})
+
+	s := gw.NewSaga()
+	ran := false
+	s.Submit(func(st *Step) error {
+		ran = true
+		return nil
+	})
+	// the skipped job must count as finished
+	if err := s.Wait(); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if ran {
+		t.Errorf("Expected the job to be skipped")
+	}
+}
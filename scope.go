@@ -0,0 +1,129 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+)
+
+// Scope is the set of jobs spawned by the function of Scope(), which
+// outlive none of them.
+type Scope struct {
+	gw     *GoWorkers
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+	pe  *PanicError
+}
+
+// Scope calls fn, which spawns jobs on the pool with s.Go(), and returns
+// only once all of them have finished, such that no job outlives the call.
+//
+// The first of fn and the jobs to fail cancels the context of the scope,
+// so that the running jobs are told to give up and the queued ones are
+// dropped, and its error is returned. A panic of fn or of a job cancels
+// the scope alike, and is raised again by Scope() as a *PanicError once
+// the jobs have finished, instead of crashing the process from a worker.
+// If ctx is done before the jobs fail, ctx.Err() is returned.
+//
+// As with Group, the errors of the jobs are delivered on ErrChan of the
+// pool as well, and the queued jobs dropped as the scope failed deliver an
+// error wrapping ErrCancelled.
+func (gw *GoWorkers) Scope(ctx context.Context, fn func(s *Scope) error) error {
+	s := &Scope{gw: gw}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	defer s.cancel()
+
+	func() {
+		defer s.recover(nil)
+		s.fail(fn(s))
+	}()
+	s.wg.Wait()
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	if s.pe != nil {
+		panic(s.pe)
+	}
+	if (s.err == nil) && (ctx.Err() != nil) {
+		return ctx.Err()
+	}
+	return s.err
+}
+
+// Context returns the context of the scope, which is cancelled once the
+// scope fails.
+func (s *Scope) Context() context.Context {
+	return s.ctx
+}
+
+// Go spawns job on the pool as part of the scope. The job is passed the
+// context of the scope, or a context deriving from it.
+//
+// Failing to submit the job, e.g. as the pool is stopped, fails the scope.
+// Jobs spawned once the scope has failed are not run.
+// Accepts optional JobOptions{} argument.
+func (s *Scope) Go(job func(ctx context.Context) error, args ...JobOptions) {
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	s.wg.Add(1)
+	err := s.gw.submit(&task{
+		run: func(ctx context.Context) (_ interface{}, err error) {
+			defer s.recover(&err)
+			return nil, job(ctx)
+		},
+		outputs: errOutput,
+		opts:    jobOptions(args),
+		ctx:     s.ctx,
+		// even if the job does not run, e.g. as an interceptor skipped it
+		onFinish: func(err error) {
+			s.fail(err)
+			s.wg.Done()
+		},
+	})
+	if err != nil {
+		s.wg.Done()
+		s.fail(err)
+	}
+}
+
+// fail records err, if any and first, and cancels the scope
+func (s *Scope) fail(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	s.cancel()
+}
+
+// recover must be deferred. It recovers a panic, if any, recording it as
+// the one of the scope if first, and as *err if err is not nil.
+func (s *Scope) recover(err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	pe := &PanicError{Value: v, Stack: debug.Stack()}
+	if err != nil {
+		*err = pe
+	}
+	s.mu.Lock()
+	if s.pe == nil {
+		s.pe = pe
+	}
+	s.mu.Unlock()
+	s.cancel()
+}
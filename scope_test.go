@@ -0,0 +1,160 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	var done int32
+	err := gw.Scope(context.Background(), func(s *Scope) error {
+		for i := 0; i < 5; i++ {
+			s.Go(func(ctx context.Context) error {
+				// jobs may spawn jobs of the scope too
+				s.Go(func(ctx context.Context) error {
+					atomic.AddInt32(&done, 1)
+					return nil
+				})
+				atomic.AddInt32(&done, 1)
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if n := atomic.LoadInt32(&done); n != 10 {
+		t.Errorf("Expected the 10 jobs to be done, Got %d", n)
+	}
+}
+
+func TestScopeError(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	defer gw.Stop(false)
+
+	failed := errors.New("failed")
+	ran := false
+	err := gw.Scope(context.Background(), func(s *Scope) error {
+		s.Go(func(ctx context.Context) error { return failed })
+		// cancelled once the first job fails
+		<-s.Context().Done()
+		s.Go(func(ctx context.Context) error {
+			ran = true
+			return nil
+		})
+		return nil
+	})
+	if err != failed {
+		t.Errorf("Expected %v, Got %v", failed, err)
+	}
+	if ran {
+		t.Errorf("Expected the job spawned after the failure not to run")
+	}
+	if err := <-gw.ErrChan; err != failed {
+		t.Errorf("Expected %v, Got %v", failed, err)
+	}
+
+	// a cancelled context fails the scope
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := gw.Scope(ctx, func(s *Scope) error { return nil }); err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+}
+
+func TestScopePanic(t *testing.T) {
+	gw := New(Options{Workers: 2})
+	defer gw.Stop(false)
+
+	var cancelled int32
+	defer func() {
+		pe, ok := recover().(*PanicError)
+		if !ok || pe.Value != "boom" || len(pe.Stack) == 0 {
+			t.Errorf("Expected the panic of the job, Got %v", pe)
+		}
+		// the other job finished before the panic was raised again
+		if atomic.LoadInt32(&cancelled) != 1 {
+			t.Errorf("Expected the other job to be cancelled")
+		}
+	}()
+
+	gw.Scope(context.Background(), func(s *Scope) error {
+		started := make(chan struct{})
+		s.Go(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			atomic.StoreInt32(&cancelled, 1)
+			return ctx.Err()
+		})
+		<-started
+		s.Go(func(ctx context.Context) error { panic("boom") })
+		return nil
+	})
+	t.Errorf("Expected Scope to panic")
+}
+
+func TestScopeJobNotRun(t *testing.T) {
+	gw := New(Options{Workers: 2, Idempotency: NewMemoryIdempotencyStore()})
+	defer gw.Stop(false)
+
+	errDenied := errors.New("denied")
+	gw.Use(func(ctx context.Context, job JobInfo, next func(ctx context.Context) error) error {
+		switch job.Name {
+		case "skipped":
+			return nil
+		case "denied":
+			return errDenied
+		}
+		return next(ctx)
+	})
+
+	// a job skipped by an interceptor does not hold up the scope
+	var ran int32
+	err := gw.Scope(context.Background(), func(s *Scope) error {
+		s.Go(func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			return nil
+		}, JobOptions{Name: "skipped"})
+		return nil
+	})
+	if err != nil || atomic.LoadInt32(&ran) != 0 {
+		t.Errorf("Expected nil and no run, Got %v and %d runs", err, ran)
+	}
+
+	// nor does a duplicate
+	for i := 0; i < 2; i++ {
+		err = gw.Scope(context.Background(), func(s *Scope) error {
+			s.Go(func(ctx context.Context) error {
+				atomic.AddInt32(&ran, 1)
+				return nil
+			}, JobOptions{Key: "report-1"})
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Expected nil, Got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&ran); n != 1 {
+		t.Errorf("Expected 1 run, Got %d", n)
+	}
+
+	// the error of an interceptor fails the scope
+	err = gw.Scope(context.Background(), func(s *Scope) error {
+		s.Go(func(ctx context.Context) error { return nil }, JobOptions{Name: "denied"})
+		return nil
+	})
+	if !errors.Is(err, errDenied) {
+		t.Errorf("Expected %v, Got %v", errDenied, err)
+	}
+	<-gw.ErrChan
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// scratchPattern names the scratch directories of the jobs
+const scratchPattern = "job-*"
+
+// ErrScratchQuota is wrapped by the error of a job that did not run as the
+// scratch directories of the pool use up Options.ScratchQuota.
+var ErrScratchQuota = errors.New("goworkers: scratch quota exceeded")
+
+// scratch holds the scratch directories of the running jobs of a pool
+type scratch struct {
+	root  string
+	quota int64
+
+	mu   sync.Mutex
+	dirs map[string]struct{}
+}
+
+func newScratch(opts Options) *scratch {
+	s := &scratch{root: opts.ScratchDir, quota: opts.ScratchQuota, dirs: make(map[string]struct{})}
+	if s.root == "" {
+		s.root = os.TempDir()
+		return s
+	}
+	// a dedicated root may hold the leftovers of a process that crashed
+	stale, _ := filepath.Glob(filepath.Join(s.root, scratchPattern))
+	for _, dir := range stale {
+		os.RemoveAll(dir)
+	}
+	return s
+}
+
+type scratchKey struct{}
+
+// ScratchDirFromContext returns the scratch directory of the job whose
+// context ctx is, or derives from, see JobOptions.Scratch.
+func ScratchDirFromContext(ctx context.Context) (string, bool) {
+	dir, ok := ctx.Value(scratchKey{}).(string)
+	return dir, ok
+}
+
+// withScratch runs run with the scratch directory dir, removed once run
+// returns, even if abandoned
+func (gw *GoWorkers) withScratch(dir string, run func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		defer gw.scratch.remove(dir)
+		return run(context.WithValue(ctx, scratchKey{}, dir))
+	}
+}
+
+// failUnrun accounts for t as failed without running it, and delivers err
+func (gw *GoWorkers) failUnrun(t *task, err error) {
+	atomic.AddUint32(&gw.numDone, 1)
+	atomic.AddUint32(&gw.numFailed, 1)
+	gw.countNamed(t.opts.Name, true, 0, Usage{})
+	gw.skip(t, err)
+}
+
+// create creates a scratch directory, unless the quota is used up
+func (s *scratch) create() (string, error) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	if s.quota > 0 {
+		if used := s.usage(); used >= s.quota {
+			return "", fmt.Errorf("%w: %d of %d bytes used", ErrScratchQuota, used, s.quota)
+		}
+	}
+	if err := os.MkdirAll(s.root, 0o700); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(s.root, scratchPattern)
+	if err != nil {
+		return "", err
+	}
+	s.dirs[dir] = struct{}{}
+	return dir, nil
+}
+
+func (s *scratch) remove(dir string) {
+	os.RemoveAll(dir)
+	s.mu.Lock()
+	delete(s.dirs, dir)
+	s.mu.Unlock()
+}
+
+// usage must be called with mu held. It returns the size of the files in
+// the scratch directories.
+func (s *scratch) usage() int64 {
+	var used int64
+	for dir := range s.dirs {
+		filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+			if (err != nil) || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				used += info.Size()
+			}
+			return nil
+		})
+	}
+	return used
+}
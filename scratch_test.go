@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScratch(t *testing.T) {
+	root := t.TempDir()
+	stale := filepath.Join(root, "job-stale")
+	os.Mkdir(stale, 0o700)
+
+	gw := New(Options{Workers: 2, ScratchDir: root, ScratchQuota: 1024})
+	defer gw.Stop(false)
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected the stale scratch directory to be removed, Got %v", err)
+	}
+
+	dirs := make(chan string, 2)
+	written := make(chan struct{})
+	release := make(chan struct{})
+	g := gw.NewGroup(context.Background(), time.Minute)
+	g.Submit(func(ctx context.Context) error {
+		dir, _ := ScratchDirFromContext(ctx)
+		dirs <- dir
+		err := os.WriteFile(filepath.Join(dir, "video.mp4"), make([]byte, 2048), 0o600)
+		close(written)
+		<-release
+		return err
+	}, JobOptions{Scratch: true})
+
+	// the quota is used up by the running job
+	<-written
+	g.Submit(func(ctx context.Context) error {
+		t.Errorf("Expected the job not to run")
+		return nil
+	}, JobOptions{Scratch: true})
+	if err := <-gw.ErrChan; !errors.Is(err, ErrScratchQuota) {
+		t.Errorf("Expected %v, Got %v", ErrScratchQuota, err)
+	}
+	close(release)
+	g.Wait()
+
+	// the directory is removed once its job returns
+	dir := <-dirs
+	if filepath.Dir(dir) != root {
+		t.Errorf("Expected a scratch directory in %s, Got %q", root, dir)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the scratch directory to be removed, Got %v", err)
+	}
+
+	// jobs without scratch directories do not get one
+	g = gw.NewGroup(context.Background(), time.Minute)
+	g.Submit(func(ctx context.Context) error {
+		if _, ok := ScratchDirFromContext(ctx); ok {
+			t.Errorf("Expected no scratch directory")
+		}
+		return nil
+	})
+	g.Wait()
+}
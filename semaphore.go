@@ -0,0 +1,118 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tryAcquireWait is how long TryAcquire() waits for the permits the pool
+// has room for to reach a worker
+const tryAcquireWait = 100 * time.Millisecond
+
+// Semaphore exposes the capacity of a worker pool through the method set of
+// golang.org/x/sync/semaphore.Weighted.
+//
+// A permit is a worker slot of the pool. Code gated on the semaphore thus
+// shares the same concurrency budget as the submitted jobs, rather than
+// accounting for concurrency with two mechanisms. Held permits count as
+// active jobs, i.e. Wait() and Stop() wait for them to be released.
+type Semaphore struct {
+	gw *GoWorkers
+	// serialises acquirers so that concurrent ones do not split the
+	// available permits between them
+	acquireMu sync.Mutex
+	mu        sync.Mutex
+	held      []chan struct{}
+}
+
+// Semaphore returns a semaphore backed by the worker slots of the pool.
+func (gw *GoWorkers) Semaphore() *Semaphore {
+	return &Semaphore{gw: gw}
+}
+
+// Acquire acquires n permits, blocking until they are available or until
+// ctx is done. On failure, returns ctx.Err() or ErrRejected and leaves the
+// semaphore unchanged.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	defer s.acquireMu.Unlock()
+	s.acquireMu.Lock()
+	return s.acquire(ctx, n)
+}
+
+// TryAcquire acquires n permits without blocking, on a best-effort basis:
+// it gives up right away if another acquirer is waiting or the pool has no
+// room for them, and otherwise waits only briefly for them to reach a
+// worker. On failure, returns false and leaves the semaphore unchanged.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	if !s.acquireMu.TryLock() {
+		return false
+	}
+	defer s.acquireMu.Unlock()
+
+	if max := s.gw.MaxWorkers(); max != 0 {
+		if jobs := int64(s.gw.JobNum()); jobs+n > int64(max) {
+			return false
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tryAcquireWait)
+	defer cancel()
+	return s.acquire(ctx, n) == nil
+}
+
+// acquire must be called with acquireMu held
+func (s *Semaphore) acquire(ctx context.Context, n int64) error {
+	// every permit is a job occupying a worker until it is released
+	running := make(chan struct{}, n)
+	releases := make([]chan struct{}, 0, n)
+	giveBack := func() {
+		for _, release := range releases {
+			close(release)
+		}
+	}
+
+	for i := int64(0); i < n; i++ {
+		release := make(chan struct{})
+		if !s.gw.TrySubmit(func() {
+			running <- struct{}{}
+			<-release
+		}) {
+			giveBack()
+			return ErrRejected
+		}
+		releases = append(releases, release)
+	}
+
+	for i := int64(0); i < n; i++ {
+		select {
+		case <-running:
+		case <-ctx.Done():
+			giveBack()
+			return ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	s.held = append(s.held, releases...)
+	s.mu.Unlock()
+	return nil
+}
+
+// Release releases n permits.
+//
+// Releasing more permits than held panics, as with semaphore.Weighted.
+func (s *Semaphore) Release(n int64) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	if n > int64(len(s.held)) {
+		panic("goworkers: semaphore released more than held")
+	}
+	for _, release := range s.held[:n] {
+		close(release)
+	}
+	s.held = s.held[n:]
+}
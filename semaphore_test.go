@@ -0,0 +1,116 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSemaphoreSharesBudget(t *testing.T) {
+	gw := New(Options{Workers: 2})
+	defer gw.Stop(false)
+
+	sem := gw.Semaphore()
+	if err := sem.Acquire(context.Background(), 2); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if sem.TryAcquire(1) {
+		t.Errorf("Expected no permits to be available")
+	}
+
+	var ran int32
+	gw.Submit(func() { atomic.AddInt32(&ran, 1) })
+
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(&ran) != 0 {
+		t.Errorf("Expected the job to wait for a permit to be released")
+	}
+
+	// the job runs once a permit is released, while the other one is held
+	sem.Release(1)
+	for atomic.LoadInt32(&ran) != 1 {
+	}
+
+	sem.Release(1)
+	gw.Wait(false)
+}
+
+func TestSemaphoreAcquireTimeout(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	defer gw.Stop(false)
+
+	sem := gw.Semaphore()
+	if !sem.TryAcquire(1) {
+		t.Fatalf("Expected a permit to be available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := sem.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, Got %v", context.DeadlineExceeded, err)
+	}
+
+	sem.Release(1)
+	gw.Wait(false)
+}
+
+func TestSemaphoreAcquireAfterStop(t *testing.T) {
+	gw := New()
+	gw.Stop(false)
+
+	if err := gw.Semaphore().Acquire(context.Background(), 1); err != ErrRejected {
+		t.Errorf("Expected %v, Got %v", ErrRejected, err)
+	}
+}
+
+func TestSemaphoreReleaseMoreThanHeld(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic")
+		}
+	}()
+	gw.Semaphore().Release(1)
+}
+
+func TestSemaphoreTryAcquireDoesNotBlock(t *testing.T) {
+	gw := New(Options{Workers: 1})
+	defer gw.Stop(false)
+
+	sem := gw.Semaphore()
+	if err := sem.Acquire(context.Background(), 1); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	acquired := make(chan error)
+	go func() { acquired <- sem.Acquire(ctx, 1) }()
+	for gw.JobNum() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// fails right away while another acquirer waits
+	tried := make(chan bool)
+	go func() { tried <- sem.TryAcquire(1) }()
+	select {
+	case ok := <-tried:
+		if ok {
+			t.Errorf("Expected no permits to be available")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected TryAcquire not to block")
+	}
+
+	cancel()
+	if err := <-acquired; err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+	sem.Release(1)
+	gw.Wait(false)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// procsPerShard is the number of Ps of a shard if unspecified, about a core
+// complex of the large machines
+const procsPerShard = 8
+
+// ShardOptions configures a sharded pool.
+//
+// Shards specifies the number of shards. If unspecified or zero, one shard
+// per 8 of GOMAXPROCS is used, and at least one.
+//
+// Pool configures every shard, except that Options.Workers is the total
+// number of workers, split evenly across the shards. The name of every
+// shard is suffixed with its index.
+type ShardOptions struct {
+	Shards int
+	Pool   Options
+}
+
+// Sharded is an Executor partitioning its workers across several pools, its
+// shards, such that the submissions and the queues are not shared by every
+// CPU of a large machine.
+//
+// Every P of the runtime, i.e. every GOMAXPROCS slot the goroutines run on,
+// is assigned a shard, round robin, as its goroutines first submit. A job
+// is dispatched to the shard of the P its submitter runs on, its local one,
+// so that the traffic of the submitters running on a P stays on one queue,
+// unless its queue is full, in which case the other shards are tried in
+// turn. Goroutines are not pinned to their P, hence a submitter that the
+// scheduler moves to another P follows it to its shard. The assignments are
+// cached by a sync.Pool, which keeps an item per P, and so are reassigned
+// once garbage collections drop them.
+//
+// The outputs of the jobs are delivered on the channels of their shard, see
+// Shards().
+type Sharded struct {
+	shards []*GoWorkers
+	// caches the *int index of the shard of every P, see local()
+	home sync.Pool
+	// the shard assigned to the next P
+	next uint32
+}
+
+var _ Executor = (*Sharded)(nil)
+
+// NewSharded creates a new sharded pool.
+//
+// Accepts optional ShardOptions{} argument.
+func NewSharded(args ...ShardOptions) (*Sharded, error) {
+	var opts ShardOptions
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if opts.Shards < 0 {
+		return nil, invalid("Shards %d is negative", opts.Shards)
+	}
+	if opts.Shards == 0 {
+		opts.Shards = runtime.GOMAXPROCS(0) / procsPerShard
+		if opts.Shards == 0 {
+			opts.Shards = 1
+		}
+	}
+	if err := opts.Pool.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Sharded{shards: make([]*GoWorkers, opts.Shards)}
+	s.home.New = func() interface{} {
+		i := int((atomic.AddUint32(&s.next, 1) - 1) % uint32(len(s.shards)))
+		return &i
+	}
+	for i := range s.shards {
+		po := opts.Pool
+		if po.Workers != 0 {
+			// the first shards take the remainder
+			po.Workers = opts.Pool.Workers / uint32(opts.Shards)
+			if uint32(i) < opts.Pool.Workers%uint32(opts.Shards) {
+				po.Workers++
+			}
+			if po.Workers == 0 {
+				po.Workers = 1
+			}
+		}
+		if po.Name != "" {
+			po.Name = po.Name + "-" + strconv.Itoa(i)
+		}
+		s.shards[i] = New(po)
+	}
+	return s, nil
+}
+
+// Shards returns the shards.
+func (s *Sharded) Shards() []*GoWorkers {
+	return append([]*GoWorkers(nil), s.shards...)
+}
+
+// local returns the index of the shard of the P the calling goroutine runs
+// on, as cached by the P
+func (s *Sharded) local() int {
+	i := s.home.Get().(*int)
+	s.home.Put(i)
+	return *i
+}
+
+// submit dispatches to the local shard, or to the next one with room if its
+// queue is full. If every queue is full, the job is left to the local one.
+func (s *Sharded) submit(fn func(gw *GoWorkers) error) error {
+	local := s.local()
+	for i := range s.shards {
+		if gw := s.shards[(local+i)%len(s.shards)]; gw.Ready() {
+			return fn(gw)
+		}
+	}
+	return fn(s.shards[local])
+}
+
+// Submit is a non-blocking call with arg of type `func()`
+//
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (s *Sharded) Submit(job func(), args ...JobOptions) error {
+	return s.submit(func(gw *GoWorkers) error {
+		return gw.Submit(job, args...)
+	})
+}
+
+// SubmitCheckError is a non-blocking call with arg of type `func() error`
+//
+// Use ErrChan buffered channel of the shard that ran the job to read
+// error, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (s *Sharded) SubmitCheckError(job func() error, args ...JobOptions) error {
+	return s.submit(func(gw *GoWorkers) error {
+		return gw.SubmitCheckError(job, args...)
+	})
+}
+
+// SubmitCheckResult is a non-blocking call with arg of type `func() (interface{}, error)`
+//
+// Use ErrChan and ResultChan buffered channels of the shard that ran the
+// job to read error and output, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped.
+func (s *Sharded) SubmitCheckResult(job func() (interface{}, error), args ...JobOptions) error {
+	return s.submit(func(gw *GoWorkers) error {
+		return gw.SubmitCheckResult(job, args...)
+	})
+}
+
+// Wait waits for the jobs of every shard to finish running.
+//
+// See GoWorkers.Wait() for the semantics of the 'wait' argument.
+// Returns the first error of the shards, if any.
+func (s *Sharded) Wait(wait bool) error {
+	var first error
+	for _, gw := range s.shards {
+		if err := gw.Wait(wait); (err != nil) && (first == nil) {
+			first = err
+		}
+	}
+	return first
+}
+
+// Stop stops every shard.
+//
+// See GoWorkers.Stop() for the semantics of the 'wait' argument.
+// Returns the first error of the shards, if any.
+func (s *Sharded) Stop(wait bool) error {
+	var first error
+	for _, gw := range s.shards {
+		if err := gw.Stop(wait); (err != nil) && (first == nil) {
+			first = err
+		}
+	}
+	return first
+}
+
+// Stats returns the aggregate stats of the shards. Busy workers are listed
+// by the shards only, as their ids are not unique across them.
+func (s *Sharded) Stats() Stats {
+	all := make([]Stats, len(s.shards))
+	for i, gw := range s.shards {
+		all[i] = gw.Stats()
+	}
+	return aggregateStats(all)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestNewSharded(t *testing.T) {
+	s, err := NewSharded(ShardOptions{Shards: 3, Pool: Options{Name: "jobs", Workers: 7}})
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	defer s.Stop(false)
+
+	shards := s.Shards()
+	if len(shards) != 3 {
+		t.Fatalf("Expected 3, Got %d", len(shards))
+	}
+	tables := []struct {
+		name    string
+		workers uint32
+	}{
+		{"jobs-0", 3},
+		{"jobs-1", 2},
+		{"jobs-2", 2},
+	}
+	for i, table := range tables {
+		if shards[i].name != table.name || shards[i].MaxWorkers() != table.workers {
+			t.Errorf("Expected %s with %d workers, Got %s with %d", table.name, table.workers, shards[i].name, shards[i].MaxWorkers())
+		}
+	}
+
+	want := runtime.GOMAXPROCS(0) / procsPerShard
+	if want == 0 {
+		want = 1
+	}
+	def, _ := NewSharded()
+	if len(def.shards) != want {
+		t.Errorf("Expected %d, Got %d", want, len(def.shards))
+	}
+	def.Stop(false)
+
+	for _, opts := range []ShardOptions{{Shards: -1}, {Pool: Options{WorkerRate: -1}}} {
+		if _, err := NewSharded(opts); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("%+v: Expected %v, Got %v", opts, ErrInvalidOptions, err)
+		}
+	}
+}
+
+func TestShardedLocal(t *testing.T) {
+	s, _ := NewSharded(ShardOptions{Shards: 3})
+	defer s.Stop(false)
+
+	// the Ps are assigned the shards round robin
+	for i := 0; i < 5; i++ {
+		if got := *s.home.New().(*int); got != i%3 {
+			t.Errorf("Expected %d, Got %d", i%3, got)
+		}
+	}
+	if i := s.local(); (i < 0) || (i >= 3) {
+		t.Errorf("Expected a shard index, Got %d", i)
+	}
+}
+
+func TestShardedLocality(t *testing.T) {
+	s, _ := NewSharded(ShardOptions{Shards: 4})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				s.Submit(func() {})
+			}
+		}()
+	}
+	wg.Wait()
+	s.Wait(false)
+
+	if st := s.Stats(); st.Completed != 80 {
+		t.Errorf("Expected 80, Got %d", st.Completed)
+	}
+	if i := s.local(); (i < 0) || (i >= 4) {
+		t.Errorf("Expected a shard index, Got %d", i)
+	}
+	s.Stop(false)
+}
+
+func TestShardedFullQueue(t *testing.T) {
+	s, _ := NewSharded(ShardOptions{Shards: 2, Pool: Options{Workers: 2}})
+	full, other := s.Shards()[0], s.Shards()[1]
+
+	// fill up the queue of a shard, the job lands on the other one wherever
+	// its submitter runs
+	release := make(chan struct{})
+	for full.Ready() {
+		full.Submit(func() { <-release })
+	}
+
+	if err := s.Submit(func() {}); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	other.Wait(false)
+	if n := other.Stats().Completed; n != 1 {
+		t.Errorf("Expected the job to run on the other shard, Got %d", n)
+	}
+
+	close(release)
+	s.Stop(false)
+}
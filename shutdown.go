@@ -0,0 +1,180 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultStopProgressInterval = time.Second
+
+// ErrDiscarded is delivered on ErrChan for a queued job that was discarded
+// without running as the pool was stopped, see StopWith().
+var ErrDiscarded = errors.New("goworkers: job discarded at stop")
+
+// States of the progress of Stop(), see StopProgress()
+const (
+	progressUnwatched int32 = iota
+	progressWatched
+	progressClosed
+)
+
+// ShutdownProgress is a snapshot of a pool being stopped, see
+// StopProgress().
+type ShutdownProgress struct {
+	// Elapsed is the time since Stop() was called.
+	Elapsed time.Duration
+	// Queued is the number of jobs left waiting for a worker.
+	Queued uint32
+	// Running is the number of jobs left running.
+	Running uint32
+	// Jobs lists the running jobs, oldest first.
+	Jobs []RunningJob
+}
+
+// RunningJob describes a job still running while the pool is stopped.
+type RunningJob struct {
+	// Worker is the id of the worker running the job.
+	Worker uint64
+	// Job is the name of the job, if named.
+	Job string
+	// Age is the time the job has been running for.
+	Age time.Duration
+}
+
+// StopProgress returns a channel on which the progress of Stop() is
+// delivered, such that operators can tell which jobs hold up a shutdown.
+// A snapshot is delivered as soon as Stop() is called, and then every
+// Options.StopProgressInterval until the pool is stopped.
+//
+// The channel holds the latest snapshot only, replacing the ones a slow
+// receiver has not read yet, and is closed once the pool is stopped.
+// Progress is only reported if StopProgress() is called before Stop(),
+// otherwise the channel is closed without delivering any.
+func (gw *GoWorkers) StopProgress() <-chan ShutdownProgress {
+	atomic.CompareAndSwapInt32(&gw.progress, progressUnwatched, progressWatched)
+	return gw.stopProgress
+}
+
+// reportShutdown delivers the progress of Stop(), called at from, until
+// the pool is stopped, if watched
+func (gw *GoWorkers) reportShutdown(from time.Time) {
+	if atomic.CompareAndSwapInt32(&gw.progress, progressUnwatched, progressClosed) {
+		close(gw.stopProgress)
+		return
+	}
+
+	ticker := gw.clock.NewTicker(gw.stopProgressInterval)
+	gw.goHelper(func() {
+		defer close(gw.stopProgress)
+		defer ticker.Stop()
+		for {
+			gw.sendProgress(from)
+			select {
+			case <-gw.stopped:
+				return
+			case <-ticker.C():
+			}
+		}
+	})
+}
+
+// sendProgress must only be called by reportShutdown(), the sole sender
+func (gw *GoWorkers) sendProgress(from time.Time) {
+	now := gw.clock.Now()
+	st := gw.Stats()
+	p := ShutdownProgress{
+		Elapsed: now.Sub(from),
+		Queued:  st.Queued,
+		Running: st.Running,
+	}
+	for _, b := range st.Busy {
+		p.Jobs = append(p.Jobs, RunningJob{Worker: b.ID, Job: b.Job, Age: now.Sub(b.Since)})
+	}
+
+	// replace the snapshot not read yet, if any
+	select {
+	case <-gw.stopProgress:
+	default:
+	}
+	gw.stopProgress <- p
+}
+
+// stopHooks holds the hooks of a pool, see OnStop()
+type stopHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+	ran   bool
+}
+
+// OnStop registers fn to be called by Stop() once the submissions are
+// rejected and the jobs have finished, but before ErrChan and ResultChan
+// are closed, e.g. to flush the sinks or the metrics layered on the pool.
+// fn may deliver on ErrChan and ResultChan.
+//
+// The hooks are called one at a time, the last registered first, as with
+// deferred calls. Hooks registered once they were called are not called.
+func (gw *GoWorkers) OnStop(fn func()) {
+	defer gw.stopHooks.mu.Unlock()
+	gw.stopHooks.mu.Lock()
+	if !gw.stopHooks.ran {
+		gw.stopHooks.hooks = append(gw.stopHooks.hooks, fn)
+	}
+}
+
+// runStopHooks calls the hooks registered with OnStop()
+func (gw *GoWorkers) runStopHooks() {
+	gw.stopHooks.mu.Lock()
+	hooks := gw.stopHooks.hooks
+	gw.stopHooks.hooks, gw.stopHooks.ran = nil, true
+	gw.stopHooks.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+}
+
+// StopOptions configures how a pool is stopped, see StopWith().
+//
+// Wait is the wait argument of Stop().
+//
+// Discard, if set, discards the queued jobs of a priority below
+// MinPriority, instead of running them, so that the shutdown deadline is
+// not spent on unimportant backlog. The jobs already running are finished.
+// OnDiscard, if set, is called with every discarded job, e.g. to
+// dead-letter it.
+type StopOptions struct {
+	Wait        bool
+	Discard     bool
+	MinPriority Priority
+	OnDiscard   func(job JobInfo)
+}
+
+// StopWith stops the pool as with Stop(), and as configured with opts.
+//
+// The discarded jobs deliver ErrDiscarded on ErrChan, unless they deliver
+// no error, and are accounted for in Stats.Discarded.
+func (gw *GoWorkers) StopWith(opts StopOptions) error {
+	return gw.stop(opts.Wait, &opts)
+}
+
+// discards discards t instead of running it, if the pool is stopped with
+// StopOptions discarding it
+func (gw *GoWorkers) discards(t *task) bool {
+	opts, _ := gw.discarding.Load().(*StopOptions)
+	if (opts == nil) || (t.opts.Priority >= opts.MinPriority) {
+		return false
+	}
+
+	atomic.AddUint32(&gw.numDiscarded, 1)
+	if opts.OnDiscard != nil {
+		opts.OnDiscard(t.info())
+	}
+	gw.skip(t, ErrDiscarded)
+	return true
+}
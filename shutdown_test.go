@@ -0,0 +1,147 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStopProgress(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1000, 0))
+	gw := New(Options{Workers: 1, Clock: clock, StopProgressInterval: time.Minute})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	}, JobOptions{Name: "slow"})
+	<-started
+	gw.Submit(func() {})
+
+	stopped := make(chan struct{})
+	go func() {
+		gw.Stop(false)
+		close(stopped)
+	}()
+
+	tables := []struct {
+		elapsed time.Duration
+	}{
+		{0},
+		{time.Minute},
+		{2 * time.Minute},
+	}
+	for i, table := range tables {
+		if i != 0 {
+			clock.Advance(time.Minute)
+		}
+		p := <-gw.StopProgress()
+		if p.Elapsed != table.elapsed || p.Queued != 1 || p.Running != 1 {
+			t.Errorf("Expected 1 queued and 1 running job after %v, Got %+v", table.elapsed, p)
+		}
+		if len(p.Jobs) != 1 || p.Jobs[0].Job != "slow" || p.Jobs[0].Age != table.elapsed {
+			t.Errorf("Expected the slow job running for %v, Got %+v", table.elapsed, p.Jobs)
+		}
+	}
+
+	close(release)
+	<-stopped
+	// the last snapshot, if any, is followed by the closing of the channel
+	for range gw.StopProgress() {
+	}
+	if err := gw.VerifyShutdown(time.Second); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+}
+
+func TestStopProgressUnwatched(t *testing.T) {
+	gw := New()
+	gw.Stop(false)
+
+	// progress is not reported once the pool is stopped without a receiver
+	if _, ok := <-gw.StopProgress(); ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+}
+
+func TestOnStop(t *testing.T) {
+	gw := New()
+
+	var calls []string
+	gw.SubmitCheckError(func() error {
+		time.Sleep(10 * time.Millisecond)
+		calls = append(calls, "job")
+		return nil
+	})
+	gw.OnStop(func() { calls = append(calls, "sink") })
+	gw.OnStop(func() {
+		calls = append(calls, "flusher")
+		// the hooks may still deliver outputs
+		gw.ErrChan <- errors.New("flushed")
+	})
+	gw.Stop(false)
+	gw.OnStop(func() { calls = append(calls, "late") })
+
+	if want := []string{"job", "flusher", "sink"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected %v, Got %v", want, calls)
+	}
+	if err := <-gw.ErrChan; (err == nil) || (err.Error() != "flushed") {
+		t.Errorf("Expected the error of the hook, Got %v", err)
+	}
+}
+
+func TestStopWithDiscard(t *testing.T) {
+	gw := New(Options{Workers: 1})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	var ran []string
+	gw.Submit(func() { ran = append(ran, "normal") })
+	gw.Submit(func() { ran = append(ran, "scavenger") }, JobOptions{Priority: Scavenger})
+	gw.SubmitCheckError(func() error {
+		ran = append(ran, "report")
+		return nil
+	}, JobOptions{Name: "report", Priority: Scavenger})
+
+	var discarded []JobInfo
+	stopped := make(chan error)
+	go func() {
+		stopped <- gw.StopWith(StopOptions{
+			Discard:     true,
+			MinPriority: Normal,
+			OnDiscard:   func(job JobInfo) { discarded = append(discarded, job) },
+		})
+	}()
+	for gw.discarding.Load() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+
+	if want := []string{"normal"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Expected %v, Got %v", want, ran)
+	}
+	if len(discarded) != 2 {
+		t.Errorf("Expected 2 discarded jobs, Got %v", discarded)
+	}
+	if st := gw.Stats(); st.Discarded != 2 || st.Completed != 2 {
+		t.Errorf("Expected 2 discarded and 2 completed jobs, Got %+v", st)
+	}
+	if err := <-gw.ErrChan; !errors.Is(err, ErrDiscarded) {
+		t.Errorf("Expected %v, Got %v", ErrDiscarded, err)
+	}
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package sqlstore implements a goworkers.Store over database/sql, so that a
+// fleet of processes can share one durable queue held in Postgres or MySQL.
+//
+// Jobs are leased with SELECT ... FOR UPDATE SKIP LOCKED, which lets
+// concurrent consumers lease distinct jobs without blocking one another.
+// It requires Postgres 9.5+ or MySQL 8.0+.
+//
+// Wrap the store with goworkers.EncryptStore() to encrypt the payloads of
+// the jobs at rest.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// DefaultTable is the name of the table holding the jobs, unless specified.
+const DefaultTable = "goworkers_jobs"
+
+// Dialect is the SQL dialect of the database.
+type Dialect int
+
+const (
+	// Postgres is the dialect of PostgreSQL.
+	Postgres Dialect = iota
+	// MySQL is the dialect of MySQL.
+	MySQL
+)
+
+// Store is a goworkers.Store backed by a SQL table.
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+	table   string
+}
+
+// New creates a store over db. If table is empty, DefaultTable is used.
+func New(db *sql.DB, dialect Dialect, table string) *Store {
+	if table == "" {
+		table = DefaultTable
+	}
+	return &Store{db: db, dialect: dialect, table: table}
+}
+
+// CreateTable creates the table holding the jobs, if it does not exist.
+//
+// Tables created before the store recorded enqueue times lack the
+// enqueued_at column, which must be added to them as
+// enqueued_at BIGINT NOT NULL DEFAULT 0. Tables created before the store
+// held checkpoints lack the nullable checkpoint column, of the type of the
+// data column.
+func (s *Store) CreateTable(ctx context.Context) error {
+	var ddl string
+	switch s.dialect {
+	case MySQL:
+		ddl = `CREATE TABLE IF NOT EXISTS %s (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	data LONGBLOB NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	leased_until BIGINT NOT NULL DEFAULT 0,
+	enqueued_at BIGINT NOT NULL DEFAULT 0,
+	checkpoint LONGBLOB,
+	dead BOOLEAN NOT NULL DEFAULT FALSE,
+	reason TEXT
+)`
+	default:
+		ddl = `CREATE TABLE IF NOT EXISTS %s (
+	id BIGSERIAL PRIMARY KEY,
+	data BYTEA NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	leased_until BIGINT NOT NULL DEFAULT 0,
+	enqueued_at BIGINT NOT NULL DEFAULT 0,
+	checkpoint BYTEA,
+	dead BOOLEAN NOT NULL DEFAULT FALSE,
+	reason TEXT
+)`
+	}
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(ddl, s.table))
+	return err
+}
+
+// Enqueue durably adds a job and returns its id.
+func (s *Store) Enqueue(data []byte) (string, error) {
+	ctx := context.Background()
+	query := s.query("INSERT INTO %s (data, enqueued_at) VALUES (?, ?)")
+	now := time.Now().UnixNano()
+
+	if s.dialect == Postgres {
+		var id int64
+		if err := s.db.QueryRowContext(ctx, query+" RETURNING id", data, now).Scan(&id); err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(id, 10), nil
+	}
+
+	res, err := s.db.ExecContext(ctx, query, data, now)
+	if err != nil {
+		return "", err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// Lease hands out the oldest available job for d.
+func (s *Store) Lease(d time.Duration) (goworkers.StoredJob, error) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return goworkers.StoredJob{}, err
+	}
+	defer tx.Rollback()
+
+	var (
+		id       int64
+		sj       goworkers.StoredJob
+		attempts uint32
+	)
+	err = tx.QueryRowContext(ctx, s.query(
+		"SELECT id, data, attempts, checkpoint FROM %s WHERE dead = FALSE AND leased_until <= ? ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED"),
+		now.UnixNano()).Scan(&id, &sj.Data, &attempts, &sj.Checkpoint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return goworkers.StoredJob{}, goworkers.ErrNoJob
+	}
+	if err != nil {
+		return goworkers.StoredJob{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, s.query("UPDATE %s SET leased_until = ?, attempts = attempts + 1 WHERE id = ?"),
+		now.Add(d).UnixNano(), id)
+	if err != nil {
+		return goworkers.StoredJob{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return goworkers.StoredJob{}, err
+	}
+
+	sj.ID = strconv.FormatInt(id, 10)
+	sj.Attempts = attempts + 1
+	return sj, nil
+}
+
+// ExtendLease extends the lease of a job to d from now.
+func (s *Store) ExtendLease(id string, d time.Duration) error {
+	n, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.exec("UPDATE %s SET leased_until = ? WHERE id = ? AND dead = FALSE", time.Now().Add(d).UnixNano(), n)
+}
+
+// SaveCheckpoint records state as the last checkpoint of a job.
+func (s *Store) SaveCheckpoint(id string, state []byte) error {
+	n, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.exec("UPDATE %s SET checkpoint = ? WHERE id = ? AND dead = FALSE", state, n)
+}
+
+// Ack removes a finished job.
+func (s *Store) Ack(id string) error {
+	n, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.exec("DELETE FROM %s WHERE id = ? AND dead = FALSE", n)
+}
+
+// DeadLetter flags a job as dead, recording the reason. Dead jobs are never
+// leased again and stay in the table for inspection.
+func (s *Store) DeadLetter(id string, reason string) error {
+	n, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	return s.exec("UPDATE %s SET dead = TRUE, reason = ? WHERE id = ? AND dead = FALSE", reason, n)
+}
+
+// Vacuum dead-letters the jobs that are not leased and were enqueued more
+// than ttl ago, if ttl is not zero. The database guarantees the integrity of
+// the rows, hence no job is quarantined, and jobs enqueued before the store
+// recorded enqueue times never expire.
+func (s *Store) Vacuum(ttl time.Duration) (goworkers.VacuumStats, error) {
+	if ttl == 0 {
+		return goworkers.VacuumStats{}, nil
+	}
+	now := time.Now()
+	res, err := s.db.ExecContext(context.Background(), s.query(
+		"UPDATE %s SET dead = TRUE, reason = ? WHERE dead = FALSE AND enqueued_at > 0 AND enqueued_at < ? AND leased_until <= ?"),
+		goworkers.ErrJobExpired.Error(), now.Add(-ttl).UnixNano(), now.UnixNano())
+	if err != nil {
+		return goworkers.VacuumStats{}, err
+	}
+	expired, err := res.RowsAffected()
+	if err != nil {
+		return goworkers.VacuumStats{}, err
+	}
+	return goworkers.VacuumStats{Expired: int(expired)}, nil
+}
+
+// Compact reclaims the storage left by the deleted rows of the acknowledged
+// jobs, with VACUUM on Postgres and OPTIMIZE TABLE on MySQL.
+func (s *Store) Compact() error {
+	query := "VACUUM %s"
+	if s.dialect == MySQL {
+		query = "OPTIMIZE TABLE %s"
+	}
+	_, err := s.db.ExecContext(context.Background(), fmt.Sprintf(query, s.table))
+	return err
+}
+
+func (s *Store) exec(query string, args ...interface{}) error {
+	res, err := s.db.ExecContext(context.Background(), s.query(query), args...)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return goworkers.ErrJobNotFound
+	}
+	return nil
+}
+
+func parseID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, goworkers.ErrJobNotFound
+	}
+	return n, nil
+}
+
+// query fills in the table name and rewrites the placeholders for the dialect
+func (s *Store) query(q string) string {
+	q = fmt.Sprintf(q, s.table)
+	if s.dialect != Postgres {
+		return q
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
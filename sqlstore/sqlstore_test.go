@@ -0,0 +1,212 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// the store must be usable wherever a goworkers.Store is expected
+var (
+	_ goworkers.Store           = (*Store)(nil)
+	_ goworkers.LeaseExtender   = (*Store)(nil)
+	_ goworkers.Maintainer      = (*Store)(nil)
+	_ goworkers.CheckpointStore = (*Store)(nil)
+)
+
+// recorder is a database/sql driver recording the statements it is given
+// and answering queries with canned rows
+type recorder struct {
+	mu         sync.Mutex
+	statements []string
+	rows       [][]driver.Value
+	affected   int64
+}
+
+func (r *recorder) Open(name string) (driver.Conn, error) { return &conn{r}, nil }
+
+func (r *recorder) record(query string) {
+	r.mu.Lock()
+	r.statements = append(r.statements, query)
+	r.mu.Unlock()
+}
+
+type conn struct{ r *recorder }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) { return &stmt{c.r, query}, nil }
+func (c *conn) Close() error                              { return nil }
+func (c *conn) Begin() (driver.Tx, error)                 { return tx{}, nil }
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type stmt struct {
+	r     *recorder
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.r.record(s.query)
+	return driver.RowsAffected(s.r.affected), nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.r.record(s.query)
+	return &rows{values: s.r.rows}, nil
+}
+
+type rows struct {
+	values [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return []string{"id", "data", "attempts", "checkpoint"} }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func open(t *testing.T, r *recorder, name string) *sql.DB {
+	sql.Register(name, r)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	return db
+}
+
+func TestQueryPlaceholders(t *testing.T) {
+	pg := New(nil, Postgres, "")
+	if q := pg.query("UPDATE %s SET a = ? WHERE id = ?"); q != "UPDATE goworkers_jobs SET a = $1 WHERE id = $2" {
+		t.Errorf("Unexpected query %s", q)
+	}
+
+	my := New(nil, MySQL, "jobs")
+	if q := my.query("UPDATE %s SET a = ? WHERE id = ?"); q != "UPDATE jobs SET a = ? WHERE id = ?" {
+		t.Errorf("Unexpected query %s", q)
+	}
+}
+
+func TestLease(t *testing.T) {
+	r := &recorder{rows: [][]driver.Value{{int64(7), []byte("job"), int64(1), []byte("step 3")}}}
+	s := New(open(t, r, "recorder-lease"), Postgres, "")
+
+	sj, err := s.Lease(time.Minute)
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if sj.ID != "7" || string(sj.Data) != "job" || sj.Attempts != 2 || string(sj.Checkpoint) != "step 3" {
+		t.Errorf("Unexpected job %+v", sj)
+	}
+
+	if len(r.statements) != 2 || !strings.HasSuffix(r.statements[0], "FOR UPDATE SKIP LOCKED") {
+		t.Errorf("Expected the job to be locked with SKIP LOCKED, Got %q", r.statements)
+	}
+}
+
+func TestLeaseEmpty(t *testing.T) {
+	s := New(open(t, &recorder{}, "recorder-empty"), MySQL, "")
+
+	if _, err := s.Lease(time.Minute); err != goworkers.ErrNoJob {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrNoJob, err)
+	}
+}
+
+func TestAckNotFound(t *testing.T) {
+	s := New(open(t, &recorder{}, "recorder-ack"), MySQL, "")
+
+	if err := s.Ack("1"); err != goworkers.ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrJobNotFound, err)
+	}
+	if err := s.DeadLetter("not-an-id", "reason"); err != goworkers.ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrJobNotFound, err)
+	}
+	if err := s.ExtendLease("1", time.Minute); err != goworkers.ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", goworkers.ErrJobNotFound, err)
+	}
+}
+
+func TestExtendLease(t *testing.T) {
+	r := &recorder{affected: 1}
+	s := New(open(t, r, "recorder-extend"), Postgres, "")
+
+	if err := s.ExtendLease("7", time.Minute); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if len(r.statements) != 1 || r.statements[0] != "UPDATE goworkers_jobs SET leased_until = $1 WHERE id = $2 AND dead = FALSE" {
+		t.Errorf("Unexpected statements %q", r.statements)
+	}
+}
+
+func TestSaveCheckpoint(t *testing.T) {
+	r := &recorder{affected: 1}
+	s := New(open(t, r, "recorder-checkpoint"), MySQL, "")
+
+	if err := s.SaveCheckpoint("7", []byte("step 3")); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if len(r.statements) != 1 || r.statements[0] != "UPDATE goworkers_jobs SET checkpoint = ? WHERE id = ? AND dead = FALSE" {
+		t.Errorf("Unexpected statements %q", r.statements)
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	r := &recorder{affected: 3}
+	s := New(open(t, r, "recorder-vacuum"), Postgres, "")
+
+	st, err := s.Vacuum(time.Hour)
+	if err != nil || st != (goworkers.VacuumStats{Expired: 3}) {
+		t.Errorf("Expected 3 expired jobs, Got %+v and %v", st, err)
+	}
+	want := "UPDATE goworkers_jobs SET dead = TRUE, reason = $1 WHERE dead = FALSE AND enqueued_at > 0 AND enqueued_at < $2 AND leased_until <= $3"
+	if len(r.statements) != 1 || r.statements[0] != want {
+		t.Errorf("Unexpected statements %q", r.statements)
+	}
+
+	// without a TTL, jobs never expire
+	if _, err := s.Vacuum(0); err != nil || len(r.statements) != 1 {
+		t.Errorf("Expected no statement, Got %q and %v", r.statements, err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	tables := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{Postgres, "VACUUM goworkers_jobs"},
+		{MySQL, "OPTIMIZE TABLE goworkers_jobs"},
+	}
+
+	for i, table := range tables {
+		r := &recorder{}
+		s := New(open(t, r, "recorder-compact-"+strconv.Itoa(i)), table.dialect, "")
+		if err := s.Compact(); err != nil {
+			t.Errorf("Expected nil, Got %v", err)
+		}
+		if len(r.statements) != 1 || r.statements[0] != table.want {
+			t.Errorf("Expected %q, Got %q", table.want, r.statements)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package sqsconsumer implements an adapter that polls an AWS SQS queue and
+// submits every message as a job to a goworkers pool.
+//
+// The visibility of a message is extended while its job runs. The message
+// is deleted if its handler succeeds. Otherwise it is left on the queue, so
+// that it becomes visible again after the visibility timeout and is moved to
+// the dead-letter queue by the queue's redrive policy, if any, once it has
+// been received too many times. With Options.Delivery set to
+// goworkers.AtMostOnce, a message is deleted before its handler runs instead.
+//
+// The number of messages in flight is bounded by the pool through
+// Options.MaxInFlight rather than by a hand-rolled semaphore.
+//
+// The package does not depend on the AWS SDK. Wrap the SQS client of your
+// choice to implement Client.
+package sqsconsumer
+
+import (
+	"context"
+	"time"
+
+	"github.com/dpaks/goworkers"
+	"github.com/dpaks/goworkers/internal/inflight"
+)
+
+const (
+	// maxBatch is the largest number of messages SQS returns per receive
+	maxBatch          = 10
+	defaultVisibility = 30 * time.Second
+	defaultWaitTime   = 20 * time.Second
+)
+
+// Message is a message received from SQS.
+type Message struct {
+	ID            string
+	ReceiptHandle string
+	Body          []byte
+}
+
+// Client is the subset of the SQS API used by the consumer.
+type Client interface {
+	// ReceiveMessages long polls for up to wait for up to max messages and
+	// hides them for visibility.
+	ReceiveMessages(ctx context.Context, queueURL string, max int, wait, visibility time.Duration) ([]Message, error)
+	// DeleteMessage deletes a received message.
+	DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error
+	// ChangeMessageVisibility hides a received message for timeout from now.
+	ChangeMessageVisibility(ctx context.Context, queueURL, receiptHandle string, timeout time.Duration) error
+}
+
+// Handler processes the body of a message. The message is deleted if the
+// handler returns nil.
+type Handler func(body []byte) error
+
+// Options configures the consumer.
+//
+// QueueURL is the URL of the SQS queue.
+//
+// MaxInFlight specifies the maximum number of messages handed over to the
+// pool but not yet finished. If unspecified or zero, the maximum number of
+// workers of the pool is used, or the size of its queue if it spawns workers
+// as per demand.
+//
+// Visibility specifies for how long a received message is hidden. It is
+// extended every Visibility/2 while its job runs.
+// If unspecified or zero, 30 seconds is used.
+//
+// WaitTime specifies the long polling duration of a receive.
+// If unspecified or zero, 20 seconds is used.
+//
+// Delivery specifies the delivery semantics. Default is
+// goworkers.AtLeastOnce. With goworkers.AtMostOnce, a message is deleted
+// right before its handler runs.
+type Options struct {
+	QueueURL    string
+	MaxInFlight uint32
+	Visibility  time.Duration
+	WaitTime    time.Duration
+	Delivery    goworkers.Delivery
+}
+
+// Consume submits the messages received from the queue to gw until ctx is done.
+//
+// This is a blocking call. It returns after the jobs handed over to the pool
+// have finished, with the context's error or the first error of the client.
+func Consume(ctx context.Context, gw *goworkers.GoWorkers, client Client, h Handler, opts Options) error {
+	if opts.MaxInFlight == 0 {
+		opts.MaxInFlight = inflight.Size(gw)
+	}
+	if opts.Visibility == 0 {
+		opts.Visibility = defaultVisibility
+	}
+	if opts.WaitTime == 0 {
+		opts.WaitTime = defaultWaitTime
+	}
+
+	limiter := inflight.New(opts.MaxInFlight)
+	defer limiter.Wait()
+
+	for {
+		n, err := limiter.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		if n > maxBatch {
+			limiter.Release(n - maxBatch)
+			n = maxBatch
+		}
+
+		msgs, err := client.ReceiveMessages(ctx, opts.QueueURL, n, opts.WaitTime, opts.Visibility)
+		limiter.Release(n - len(msgs))
+		if err != nil {
+			if cerr := ctx.Err(); cerr != nil {
+				return cerr
+			}
+			return err
+		}
+
+		for _, msg := range msgs {
+			m := msg
+			// if rejected, the message becomes visible again after the timeout
+			limiter.Submit(gw, func() {
+				process(client, h, opts, m)
+			})
+		}
+	}
+}
+
+func process(client Client, h Handler, opts Options, m Message) {
+	if opts.Delivery == goworkers.AtMostOnce {
+		if client.DeleteMessage(context.Background(), opts.QueueURL, m.ReceiptHandle) == nil {
+			_ = h(m.Body)
+		}
+		return
+	}
+
+	stop := make(chan struct{})
+	extended := make(chan struct{})
+	go func() {
+		defer close(extended)
+		ticker := time.NewTicker(opts.Visibility / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				_ = client.ChangeMessageVisibility(context.Background(), opts.QueueURL, m.ReceiptHandle, opts.Visibility)
+			}
+		}
+	}()
+
+	err := h(m.Body)
+	close(stop)
+	<-extended
+
+	if err == nil {
+		_ = client.DeleteMessage(context.Background(), opts.QueueURL, m.ReceiptHandle)
+	}
+}
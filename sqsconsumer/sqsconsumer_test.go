@@ -0,0 +1,142 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package sqsconsumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+type fakeClient struct {
+	mu       sync.Mutex
+	queue    []Message
+	deleted  map[string]bool
+	extended map[string]int
+	maxBatch int
+}
+
+func (c *fakeClient) ReceiveMessages(ctx context.Context, queueURL string, max int, wait, visibility time.Duration) ([]Message, error) {
+	c.mu.Lock()
+	if max > c.maxBatch {
+		c.maxBatch = max
+	}
+	if len(c.queue) > 0 {
+		if max > len(c.queue) {
+			max = len(c.queue)
+		}
+		msgs := c.queue[:max]
+		c.queue = c.queue[max:]
+		c.mu.Unlock()
+		return msgs, nil
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(10 * time.Millisecond):
+		return nil, nil
+	}
+}
+
+func (c *fakeClient) DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+	c.deleted[receiptHandle] = true
+	return nil
+}
+
+func (c *fakeClient) ChangeMessageVisibility(ctx context.Context, queueURL, receiptHandle string, timeout time.Duration) error {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+	c.extended[receiptHandle]++
+	return nil
+}
+
+func TestConsume(t *testing.T) {
+	client := &fakeClient{deleted: map[string]bool{}, extended: map[string]int{}}
+	for i := 0; i < 30; i++ {
+		client.queue = append(client.queue, Message{ID: fmt.Sprint(i), ReceiptHandle: fmt.Sprint("r", i), Body: []byte(fmt.Sprint(i))})
+	}
+
+	gw := goworkers.New()
+	defer gw.Stop(false)
+
+	var mu sync.Mutex
+	handled := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, gw, client, func(body []byte) error {
+			mu.Lock()
+			handled++
+			if handled == 30 {
+				cancel()
+			}
+			mu.Unlock()
+			if string(body) == "7" {
+				// long enough for the visibility to be extended
+				time.Sleep(50 * time.Millisecond)
+				return fmt.Errorf("failed")
+			}
+			return nil
+		}, Options{MaxInFlight: 20, Visibility: 20 * time.Millisecond})
+	}()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.deleted) != 29 || client.deleted["r7"] {
+		t.Errorf("Expected all but the failed message to be deleted, Got %d deleted", len(client.deleted))
+	}
+	if client.extended["r7"] == 0 {
+		t.Errorf("Expected the visibility of the long running message to be extended")
+	}
+	if client.maxBatch > maxBatch {
+		t.Errorf("Expected batches of at most %d, Got %d", maxBatch, client.maxBatch)
+	}
+}
+
+func TestConsumeDefaultMaxInFlight(t *testing.T) {
+	client := &fakeClient{deleted: map[string]bool{}, extended: map[string]int{}}
+	for i := 0; i < 12; i++ {
+		client.queue = append(client.queue, Message{ID: fmt.Sprint(i), ReceiptHandle: fmt.Sprint("r", i), Body: []byte(fmt.Sprint(i))})
+	}
+
+	gw := goworkers.New(goworkers.Options{Workers: 4})
+	defer gw.Stop(false)
+
+	var mu sync.Mutex
+	handled := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, gw, client, func(body []byte) error {
+			mu.Lock()
+			handled++
+			if handled == 12 {
+				cancel()
+			}
+			mu.Unlock()
+			return nil
+		}, Options{})
+	}()
+	<-done
+
+	// bounded by the workers of the pool
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.maxBatch != 4 {
+		t.Errorf("Expected batches of at most 4, Got %d", client.maxBatch)
+	}
+}
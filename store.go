@@ -0,0 +1,456 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLease        = 30 * time.Second
+	defaultMaxAttempts  = 3
+	defaultPollInterval = time.Second
+)
+
+var (
+	// ErrNoJob is returned by Store.Lease when no job is available.
+	ErrNoJob = errors.New("goworkers: no job available")
+	// ErrJobNotFound is returned by a Store when no job has the given id.
+	ErrJobNotFound = errors.New("goworkers: job not found")
+)
+
+// StoredJob is a job held by a Store.
+type StoredJob struct {
+	ID   string
+	Data []byte
+	// Attempts is the number of times the job has been leased, including
+	// the current lease.
+	Attempts uint32
+	// Checkpoint is the last checkpoint recorded by the previous attempts
+	// of the job, if the store is a CheckpointStore.
+	Checkpoint []byte
+}
+
+// Store is a durable queue of encoded jobs.
+//
+// A leased job is hidden from other leases until its lease expires, after
+// which it is handed out again unless it was acknowledged or dead-lettered.
+type Store interface {
+	// Enqueue durably adds a job and returns its id.
+	Enqueue(data []byte) (string, error)
+	// Lease hands out the oldest available job for d.
+	// Returns ErrNoJob if no job is available.
+	Lease(d time.Duration) (StoredJob, error)
+	// Ack removes a finished job.
+	Ack(id string) error
+	// DeadLetter moves a job that cannot be processed out of the queue,
+	// recording the reason.
+	DeadLetter(id string, reason string) error
+}
+
+// LeaseExtender is implemented by the stores that can extend the lease of a
+// job, such that a job running for longer than a lease is not handed out
+// again while it runs.
+type LeaseExtender interface {
+	// ExtendLease extends the lease of a job to d from now.
+	// Returns ErrJobNotFound if the job was acknowledged or dead-lettered.
+	ExtendLease(id string, d time.Duration) error
+}
+
+// StoreOptions configures how jobs are consumed from a Store.
+//
+// Format specifies how the jobs are encoded as Envelopes. Default is JSON.
+//
+// Lease specifies for how long a job is leased.
+// If unspecified or zero, 30 seconds is used.
+//
+// MaxAttempts specifies how many times a failing job is attempted before it
+// is dead-lettered. If unspecified or zero, 3 is used.
+//
+// PollInterval specifies how long to wait before leasing again when the
+// store is empty or the pool is saturated.
+// If unspecified or zero, 1 second is used.
+//
+// Delivery specifies the delivery semantics. Default is AtLeastOnce.
+// With AtMostOnce, MaxAttempts is not used as a failed job is not attempted
+// again.
+type StoreOptions struct {
+	Format       Format
+	Lease        time.Duration
+	MaxAttempts  uint32
+	PollInterval time.Duration
+	Delivery     Delivery
+}
+
+// ConsumeStore leases the jobs of s and submits them until ctx is done.
+//
+// Every job must be an Envelope of a registered job type. A job is
+// acknowledged as per opts.Delivery, by default once it runs successfully.
+// If s is a LeaseExtender, the lease of a running job is extended every half
+// lease, so that only the jobs of a consumer that crashed or hung are handed
+// out again. A failed job is attempted again after its lease expires, and
+// dead-lettered after MaxAttempts attempts. A job rejected by the pool is
+// leased again once its lease expires, and the rejected lease does not count
+// as an attempt. A ResumableJob is resumed from the last checkpoint of its
+// previous attempts, if s is a CheckpointStore.
+// Errors of failed jobs are delivered on ErrChan as a *JobError carrying the
+// name and the metadata of the envelope.
+//
+// Poison pills, i.e. jobs that keep crashing their consumer or outliving
+// their lease, are quarantined once leased more than MaxAttempts times: they
+// are dead-lettered without running, and an error wrapping ErrQuarantined is
+// delivered on ErrChan. So are the jobs of the idempotency key of a job that
+// was quarantined or dead-lettered for failing, see Quarantined().
+// This is a blocking call and returns the context's error or the first
+// error of the store. Accepts optional StoreOptions{} argument.
+func (gw *GoWorkers) ConsumeStore(ctx context.Context, s Store, args ...StoreOptions) error {
+	var opts StoreOptions
+	if len(args) == 1 {
+		opts = args[0]
+	}
+	if opts.Lease == 0 {
+		opts.Lease = defaultLease
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	rejected := &rejectedLeases{ids: make(map[string]uint32)}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if !gw.Ready() {
+			if err := sleepCtx(ctx, gw.clock, opts.PollInterval); err != nil {
+				return err
+			}
+			continue
+		}
+
+		sj, err := s.Lease(opts.Lease)
+		if errors.Is(err, ErrNoJob) {
+			if err := sleepCtx(ctx, gw.clock, opts.PollInterval); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		sj.Attempts -= rejected.count(sj.ID)
+
+		e, job, err := decodeStoredJob(opts.Format, sj)
+		if err != nil {
+			// a job that cannot be decoded will never succeed
+			if err := s.DeadLetter(sj.ID, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := gw.poisonPill(e, sj, opts.MaxAttempts); err != nil {
+			gw.quarantineKey(e.Key)
+			rejected.forget(sj.ID)
+			if err := s.DeadLetter(sj.ID, err.Error()); err != nil {
+				return err
+			}
+			gw.sendErr(&JobError{Name: e.Name, Metadata: e.Metadata, Err: err})
+			continue
+		}
+
+		// the store job is acknowledged even if deduplicated, so the key is
+		// applied to the run of the job only
+		jobOpts := e.options()
+		jobOpts.Key = ""
+		run := runStoredJob(s, sj, job)
+		if (gw.idempotency != nil) && (e.Key != "") {
+			job := run
+			run = func(ctx context.Context) error {
+				_, err := gw.idempotency.run(e.Key, func() (interface{}, error) {
+					return nil, job(ctx)
+				})
+				return err
+			}
+		}
+
+		accepted := gw.trySubmit(&task{
+			run: func(ctx context.Context) (interface{}, error) {
+				if opts.Delivery == AtMostOnce {
+					rejected.forget(sj.ID)
+					if err := s.Ack(sj.ID); err != nil {
+						// acknowledged by another consumer, or lost
+						return nil, nil
+					}
+					return nil, run(ctx)
+				}
+				if ext, ok := s.(LeaseExtender); ok {
+					stop := gw.keepLeased(ext, sj.ID, opts.Lease)
+					defer stop()
+				}
+				if err := run(ctx); err != nil {
+					if sj.Attempts >= opts.MaxAttempts {
+						gw.quarantineKey(e.Key)
+						rejected.forget(sj.ID)
+						_ = s.DeadLetter(sj.ID, err.Error())
+					}
+					return nil, err
+				}
+				rejected.forget(sj.ID)
+				_ = s.Ack(sj.ID)
+				return nil, nil
+			},
+			outputs: errOutput,
+			opts:    jobOpts,
+		})
+		if !accepted {
+			// the job is leased again once its lease expires
+			rejected.add(sj.ID)
+		}
+	}
+}
+
+// rejectedLeases counts the leases of the jobs of a store that the pool
+// rejected, which are not attempts of the jobs
+type rejectedLeases struct {
+	mu  sync.Mutex
+	ids map[string]uint32
+}
+
+func (r *rejectedLeases) add(id string) {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+	r.ids[id]++
+}
+
+func (r *rejectedLeases) count(id string) uint32 {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+	return r.ids[id]
+}
+
+func (r *rejectedLeases) forget(id string) {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+	delete(r.ids, id)
+}
+
+// keepLeased extends the lease of the job id every half lease until the
+// returned function is called
+func (gw *GoWorkers) keepLeased(ext LeaseExtender, id string, lease time.Duration) func() {
+	done := make(chan struct{})
+	ticker := gw.clock.NewTicker(lease / 2)
+	gw.goHelper(func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C():
+				if err := ext.ExtendLease(id, lease); err != nil {
+					// the job may be handed out again, nothing more to do
+					return
+				}
+			}
+		}
+	})
+	return func() { close(done) }
+}
+
+func decodeStoredJob(f Format, sj StoredJob) (Envelope, Job, error) {
+	e, err := DecodeEnvelope(f, sj.Data)
+	if err != nil {
+		return e, nil, err
+	}
+	job, err := e.Job()
+	return e, job, err
+}
+
+func sleepCtx(ctx context.Context, clock Clock, d time.Duration) error {
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// MemoryStore is a Store held in memory.
+//
+// It is not durable and is meant for tests and for development.
+type MemoryStore struct {
+	mu     sync.Mutex
+	lastID uint64
+	jobs   map[string]*memoryJob
+	dead   map[string]deadJob
+}
+
+type memoryJob struct {
+	seq         uint64
+	job         StoredJob
+	enqueued    time.Time
+	leasedUntil time.Time
+}
+
+type deadJob struct {
+	job    StoredJob
+	reason string
+}
+
+// NewMemoryStore creates a new in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs: make(map[string]*memoryJob),
+		dead: make(map[string]deadJob),
+	}
+}
+
+// Enqueue adds a job and returns its id.
+func (m *MemoryStore) Enqueue(data []byte) (string, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	m.lastID++
+	id := strconv.FormatUint(m.lastID, 10)
+	m.jobs[id] = &memoryJob{seq: m.lastID, job: StoredJob{ID: id, Data: data}, enqueued: time.Now()}
+	return id, nil
+}
+
+// Lease hands out the oldest available job for d.
+func (m *MemoryStore) Lease(d time.Duration) (StoredJob, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+
+	jobs := make([]*memoryJob, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].seq < jobs[k].seq })
+
+	now := time.Now()
+	for _, j := range jobs {
+		if now.Before(j.leasedUntil) {
+			continue
+		}
+		j.leasedUntil = now.Add(d)
+		j.job.Attempts++
+		return j.job, nil
+	}
+	return StoredJob{}, ErrNoJob
+}
+
+// ExtendLease extends the lease of a job to d from now.
+func (m *MemoryStore) ExtendLease(id string, d time.Duration) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	j.leasedUntil = time.Now().Add(d)
+	return nil
+}
+
+// Ack removes a finished job.
+func (m *MemoryStore) Ack(id string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	if _, ok := m.jobs[id]; !ok {
+		return ErrJobNotFound
+	}
+	delete(m.jobs, id)
+	return nil
+}
+
+// DeadLetter moves a job out of the queue, recording the reason.
+func (m *MemoryStore) DeadLetter(id string, reason string) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	delete(m.jobs, id)
+	m.dead[id] = deadJob{job: j.job, reason: reason}
+	return nil
+}
+
+// Vacuum dead-letters the jobs that are not leased and were enqueued more
+// than ttl ago, if ttl is not zero. Jobs held in memory cannot be corrupted.
+func (m *MemoryStore) Vacuum(ttl time.Duration) (VacuumStats, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	var st VacuumStats
+	if ttl == 0 {
+		return st, nil
+	}
+	now := time.Now()
+	for id, j := range m.jobs {
+		if now.Before(j.leasedUntil) || (now.Sub(j.enqueued) <= ttl) {
+			continue
+		}
+		delete(m.jobs, id)
+		m.dead[id] = deadJob{job: j.job, reason: ErrJobExpired.Error()}
+		st.Expired++
+	}
+	return st, nil
+}
+
+// SaveCheckpoint records state as the last checkpoint of a job.
+func (m *MemoryStore) SaveCheckpoint(id string, state []byte) error {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	j.job.Checkpoint = append([]byte(nil), state...)
+	return nil
+}
+
+// Compact does nothing, as the acknowledged jobs are freed right away.
+func (m *MemoryStore) Compact() error {
+	return nil
+}
+
+// DeadLetters returns the reason for every dead-lettered job by its id.
+func (m *MemoryStore) DeadLetters() map[string]string {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	dead := make(map[string]string, len(m.dead))
+	for id, d := range m.dead {
+		dead[id] = d.reason
+	}
+	return dead
+}
+
+// DeadJob returns the dead-lettered job of the given id, e.g. to inspect the
+// metadata of its Envelope.
+// Returns ErrJobNotFound if no job of the id was dead-lettered.
+func (m *MemoryStore) DeadJob(id string) (StoredJob, error) {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	d, ok := m.dead[id]
+	if !ok {
+		return StoredJob{}, ErrJobNotFound
+	}
+	return d.job, nil
+}
+
+// Len returns number of jobs in the queue, leased or not.
+func (m *MemoryStore) Len() int {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	return len(m.jobs)
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var noopRuns, slowRuns int32
+
+type noopJob struct{}
+
+func (noopJob) Run() error {
+	atomic.AddInt32(&noopRuns, 1)
+	return nil
+}
+
+// slowJob outlives the leases of TestConsumeStoreExtendsLease
+type slowJob struct{}
+
+func (slowJob) Run() error {
+	atomic.AddInt32(&slowRuns, 1)
+	time.Sleep(100 * time.Millisecond)
+	return nil
+}
+
+func init() {
+	RegisterJobType("noop", func(payload []byte) Job { return noopJob{} })
+	RegisterJobType("slow", func(payload []byte) Job { return slowJob{} })
+}
+
+func enqueue(t *testing.T, s Store, e Envelope) string {
+	data, err := e.Encode(JSON)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	id, err := s.Enqueue(data)
+	if err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	return id
+}
+
+func TestMemoryStoreLease(t *testing.T) {
+	s := NewMemoryStore()
+	first, _ := s.Enqueue([]byte("1"))
+	_, _ = s.Enqueue([]byte("2"))
+
+	sj, err := s.Lease(time.Hour)
+	if err != nil || sj.ID != first || sj.Attempts != 1 {
+		t.Fatalf("Expected the oldest job on its first attempt, Got %+v and %v", sj, err)
+	}
+	if sj, _ := s.Lease(time.Hour); sj.ID == first {
+		t.Errorf("Expected a leased job to be hidden")
+	}
+	if _, err := s.Lease(time.Hour); err != ErrNoJob {
+		t.Errorf("Expected %v, Got %v", ErrNoJob, err)
+	}
+
+	if err := s.Ack(first); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if err := s.Ack(first); err != ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", ErrJobNotFound, err)
+	}
+}
+
+func TestMemoryStoreLeaseExpiry(t *testing.T) {
+	s := NewMemoryStore()
+	id, _ := s.Enqueue([]byte("1"))
+
+	_, _ = s.Lease(10 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	sj, err := s.Lease(time.Hour)
+	if err != nil || sj.ID != id || sj.Attempts != 2 {
+		t.Errorf("Expected the expired job on its second attempt, Got %+v and %v", sj, err)
+	}
+}
+
+func TestMemoryStoreExtendLease(t *testing.T) {
+	s := NewMemoryStore()
+	id, _ := s.Enqueue([]byte("1"))
+
+	_, _ = s.Lease(10 * time.Millisecond)
+	if err := s.ExtendLease(id, time.Hour); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := s.Lease(time.Hour); err != ErrNoJob {
+		t.Errorf("Expected %v, Got %v", ErrNoJob, err)
+	}
+	if err := s.ExtendLease("unknown", time.Hour); err != ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", ErrJobNotFound, err)
+	}
+}
+
+func TestConsumeStoreExtendsLease(t *testing.T) {
+	s := NewMemoryStore()
+	enqueue(t, s, Envelope{Name: "slow"})
+
+	gw := New()
+	defer gw.Stop(false)
+
+	atomic.StoreInt32(&slowRuns, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- gw.ConsumeStore(ctx, s, StoreOptions{
+			Lease:        20 * time.Millisecond,
+			PollInterval: time.Millisecond,
+		})
+	}()
+
+	for s.Len() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if n := atomic.LoadInt32(&slowRuns); n != 1 {
+		t.Errorf("Expected the job to run once while its lease is extended, Got %d runs", n)
+	}
+}
+
+// saturatingStore fills the pool as it hands out its first job, such that
+// the pool rejects it
+type saturatingStore struct {
+	*MemoryStore
+	gw     *GoWorkers
+	leases int32
+}
+
+func (s *saturatingStore) Lease(d time.Duration) (StoredJob, error) {
+	sj, err := s.MemoryStore.Lease(d)
+	if (err != nil) || (atomic.AddInt32(&s.leases, 1) != 1) {
+		return sj, err
+	}
+	release := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		s.gw.Submit(func() { <-release })
+	}
+	for !s.gw.Saturated() {
+		time.Sleep(time.Millisecond)
+	}
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	return sj, err
+}
+
+func TestConsumeStoreRejected(t *testing.T) {
+	gw := New(Options{Workers: 1, QSize: 1})
+	defer gw.Stop(false)
+	s := &saturatingStore{MemoryStore: NewMemoryStore(), gw: gw}
+	enqueue(t, s, Envelope{Name: "noop"})
+
+	atomic.StoreInt32(&noopRuns, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- gw.ConsumeStore(ctx, s, StoreOptions{
+			Lease:        10 * time.Millisecond,
+			MaxAttempts:  1,
+			PollInterval: time.Millisecond,
+		})
+	}()
+
+	for s.Len() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// the rejected lease is not an attempt, so the job is not quarantined
+	if n := atomic.LoadInt32(&noopRuns); n != 1 {
+		t.Errorf("Expected 1 run, Got %d", n)
+	}
+	if n := atomic.LoadInt32(&s.leases); n < 2 {
+		t.Errorf("Expected the job to be leased again, Got %d leases", n)
+	}
+	if dead := s.DeadLetters(); len(dead) != 0 {
+		t.Errorf("Expected no dead letters, Got %v", dead)
+	}
+}
+
+func TestConsumeStore(t *testing.T) {
+	s := NewMemoryStore()
+	for i := 0; i < 5; i++ {
+		enqueue(t, s, Envelope{Name: "noop"})
+	}
+	metadata := map[string]string{"tenant": "acme"}
+	failing := enqueue(t, s, Envelope{Name: "greet", Payload: []byte("store"), Metadata: metadata})
+	undecodable, _ := s.Enqueue([]byte("{"))
+
+	gw := New()
+	defer gw.Stop(false)
+
+	atomic.StoreInt32(&noopRuns, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- gw.ConsumeStore(ctx, s, StoreOptions{
+			Lease:        10 * time.Millisecond,
+			MaxAttempts:  2,
+			PollInterval: time.Millisecond,
+		})
+	}()
+
+	for s.Len() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+	if n := atomic.LoadInt32(&noopRuns); n != 5 {
+		t.Errorf("Expected 5 successful jobs, Got %d", n)
+	}
+
+	dead := s.DeadLetters()
+	if dead[failing] != "hello store" {
+		t.Errorf("Expected the failing job to be dead-lettered with its error, Got %q", dead[failing])
+	}
+	if _, ok := dead[undecodable]; !ok {
+		t.Errorf("Expected the undecodable job to be dead-lettered")
+	}
+
+	sj, err := s.DeadJob(failing)
+	if err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if e, err := DecodeEnvelope(JSON, sj.Data); err != nil || e.Metadata["tenant"] != "acme" {
+		t.Errorf("Expected the dead-lettered job to carry its metadata, Got %v, %v", e.Metadata, err)
+	}
+	if _, err := s.DeadJob("unknown"); err != ErrJobNotFound {
+		t.Errorf("Expected %v, Got %v", ErrJobNotFound, err)
+	}
+
+	var je *JobError
+	if err := <-gw.ErrChan; !errors.As(err, &je) || je.Name != "greet" || je.Metadata["tenant"] != "acme" {
+		t.Errorf("Expected the error of job greet to carry its metadata, Got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SubscribeOption selects the jobs whose outputs a subscription receives,
+// see SubscribeResults() and SubscribeErrors().
+type SubscribeOption func(*filter)
+
+// WithTag selects the jobs tagged with tag, see JobOptions.Tags.
+func WithTag(tag string) SubscribeOption {
+	return func(f *filter) {
+		f.tags = append(f.tags, tag)
+	}
+}
+
+// filter selects jobs by their tags
+type filter struct {
+	tags []string
+}
+
+func newFilter(opts []SubscribeOption) filter {
+	var f filter
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return f
+}
+
+// match reports whether the job tagged with tags carries all the tags of f
+func (f filter) match(tags []string) bool {
+	for _, want := range f.tags {
+		found := false
+		for _, tag := range tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ResultSubscription receives the results of the jobs selected by its
+// options, see SubscribeResults().
+type ResultSubscription struct {
+	gw *GoWorkers
+	f  filter
+	c  chan interface{}
+	// C is a buffered output channel of size 100 on which the results are
+	// delivered. It is closed by Close(), or after Stop() returns.
+	C <-chan interface{}
+}
+
+// ErrorSubscription receives the errors of the jobs selected by its
+// options, see SubscribeErrors().
+type ErrorSubscription struct {
+	gw *GoWorkers
+	f  filter
+	c  chan error
+	// C is a buffered output channel of size 100 on which the errors are
+	// delivered. It is closed by Close(), or after Stop() returns.
+	C <-chan error
+}
+
+// subscriptions holds the subscriptions of a pool
+type subscriptions struct {
+	mu      sync.RWMutex
+	closed  bool
+	results map[*ResultSubscription]struct{}
+	errs    map[*ErrorSubscription]struct{}
+}
+
+// SubscribeResults subscribes to the results of the jobs selected by opts,
+// e.g. WithTag("export"), such that the components sharing a pool consume
+// their own outputs only. Without options, the results of every job are
+// received.
+//
+// The results received by subscriptions are not delivered on ResultChan,
+// which only gets those that no subscription selects. A result is received
+// by every subscription selecting it, and is dropped for the subscriptions
+// whose channel is full, regardless of StrictOutputs.
+func (gw *GoWorkers) SubscribeResults(opts ...SubscribeOption) *ResultSubscription {
+	c := make(chan interface{}, outputChanSize)
+	s := &ResultSubscription{gw: gw, f: newFilter(opts), c: c, C: c}
+
+	subs := &gw.subscriptions
+	defer subs.mu.Unlock()
+	subs.mu.Lock()
+	if subs.closed {
+		close(c)
+		return s
+	}
+	if subs.results == nil {
+		subs.results = make(map[*ResultSubscription]struct{})
+	}
+	subs.results[s] = struct{}{}
+	return s
+}
+
+// SubscribeErrors subscribes to the errors of the jobs selected by opts, as
+// with SubscribeResults(). The errors received by subscriptions are not
+// delivered on ErrChan.
+func (gw *GoWorkers) SubscribeErrors(opts ...SubscribeOption) *ErrorSubscription {
+	c := make(chan error, outputChanSize)
+	s := &ErrorSubscription{gw: gw, f: newFilter(opts), c: c, C: c}
+
+	subs := &gw.subscriptions
+	defer subs.mu.Unlock()
+	subs.mu.Lock()
+	if subs.closed {
+		close(c)
+		return s
+	}
+	if subs.errs == nil {
+		subs.errs = make(map[*ErrorSubscription]struct{})
+	}
+	subs.errs[s] = struct{}{}
+	return s
+}
+
+// Close ends the subscription and closes its channel.
+func (s *ResultSubscription) Close() {
+	subs := &s.gw.subscriptions
+	defer subs.mu.Unlock()
+	subs.mu.Lock()
+	if _, ok := subs.results[s]; ok {
+		delete(subs.results, s)
+		close(s.c)
+	}
+}
+
+// Close ends the subscription and closes its channel.
+func (s *ErrorSubscription) Close() {
+	subs := &s.gw.subscriptions
+	defer subs.mu.Unlock()
+	subs.mu.Lock()
+	if _, ok := subs.errs[s]; ok {
+		delete(subs.errs, s)
+		close(s.c)
+	}
+}
+
+// deliverResult delivers the result of the job tagged with tags to the
+// subscriptions selecting it, and reports whether there were any
+func (gw *GoWorkers) deliverResult(tags []string, result interface{}) bool {
+	subs := &gw.subscriptions
+	defer subs.mu.RUnlock()
+	subs.mu.RLock()
+	delivered := false
+	for s := range subs.results {
+		if !s.f.match(tags) {
+			continue
+		}
+		delivered = true
+		select {
+		case s.c <- result:
+		default:
+			atomic.AddUint32(&gw.numDropped, 1)
+		}
+	}
+	return delivered
+}
+
+// deliverErr delivers the error of the job tagged with tags to the
+// subscriptions selecting it, and reports whether there were any
+func (gw *GoWorkers) deliverErr(tags []string, err error) bool {
+	subs := &gw.subscriptions
+	defer subs.mu.RUnlock()
+	subs.mu.RLock()
+	delivered := false
+	for s := range subs.errs {
+		if !s.f.match(tags) {
+			continue
+		}
+		delivered = true
+		select {
+		case s.c <- err:
+		default:
+			atomic.AddUint32(&gw.numDropped, 1)
+		}
+	}
+	return delivered
+}
+
+// closeSubscriptions closes the channels of the subscriptions along with
+// the output channels of the pool
+func (gw *GoWorkers) closeSubscriptions() {
+	subs := &gw.subscriptions
+	defer subs.mu.Unlock()
+	subs.mu.Lock()
+	subs.closed = true
+	for s := range subs.results {
+		close(s.c)
+	}
+	for s := range subs.errs {
+		close(s.c)
+	}
+	subs.results, subs.errs = nil, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSubscribeResults(t *testing.T) {
+	gw := New()
+	exports := gw.SubscribeResults(WithTag("export"))
+	reports := gw.SubscribeResults(WithTag("export"), WithTag("report"))
+
+	_ = gw.SubmitCheckResult(func() (interface{}, error) { return "csv", nil }, JobOptions{Tags: []string{"export"}})
+	_ = gw.SubmitCheckResult(func() (interface{}, error) { return "pdf", nil }, JobOptions{Tags: []string{"export", "report"}})
+	_ = gw.SubmitCheckResult(func() (interface{}, error) { return "untagged", nil })
+	gw.Wait(false)
+
+	tables := []struct {
+		c        <-chan interface{}
+		expected int
+	}{
+		{exports.C, 2},
+		{reports.C, 1},
+		{gw.ResultChan, 1},
+	}
+	for i, table := range tables {
+		if n := len(table.c); n != table.expected {
+			t.Errorf("%d: Expected %v, Got %v", i, table.expected, n)
+		}
+	}
+	if res := <-reports.C; res != "pdf" {
+		t.Errorf("Expected %v, Got %v", "pdf", res)
+	}
+	if res := <-gw.ResultChan; res != "untagged" {
+		t.Errorf("Expected %v, Got %v", "untagged", res)
+	}
+
+	reports.Close()
+	if _, ok := <-reports.C; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+
+	gw.Stop(false)
+	for range exports.C {
+	}
+	if _, ok := <-gw.SubscribeResults().C; ok {
+		t.Errorf("Expected the channel of a stopped pool to be closed")
+	}
+}
+
+func TestSubscribeErrors(t *testing.T) {
+	gw := New()
+	imports := gw.SubscribeErrors(WithTag("import"))
+
+	errImport := errors.New("import failed")
+	_ = gw.SubmitCheckError(func() error { return errImport }, JobOptions{Tags: []string{"import"}})
+	_ = gw.SubmitCheckError(func() error { return errors.New("other") }, JobOptions{Tags: []string{"export"}})
+	gw.Wait(false)
+
+	if err := <-imports.C; err != errImport {
+		t.Errorf("Expected %v, Got %v", errImport, err)
+	}
+	if n := len(gw.ErrChan); n != 1 {
+		t.Errorf("Expected %v, Got %v", 1, n)
+	}
+
+	imports.Close()
+	imports.Close()
+	gw.Stop(false)
+}
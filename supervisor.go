@@ -0,0 +1,331 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNoMember is returned by a Supervisor that has no pool a job may be
+// routed to.
+var ErrNoMember = errors.New("goworkers: no pool to route the job to")
+
+// Member is a pool managed by a Supervisor, satisfied by *GoWorkers.
+// Adapters of pools running on other backends or hosts can implement it
+// to join a Supervisor.
+type Member interface {
+	Executor
+	JobNum() uint32
+	WorkerNum() uint32
+	Healthy(args ...HealthOptions) error
+	Stats() Stats
+}
+
+var _ Member = (*GoWorkers)(nil)
+
+// SupervisorOptions configures how a Supervisor routes the jobs.
+//
+// Health tells when a member is unhealthy, see Healthy().
+//
+// Policy, if set, tells whether the job of the options may be routed to the
+// member of a name, e.g. to keep the jobs of a tenant on its own pools.
+// If unspecified, every job may be routed to every member.
+type SupervisorOptions struct {
+	Health HealthOptions
+	Policy func(opts JobOptions, member string) bool
+}
+
+// Supervisor is an Executor routing the jobs to the least loaded of several
+// pools, its members, as a single worker fleet.
+//
+// A job is routed among the members its policy allows to the healthy one
+// having the fewest jobs per worker, trying the next one while a member
+// rejects it with ErrStopped or ErrOverloaded. If no allowed member is
+// healthy, the job is routed among the unhealthy ones alike, so that the
+// fleet degrades instead of rejecting the jobs.
+//
+// The outputs of the jobs are delivered on the channels of the member that
+// ran them.
+type Supervisor struct {
+	opts SupervisorOptions
+	// breaks the ties between equally loaded members
+	next uint32
+
+	mu      sync.RWMutex
+	members []supervised
+}
+
+type supervised struct {
+	name string
+	m    Member
+}
+
+var _ Executor = (*Supervisor)(nil)
+
+// NewSupervisor creates a new supervisor without members, see Add().
+//
+// Accepts optional SupervisorOptions{} argument.
+func NewSupervisor(args ...SupervisorOptions) *Supervisor {
+	s := &Supervisor{}
+	if len(args) == 1 {
+		s.opts = args[0]
+	}
+	return s
+}
+
+// Add makes m a member of the supervisor under name, replacing the member
+// of the name, if any.
+func (s *Supervisor) Add(name string, m Member) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for i := range s.members {
+		if s.members[i].name == name {
+			s.members[i].m = m
+			return
+		}
+	}
+	s.members = append(s.members, supervised{name: name, m: m})
+}
+
+// Remove stops routing jobs to the member of name and returns it, or nil if
+// there is none. The member is neither waited for nor stopped.
+func (s *Supervisor) Remove(name string) Member {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for i, sv := range s.members {
+		if sv.name == name {
+			s.members = append(s.members[:i], s.members[i+1:]...)
+			return sv.m
+		}
+	}
+	return nil
+}
+
+// Members returns the names of the members.
+func (s *Supervisor) Members() []string {
+	defer s.mu.RUnlock()
+	s.mu.RLock()
+	names := make([]string, len(s.members))
+	for i, sv := range s.members {
+		names[i] = sv.name
+	}
+	return names
+}
+
+// candidates returns the members the job of opts may be routed to, the
+// healthy ones first, each by increasing load
+func (s *Supervisor) candidates(opts JobOptions) []Member {
+	s.mu.RLock()
+	members := make([]supervised, 0, len(s.members))
+	for _, sv := range s.members {
+		if (s.opts.Policy == nil) || s.opts.Policy(opts, sv.name) {
+			members = append(members, sv)
+		}
+	}
+	s.mu.RUnlock()
+	if len(members) == 0 {
+		return nil
+	}
+
+	type candidate struct {
+		m       Member
+		healthy bool
+		load    float64
+	}
+	// rotate the members so that equally loaded ones take turns
+	start := int(atomic.AddUint32(&s.next, 1) % uint32(len(members)))
+	cs := make([]candidate, len(members))
+	for i := range members {
+		m := members[(start+i)%len(members)].m
+		workers := m.WorkerNum()
+		if workers == 0 {
+			workers = 1
+		}
+		cs[i] = candidate{
+			m:       m,
+			healthy: m.Healthy(s.opts.Health) == nil,
+			load:    float64(m.JobNum()) / float64(workers),
+		}
+	}
+	sort.SliceStable(cs, func(i, j int) bool {
+		if cs[i].healthy != cs[j].healthy {
+			return cs[i].healthy
+		}
+		return cs[i].load < cs[j].load
+	})
+
+	ms := make([]Member, len(cs))
+	for i, c := range cs {
+		ms[i] = c.m
+	}
+	return ms
+}
+
+func (s *Supervisor) submit(opts JobOptions, fn func(Executor) error) error {
+	err := ErrNoMember
+	for _, m := range s.candidates(opts) {
+		err = fn(m)
+		if (err != ErrStopped) && (err != ErrOverloaded) {
+			return err
+		}
+	}
+	return err
+}
+
+// Submit is a non-blocking call with arg of type `func()`
+//
+// Accepts optional JobOptions{} argument.
+// Returns ErrNoMember if no member may run the job, else the error of the
+// last member tried if every member rejected it.
+func (s *Supervisor) Submit(job func(), args ...JobOptions) error {
+	return s.submit(jobOptions(args), func(e Executor) error {
+		return e.Submit(job, args...)
+	})
+}
+
+// SubmitCheckError is a non-blocking call with arg of type `func() error`
+//
+// Use ErrChan buffered channel of the member that ran the job to read
+// error, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrNoMember if no member may run the job, else the error of the
+// last member tried if every member rejected it.
+func (s *Supervisor) SubmitCheckError(job func() error, args ...JobOptions) error {
+	return s.submit(jobOptions(args), func(e Executor) error {
+		return e.SubmitCheckError(job, args...)
+	})
+}
+
+// SubmitCheckResult is a non-blocking call with arg of type `func() (interface{}, error)`
+//
+// Use ErrChan and ResultChan buffered channels of the member that ran the
+// job to read error and output, if any.
+// Accepts optional JobOptions{} argument.
+// Returns ErrNoMember if no member may run the job, else the error of the
+// last member tried if every member rejected it.
+func (s *Supervisor) SubmitCheckResult(job func() (interface{}, error), args ...JobOptions) error {
+	return s.submit(jobOptions(args), func(e Executor) error {
+		return e.SubmitCheckResult(job, args...)
+	})
+}
+
+// each calls fn on every member, returning the first error
+func (s *Supervisor) each(fn func(Member) error) error {
+	s.mu.RLock()
+	members := append([]supervised(nil), s.members...)
+	s.mu.RUnlock()
+
+	var first error
+	for _, sv := range members {
+		if err := fn(sv.m); (err != nil) && (first == nil) {
+			first = err
+		}
+	}
+	return first
+}
+
+// Wait waits for the jobs of every member to finish running.
+//
+// See GoWorkers.Wait() for the semantics of the 'wait' argument.
+// Returns the first error of the members, if any.
+func (s *Supervisor) Wait(wait bool) error {
+	return s.each(func(m Member) error { return m.Wait(wait) })
+}
+
+// Stop stops every member.
+//
+// See GoWorkers.Stop() for the semantics of the 'wait' argument.
+// Returns the first error of the members, if any.
+func (s *Supervisor) Stop(wait bool) error {
+	return s.each(func(m Member) error { return m.Stop(wait) })
+}
+
+// SupervisorStats is a point-in-time snapshot of the members of a
+// Supervisor.
+type SupervisorStats struct {
+	// Total aggregates the stats of the members. Its MaxWorkers is zero if
+	// any member spawns workers as per demand, its QueueWait is the longest
+	// of the members, and it is Paused if every member is. Busy workers are
+	// listed by the members only, as their ids are not unique across them.
+	Total Stats `json:"total"`
+	// Members holds the stats of the members by name.
+	Members map[string]Stats `json:"members"`
+	// Unhealthy holds why every unhealthy member is unhealthy, by name.
+	Unhealthy map[string]string `json:"unhealthy,omitempty"`
+}
+
+// Stats returns a snapshot of the stats of the members along with their
+// aggregate.
+func (s *Supervisor) Stats() SupervisorStats {
+	s.mu.RLock()
+	members := append([]supervised(nil), s.members...)
+	s.mu.RUnlock()
+
+	st := SupervisorStats{Members: make(map[string]Stats, len(members))}
+	all := make([]Stats, 0, len(members))
+	for _, sv := range members {
+		ms := sv.m.Stats()
+		st.Members[sv.name] = ms
+		all = append(all, ms)
+		if err := sv.m.Healthy(s.opts.Health); err != nil {
+			if st.Unhealthy == nil {
+				st.Unhealthy = make(map[string]string)
+			}
+			st.Unhealthy[sv.name] = err.Error()
+		}
+	}
+	st.Total = aggregateStats(all)
+	return st
+}
+
+// aggregateStats sums up the stats of several pools. MaxWorkers is zero if
+// any pool spawns workers as per demand, QueueWait is the longest of the
+// pools, and the aggregate is Paused if every pool is. Busy workers are left
+// out, as their ids are not unique across pools.
+func aggregateStats(all []Stats) Stats {
+	var total Stats
+	total.Paused = len(all) != 0
+	bounded := true
+	for _, st := range all {
+		total.Workers += st.Workers
+		total.MaxWorkers += st.MaxWorkers
+		bounded = bounded && (st.MaxWorkers != 0)
+		total.Jobs += st.Jobs
+		total.Running += st.Running
+		total.Queued += st.Queued
+		total.Completed += st.Completed
+		total.Failed += st.Failed
+		total.Abandoned += st.Abandoned
+		total.TimedOut += st.TimedOut
+		total.Cancelled += st.Cancelled
+		total.Discarded += st.Discarded
+		total.Restarts += st.Restarts
+		total.Dropped += st.Dropped
+		if st.QueueWait > total.QueueWait {
+			total.QueueWait = st.QueueWait
+		}
+		total.Runtime += st.Runtime
+		total.Usage.add(st.Usage)
+		total.Paused = total.Paused && st.Paused
+		for name, ns := range st.Named {
+			if total.Named == nil {
+				total.Named = make(map[string]NamedStats)
+			}
+			agg := total.Named[name]
+			agg.Completed += ns.Completed
+			agg.Failed += ns.Failed
+			agg.Runtime += ns.Runtime
+			agg.Usage.add(ns.Usage)
+			total.Named[name] = agg
+		}
+	}
+	if !bounded {
+		total.MaxWorkers = 0
+	}
+	return total
+}
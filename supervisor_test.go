@@ -0,0 +1,131 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSupervisorLeastLoaded(t *testing.T) {
+	busy, idle := New(Options{Workers: 1}), New(Options{Workers: 1})
+	s := NewSupervisor()
+	s.Add("busy", busy)
+	s.Add("idle", idle)
+
+	release := make(chan struct{})
+	busy.Submit(func() { <-release })
+	busy.Submit(func() { <-release })
+
+	var ran int32
+	for i := 0; i < 2; i++ {
+		if err := s.Submit(func() { atomic.AddInt32(&ran, 1) }); err != nil {
+			t.Fatalf("Expected nil, Got %v", err)
+		}
+		idle.Wait(false)
+	}
+	if ran != 2 {
+		t.Errorf("Expected both jobs to run on the idle pool, Got %d", ran)
+	}
+
+	close(release)
+	if err := s.Stop(false); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if st := s.Stats(); st.Total.Completed != 4 || st.Members["idle"].Completed != 2 {
+		t.Errorf("Unexpected stats %+v", st)
+	}
+}
+
+func TestSupervisorSkipsStopped(t *testing.T) {
+	stopped, live := New(), New()
+	stopped.Stop(false)
+	s := NewSupervisor()
+	s.Add("stopped", stopped)
+	s.Add("live", live)
+	defer live.Stop(false)
+
+	for i := 0; i < 4; i++ {
+		if err := s.Submit(func() {}); err != nil {
+			t.Errorf("Expected nil, Got %v", err)
+		}
+	}
+	live.Wait(false)
+	if n := live.Stats().Completed; n != 4 {
+		t.Errorf("Expected 4, Got %d", n)
+	}
+
+	s.Remove("live")
+	if err := s.Submit(func() {}); err != ErrStopped {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+}
+
+func TestSupervisorPolicy(t *testing.T) {
+	shared, dedicated := New(), New()
+	s := NewSupervisor(SupervisorOptions{
+		Policy: func(opts JobOptions, member string) bool {
+			return (opts.Tenant == "acme") == (member == "acme")
+		},
+	})
+	s.Add("shared", shared)
+	s.Add("acme", dedicated)
+
+	for i := 0; i < 3; i++ {
+		_ = s.Submit(func() {}, JobOptions{Tenant: "acme"})
+	}
+	_ = s.Submit(func() {})
+	s.Wait(false)
+
+	if n := dedicated.Stats().Completed; n != 3 {
+		t.Errorf("Expected 3, Got %d", n)
+	}
+	if n := shared.Stats().Completed; n != 1 {
+		t.Errorf("Expected 1, Got %d", n)
+	}
+
+	s.Remove("acme")
+	if err := s.Submit(func() {}, JobOptions{Tenant: "acme"}); err != ErrNoMember {
+		t.Errorf("Expected %v, Got %v", ErrNoMember, err)
+	}
+	s.Stop(false)
+	dedicated.Stop(false)
+}
+
+func TestSupervisorStats(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	a, b := New(Options{Workers: 2}), New(Options{Clock: clock})
+	s := NewSupervisor()
+	s.Add("a", a)
+	s.Add("b", b)
+
+	a.SubmitNamed("resize", func() {})
+	b.SubmitNamed("resize", func() {})
+	a.Wait(false)
+	b.Wait(false)
+
+	// b stalls with a job running for longer than StallAfter
+	release := make(chan struct{})
+	b.Submit(func() { <-release })
+	clock.Advance(2 * defaultStallAfter)
+
+	st := s.Stats()
+	if st.Total.Named["resize"].Completed != 2 || st.Total.Completed != 2 {
+		t.Errorf("Expected 2 completed resize jobs, Got %+v", st.Total)
+	}
+	if st.Total.MaxWorkers != 0 {
+		t.Errorf("Expected unbounded workers, Got %d", st.Total.MaxWorkers)
+	}
+	if _, ok := st.Unhealthy["b"]; !ok || len(st.Unhealthy) != 1 {
+		t.Errorf("Expected b to be unhealthy, Got %v", st.Unhealthy)
+	}
+	if got := s.Members(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Unexpected members %v", got)
+	}
+
+	close(release)
+	s.Stop(false)
+}
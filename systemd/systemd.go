@@ -0,0 +1,103 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package systemd ties the state of a goworkers pool to the lifecycle of a
+// systemd service of Type=notify, complementing the k8s package for the
+// deployments on VMs.
+//
+// Ready tells systemd that the service is started. Drain tells it that the
+// service is stopping, and keeps extending the stop timeout for as long as
+// the pool makes progress draining its jobs, so that systemd neither kills
+// a service that drains nor waits on one that is stuck.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// DefaultInterval is how often the drain progress is reported, unless
+// specified.
+const DefaultInterval = time.Second
+
+// DrainReport is the outcome of draining a pool.
+type DrainReport struct {
+	// Leftover is the number of jobs that did not finish within the grace period.
+	Leftover uint32 `json:"leftover"`
+	// Duration is how long the drain took.
+	Duration time.Duration `json:"duration"`
+}
+
+// Notify sends state, e.g. "READY=1", to systemd over the socket named by
+// the NOTIFY_SOCKET environment variable. It returns false if the variable
+// is unset, as when the service is not run by systemd.
+func Notify(state string) (bool, error) {
+	name := os.Getenv("NOTIFY_SOCKET")
+	if name == "" {
+		return false, nil
+	}
+	// abstract sockets are named with a leading @ in the variable
+	if name[0] == '@' {
+		name = "\x00" + name[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Ready tells systemd that the service is started, once gw accepts jobs.
+func Ready(gw *goworkers.GoWorkers) error {
+	_, err := Notify(fmt.Sprintf("READY=1\nSTATUS=%s", gw))
+	return err
+}
+
+// Drain tells systemd that the service is stopping, then stops intake and
+// waits up to grace for the jobs of gw to finish.
+//
+// Every interval, the number of jobs left is reported as the status of the
+// service and, if it went down since the previous report, the stop timeout
+// of the service is extended by twice the interval. If interval is zero,
+// DefaultInterval is used. Errors of notifying systemd are ignored, as the
+// pool is drained regardless.
+func Drain(gw *goworkers.GoWorkers, grace, interval time.Duration) DrainReport {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	start := time.Now()
+	_, _ = Notify("STOPPING=1")
+
+	done := make(chan uint32, 1)
+	go func() {
+		done <- gw.Drain(grace)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := gw.JobNum()
+	for {
+		select {
+		case leftover := <-done:
+			return DrainReport{Leftover: leftover, Duration: time.Since(start)}
+		case <-ticker.C:
+			left := gw.JobNum()
+			state := fmt.Sprintf("STATUS=draining, %d jobs left", left)
+			if left < last {
+				state = fmt.Sprintf("EXTEND_TIMEOUT_USEC=%d\n%s", (2 * interval).Microseconds(), state)
+			}
+			last = left
+			_, _ = Notify(state)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+// listen sets up a notify socket and returns the states sent to it
+func listen(t *testing.T) <-chan string {
+	// socket paths are short, unlike the ones of t.TempDir()
+	dir, err := os.MkdirTemp("", "sd")
+	if err != nil {
+		t.Fatalf("Failed to create a directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	name := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", name)
+
+	states := make(chan string, 100)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			states <- string(buf[:n])
+		}
+	}()
+	return states
+}
+
+func TestNotifyUnset(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := Notify("READY=1"); sent || err != nil {
+		t.Errorf("Expected nothing to be sent, Got %v and %v", sent, err)
+	}
+}
+
+func TestReady(t *testing.T) {
+	states := listen(t)
+	gw := goworkers.New(goworkers.Options{Name: "mailer"})
+	defer gw.Stop(false)
+
+	if err := Ready(gw); err != nil {
+		t.Fatalf("Expected nil, Got %v", err)
+	}
+	if state := <-states; !strings.HasPrefix(state, "READY=1\nSTATUS=goworkers \"mailer\"") {
+		t.Errorf("Unexpected state %q", state)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	states := listen(t)
+	gw := goworkers.New(goworkers.Options{Workers: 1})
+
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		gw.Submit(func() { <-release })
+	}
+	go func() {
+		// let a report find the jobs stuck, then make progress
+		time.Sleep(30 * time.Millisecond)
+		release <- struct{}{}
+		time.Sleep(30 * time.Millisecond)
+		close(release)
+	}()
+
+	report := Drain(gw, time.Second, 10*time.Millisecond)
+	if report.Leftover != 0 {
+		t.Errorf("Expected no leftover jobs, Got %d", report.Leftover)
+	}
+
+	if state := <-states; state != "STOPPING=1" {
+		t.Errorf("Expected STOPPING=1, Got %q", state)
+	}
+	var stuck, extended bool
+	for len(states) != 0 {
+		state := <-states
+		switch {
+		case strings.HasPrefix(state, "EXTEND_TIMEOUT_USEC=20000\nSTATUS=draining, 2 jobs left"):
+			extended = true
+		case state == "STATUS=draining, 3 jobs left":
+			stuck = true
+		}
+	}
+	if !stuck || !extended {
+		t.Errorf("Expected the timeout to be extended on progress only, Got %v and %v", stuck, extended)
+	}
+}
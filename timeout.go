@@ -0,0 +1,62 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTimeout is wrapped by the *JobError delivered on ErrChan for a job that
+// ran for longer than its timeout, see JobOptions.Timeout.
+var ErrTimeout = errors.New("goworkers: job timed out")
+
+// timeout returns the timeout of t, zero if none
+func (gw *GoWorkers) timeout(t *task) time.Duration {
+	if t.opts.Timeout > 0 {
+		return t.opts.Timeout
+	}
+	return time.Duration(atomic.LoadInt64(&gw.jobTimeout))
+}
+
+// withTimeout runs run with a context cancelled once d elapses, at which
+// point the job is given up on as with withHardDeadline(), while it winds
+// down on a goroutine of its own
+func (gw *GoWorkers) withTimeout(d time.Duration, run func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		o, ok := gw.detach(ctx, d, run)
+		if !ok {
+			atomic.AddUint32(&gw.numTimedOut, 1)
+			return nil, fmt.Errorf("%w after %s", ErrTimeout, d)
+		}
+		return o.result, o.err
+	}
+}
+
+// SubmitWithTimeout is a non-blocking call with arg of type
+// `func(ctx context.Context)`
+//
+// It is a shorthand for SubmitCtx() with a background context and
+// JobOptions{Timeout: d}, see JobOptions.Timeout. The timeout error is
+// delivered on ErrChan.
+// Accepts optional JobOptions{} argument, whose Timeout is overridden.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (gw *GoWorkers) SubmitWithTimeout(d time.Duration, job func(ctx context.Context), args ...JobOptions) error {
+	opts := jobOptions(args)
+	opts.Timeout = d
+	return gw.submit(&task{
+		run: func(ctx context.Context) (interface{}, error) {
+			job(ctx)
+			return nil, nil
+		},
+		outputs: errOutput,
+		opts:    opts,
+	})
+}
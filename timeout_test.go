@@ -0,0 +1,73 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubmitWithTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Workers: 1, Clock: clock})
+	defer gw.Stop(false)
+
+	wound := make(chan error)
+	gw.SubmitWithTimeout(time.Second, func(ctx context.Context) {
+		<-ctx.Done()
+		wound <- ctx.Err()
+	}, JobOptions{Name: "export"})
+
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+
+	var je *JobError
+	if err := <-gw.ErrChan; !errors.Is(err, ErrTimeout) || !errors.As(err, &je) || je.Name != "export" {
+		t.Errorf("Expected the job to time out, Got %v", err)
+	}
+	// the job observes the cancellation and winds down
+	if err := <-wound; err != context.Canceled {
+		t.Errorf("Expected %v, Got %v", context.Canceled, err)
+	}
+
+	gw.Wait(false)
+	if st := gw.Stats(); st.TimedOut != 1 || st.Failed != 1 {
+		t.Errorf("Expected 1 timed out job, Got %+v", st)
+	}
+}
+
+func TestJobTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	gw := New(Options{Workers: 1, Clock: clock, JobTimeout: time.Minute})
+	defer gw.Stop(false)
+
+	stuck := make(chan struct{})
+	defer close(stuck)
+	gw.Submit(func() { <-stuck })
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+	// the timeout of an unnamed job tells its worker
+	var je *JobError
+	if err := <-gw.ErrChan; !errors.Is(err, ErrTimeout) || !errors.As(err, &je) || je.Worker == 0 {
+		t.Errorf("Expected the job to time out on a worker, Got %v", err)
+	} else if want := "worker " + strconv.FormatUint(je.Worker, 10) + ": "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("Expected %q to start with %q", err.Error(), want)
+	}
+
+	// the worker is free again, and a timeout of the job takes precedence
+	gw.SubmitCheckError(func() error { return nil }, JobOptions{Timeout: time.Hour})
+	gw.Wait(false)
+	if st := gw.Stats(); st.TimedOut != 1 || st.Completed != 2 {
+		t.Errorf("Expected 1 timed out job out of 2, Got %+v", st)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+// Package tunnyshim implements the API of the pools of Jeffail/tunny on top
+// of goworkers, such that code written against tunny keeps its call sites:
+//
+//	p := tunnyshim.NewFunc(4, func(payload interface{}) interface{} { ... })
+//	defer p.Close()
+//	result := p.Process(payload)
+//
+// Only the pools of functions, created with NewFunc(), are supported.
+package tunnyshim
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+var (
+	// ErrPoolNotRunning is returned when the pool is closed, as with tunny.
+	ErrPoolNotRunning = errors.New("the pool is not running")
+	// ErrJobTimedOut is returned by ProcessTimed() when the job does not
+	// finish in time, as with tunny.
+	ErrJobTimedOut = errors.New("job request timed out")
+	// ErrJobSkipped is returned when the payload is not processed as the
+	// job was skipped, e.g. by an interceptor of the pool.
+	ErrJobSkipped = errors.New("job skipped")
+)
+
+// Pool is a pool of workers processing payloads with a function, with the
+// API of tunny.Pool.
+type Pool struct {
+	gw     *goworkers.GoWorkers
+	fn     func(interface{}) interface{}
+	queued int64
+}
+
+// NewFunc creates a new pool of n workers processing payloads with f.
+func NewFunc(n int, f func(interface{}) interface{}) *Pool {
+	return &Pool{gw: goworkers.New(goworkers.Options{Workers: size(n)}), fn: f}
+}
+
+func size(n int) uint32 {
+	if n < 1 {
+		return 1
+	}
+	return uint32(n)
+}
+
+// Pool returns the goworkers pool backing p.
+func (p *Pool) Pool() *goworkers.GoWorkers {
+	return p.gw
+}
+
+// Process processes payload on a worker and returns the result. It panics
+// if the pool is closed, as with tunny, or if the function panicked, with a
+// *goworkers.PanicError.
+func (p *Pool) Process(payload interface{}) interface{} {
+	result, err := p.ProcessCtx(context.Background(), payload)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// ProcessTimed processes payload as with Process(), giving up after
+// timeout. Returns ErrJobTimedOut if the payload is not processed in time,
+// or ErrPoolNotRunning if the pool is closed.
+func (p *Pool) ProcessTimed(payload interface{}, timeout time.Duration) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	result, err := p.ProcessCtx(ctx, payload)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, ErrJobTimedOut
+	}
+	return result, err
+}
+
+// ProcessCtx processes payload as with Process(), giving up as ctx is done.
+// Returns the error of ctx if the payload is not processed in time, or
+// ErrPoolNotRunning if the pool is closed. A payload given up on while
+// waiting for a worker is not processed. A panic of the function is
+// returned as a *goworkers.PanicError.
+func (p *Pool) ProcessCtx(ctx context.Context, payload interface{}) (interface{}, error) {
+	done := make(chan outcome, 1)
+	var dequeued int32
+	dequeue := func() {
+		if atomic.CompareAndSwapInt32(&dequeued, 0, 1) {
+			atomic.AddInt64(&p.queued, -1)
+		}
+	}
+
+	atomic.AddInt64(&p.queued, 1)
+	err := p.gw.Submit(func() {
+		dequeue()
+		if ctx.Err() != nil {
+			return
+		}
+		done <- outcome{result: p.fn(payload)}
+	}, goworkers.JobOptions{
+		// the function panicked, or the job was skipped without running,
+		// unless the result was handed over already
+		OnFinish: func(err error) {
+			dequeue()
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err == nil {
+				err = ErrJobSkipped
+			}
+			select {
+			case done <- outcome{err: err}:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		dequeue()
+		return nil, ErrPoolNotRunning
+	}
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// outcome is the outcome of processing a payload
+type outcome struct {
+	result interface{}
+	err    error
+}
+
+// QueueLength returns the number of payloads waiting for a worker.
+func (p *Pool) QueueLength() int64 {
+	return atomic.LoadInt64(&p.queued)
+}
+
+// GetSize returns the number of workers.
+func (p *Pool) GetSize() int {
+	return int(p.gw.MaxWorkers())
+}
+
+// SetSize changes the number of workers. The payloads being processed by
+// the workers in excess are processed to completion.
+func (p *Pool) SetSize(n int) {
+	_ = p.gw.ApplyOptions(goworkers.Options{Workers: size(n)})
+}
+
+// Close waits for the payloads being processed and closes the pool.
+func (p *Pool) Close() {
+	_ = p.gw.Stop(false)
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package tunnyshim
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dpaks/goworkers"
+)
+
+func TestProcess(t *testing.T) {
+	p := NewFunc(2, func(payload interface{}) interface{} {
+		return payload.(int) * 2
+	})
+
+	if res := p.Process(21); res != 42 {
+		t.Errorf("Expected %v, Got %v", 42, res)
+	}
+	if n := p.GetSize(); n != 2 {
+		t.Errorf("Expected %v, Got %v", 2, n)
+	}
+	p.SetSize(4)
+	if n := p.GetSize(); n != 4 {
+		t.Errorf("Expected %v, Got %v", 4, n)
+	}
+
+	p.Close()
+	if _, err := p.ProcessTimed(1, time.Second); err != ErrPoolNotRunning {
+		t.Errorf("Expected %v, Got %v", ErrPoolNotRunning, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic")
+		}
+	}()
+	p.Process(1)
+}
+
+func TestProcessTimed(t *testing.T) {
+	release := make(chan struct{})
+	p := NewFunc(1, func(payload interface{}) interface{} {
+		<-release
+		return payload
+	})
+
+	if _, err := p.ProcessTimed(1, 10*time.Millisecond); err != ErrJobTimedOut {
+		t.Errorf("Expected %v, Got %v", ErrJobTimedOut, err)
+	}
+	close(release)
+	p.Close()
+	if n := p.QueueLength(); n != 0 {
+		t.Errorf("Expected %v, Got %v", 0, n)
+	}
+}
+
+func TestProcessPanic(t *testing.T) {
+	p := NewFunc(1, func(payload interface{}) interface{} {
+		panic("boom")
+	})
+	defer p.Close()
+
+	var pe *goworkers.PanicError
+	if _, err := p.ProcessTimed(1, 5*time.Second); !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Errorf("Expected a *PanicError, Got %v", err)
+	}
+	if n := p.QueueLength(); n != 0 {
+		t.Errorf("Expected %v, Got %v", 0, n)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic")
+		}
+	}()
+	p.Process(1)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Pool is a pool running a single kind of job, taking inputs of type T and
+// delivering outputs of type R, see NewTyped(). It spares the type
+// assertions on the outputs of SubmitCheckResult().
+type Pool[T, R any] struct {
+	gw      *GoWorkers
+	fn      func(ctx context.Context, in T) (R, error)
+	results chan R
+}
+
+// NewTyped creates a new pool running fn on the inputs submitted to it.
+//
+// Accepts optional Options{} argument.
+func NewTyped[T, R any](fn func(ctx context.Context, in T) (R, error), args ...Options) *Pool[T, R] {
+	p := &Pool[T, R]{gw: New(args...), fn: fn, results: make(chan R, outputChanSize)}
+	p.gw.goHelper(p.forward)
+	return p
+}
+
+// forward delivers the outputs of ResultChan on Results(), buffered and
+// dropped as they are on ResultChan, such that it never blocks the pool
+// from stopping
+func (p *Pool[T, R]) forward() {
+	defer close(p.results)
+	for v := range p.gw.ResultChan {
+		r := typedResult[R](v)
+		select {
+		case p.results <- r:
+		default:
+			p.gw.drop(r, func() {
+				select {
+				case p.results <- r:
+				case <-p.gw.stopped:
+					atomic.AddUint32(&p.gw.numDropped, 1)
+				}
+			})
+		}
+	}
+}
+
+// typedResult returns the output of a job delivered on ResultChan
+func typedResult[R any](v interface{}) R {
+	r, ok := v.(R)
+	if !ok {
+		// outputs with metadata or usage are delivered as JobResult
+		if jr, isJR := v.(JobResult); isJR {
+			r, _ = jr.Value.(R)
+		}
+	}
+	return r
+}
+
+// Submit is a non-blocking call submitting a job running the function of
+// the pool on in.
+//
+// The output of the job is delivered on Results(), and its error, if any,
+// on Errors().
+// Accepts optional JobOptions{} argument.
+// Returns ErrStopped if the pool is stopped, or is being stopped or waited for.
+func (p *Pool[T, R]) Submit(in T, args ...JobOptions) error {
+	return p.gw.submit(&task{
+		run: func(ctx context.Context) (interface{}, error) {
+			return p.fn(ctx, in)
+		},
+		outputs: resultOutput,
+		opts:    jobOptions(args),
+	})
+}
+
+// Results returns the channel on which the outputs of the jobs are
+// delivered. It is buffered as ResultChan is, and the outputs that do not
+// fit are dropped as per Options.StrictOutputs, while those blocked on it
+// with BlockOnDrop are dropped once the pool is stopped. It is closed after
+// Stop() returns.
+func (p *Pool[T, R]) Results() <-chan R {
+	return p.results
+}
+
+// Errors returns the channel on which the errors of the jobs are delivered,
+// i.e. ErrChan of the underlying pool.
+func (p *Pool[T, R]) Errors() <-chan error {
+	return p.gw.ErrChan
+}
+
+// Wait waits for the jobs to finish running, see GoWorkers.Wait().
+func (p *Pool[T, R]) Wait(wait bool) error {
+	return p.gw.Wait(wait)
+}
+
+// Stop stops the pool, see GoWorkers.Stop().
+func (p *Pool[T, R]) Stop(wait bool) error {
+	return p.gw.Stop(wait)
+}
+
+// Untyped returns the underlying pool, e.g. for its stats. Its ResultChan
+// must not be read from, as it feeds Results().
+func (p *Pool[T, R]) Untyped() *GoWorkers {
+	return p.gw
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTypedPool(t *testing.T) {
+	errOdd := errors.New("odd")
+	p := NewTyped(func(ctx context.Context, n int) (string, error) {
+		if n%2 == 1 {
+			return "", errOdd
+		}
+		return strconv.Itoa(n), nil
+	}, Options{Workers: 2})
+
+	for i := 0; i < 4; i++ {
+		p.Submit(i)
+	}
+	// outputs with metadata are unwrapped
+	p.Submit(6, JobOptions{Metadata: map[string]string{"user": "42"}})
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, <-p.Results())
+	}
+	sort.Strings(got)
+	if want := []string{"0", "2", "6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, Got %v", want, got)
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-p.Errors(); err != errOdd {
+			t.Errorf("Expected %v, Got %v", errOdd, err)
+		}
+	}
+
+	p.Stop(false)
+	if _, ok := <-p.Results(); ok {
+		t.Errorf("Expected Results() to be closed")
+	}
+	if st := p.Untyped().Stats(); st.Completed != 5 || st.Failed != 2 {
+		t.Errorf("Expected 2 failed jobs out of 5, Got %+v", st)
+	}
+}
+
+func TestTypedPoolUnread(t *testing.T) {
+	p := NewTyped(func(ctx context.Context, n int) (int, error) {
+		return n, nil
+	}, Options{Workers: 4})
+
+	// nobody reads the outputs, the overflow is dropped
+	n := 3 * outputChanSize
+	for i := 0; i < n; i++ {
+		p.Submit(i)
+	}
+	p.Stop(false)
+
+	if err := p.Untyped().VerifyShutdown(time.Second); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+	if st := p.Untyped().Stats(); st.Dropped == 0 {
+		t.Errorf("Expected dropped outputs, Got %+v", st)
+	}
+	got := 0
+	for range p.Results() {
+		got++
+	}
+	if got != outputChanSize {
+		t.Errorf("Expected %d, Got %d", outputChanSize, got)
+	}
+}
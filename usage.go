@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"runtime/metrics"
+	"sync/atomic"
+	"time"
+)
+
+// Usage is the resources used by jobs, measured with Options.MeasureUsage.
+type Usage struct {
+	// Allocs is the number of bytes allocated on the heap.
+	Allocs uint64 `json:"allocs"`
+	// CPU is the estimated CPU time spent running Go code.
+	CPU time.Duration `json:"cpu"`
+}
+
+func (u *Usage) add(o Usage) {
+	u.Allocs += o.Allocs
+	u.CPU += o.CPU
+}
+
+const (
+	allocsMetric = "/gc/heap/allocs:bytes"
+	cpuMetric    = "/cpu/classes/user:cpu-seconds"
+)
+
+// readUsage returns the cumulative usage of the process. The metrics the
+// runtime does not support read as zero.
+func readUsage() Usage {
+	samples := []metrics.Sample{{Name: allocsMetric}, {Name: cpuMetric}}
+	metrics.Read(samples)
+
+	var u Usage
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		u.Allocs = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindFloat64 {
+		u.CPU = time.Duration(samples[1].Value.Float64() * float64(time.Second))
+	}
+	return u
+}
+
+// since returns the usage from u to now
+func (u Usage) since() Usage {
+	now := readUsage()
+	d := Usage{CPU: now.CPU - u.CPU}
+	if now.Allocs > u.Allocs {
+		d.Allocs = now.Allocs - u.Allocs
+	}
+	if d.CPU < 0 {
+		d.CPU = 0
+	}
+	return d
+}
+
+func (gw *GoWorkers) countUsage(u Usage) {
+	atomic.AddUint64(&gw.allocs, u.Allocs)
+	atomic.AddInt64(&gw.cpu, int64(u.CPU))
+}
+
+func (gw *GoWorkers) usage() Usage {
+	return Usage{
+		Allocs: atomic.LoadUint64(&gw.allocs),
+		CPU:    time.Duration(atomic.LoadInt64(&gw.cpu)),
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"testing"
+)
+
+var sink []byte
+
+func TestMeasureUsage(t *testing.T) {
+	gw := New(Options{MeasureUsage: true})
+
+	const size = 1 << 20
+	_ = gw.SubmitCheckResult(func() (interface{}, error) {
+		sink = make([]byte, size)
+		return len(sink), nil
+	}, JobOptions{Name: "alloc"})
+
+	res, ok := (<-gw.ResultChan).(JobResult)
+	if !ok {
+		t.Fatalf("Expected a JobResult")
+	}
+	if res.Value != size {
+		t.Errorf("Expected %v, Got %v", size, res.Value)
+	}
+	if res.Usage.Allocs < size {
+		t.Errorf("Expected at least %v bytes allocated, Got %v", size, res.Usage.Allocs)
+	}
+	gw.Stop(false)
+
+	st := gw.Stats()
+	if st.Usage.Allocs < size {
+		t.Errorf("Expected at least %v bytes allocated, Got %v", size, st.Usage.Allocs)
+	}
+	if st.Named["alloc"].Usage != res.Usage {
+		t.Errorf("Expected %+v, Got %+v", res.Usage, st.Named["alloc"].Usage)
+	}
+}
+
+func TestUsageNotMeasured(t *testing.T) {
+	gw := New()
+	_ = gw.SubmitCheckResult(func() (interface{}, error) {
+		sink = make([]byte, 1<<20)
+		return nil, nil
+	})
+	if res := <-gw.ResultChan; res != nil {
+		t.Errorf("Expected nil, Got %v", res)
+	}
+	gw.Stop(false)
+
+	if u := gw.Stats().Usage; u != (Usage{}) {
+		t.Errorf("Expected no usage, Got %+v", u)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// The number of directories read concurrently by WalkDir() if the pool
+// spawns workers as per demand
+const defaultWalkFanOut = 16
+
+// MultiError aggregates the errors of several jobs.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the aggregated errors.
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+type walkResult struct {
+	dirs []string
+	errs []error
+}
+
+// WalkDir walks the file tree rooted at root, calling fn for each file or
+// directory in the tree, including root. Directories are read concurrently
+// by the workers of the pool, so fn must be safe for concurrent use.
+//
+// The number of directories read at once is bounded by Options.Workers, if
+// specified, or else by 16. Unlike fs.WalkDir(), the walk carries on after
+// fn or reading a directory fails, and the errors are returned as a
+// MultiError. As with fs.WalkDir(), if fn returns fs.SkipDir for a directory
+// it is not descended into, and if it does so for a file the remaining
+// entries of its directory are skipped. A directory that cannot be
+// submitted, e.g. as the pool is stopped, fails with the error of Submit().
+//
+// This is a blocking call and must not be called from a job of the same pool.
+func (gw *GoWorkers) WalkDir(fsys fs.FS, root string, fn func(path string, d fs.DirEntry) error) error {
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return MultiError{err}
+	}
+	if err := fn(root, fs.FileInfoToDirEntry(info)); err != nil {
+		if err == fs.SkipDir {
+			return nil
+		}
+		return MultiError{err}
+	}
+	if !info.IsDir() {
+		return nil
+	}
+
+	fanOut := int(gw.MaxWorkers())
+	if fanOut == 0 {
+		fanOut = defaultWalkFanOut
+	}
+
+	var errs MultiError
+	results := make(chan walkResult)
+	queue := []string{root}
+	inflight := 0
+	for len(queue) > 0 || inflight > 0 {
+		for inflight < fanOut && len(queue) > 0 {
+			dir := queue[0]
+			queue = queue[1:]
+			// the result is sent once the job finishes, even if it was
+			// skipped or fn panicked
+			var res walkResult
+			if err := gw.Submit(func() { res = readDir(fsys, dir, fn) }, JobOptions{
+				OnFinish: func(err error) {
+					if err != nil {
+						res.errs = append(res.errs, &fs.PathError{Op: "walk", Path: dir, Err: err})
+					}
+					results <- res
+				},
+			}); err != nil {
+				errs = append(errs, &fs.PathError{Op: "walk", Path: dir, Err: err})
+				continue
+			}
+			inflight++
+		}
+		if inflight == 0 {
+			break
+		}
+
+		res := <-results
+		inflight--
+		queue = append(queue, res.dirs...)
+		errs = append(errs, res.errs...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// readDir calls fn for the entries of dir and returns the subdirectories to walk
+func readDir(fsys fs.FS, dir string, fn func(path string, d fs.DirEntry) error) walkResult {
+	var res walkResult
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		res.errs = append(res.errs, err)
+	}
+
+	for _, d := range entries {
+		name := path.Join(dir, d.Name())
+		err := fn(name, d)
+		if err == fs.SkipDir {
+			if d.IsDir() {
+				continue
+			}
+			break
+		}
+		if err != nil {
+			res.errs = append(res.errs, err)
+		}
+		if d.IsDir() {
+			res.dirs = append(res.dirs, name)
+		}
+	}
+	return res
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sort"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWalkDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/1.txt":       {},
+		"a/b/2.txt":     {},
+		"a/b/c/3.txt":   {},
+		"a/skip/4.txt":  {},
+		"a/d/5.txt":     {},
+		"a/d/6.txt":     {},
+		"a/e/f/g/7.txt": {},
+	}
+
+	tables := []struct {
+		workers uint32
+	}{
+		{0},
+		{1},
+		{4},
+	}
+
+	for _, table := range tables {
+		gw := New(Options{Workers: table.workers})
+
+		var mu sync.Mutex
+		var visited []string
+		err := gw.WalkDir(fsys, "a", func(path string, d fs.DirEntry) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if path == "a/skip" {
+				return fs.SkipDir
+			}
+			if path == "a/d/5.txt" {
+				return fs.SkipDir
+			}
+			visited = append(visited, path)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Expected nil, Got %v", err)
+		}
+
+		sort.Strings(visited)
+		want := []string{"a", "a/1.txt", "a/b", "a/b/2.txt", "a/b/c", "a/b/c/3.txt", "a/d", "a/e", "a/e/f", "a/e/f/g", "a/e/f/g/7.txt"}
+		if len(visited) != len(want) {
+			t.Fatalf("Expected %v, Got %v", want, visited)
+		}
+		for i := range want {
+			if visited[i] != want[i] {
+				t.Errorf("Expected %s, Got %s", want[i], visited[i])
+			}
+		}
+
+		gw.Stop(false)
+	}
+}
+
+func TestWalkDirErrors(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	fsys := fstest.MapFS{
+		"x/1.txt": {},
+		"x/2.txt": {},
+		"y/3.txt": {},
+	}
+
+	errBad := errors.New("bad file")
+	var mu sync.Mutex
+	count := 0
+	err := gw.WalkDir(fsys, ".", func(path string, d fs.DirEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		count++
+		if !d.IsDir() && path != "y/3.txt" {
+			return errBad
+		}
+		return nil
+	})
+
+	var merr MultiError
+	if !errors.As(err, &merr) || len(merr) != 2 {
+		t.Fatalf("Expected 2 aggregated errors, Got %v", err)
+	}
+	if !errors.Is(merr[0], errBad) {
+		t.Errorf("Expected %v, Got %v", errBad, merr[0])
+	}
+	// the walk carries on after an error
+	if count != 6 {
+		t.Errorf("Expected 6, Got %d", count)
+	}
+
+	if err := gw.WalkDir(fsys, "missing", func(string, fs.DirEntry) error { return nil }); err == nil {
+		t.Errorf("Expected an error for a missing root")
+	}
+}
+
+func TestWalkDirStopped(t *testing.T) {
+	gw := New()
+	gw.Stop(false)
+
+	fsys := fstest.MapFS{"a/1.txt": {}}
+	err := gw.WalkDir(fsys, "a", func(string, fs.DirEntry) error { return nil })
+	var merr MultiError
+	if !errors.As(err, &merr) || !errors.Is(merr[0], ErrStopped) {
+		t.Errorf("Expected %v, Got %v", ErrStopped, err)
+	}
+}
+
+func TestWalkDirBusy(t *testing.T) {
+	gw := New(Options{Workers: 1, QSize: 1})
+	defer gw.Stop(false)
+
+	// the queue is full, the directories are queued all the same
+	release := make(chan struct{})
+	started := make(chan struct{})
+	gw.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	gw.Submit(func() {})
+	if !gw.Saturated() {
+		t.Fatalf("Expected the queue to be full")
+	}
+	time.AfterFunc(10*time.Millisecond, func() { close(release) })
+
+	fsys := fstest.MapFS{"a/b/1.txt": {}}
+	if err := gw.WalkDir(fsys, "a", func(string, fs.DirEntry) error { return nil }); err != nil {
+		t.Errorf("Expected nil, Got %v", err)
+	}
+}
+
+func TestWalkDirJobNotRun(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	errSkipped := errors.New("skipped")
+	gw.Use(func(ctx context.Context, job JobInfo, next func(ctx context.Context) error) error {
+		return errSkipped
+	})
+	go func() {
+		for range gw.ErrChan {
+		}
+	}()
+
+	fsys := fstest.MapFS{"a/1.txt": {}}
+	err := gw.WalkDir(fsys, "a", func(string, fs.DirEntry) error { return nil })
+	var merr MultiError
+	if !errors.As(err, &merr) || !errors.Is(merr[0], errSkipped) {
+		t.Errorf("Expected %v, Got %v", errSkipped, err)
+	}
+}
+
+func TestWalkDirPanic(t *testing.T) {
+	gw := New()
+	defer gw.Stop(false)
+
+	fsys := fstest.MapFS{"a/1.txt": {}}
+	err := gw.WalkDir(fsys, "a", func(path string, d fs.DirEntry) error {
+		if path == "a/1.txt" {
+			panic("bad file")
+		}
+		return nil
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "bad file" {
+		t.Errorf("Expected a *PanicError, Got %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !js && !wasip1
+// +build !js,!wasip1
+
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+// yield is called by the loops polling for the pool to settle. The
+// scheduler preempts them on the platforms it can, hence it does nothing.
+func yield() {}
@@ -0,0 +1,17 @@
+//go:build js || wasip1
+// +build js wasip1
+
+/*
+Copyright 2020 Deepak S<deepaks@outlook.in>
+*/
+
+package goworkers
+
+import "runtime"
+
+// yield is called by the loops polling for the pool to settle. The browser
+// and edge runtimes run goroutines on a single thread without preempting
+// them, hence a polling loop must give way to the workers it waits for.
+func yield() {
+	runtime.Gosched()
+}